@@ -6,6 +6,7 @@ import (
 	"sync"
 
 	"github.com/tmdgusya/database-class/pkg/distance"
+	"github.com/tmdgusya/database-class/pkg/index"
 	"github.com/tmdgusya/database-class/pkg/vector"
 )
 
@@ -27,12 +28,8 @@ type Config struct {
 	Metric distance.Metric // Distance function to use
 }
 
-// SearchResult represents a single search result
-type SearchResult struct {
-	Vector   vector.Vector // The found vector
-	Distance float64       // Distance to query
-	Index    int           // Position in the index (0-based)
-}
+// SearchResult is the result type shared by every index (see pkg/index)
+type SearchResult = index.SearchResult
 
 // NewFlatIndex creates a new flat index
 func NewFlatIndex(cfg Config) (*FlatIndex, error) {
@@ -2,19 +2,27 @@ package solution
 
 import (
 	"fmt"
-	"sort"
 	"sync"
+	"unsafe"
 
 	"github.com/tmdgusya/database-class/pkg/distance"
+	"github.com/tmdgusya/database-class/pkg/index"
+	"github.com/tmdgusya/database-class/pkg/mmapfile"
+	"github.com/tmdgusya/database-class/pkg/topk"
 	"github.com/tmdgusya/database-class/pkg/vector"
 )
 
+// deadlineCheckInterval is how many vectors are scanned between timeout checks
+const deadlineCheckInterval = 1024
+
 // FlatIndex implements a brute-force vector index
 type FlatIndex struct {
-	vectors   []vector.Vector // All stored vectors
-	metric    distance.Metric // Distance function
-	dimension int             // Vector dimension (for validation)
-	mu        sync.RWMutex    // Thread safety
+	vectors   []vector.Vector          // All stored vectors
+	metric    distance.Metric          // Distance function
+	fast      distance.UncheckedMetric // Validation-free metric (nil if custom)
+	dimension int                      // Vector dimension (for validation)
+	mapped    *mmapfile.File           // Backing file if memory-mapped (read-only)
+	mu        sync.RWMutex             // Thread safety
 }
 
 // Config holds configuration for FlatIndex
@@ -22,12 +30,14 @@ type Config struct {
 	Metric distance.Metric
 }
 
-// SearchResult represents a single search result
-type SearchResult struct {
-	Vector   vector.Vector
-	Distance float64
-	Index    int
-}
+// SearchResult is the result type shared by every index (see pkg/index)
+type SearchResult = index.SearchResult
+
+// Compile-time checks that the index satisfies the shared interfaces
+var (
+	_ index.OptionSearcher = (*FlatIndex)(nil)
+	_ index.MemoryReporter = (*FlatIndex)(nil)
+)
 
 // NewFlatIndex creates a new flat index
 func NewFlatIndex(cfg Config) (*FlatIndex, error) {
@@ -36,9 +46,12 @@ func NewFlatIndex(cfg Config) (*FlatIndex, error) {
 		return nil, fmt.Errorf("metric cannot be nil")
 	}
 
+	fast, _ := distance.Unchecked(cfg.Metric)
+
 	return &FlatIndex{
 		vectors:   make([]vector.Vector, 0),
 		metric:    cfg.Metric,
+		fast:      fast,
 		dimension: -1, // -1 means not set yet
 	}, nil
 }
@@ -53,6 +66,10 @@ func (idx *FlatIndex) Add(v vector.Vector) error {
 	idx.mu.Lock()
 	defer idx.mu.Unlock()
 
+	if idx.mapped != nil {
+		return errReadOnly
+	}
+
 	// Check dimension consistency
 	if idx.dimension == -1 {
 		// First vector - set dimension
@@ -71,8 +88,32 @@ func (idx *FlatIndex) Add(v vector.Vector) error {
 	return nil
 }
 
+// measure computes the distance between two vectors of the index's dimension
+// Dimensions are validated once on Add and Search, so built-in metrics skip
+// their per-pair checks.
+func (idx *FlatIndex) measure(a, b vector.Vector) (float64, error) {
+	if idx.fast != nil {
+		return idx.fast(a, b), nil
+	}
+	return idx.metric(a, b)
+}
+
 // Search performs k-nearest neighbor search
 func (idx *FlatIndex) Search(query vector.Vector, k int) ([]SearchResult, error) {
+	return idx.SearchWithOptions(query, k, index.DefaultSearchOptions())
+}
+
+// SearchWithOptions performs k-nearest neighbor search with per-call settings
+// The flat index is exact, so of the tuning knobs only Filter, Timeout and
+// IncludeVectors apply; Stats and Tracer record the scan and select phases.
+func (idx *FlatIndex) SearchWithOptions(query vector.Vector, k int, opts index.SearchOptions) ([]SearchResult, error) {
+	tr := opts.StartTrace("flat.Search")
+	results, err := idx.search(query, k, opts, &tr)
+	tr.End(err)
+	return results, err
+}
+
+func (idx *FlatIndex) search(query vector.Vector, k int, opts index.SearchOptions, tr *index.Trace) ([]SearchResult, error) {
 	// Validate query
 	if err := query.Validate(); err != nil {
 		return nil, fmt.Errorf("invalid query: %w", err)
@@ -82,6 +123,10 @@ func (idx *FlatIndex) Search(query vector.Vector, k int) ([]SearchResult, error)
 		return nil, fmt.Errorf("k must be positive, got %d", k)
 	}
 
+	if err := opts.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid search options: %w", err)
+	}
+
 	idx.mu.RLock()
 	defer idx.mu.RUnlock()
 
@@ -96,32 +141,48 @@ func (idx *FlatIndex) Search(query vector.Vector, k int) ([]SearchResult, error)
 			idx.dimension, query.Dimension())
 	}
 
-	// Calculate distances to all vectors
-	results := make([]SearchResult, len(idx.vectors))
+	deadline := opts.Deadline()
+	stats := tr.Stats()
+
+	// Keep the k closest (accepted) vectors in a bounded heap
+	tr.Phase(index.PhaseScan)
+	best := topk.New[int](k)
 	for i, v := range idx.vectors {
-		dist, err := idx.metric(query, v)
+		if i%deadlineCheckInterval == 0 && deadline.Expired() {
+			return nil, index.ErrTimeout
+		}
+		if !opts.Accept(i) {
+			continue
+		}
+
+		dist, err := idx.measure(query, v)
 		if err != nil {
 			return nil, fmt.Errorf("distance calculation failed at index %d: %w", i, err)
 		}
+		stats.CandidatesScanned++
+		best.Push(i, dist)
+	}
+	stats.DistanceComputations = stats.CandidatesScanned
 
+	// Sorted by distance (ascending); fewer than k if the index is smaller
+	tr.Phase(index.PhaseSelect)
+	items := best.Sorted()
+	results := make([]SearchResult, len(items))
+	for i, item := range items {
 		results[i] = SearchResult{
-			Vector:   v,
-			Distance: dist,
-			Index:    i,
+			Distance: item.Distance,
+			Index:    item.Value,
+		}
+		if opts.IncludeVectors {
+			results[i].Vector = idx.vectors[item.Value]
 		}
 	}
 
-	// Sort by distance (ascending)
-	sort.Slice(results, func(i, j int) bool {
-		return results[i].Distance < results[j].Distance
-	})
-
-	// Return top k (or all if k > size)
-	if k > len(results) {
-		k = len(results)
+	if idx.mapped != nil {
+		// Results outlive the search, and Close unmaps what they point at
+		results = index.CloneVectors(results)
 	}
-
-	return results[:k], nil
+	return results, nil
 }
 
 // Size returns the number of vectors in the index
@@ -130,3 +191,15 @@ func (idx *FlatIndex) Size() int {
 	defer idx.mu.RUnlock()
 	return len(idx.vectors)
 }
+
+// MemoryUsage reports the bytes held by the index
+// A flat index is nothing but its vectors; IDs are slice positions.
+func (idx *FlatIndex) MemoryUsage() index.MemoryBreakdown {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	return index.MemoryBreakdown{
+		Vectors:  index.VectorsBytes(idx.vectors),
+		Metadata: uint64(unsafe.Sizeof(*idx)),
+	}
+}
@@ -0,0 +1,89 @@
+package solution
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tmdgusya/database-class/pkg/distance"
+	"github.com/tmdgusya/database-class/pkg/index"
+	"github.com/tmdgusya/database-class/pkg/metrics"
+	"github.com/tmdgusya/database-class/pkg/testdata"
+)
+
+// The shared metrics helpers take index.Index, which FlatIndex satisfies
+func TestFlatIndexWithMetrics(t *testing.T) {
+	idx, _ := NewFlatIndex(Config{Metric: distance.L2Distance})
+	vectors := testdata.GenerateRandomVectors(200, 8, 42)
+	for _, v := range vectors {
+		if err := idx.Add(v); err != nil {
+			t.Fatalf("Add() failed: %v", err)
+		}
+	}
+	queries := testdata.GenerateRandomVectors(10, 8, 7)
+
+	if _, err := metrics.MeasureSearchLatency(idx, queries, 5); err != nil {
+		t.Fatalf("MeasureSearchLatency() failed: %v", err)
+	}
+
+	// Ground truth from testdata must agree with the exact index
+	truth, err := testdata.ComputeGroundTruth(queries, vectors, 5, distance.L2Distance)
+	if err != nil {
+		t.Fatalf("ComputeGroundTruth() failed: %v", err)
+	}
+	got, err := metrics.SearchBatch(idx, queries, 5)
+	if err != nil {
+		t.Fatalf("SearchBatch() failed: %v", err)
+	}
+	recall, err := metrics.CalculateRecall(got, truth, 5)
+	if err != nil {
+		t.Fatalf("CalculateRecall() failed: %v", err)
+	}
+	if recall != 1 {
+		t.Errorf("flat recall = %v, want 1", recall)
+	}
+}
+
+// Concurrent readers and writers must not race on the index
+func TestFlatIndexUnderLoad(t *testing.T) {
+	idx, _ := NewFlatIndex(Config{Metric: distance.L2Distance})
+	for _, v := range testdata.GenerateRandomVectors(100, 8, 42) {
+		if err := idx.Add(v); err != nil {
+			t.Fatalf("Add() failed: %v", err)
+		}
+	}
+
+	result, err := metrics.RunLoad(idx,
+		testdata.GenerateRandomVectors(10, 8, 7),
+		testdata.GenerateRandomVectors(50, 8, 9),
+		metrics.LoadConfig{Readers: 4, Writers: 2, ReadRate: 2000, WriteRate: 200, Duration: 50 * time.Millisecond, K: 5},
+	)
+	if err != nil {
+		t.Fatalf("RunLoad() failed: %v", err)
+	}
+	if idx.Size() != 100+int(result.Writes.Operations) {
+		t.Errorf("Size() = %d after %d adds", idx.Size(), result.Writes.Operations)
+	}
+}
+
+func TestFlatMemoryUsage(t *testing.T) {
+	idx, _ := NewFlatIndex(Config{Metric: distance.L2Distance})
+	if m := idx.MemoryUsage(); m.Vectors != 0 || m.Metadata == 0 {
+		t.Errorf("empty index breakdown = %+v", m)
+	}
+
+	for _, v := range testdata.GenerateRandomVectors(100, 8, 42) {
+		if err := idx.Add(v); err != nil {
+			t.Fatalf("Add() failed: %v", err)
+		}
+	}
+	m := idx.MemoryUsage()
+
+	// Clones are exactly dim long; the outer slice may have spare capacity
+	want := uint64(cap(idx.vectors))*index.SliceHeaderBytes + 100*8*8
+	if m.Vectors != want {
+		t.Errorf("Vectors = %d, want %d", m.Vectors, want)
+	}
+	if m.Total() != m.Vectors+m.Metadata {
+		t.Errorf("flat index should hold only vectors and metadata, got %+v", m)
+	}
+}
@@ -0,0 +1,86 @@
+package solution
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/tmdgusya/database-class/pkg/distance"
+	"github.com/tmdgusya/database-class/pkg/mmapfile"
+	"github.com/tmdgusya/database-class/pkg/vector"
+)
+
+// mappedMagic starts a file written by WriteMapped
+const mappedMagic = "VECFLAT1"
+
+// errReadOnly is returned by Add on a memory-mapped index
+var errReadOnly = errors.New("index is read-only (memory-mapped)")
+
+// WriteMapped writes the index in the layout OpenMappedFlatIndex maps:
+//
+//	magic "VECFLAT1", dimension, count, then count*dimension float64s
+//
+// All values are little-endian 8-byte words (see pkg/mmapfile).
+func (idx *FlatIndex) WriteMapped(w io.Writer) error {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	mw := mmapfile.NewWriter(w)
+	mw.Magic(mappedMagic)
+	mw.Int(idx.dimension)
+	mw.Int(len(idx.vectors))
+	for _, v := range idx.vectors {
+		mw.Float64s(v)
+	}
+	return mw.Flush()
+}
+
+// OpenMappedFlatIndex maps a file written by WriteMapped and searches it in
+// place
+// No vector is copied: each is a view into the mapping, paged in by the OS
+// when a search first touches it. The index is read-only (Add fails).
+// Vectors returned with IncludeVectors are copied out of the mapping, so
+// results stay valid after Close, which waits for searches in progress.
+func OpenMappedFlatIndex(path string, metric distance.Metric) (*FlatIndex, error) {
+	idx, err := NewFlatIndex(Config{Metric: metric})
+	if err != nil {
+		return nil, err
+	}
+	f, err := mmapfile.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	r := mmapfile.NewReader(f.Bytes())
+	r.Magic(mappedMagic)
+	dim := r.Int("dimension", -1, math.MaxInt32)
+	count := r.Int("count", 0, r.Remaining()/max(dim, 1))
+	vectors := make([]vector.Vector, count)
+	for i := range vectors {
+		vectors[i] = r.Float64s(dim)
+	}
+	if err := r.Finish(); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s: corrupt mapped flat index: %w", path, err)
+	}
+
+	idx.mapped = f
+	idx.dimension = dim
+	idx.vectors = vectors
+	return idx, nil
+}
+
+// Close releases the mapping of an index opened with OpenMappedFlatIndex,
+// leaving it empty; for other indexes it does nothing
+func (idx *FlatIndex) Close() error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if idx.mapped == nil {
+		return nil
+	}
+	idx.vectors = nil
+	idx.dimension = -1
+	return idx.mapped.Close()
+}
@@ -0,0 +1,70 @@
+package solution
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tmdgusya/database-class/pkg/distance"
+	"github.com/tmdgusya/database-class/pkg/testdata"
+)
+
+func TestFlatMapped(t *testing.T) {
+	idx, _ := NewFlatIndex(Config{Metric: distance.L2Distance})
+	for _, v := range testdata.GenerateRandomVectors(200, 8, 42) {
+		idx.Add(v)
+	}
+
+	path := filepath.Join(t.TempDir(), "flat.map")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := idx.WriteMapped(f); err != nil {
+		t.Fatalf("WriteMapped() failed: %v", err)
+	}
+	f.Close()
+
+	mapped, err := OpenMappedFlatIndex(path, distance.L2Distance)
+	if err != nil {
+		t.Fatalf("OpenMappedFlatIndex() failed: %v", err)
+	}
+	if mapped.Size() != 200 {
+		t.Fatalf("Size() = %d, want 200", mapped.Size())
+	}
+
+	for _, query := range testdata.GenerateRandomVectors(5, 8, 7) {
+		want, _ := idx.Search(query, 5)
+		got, err := mapped.Search(query, 5)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range want {
+			if got[i].Index != want[i].Index || got[i].Distance != want[i].Distance || !got[i].Vector.Equal(want[i].Vector, 0) {
+				t.Fatalf("result %d = %+v, want %+v", i, got[i], want[i])
+			}
+		}
+	}
+
+	if err := mapped.Add(testdata.GenerateRandomVectors(1, 8, 1)[0]); err == nil {
+		t.Error("Add() succeeded on a mapped index")
+	}
+	kept, _ := mapped.Search(idx.vectors[3], 1)
+	if err := mapped.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if mapped.Size() != 0 {
+		t.Errorf("Size() = %d after Close", mapped.Size())
+	}
+	// Result vectors were copied out of the mapping before it went away
+	if !kept[0].Vector.Equal(idx.vectors[3], 0) {
+		t.Errorf("result vector after Close = %v", kept[0].Vector)
+	}
+
+	// A truncated file is rejected rather than read past its end
+	data, _ := os.ReadFile(path)
+	os.WriteFile(path, data[:len(data)-8], 0o644)
+	if _, err := OpenMappedFlatIndex(path, distance.L2Distance); err == nil {
+		t.Error("OpenMappedFlatIndex() accepted a truncated file")
+	}
+}
@@ -0,0 +1,109 @@
+package solution
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tmdgusya/database-class/pkg/distance"
+	"github.com/tmdgusya/database-class/pkg/index"
+	"github.com/tmdgusya/database-class/pkg/testdata"
+)
+
+func TestSearchWithOptionsFilter(t *testing.T) {
+	idx, _ := NewFlatIndex(Config{Metric: distance.L2Distance})
+	vectors := testdata.GenerateRandomVectors(100, 8, 42)
+	for _, v := range vectors {
+		idx.Add(v)
+	}
+
+	even := func(id int) bool { return id%2 == 0 }
+	results, err := idx.SearchWithOptions(vectors[3], 10, index.SearchOptions{Filter: even})
+	if err != nil {
+		t.Fatalf("SearchWithOptions() failed: %v", err)
+	}
+
+	if len(results) != 10 {
+		t.Fatalf("got %d results, want 10", len(results))
+	}
+	for _, r := range results {
+		if r.Index%2 != 0 {
+			t.Errorf("filtered search returned odd ID %d", r.Index)
+		}
+		if r.Vector != nil {
+			t.Errorf("IncludeVectors=false should leave Vector nil")
+		}
+	}
+}
+
+func TestSearchWithOptionsIncludeVectors(t *testing.T) {
+	idx, _ := NewFlatIndex(Config{Metric: distance.L2Distance})
+	vectors := testdata.GenerateRandomVectors(10, 4, 42)
+	for _, v := range vectors {
+		idx.Add(v)
+	}
+
+	results, err := idx.SearchWithOptions(vectors[5], 1, index.SearchOptions{IncludeVectors: true})
+	if err != nil {
+		t.Fatalf("SearchWithOptions() failed: %v", err)
+	}
+	if !results[0].Vector.Equal(vectors[5], 1e-12) {
+		t.Errorf("Vector = %v, want %v", results[0].Vector, vectors[5])
+	}
+}
+
+func TestSearchWithOptionsTimeout(t *testing.T) {
+	idx, _ := NewFlatIndex(Config{Metric: distance.L2Distance})
+	vectors := testdata.GenerateRandomVectors(2*deadlineCheckInterval, 4, 42)
+	for _, v := range vectors {
+		idx.Add(v)
+	}
+
+	// The first filter call outlasts the timeout, so the next check must fire
+	var once sync.Once
+	slowFilter := func(id int) bool {
+		once.Do(func() { time.Sleep(5 * time.Millisecond) })
+		return true
+	}
+
+	_, err := idx.SearchWithOptions(vectors[0], 5, index.SearchOptions{
+		Filter:  slowFilter,
+		Timeout: time.Millisecond,
+	})
+	if !errors.Is(err, index.ErrTimeout) {
+		t.Errorf("SearchWithOptions() error = %v, want ErrTimeout", err)
+	}
+}
+
+func TestSearchWithOptionsInvalid(t *testing.T) {
+	idx, _ := NewFlatIndex(Config{Metric: distance.L2Distance})
+	idx.Add(testdata.GenerateRandomVectors(1, 4, 42)[0])
+
+	_, err := idx.SearchWithOptions(testdata.GenerateRandomVectors(1, 4, 1)[0], 1, index.SearchOptions{Timeout: -time.Second})
+	if err == nil {
+		t.Error("SearchWithOptions() should reject a negative timeout")
+	}
+}
+
+func TestSearchWithOptionsStats(t *testing.T) {
+	idx, _ := NewFlatIndex(Config{Metric: distance.L2Distance})
+	vectors := testdata.GenerateRandomVectors(100, 8, 42)
+	for _, v := range vectors {
+		idx.Add(v)
+	}
+
+	var stats index.SearchStats
+	even := func(id int) bool { return id%2 == 0 }
+	if _, err := idx.SearchWithOptions(vectors[0], 5, index.SearchOptions{Filter: even, Stats: &stats}); err != nil {
+		t.Fatalf("SearchWithOptions() failed: %v", err)
+	}
+
+	// The filter runs before the metric, so only even IDs are scored
+	if stats.CandidatesScanned != 50 || stats.DistanceComputations != 50 {
+		t.Errorf("stats = %+v, want 50 scanned and 50 distances", stats)
+	}
+	if len(stats.Phases) != 2 || stats.Phases[0].Name != index.PhaseScan || stats.Phases[1].Name != index.PhaseSelect {
+		t.Errorf("phases = %+v", stats.Phases)
+	}
+}
@@ -0,0 +1,61 @@
+package solution
+
+import (
+	"encoding/gob"
+	"fmt"
+	"io"
+
+	"github.com/tmdgusya/database-class/pkg/distance"
+	"github.com/tmdgusya/database-class/pkg/vector"
+)
+
+// fileVersion is bumped whenever flatFile changes incompatibly
+const fileVersion = 1
+
+// flatFile is the serialized form of a FlatIndex
+type flatFile struct {
+	Version   int
+	Dimension int
+	Vectors   []vector.Vector
+}
+
+// Save writes the index to w with encoding/gob
+// The metric is a function and cannot be saved; pass it to LoadFlatIndex.
+func (idx *FlatIndex) Save(w io.Writer) error {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	return gob.NewEncoder(w).Encode(flatFile{
+		Version:   fileVersion,
+		Dimension: idx.dimension,
+		Vectors:   idx.vectors,
+	})
+}
+
+// LoadFlatIndex reads an index written by Save
+func LoadFlatIndex(r io.Reader, metric distance.Metric) (*FlatIndex, error) {
+	idx, err := NewFlatIndex(Config{Metric: metric})
+	if err != nil {
+		return nil, err
+	}
+
+	var f flatFile
+	if err := gob.NewDecoder(r).Decode(&f); err != nil {
+		return nil, fmt.Errorf("decode flat index: %w", err)
+	}
+	if f.Version != fileVersion {
+		return nil, fmt.Errorf("unsupported flat index version %d (want %d)", f.Version, fileVersion)
+	}
+	for i, v := range f.Vectors {
+		if v.Dimension() != f.Dimension {
+			return nil, fmt.Errorf("corrupt flat index: vector %d has dimension %d, want %d",
+				i, v.Dimension(), f.Dimension)
+		}
+	}
+
+	idx.dimension = f.Dimension
+	if f.Vectors != nil {
+		idx.vectors = f.Vectors
+	}
+	return idx, nil
+}
@@ -0,0 +1,54 @@
+package solution
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/tmdgusya/database-class/pkg/distance"
+	"github.com/tmdgusya/database-class/pkg/testdata"
+)
+
+func TestFlatSaveLoad(t *testing.T) {
+	idx, _ := NewFlatIndex(Config{Metric: distance.L2Distance})
+	for _, v := range testdata.GenerateRandomVectors(100, 8, 42) {
+		idx.Add(v)
+	}
+
+	var buf bytes.Buffer
+	if err := idx.Save(&buf); err != nil {
+		t.Fatalf("Save() failed: %v", err)
+	}
+	loaded, err := LoadFlatIndex(&buf, distance.L2Distance)
+	if err != nil {
+		t.Fatalf("LoadFlatIndex() failed: %v", err)
+	}
+	if loaded.Size() != 100 {
+		t.Fatalf("Size() = %d after load", loaded.Size())
+	}
+
+	query := testdata.GenerateRandomVectors(1, 8, 7)[0]
+	want, _ := idx.Search(query, 5)
+	got, _ := loaded.Search(query, 5)
+	for i := range want {
+		if got[i].Index != want[i].Index || got[i].Distance != want[i].Distance {
+			t.Fatalf("result %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	// The dimension survives, so mismatched vectors are still rejected
+	if err := loaded.Add(testdata.GenerateRandomVectors(1, 3, 1)[0]); err == nil {
+		t.Error("Add() accepted a 3-d vector into an 8-d index")
+	}
+
+	// An empty index round-trips too
+	empty, _ := NewFlatIndex(Config{Metric: distance.L2Distance})
+	buf.Reset()
+	empty.Save(&buf)
+	if loaded, err := LoadFlatIndex(&buf, distance.L2Distance); err != nil || loaded.Size() != 0 {
+		t.Errorf("empty round trip: %v, %v", loaded, err)
+	}
+
+	if _, err := LoadFlatIndex(bytes.NewReader([]byte("not gob")), distance.L2Distance); err == nil {
+		t.Error("LoadFlatIndex() accepted garbage")
+	}
+}
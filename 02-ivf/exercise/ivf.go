@@ -4,6 +4,7 @@ import (
 	"sync"
 
 	"github.com/tmdgusya/database-class/pkg/distance"
+	"github.com/tmdgusya/database-class/pkg/index"
 	"github.com/tmdgusya/database-class/pkg/vector"
 )
 
@@ -29,12 +30,8 @@ type Config struct {
 	NumProbes   int             // nprobe: number of clusters to search
 }
 
-// SearchResult represents a single search result
-type SearchResult struct {
-	Vector   vector.Vector
-	Distance float64
-	Index    int // Global index
-}
+// SearchResult is the result type shared by every index (see pkg/index)
+type SearchResult = index.SearchResult
 
 // NewIVFIndex creates a new IVF index
 func NewIVFIndex(cfg Config) (*IVFIndex, error) {
@@ -0,0 +1,80 @@
+package solution
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/tmdgusya/database-class/pkg/distance"
+	"github.com/tmdgusya/database-class/pkg/index"
+	"github.com/tmdgusya/database-class/pkg/topk"
+	"github.com/tmdgusya/database-class/pkg/vector"
+)
+
+// searchAdaptive probes clusters in centroid-distance order until the
+// remaining clusters cannot improve the top k or the candidate budget runs out
+// Caller must hold the read lock and sorts the returned heap.
+func (idx *IVFIndex) searchAdaptive(
+	query vector.Vector,
+	k int,
+	ranked []centroidDist,
+	minProbes int,
+	minCandidates int,
+	opts index.SearchOptions,
+	deadline index.Deadline,
+	stats *index.SearchStats,
+) (*topk.Heap[SearchResult], error) {
+	lowerBound, err := idx.lowerBound()
+	if err != nil {
+		return nil, err
+	}
+
+	best := topk.New[SearchResult](k)
+	scanned := 0
+	defer func() { recordScan(stats, scanned) }()
+
+	for probed, c := range ranked {
+		if probed >= minProbes && scanned >= minCandidates {
+			if opts.MaxCandidates > 0 && scanned >= opts.MaxCandidates {
+				break
+			}
+			// Nothing in this cluster can be closer than its lower bound.
+			// Clusters are ranked by centroid distance, not by lower bound,
+			// so a later cluster may still qualify: skip rather than stop.
+			if best.Full() && best.Threshold() <= lowerBound(c) {
+				continue
+			}
+		}
+
+		stats.ClustersProbed++
+		if err := idx.scanCluster(query, c.index, opts, deadline, func(r SearchResult) {
+			best.Push(r, r.Distance)
+			scanned++
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	return best, nil
+}
+
+// lowerBound returns the function giving the smallest distance any vector
+// in a cluster can have to the query
+// The bound dist(q, c) - radius comes from the triangle inequality, so it only
+// exists for Euclidean metrics; cosine and dot product have no such bound and
+// pruning with it would drop true neighbours.
+func (idx *IVFIndex) lowerBound() (func(c centroidDist) float64, error) {
+	switch {
+	case distance.Same(idx.metric, distance.L2Distance):
+		return func(c centroidDist) float64 {
+			return math.Max(0, c.distance-idx.radii[c.index])
+		}, nil
+	case distance.Same(idx.metric, distance.L2DistanceSquared):
+		// Distances and radii are squared; bound in Euclidean terms first
+		return func(c centroidDist) float64 {
+			d := math.Max(0, math.Sqrt(c.distance)-math.Sqrt(idx.radii[c.index]))
+			return d * d
+		}, nil
+	default:
+		return nil, fmt.Errorf("adaptive search requires L2Distance or L2DistanceSquared")
+	}
+}
@@ -0,0 +1,127 @@
+package solution
+
+import (
+	"testing"
+
+	"github.com/tmdgusya/database-class/pkg/distance"
+	"github.com/tmdgusya/database-class/pkg/testdata"
+)
+
+func TestAdaptiveSearchIsExact(t *testing.T) {
+	// With L2Distance the centroid lower bound is exact, so adaptive search
+	// without a budget must match brute force even starting from nprobe=1
+	vectors := testdata.GenerateClusteredVectors(500, 32, 10, 42)
+	queries := testdata.GenerateRandomVectors(20, 32, 123)
+	idx := buildTrainedIVF(t, vectors, vectors, 10, 1)
+	flatIdx := buildFlatIndex(vectors)
+
+	for qi, q := range queries {
+		got, err := idx.SearchWithParams(q, 10, SearchParams{Adaptive: true})
+		if err != nil {
+			t.Fatalf("SearchWithParams() failed: %v", err)
+		}
+		want, _ := flatIdx.Search(q, 10)
+
+		if len(got) != len(want) {
+			t.Fatalf("query %d: got %d results, want %d", qi, len(got), len(want))
+		}
+		for i := range want {
+			if got[i].Index != want[i].Index {
+				t.Errorf("query %d rank %d: got %d, want %d", qi, i, got[i].Index, want[i].Index)
+			}
+		}
+	}
+}
+
+func TestAdaptiveSearchMetrics(t *testing.T) {
+	vectors := testdata.GenerateClusteredVectors(2000, 16, 20, 11)
+	queries := testdata.GenerateClusteredVectors(50, 16, 20, 12)
+
+	newIndex := func(metric distance.Metric) *IVFIndex {
+		idx, err := NewIVFIndex(Config{Metric: metric, NumClusters: 20, NumProbes: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := idx.Train(vectors); err != nil {
+			t.Fatal(err)
+		}
+		for _, v := range vectors {
+			idx.Add(v)
+		}
+		return idx
+	}
+
+	// The squared bound is as exact as the Euclidean one
+	idx := newIndex(distance.L2DistanceSquared)
+	for qi, q := range queries {
+		got, err := idx.SearchWithParams(q, 10, SearchParams{Adaptive: true})
+		if err != nil {
+			t.Fatalf("SearchWithParams() failed: %v", err)
+		}
+		want, _ := idx.SearchWithParams(q, 10, SearchParams{NProbe: 20})
+		for i := range want {
+			if got[i].Index != want[i].Index {
+				t.Fatalf("query %d rank %d: got %d, want %d", qi, i, got[i].Index, want[i].Index)
+			}
+		}
+	}
+
+	// Cosine and dot product have no lower bound to prune with
+	for _, metric := range []distance.Metric{distance.CosineDistance, distance.DotProduct} {
+		idx := newIndex(metric)
+		if _, err := idx.SearchWithParams(queries[0], 10, SearchParams{Adaptive: true}); err == nil {
+			t.Error("adaptive search without a lower bound succeeded")
+		}
+	}
+}
+
+func TestAdaptiveSearchCandidateBudget(t *testing.T) {
+	vectors := testdata.GenerateClusteredVectors(500, 32, 10, 42)
+	idx := buildTrainedIVF(t, vectors, vectors, 10, 1)
+
+	sizes := idx.ListSizes()
+	largest := 0
+	for _, size := range sizes {
+		if size > largest {
+			largest = size
+		}
+	}
+
+	// A budget of one vector still scans the first probed cluster entirely
+	query := testdata.GenerateRandomVectors(1, 32, 7)[0]
+	results, err := idx.SearchWithParams(query, largest+1, SearchParams{Adaptive: true, MaxCandidates: 1})
+	if err != nil {
+		t.Fatalf("SearchWithParams() failed: %v", err)
+	}
+	if len(results) == 0 || len(results) > largest {
+		t.Errorf("budget of 1 returned %d results, want between 1 and %d", len(results), largest)
+	}
+}
+
+func TestSearchParamsNProbeOverride(t *testing.T) {
+	vectors := testdata.GenerateClusteredVectors(300, 16, 6, 9)
+	queries := testdata.GenerateRandomVectors(10, 16, 10)
+	idx := buildTrainedIVF(t, vectors, vectors, 6, 1)
+	flatIdx := buildFlatIndex(vectors)
+
+	// Probing every list is exact, without touching the index-wide nprobe
+	for _, q := range queries {
+		got, err := idx.SearchWithParams(q, 5, SearchParams{NProbe: 6})
+		if err != nil {
+			t.Fatalf("SearchWithParams() failed: %v", err)
+		}
+		want, _ := flatIdx.Search(q, 5)
+		for i := range want {
+			if got[i].Index != want[i].Index {
+				t.Fatalf("NProbe=nlist rank %d: got %d, want %d", i, got[i].Index, want[i].Index)
+			}
+		}
+	}
+
+	if _, err := idx.SearchWithParams(queries[0], 5, SearchParams{NProbe: 7}); err == nil {
+		t.Error("SearchWithParams() should fail when NProbe > nlist")
+	}
+	if _, err := idx.SearchWithParams(queries[0], 5, SearchParams{NProbe: -1}); err == nil {
+		t.Error("SearchWithParams() should fail with negative NProbe")
+	}
+}
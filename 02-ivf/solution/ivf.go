@@ -4,21 +4,40 @@ import (
 	"fmt"
 	"sort"
 	"sync"
+	"unsafe"
 
 	"github.com/tmdgusya/database-class/pkg/distance"
+	"github.com/tmdgusya/database-class/pkg/index"
+	"github.com/tmdgusya/database-class/pkg/mmapfile"
+	"github.com/tmdgusya/database-class/pkg/topk"
 	"github.com/tmdgusya/database-class/pkg/vector"
 )
 
+// deadlineCheckInterval is how many vectors are scanned between timeout checks
+const deadlineCheckInterval = 1024
+
 // IVFIndex implements Inverted File Index
 type IVFIndex struct {
-	centroids []vector.Vector   // Cluster centroids
-	clusters  [][]vector.Vector // Vectors in each cluster
-	metric    distance.Metric   // Distance function
-	nlist     int               // Number of clusters
-	nprobe    int               // Number of clusters to search
-	trained   bool              // Whether index is trained
-	dimension int               // Vector dimension
-	mu        sync.RWMutex      // Thread safety
+	centroids []vector.Vector          // Cluster centroids
+	clusters  [][]vector.Vector        // Vectors in each cluster
+	ids       [][]int                  // Stable IDs, parallel to clusters
+	radii     []float64                // Farthest member distance from each centroid
+	nextID    int                      // ID assigned to the next added vector
+	metric    distance.Metric          // Distance function
+	fast      distance.UncheckedMetric // Validation-free metric (nil if custom)
+	nlist     int                      // Number of clusters
+	nprobe    int                      // Number of clusters to search
+	trained   bool                     // Whether index is trained
+	dimension int                      // Vector dimension
+	mapped    *mmapfile.File           // Backing file if memory-mapped (read-only)
+	version   uint64                   // Incremented by every change
+	shared    bool                     // A snapshot shares clusters, ids and radii
+	pinned    int                      // Snapshots not yet released
+	mu        sync.RWMutex             // Thread safety
+
+	// retrainMu serializes Retrain and Rebalance so that only one
+	// layout rebuild runs at a time
+	retrainMu sync.Mutex
 }
 
 // Config holds IVF configuration
@@ -28,11 +47,31 @@ type Config struct {
 	NumProbes   int // nprobe
 }
 
-// SearchResult represents a single search result
-type SearchResult struct {
-	Vector   vector.Vector
-	Distance float64
-	Index    int // Global index across all clusters
+// SearchResult is the result type shared by every index (see pkg/index)
+type SearchResult = index.SearchResult
+
+// Compile-time checks that the index satisfies the shared interfaces
+var (
+	_ index.Trainable      = (*IVFIndex)(nil)
+	_ index.OptionSearcher = (*IVFIndex)(nil)
+	_ index.MemoryReporter = (*IVFIndex)(nil)
+)
+
+// SearchParams overrides search settings for a single call
+type SearchParams struct {
+	NProbe int // Clusters to probe; 0 uses the index's nprobe
+
+	// Adaptive keeps probing clusters in centroid-distance order (at least
+	// NProbe of them) until no remaining cluster can hold a vector closer
+	// than the current k-th result. A cluster's lower bound is
+	// dist(query, centroid) - radius, which is exact for L2Distance (and,
+	// squared, for L2DistanceSquared). Other metrics have no such bound and
+	// return an error.
+	Adaptive bool
+
+	// MaxCandidates stops adaptive search once this many vectors have been
+	// scanned (0 means no budget)
+	MaxCandidates int
 }
 
 // NewIVFIndex creates a new IVF index
@@ -52,8 +91,11 @@ func NewIVFIndex(cfg Config) (*IVFIndex, error) {
 			cfg.NumProbes, cfg.NumClusters)
 	}
 
+	fast, _ := distance.Unchecked(cfg.Metric)
+
 	return &IVFIndex{
 		metric:  cfg.Metric,
+		fast:    fast,
 		nlist:   cfg.NumClusters,
 		nprobe:  cfg.NumProbes,
 		trained: false,
@@ -66,14 +108,6 @@ func (idx *IVFIndex) Train(vectors []vector.Vector) error {
 		return fmt.Errorf("no training vectors provided")
 	}
 
-	// Check if enough training data
-	// Recommended: at least 30 * nlist vectors
-	minVectors := idx.nlist
-	if len(vectors) < minVectors {
-		return fmt.Errorf("insufficient training data: need at least %d vectors, got %d",
-			minVectors, len(vectors))
-	}
-
 	// Validate all vectors
 	dim := vectors[0].Dimension()
 	for i, v := range vectors {
@@ -86,9 +120,25 @@ func (idx *IVFIndex) Train(vectors []vector.Vector) error {
 		}
 	}
 
+	idx.retrainMu.Lock()
+	defer idx.retrainMu.Unlock()
+
 	idx.mu.Lock()
 	defer idx.mu.Unlock()
 
+	if idx.mapped != nil {
+		return errReadOnly
+	}
+
+	// Check if enough training data
+	// Recommended: at least 30 * nlist vectors. nlist is read under the lock
+	// because Rebalance can change it.
+	minVectors := idx.nlist
+	if len(vectors) < minVectors {
+		return fmt.Errorf("insufficient training data: need at least %d vectors, got %d",
+			minVectors, len(vectors))
+	}
+
 	// Run k-means clustering
 	centroids, err := KMeans(vectors, idx.nlist, 100, idx.metric)
 	if err != nil {
@@ -98,9 +148,15 @@ func (idx *IVFIndex) Train(vectors []vector.Vector) error {
 	// Store centroids and initialize empty clusters
 	idx.centroids = centroids
 	idx.clusters = make([][]vector.Vector, idx.nlist)
+	idx.ids = make([][]int, idx.nlist)
+	idx.radii = make([]float64, idx.nlist)
 	for i := range idx.clusters {
 		idx.clusters[i] = make([]vector.Vector, 0)
+		idx.ids[i] = make([]int, 0)
 	}
+	idx.nextID = 0
+	idx.shared = false
+	idx.version++
 
 	idx.trained = true
 	idx.dimension = dim
@@ -118,6 +174,10 @@ func (idx *IVFIndex) Add(v vector.Vector) error {
 	idx.mu.Lock()
 	defer idx.mu.Unlock()
 
+	if idx.mapped != nil {
+		return errReadOnly
+	}
+
 	// Check if trained
 	if !idx.trained {
 		return fmt.Errorf("index not trained: call Train() first")
@@ -130,19 +190,50 @@ func (idx *IVFIndex) Add(v vector.Vector) error {
 	}
 
 	// Find nearest centroid
-	centroidIdx, err := FindNearestCentroid(v, idx.centroids, idx.metric)
+	centroidIdx, dist, err := nearestCentroid(v, idx.centroids, idx.metric)
 	if err != nil {
 		return fmt.Errorf("failed to find nearest centroid: %w", err)
 	}
+	idx.unshare()
+	if dist > idx.radii[centroidIdx] {
+		idx.radii[centroidIdx] = dist
+	}
 
 	// Add to that cluster
 	idx.clusters[centroidIdx] = append(idx.clusters[centroidIdx], v.Clone())
+	idx.ids[centroidIdx] = append(idx.ids[centroidIdx], idx.nextID)
+	idx.nextID++
+	idx.version++
 
 	return nil
 }
 
 // Search performs approximate k-NN search
 func (idx *IVFIndex) Search(query vector.Vector, k int) ([]SearchResult, error) {
+	return idx.SearchWithOptions(query, k, index.DefaultSearchOptions())
+}
+
+// SearchWithParams performs approximate k-NN search with per-call settings
+func (idx *IVFIndex) SearchWithParams(query vector.Vector, k int, params SearchParams) ([]SearchResult, error) {
+	opts := index.DefaultSearchOptions()
+	opts.NProbe = params.NProbe
+	opts.Adaptive = params.Adaptive
+	opts.MaxCandidates = params.MaxCandidates
+	return idx.SearchWithOptions(query, k, opts)
+}
+
+// SearchWithOptions performs approximate k-NN search with per-call settings
+// NProbe overrides the index's nprobe for this call only; the filter is
+// applied before distances are computed. Stats and Tracer split the time
+// into ranking centroids, scanning lists and selecting results.
+func (idx *IVFIndex) SearchWithOptions(query vector.Vector, k int, opts index.SearchOptions) ([]SearchResult, error) {
+	tr := opts.StartTrace("ivf.Search")
+	results, err := idx.search(query, k, opts, &tr)
+	tr.End(err)
+	return results, err
+}
+
+func (idx *IVFIndex) search(query vector.Vector, k int, opts index.SearchOptions, tr *index.Trace) ([]SearchResult, error) {
 	// Validate query
 	if err := query.Validate(); err != nil {
 		return nil, fmt.Errorf("invalid query: %w", err)
@@ -152,6 +243,10 @@ func (idx *IVFIndex) Search(query vector.Vector, k int) ([]SearchResult, error)
 		return nil, fmt.Errorf("k must be positive, got %d", k)
 	}
 
+	if err := opts.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid search options: %w", err)
+	}
+
 	idx.mu.RLock()
 	defer idx.mu.RUnlock()
 
@@ -166,65 +261,87 @@ func (idx *IVFIndex) Search(query vector.Vector, k int) ([]SearchResult, error)
 			idx.dimension, query.Dimension())
 	}
 
-	// Find nprobe nearest centroids
-	nearestCentroids, err := idx.findNearestCentroids(query, idx.nprobe)
+	nprobe := idx.nprobe
+	if opts.NProbe != 0 {
+		if opts.NProbe > idx.nlist {
+			return nil, fmt.Errorf("nprobe (%d) cannot exceed nlist (%d)", opts.NProbe, idx.nlist)
+		}
+		nprobe = opts.NProbe
+	}
+
+	// Without a rerank factor only the nprobe closest clusters are searched
+	minCandidates := 0
+	if opts.RerankFactor > 1 {
+		minCandidates = opts.Candidates(k)
+	}
+
+	// Rank clusters by centroid distance
+	tr.Phase(index.PhaseRankCentroids)
+	ranked, err := idx.rankCentroids(query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to find nearest centroids: %w", err)
 	}
+	stats := tr.Stats()
+	stats.DistanceComputations += len(ranked)
 
-	// Collect candidates from selected clusters
-	var candidates []SearchResult
-	globalIdx := 0
+	deadline := opts.Deadline()
 
-	for clusterIdx := 0; clusterIdx < idx.nlist; clusterIdx++ {
-		cluster := idx.clusters[clusterIdx]
+	tr.Phase(index.PhaseScan)
+	if opts.Adaptive {
+		best, err := idx.searchAdaptive(query, k, ranked, nprobe, minCandidates, opts, deadline, stats)
+		if err != nil {
+			return nil, err
+		}
+		tr.Phase(index.PhaseSelect)
+		results := sortedResults(best)
+		if idx.mapped != nil {
+			// Results outlive the search, and Close unmaps what they point at
+			results = index.CloneVectors(results)
+		}
+		return results, nil
+	}
+
+	// Keep the k closest candidates from the selected clusters
+	best := topk.New[SearchResult](k)
+	scanned := 0
+	defer func() { recordScan(stats, scanned) }()
 
-		// Check if this cluster is in our search list
-		searchThisCluster := false
-		for _, nearestIdx := range nearestCentroids {
-			if nearestIdx == clusterIdx {
-				searchThisCluster = true
-				break
-			}
+	for probed, c := range ranked {
+		if probed >= nprobe && scanned >= minCandidates {
+			break
 		}
 
-		if searchThisCluster {
-			// Search this cluster
-			for _, v := range cluster {
-				dist, err := idx.metric(query, v)
-				if err != nil {
-					return nil, fmt.Errorf("distance calculation failed: %w", err)
-				}
-
-				candidates = append(candidates, SearchResult{
-					Vector:   v,
-					Distance: dist,
-					Index:    globalIdx,
-				})
-				globalIdx++
-			}
-		} else {
-			// Skip this cluster but update global index
-			globalIdx += len(cluster)
+		stats.ClustersProbed++
+		if err := idx.scanCluster(query, c.index, opts, deadline, func(r SearchResult) {
+			best.Push(r, r.Distance)
+			scanned++
+		}); err != nil {
+			return nil, err
 		}
 	}
 
-	// Handle case: no candidates found
-	if len(candidates) == 0 {
-		return []SearchResult{}, nil
+	tr.Phase(index.PhaseSelect)
+	results := sortedResults(best)
+	if idx.mapped != nil {
+		results = index.CloneVectors(results)
 	}
+	return results, nil
+}
 
-	// Sort by distance
-	sort.Slice(candidates, func(i, j int) bool {
-		return candidates[i].Distance < candidates[j].Distance
-	})
+// recordScan adds the vectors scored while scanning lists to stats
+func recordScan(stats *index.SearchStats, scanned int) {
+	stats.CandidatesScanned += scanned
+	stats.DistanceComputations += scanned
+}
 
-	// Return top k
-	if k > len(candidates) {
-		k = len(candidates)
+// measure computes the distance between two vectors of the index's dimension
+// Dimensions are validated once on Train, Add and Search, so built-in metrics
+// skip their per-pair checks.
+func (idx *IVFIndex) measure(a, b vector.Vector) (float64, error) {
+	if idx.fast != nil {
+		return idx.fast(a, b), nil
 	}
-
-	return candidates[:k], nil
+	return idx.metric(a, b)
 }
 
 // SetNumProbes adjusts nprobe parameter at runtime
@@ -244,6 +361,50 @@ func (idx *IVFIndex) SetNumProbes(nprobe int) error {
 	return nil
 }
 
+// scanCluster computes the distance from query to every accepted vector in a
+// cluster and hands each result to emit
+func (idx *IVFIndex) scanCluster(
+	query vector.Vector,
+	clusterIdx int,
+	opts index.SearchOptions,
+	deadline index.Deadline,
+	emit func(SearchResult),
+) error {
+	for i, v := range idx.clusters[clusterIdx] {
+		if i%deadlineCheckInterval == 0 && deadline.Expired() {
+			return index.ErrTimeout
+		}
+
+		id := idx.ids[clusterIdx][i]
+		if !opts.Accept(id) {
+			continue
+		}
+
+		dist, err := idx.measure(query, v)
+		if err != nil {
+			return fmt.Errorf("distance calculation failed: %w", err)
+		}
+
+		r := SearchResult{Distance: dist, Index: id}
+		if opts.IncludeVectors {
+			r.Vector = v
+		}
+		emit(r)
+	}
+
+	return nil
+}
+
+// sortedResults drains best into a slice ordered by distance
+func sortedResults(best *topk.Heap[SearchResult]) []SearchResult {
+	items := best.Sorted()
+	results := make([]SearchResult, len(items))
+	for i, item := range items {
+		results[i] = item.Value
+	}
+	return results
+}
+
 // Size returns the total number of vectors in the index
 func (idx *IVFIndex) Size() int {
 	idx.mu.RLock()
@@ -260,21 +421,44 @@ func (idx *IVFIndex) Size() int {
 	return total
 }
 
-// findNearestCentroids finds nprobe nearest centroids to query
-func (idx *IVFIndex) findNearestCentroids(query vector.Vector, nprobe int) ([]int, error) {
+// MemoryUsage reports the bytes held by the index
+// Inverted lists count as vectors (and their parallel ID lists as IDs),
+// including the outer per-list slice headers; radii count as metadata.
+func (idx *IVFIndex) MemoryUsage() index.MemoryBreakdown {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	m := index.MemoryBreakdown{
+		Centroids: index.VectorsBytes(idx.centroids),
+		Vectors:   uint64(cap(idx.clusters)) * index.SliceHeaderBytes,
+		IDs:       uint64(cap(idx.ids)) * index.SliceHeaderBytes,
+		Metadata:  uint64(unsafe.Sizeof(*idx)) + uint64(cap(idx.radii))*uint64(unsafe.Sizeof(float64(0))),
+	}
+	for _, cluster := range idx.clusters {
+		m.Vectors += index.VectorsBytes(cluster)
+	}
+	for _, ids := range idx.ids {
+		m.IDs += index.IntsBytes(ids)
+	}
+	return m
+}
+
+// centroidDist pairs a list with its centroid's distance to a query
+type centroidDist struct {
+	index    int
+	distance float64
+}
+
+// rankCentroids returns every centroid sorted by distance to query
+func (idx *IVFIndex) rankCentroids(query vector.Vector) ([]centroidDist, error) {
 	if len(idx.centroids) == 0 {
 		return nil, fmt.Errorf("no centroids available")
 	}
 
 	// Calculate distance to all centroids
-	type centroidDist struct {
-		index    int
-		distance float64
-	}
-
 	distances := make([]centroidDist, len(idx.centroids))
 	for i, centroid := range idx.centroids {
-		dist, err := idx.metric(query, centroid)
+		dist, err := idx.measure(query, centroid)
 		if err != nil {
 			return nil, fmt.Errorf("distance calculation failed: %w", err)
 		}
@@ -289,15 +473,5 @@ func (idx *IVFIndex) findNearestCentroids(query vector.Vector, nprobe int) ([]in
 		return distances[i].distance < distances[j].distance
 	})
 
-	// Return indices of nprobe nearest
-	if nprobe > len(distances) {
-		nprobe = len(distances)
-	}
-
-	result := make([]int, nprobe)
-	for i := 0; i < nprobe; i++ {
-		result[i] = distances[i].index
-	}
-
-	return result, nil
+	return distances, nil
 }
@@ -226,7 +226,8 @@ func TestIVFRecallVsNprobe(t *testing.T) {
 		fmt.Printf("nprobe=%2d: recall=%5.1f%%\n", nprobe, recall*100)
 	}
 
-	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━\n")
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━")
+	fmt.Println()
 }
 
 func TestIVFDimensionMismatch(t *testing.T) {
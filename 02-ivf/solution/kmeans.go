@@ -39,6 +39,12 @@ func KMeans(
 		}
 	}
 
+	// Dimensions are consistent from here on, so built-in metrics can skip
+	// their per-pair checks in the assignment loops
+	if fast, ok := distance.Unchecked(metric); ok {
+		metric = func(a, b vector.Vector) (float64, error) { return fast(a, b), nil }
+	}
+
 	// Initialize centroids with k-means++
 	centroids := initializeCentroidsKMeansPlusPlus(vectors, k, metric)
 
@@ -96,20 +102,30 @@ func FindNearestCentroid(
 	centroids []vector.Vector,
 	metric distance.Metric,
 ) (int, error) {
+	minIdx, _, err := nearestCentroid(v, centroids, metric)
+	return minIdx, err
+}
+
+// nearestCentroid is FindNearestCentroid that also returns the distance
+func nearestCentroid(
+	v vector.Vector,
+	centroids []vector.Vector,
+	metric distance.Metric,
+) (int, float64, error) {
 	if len(centroids) == 0 {
-		return 0, fmt.Errorf("no centroids provided")
+		return 0, 0, fmt.Errorf("no centroids provided")
 	}
 
 	minIdx := 0
 	minDist, err := metric(v, centroids[0])
 	if err != nil {
-		return 0, fmt.Errorf("distance calculation failed: %w", err)
+		return 0, 0, fmt.Errorf("distance calculation failed: %w", err)
 	}
 
 	for i := 1; i < len(centroids); i++ {
 		dist, err := metric(v, centroids[i])
 		if err != nil {
-			return 0, fmt.Errorf("distance calculation failed: %w", err)
+			return 0, 0, fmt.Errorf("distance calculation failed: %w", err)
 		}
 
 		if dist < minDist {
@@ -118,7 +134,7 @@ func FindNearestCentroid(
 		}
 	}
 
-	return minIdx, nil
+	return minIdx, minDist, nil
 }
 
 // initializeCentroidsKMeansPlusPlus implements k-means++ initialization
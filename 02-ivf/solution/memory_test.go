@@ -0,0 +1,39 @@
+package solution
+
+import (
+	"testing"
+
+	"github.com/tmdgusya/database-class/pkg/index"
+	"github.com/tmdgusya/database-class/pkg/testdata"
+)
+
+func TestMemoryUsage(t *testing.T) {
+	const n, dim, nlist = 300, 16, 6
+	vectors := testdata.GenerateClusteredVectors(n, dim, nlist, 42)
+	idx := buildTrainedIVF(t, vectors, vectors, nlist, 2)
+
+	m := idx.MemoryUsage()
+
+	// Every vector and ID is stored once; headers and spare capacity only add
+	vectorPayload := uint64(n * dim * 8)
+	if m.Vectors < vectorPayload+n*index.SliceHeaderBytes {
+		t.Errorf("Vectors = %d, want at least %d", m.Vectors, vectorPayload+n*index.SliceHeaderBytes)
+	}
+	if m.IDs < n*8 {
+		t.Errorf("IDs = %d, want at least %d", m.IDs, n*8)
+	}
+	if m.Centroids < uint64(nlist*dim*8) {
+		t.Errorf("Centroids = %d, want at least %d", m.Centroids, nlist*dim*8)
+	}
+	if m.Graph != 0 || m.Codes != 0 {
+		t.Errorf("IVF has no graph or codes, got %+v", m)
+	}
+	if m.Total() != m.Vectors+m.Centroids+m.IDs+m.Metadata {
+		t.Errorf("Total() = %d does not add up: %+v", m.Total(), m)
+	}
+
+	// Accounting is computed, not sampled: repeated calls agree exactly
+	if again := idx.MemoryUsage(); again != m {
+		t.Errorf("MemoryUsage() changed between calls: %+v vs %+v", m, again)
+	}
+}
@@ -0,0 +1,150 @@
+package solution
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/tmdgusya/database-class/pkg/distance"
+	"github.com/tmdgusya/database-class/pkg/mmapfile"
+	"github.com/tmdgusya/database-class/pkg/vector"
+)
+
+// mappedMagic starts a file written by WriteMapped
+const mappedMagic = "VECIVF01"
+
+// errReadOnly is returned by the mutating methods of a memory-mapped index
+var errReadOnly = errors.New("index is read-only (memory-mapped)")
+
+// WriteMapped writes a trained index in the layout OpenMappedIVFIndex maps:
+//
+//	magic "VECIVF01", nlist, nprobe, dimension, next ID
+//	centroids  nlist*dimension float64s
+//	radii      nlist float64s
+//	list sizes nlist int64s
+//	each list  its IDs (int64s), then its vectors (float64s)
+//
+// All values are little-endian 8-byte words (see pkg/mmapfile).
+func (idx *IVFIndex) WriteMapped(w io.Writer) error {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	if !idx.trained {
+		return fmt.Errorf("index not trained: call Train() first")
+	}
+
+	mw := mmapfile.NewWriter(w)
+	mw.Magic(mappedMagic)
+	mw.Int(idx.nlist)
+	mw.Int(idx.nprobe)
+	mw.Int(idx.dimension)
+	mw.Int(idx.nextID)
+	for _, c := range idx.centroids {
+		mw.Float64s(c)
+	}
+	mw.Float64s(idx.radii)
+	for _, cluster := range idx.clusters {
+		mw.Int(len(cluster))
+	}
+	for c, cluster := range idx.clusters {
+		mw.Ints(idx.ids[c])
+		for _, v := range cluster {
+			mw.Float64s(v)
+		}
+	}
+	return mw.Flush()
+}
+
+// OpenMappedIVFIndex maps a file written by WriteMapped and searches it in
+// place
+// Centroids, inverted lists and their IDs are views into the mapping; only
+// the slice headers are allocated. The index is read-only (Add, Train,
+// Retrain and Rebalance fail). Vectors returned with IncludeVectors are
+// copied out of the mapping, so results stay valid after Close, which waits
+// for searches in progress.
+func OpenMappedIVFIndex(path string, metric distance.Metric) (*IVFIndex, error) {
+	f, err := mmapfile.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	idx, err := mapIVF(f, metric)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s: corrupt mapped IVF index: %w", path, err)
+	}
+	return idx, nil
+}
+
+func mapIVF(f *mmapfile.File, metric distance.Metric) (*IVFIndex, error) {
+	r := mmapfile.NewReader(f.Bytes())
+	r.Magic(mappedMagic)
+	nlist := r.Int("nlist", 1, r.Remaining())
+	nprobe := r.Int("nprobe", 1, nlist)
+	dim := r.Int("dimension", 1, math.MaxInt32)
+	nextID := r.Int("next ID", 0, math.MaxInt)
+	if err := r.Err(); err != nil {
+		return nil, err
+	}
+
+	idx, err := NewIVFIndex(Config{Metric: metric, NumClusters: nlist, NumProbes: nprobe})
+	if err != nil {
+		return nil, err
+	}
+
+	centroids := make([]vector.Vector, nlist)
+	for c := range centroids {
+		centroids[c] = r.Float64s(dim)
+	}
+	radii := r.Float64s(nlist)
+	sizes := make([]int, nlist)
+	for c := range sizes {
+		sizes[c] = r.Int("list size", 0, r.Remaining()/(dim+1))
+	}
+
+	clusters := make([][]vector.Vector, nlist)
+	ids := make([][]int, nlist)
+	for c, size := range sizes {
+		ids[c] = r.Ints(size)
+		clusters[c] = make([]vector.Vector, size)
+		for i := range clusters[c] {
+			clusters[c][i] = r.Float64s(dim)
+		}
+		for _, id := range ids[c] {
+			if id < 0 || id >= nextID {
+				return nil, fmt.Errorf("list %d holds ID %d outside [0, %d)", c, id, nextID)
+			}
+		}
+	}
+	if err := r.Finish(); err != nil {
+		return nil, err
+	}
+
+	idx.mapped = f
+	idx.trained = true
+	idx.dimension = dim
+	idx.nextID = nextID
+	idx.centroids = centroids
+	idx.radii = radii
+	idx.clusters = clusters
+	idx.ids = ids
+	return idx, nil
+}
+
+// Close releases the mapping of an index opened with OpenMappedIVFIndex,
+// leaving it untrained; it fails while snapshots of the index are still
+// unreleased. For other indexes it does nothing
+func (idx *IVFIndex) Close() error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if idx.mapped == nil {
+		return nil
+	}
+	if idx.pinned > 0 {
+		return fmt.Errorf("cannot unmap: %d snapshots not released", idx.pinned)
+	}
+	idx.trained = false
+	idx.centroids, idx.radii, idx.clusters, idx.ids = nil, nil, nil, nil
+	return idx.mapped.Close()
+}
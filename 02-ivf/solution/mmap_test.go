@@ -0,0 +1,91 @@
+package solution
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tmdgusya/database-class/pkg/distance"
+	"github.com/tmdgusya/database-class/pkg/index"
+	"github.com/tmdgusya/database-class/pkg/testdata"
+)
+
+func TestIVFMapped(t *testing.T) {
+	vectors := testdata.GenerateClusteredVectors(500, 8, 5, 42)
+	idx, _ := NewIVFIndex(Config{Metric: distance.L2Distance, NumClusters: 8, NumProbes: 2})
+	if err := idx.Train(vectors); err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range vectors {
+		idx.Add(v)
+	}
+
+	path := filepath.Join(t.TempDir(), "ivf.map")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := idx.WriteMapped(f); err != nil {
+		t.Fatalf("WriteMapped() failed: %v", err)
+	}
+	f.Close()
+
+	mapped, err := OpenMappedIVFIndex(path, distance.L2Distance)
+	if err != nil {
+		t.Fatalf("OpenMappedIVFIndex() failed: %v", err)
+	}
+	defer mapped.Close()
+
+	for _, q := range testdata.GenerateRandomVectors(10, 8, 7) {
+		for _, opts := range []index.SearchOptions{{}, {NProbe: 8}, {Adaptive: true}} {
+			want, _ := idx.SearchWithOptions(q, 5, opts)
+			got, err := mapped.SearchWithOptions(q, 5, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(want) {
+				t.Fatalf("%d results, want %d", len(got), len(want))
+			}
+			for i := range want {
+				if got[i].Index != want[i].Index || got[i].Distance != want[i].Distance {
+					t.Fatalf("options %+v: result %d = %+v, want %+v", opts, i, got[i], want[i])
+				}
+			}
+		}
+	}
+
+	if err := mapped.Add(vectors[0]); err == nil {
+		t.Error("Add() succeeded on a mapped index")
+	}
+	if err := mapped.Train(vectors); err == nil {
+		t.Error("Train() succeeded on a mapped index")
+	}
+	if err := mapped.Rebalance(100, 0); err == nil {
+		t.Error("Rebalance() succeeded on a mapped index")
+	}
+
+	probe := vectors[5]
+	kept, _ := mapped.SearchWithOptions(probe, 1, index.SearchOptions{IncludeVectors: true, NProbe: 8})
+	if err := mapped.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// Result vectors were copied out of the mapping before it went away
+	if !kept[0].Vector.Equal(probe, 0) {
+		t.Errorf("result vector after Close = %v, want %v", kept[0].Vector, probe)
+	}
+
+	untrained, _ := NewIVFIndex(Config{Metric: distance.L2Distance, NumClusters: 8, NumProbes: 2})
+	if err := untrained.WriteMapped(io.Discard); err == nil {
+		t.Error("WriteMapped() wrote an untrained index")
+	}
+
+	// An ID beyond next ID would make Filter callers index out of range
+	data, _ := os.ReadFile(path)
+	data[4*8] = 0 // next ID
+	bad := filepath.Join(t.TempDir(), "bad.map")
+	os.WriteFile(bad, data, 0o644)
+	if _, err := OpenMappedIVFIndex(bad, distance.L2Distance); err == nil {
+		t.Error("OpenMappedIVFIndex() accepted out-of-range IDs")
+	}
+}
@@ -0,0 +1,155 @@
+package solution
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tmdgusya/database-class/pkg/index"
+	"github.com/tmdgusya/database-class/pkg/testdata"
+)
+
+func TestSearchWithOptionsFilter(t *testing.T) {
+	vectors := testdata.GenerateClusteredVectors(300, 16, 6, 42)
+	idx := buildTrainedIVF(t, vectors, vectors, 6, 6)
+
+	below := func(id int) bool { return id < 50 }
+	results, err := idx.SearchWithOptions(vectors[100], 10, index.SearchOptions{Filter: below})
+	if err != nil {
+		t.Fatalf("SearchWithOptions() failed: %v", err)
+	}
+	if len(results) != 10 {
+		t.Fatalf("got %d results, want 10", len(results))
+	}
+	for _, r := range results {
+		if r.Index >= 50 {
+			t.Errorf("filtered search returned ID %d", r.Index)
+		}
+		if r.Vector != nil {
+			t.Error("IncludeVectors=false should leave Vector nil")
+		}
+	}
+}
+
+func TestSearchWithOptionsRerankFactorProbesMore(t *testing.T) {
+	vectors := testdata.GenerateClusteredVectors(300, 16, 6, 42)
+	idx := buildTrainedIVF(t, vectors, vectors, 6, 1)
+
+	// Asking for more candidates than the whole index forces every list to be probed
+	query := testdata.GenerateRandomVectors(1, 16, 3)[0]
+	got, err := idx.SearchWithOptions(query, 10, index.SearchOptions{RerankFactor: 100})
+	if err != nil {
+		t.Fatalf("SearchWithOptions() failed: %v", err)
+	}
+	want, _ := buildFlatIndex(vectors).Search(query, 10)
+	for i := range want {
+		if got[i].Index != want[i].Index {
+			t.Errorf("rank %d: got %d, want %d", i, got[i].Index, want[i].Index)
+		}
+	}
+}
+
+func TestSearchWithOptionsTimeout(t *testing.T) {
+	vectors := testdata.GenerateClusteredVectors(300, 16, 6, 42)
+	idx := buildTrainedIVF(t, vectors, vectors, 6, 6)
+
+	var once sync.Once
+	slowFilter := func(id int) bool {
+		once.Do(func() { time.Sleep(5 * time.Millisecond) })
+		return true
+	}
+
+	_, err := idx.SearchWithOptions(vectors[0], 5, index.SearchOptions{
+		Filter:  slowFilter,
+		Timeout: time.Millisecond,
+	})
+	if !errors.Is(err, index.ErrTimeout) {
+		t.Errorf("SearchWithOptions() error = %v, want ErrTimeout", err)
+	}
+}
+
+func TestSearchWithOptionsConcurrentCallers(t *testing.T) {
+	vectors := testdata.GenerateClusteredVectors(300, 16, 6, 42)
+	idx := buildTrainedIVF(t, vectors, vectors, 6, 1)
+	flatIdx := buildFlatIndex(vectors)
+
+	// Recall-sensitive callers must get exact results while fast callers run
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 20; i++ {
+				q := vectors[(g*20+i)%len(vectors)]
+				if g%2 == 0 {
+					idx.SearchWithOptions(q, 5, index.SearchOptions{NProbe: 1})
+					continue
+				}
+				got, err := idx.SearchWithOptions(q, 5, index.SearchOptions{NProbe: 6})
+				if err != nil {
+					t.Errorf("SearchWithOptions() failed: %v", err)
+					return
+				}
+				want, _ := flatIdx.Search(q, 5)
+				if got[0].Index != want[0].Index {
+					t.Errorf("NProbe=nlist top result %d, want %d", got[0].Index, want[0].Index)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+}
+
+// spanNames records the names of the spans it starts
+type spanNames []string
+
+func (s *spanNames) Start(ctx context.Context, name string) (context.Context, index.Span) {
+	*s = append(*s, name)
+	return ctx, noopSpan{}
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttributes(...index.Attribute) {}
+func (noopSpan) RecordError(error)                {}
+func (noopSpan) End()                             {}
+
+func TestSearchWithOptionsStats(t *testing.T) {
+	vectors := testdata.GenerateClusteredVectors(300, 16, 6, 42)
+	idx := buildTrainedIVF(t, vectors, vectors, 6, 2)
+
+	var stats index.SearchStats
+	var spans spanNames
+	opts := index.SearchOptions{Stats: &stats, Tracer: &spans}
+	if _, err := idx.SearchWithOptions(vectors[0], 5, opts); err != nil {
+		t.Fatalf("SearchWithOptions() failed: %v", err)
+	}
+
+	if stats.ClustersProbed != 2 {
+		t.Errorf("ClustersProbed = %d, want nprobe=2", stats.ClustersProbed)
+	}
+	// Every centroid is ranked, then each scanned vector is scored once
+	if stats.DistanceComputations != 6+stats.CandidatesScanned || stats.CandidatesScanned == 0 {
+		t.Errorf("stats = %+v", stats)
+	}
+	want := []string{"ivf.Search", index.PhaseRankCentroids, index.PhaseScan, index.PhaseSelect}
+	if len(spans) != len(want) {
+		t.Fatalf("spans = %v, want %v", spans, want)
+	}
+	for i := range want {
+		if spans[i] != want[i] || (i > 0 && stats.Phases[i-1].Name != want[i]) {
+			t.Fatalf("spans = %v, phases = %+v, want %v", spans, stats.Phases, want)
+		}
+	}
+
+	// Adaptive search reports through the same counters
+	opts.Adaptive = true
+	if _, err := idx.SearchWithOptions(vectors[0], 5, opts); err != nil {
+		t.Fatalf("adaptive SearchWithOptions() failed: %v", err)
+	}
+	if stats.ClustersProbed < 2 || stats.PhaseDuration(index.PhaseScan) == 0 {
+		t.Errorf("adaptive stats = %+v", stats)
+	}
+}
@@ -0,0 +1,115 @@
+package solution
+
+import (
+	"encoding/gob"
+	"fmt"
+	"io"
+
+	"github.com/tmdgusya/database-class/pkg/distance"
+	"github.com/tmdgusya/database-class/pkg/vector"
+)
+
+// fileVersion is bumped whenever ivfFile changes incompatibly
+const fileVersion = 1
+
+// ivfFile is the serialized form of an IVFIndex
+type ivfFile struct {
+	Version   int
+	NList     int
+	NProbe    int
+	Trained   bool
+	Dimension int
+	NextID    int
+	Centroids []vector.Vector
+	Clusters  [][]vector.Vector
+	IDs       [][]int
+	Radii     []float64
+}
+
+// Save writes the index, trained or not, to w with encoding/gob
+// The metric is a function and cannot be saved; pass it to LoadIVFIndex.
+func (idx *IVFIndex) Save(w io.Writer) error {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	return gob.NewEncoder(w).Encode(ivfFile{
+		Version:   fileVersion,
+		NList:     idx.nlist,
+		NProbe:    idx.nprobe,
+		Trained:   idx.trained,
+		Dimension: idx.dimension,
+		NextID:    idx.nextID,
+		Centroids: idx.centroids,
+		Clusters:  idx.clusters,
+		IDs:       idx.ids,
+		Radii:     idx.radii,
+	})
+}
+
+// LoadIVFIndex reads an index written by Save
+func LoadIVFIndex(r io.Reader, metric distance.Metric) (*IVFIndex, error) {
+	var f ivfFile
+	if err := gob.NewDecoder(r).Decode(&f); err != nil {
+		return nil, fmt.Errorf("decode IVF index: %w", err)
+	}
+	if f.Version != fileVersion {
+		return nil, fmt.Errorf("unsupported IVF index version %d (want %d)", f.Version, fileVersion)
+	}
+
+	idx, err := NewIVFIndex(Config{Metric: metric, NumClusters: f.NList, NumProbes: f.NProbe})
+	if err != nil {
+		return nil, fmt.Errorf("corrupt IVF index: %w", err)
+	}
+	if !f.Trained {
+		return idx, nil
+	}
+	if err := f.check(); err != nil {
+		return nil, fmt.Errorf("corrupt IVF index: %w", err)
+	}
+
+	idx.trained = true
+	idx.dimension = f.Dimension
+	idx.nextID = f.NextID
+	idx.centroids = f.Centroids
+	idx.clusters = f.Clusters
+	idx.ids = f.IDs
+	idx.radii = f.Radii
+	return idx, nil
+}
+
+// check verifies that the lists are parallel and every vector fits
+func (f *ivfFile) check() error {
+	if len(f.Centroids) != f.NList || len(f.Clusters) != f.NList ||
+		len(f.IDs) != f.NList || len(f.Radii) != f.NList {
+		return fmt.Errorf("%d lists but %d centroids, %d clusters, %d ID lists and %d radii",
+			f.NList, len(f.Centroids), len(f.Clusters), len(f.IDs), len(f.Radii))
+	}
+
+	// gob drops empty slices; restore them so every list is non-nil
+	for c := range f.Clusters {
+		if f.Clusters[c] == nil {
+			f.Clusters[c] = make([]vector.Vector, 0)
+		}
+		if f.IDs[c] == nil {
+			f.IDs[c] = make([]int, 0)
+		}
+	}
+
+	for c, centroid := range f.Centroids {
+		if centroid.Dimension() != f.Dimension {
+			return fmt.Errorf("centroid %d has dimension %d, want %d", c, centroid.Dimension(), f.Dimension)
+		}
+		if len(f.Clusters[c]) != len(f.IDs[c]) {
+			return fmt.Errorf("list %d has %d vectors but %d IDs", c, len(f.Clusters[c]), len(f.IDs[c]))
+		}
+		for i, v := range f.Clusters[c] {
+			if v.Dimension() != f.Dimension {
+				return fmt.Errorf("list %d, vector %d has dimension %d, want %d", c, i, v.Dimension(), f.Dimension)
+			}
+			if id := f.IDs[c][i]; id < 0 || id >= f.NextID {
+				return fmt.Errorf("list %d holds ID %d outside [0, %d)", c, id, f.NextID)
+			}
+		}
+	}
+	return nil
+}
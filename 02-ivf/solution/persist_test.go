@@ -0,0 +1,85 @@
+package solution
+
+import (
+	"bytes"
+	"encoding/gob"
+	"testing"
+
+	"github.com/tmdgusya/database-class/pkg/distance"
+	"github.com/tmdgusya/database-class/pkg/testdata"
+	"github.com/tmdgusya/database-class/pkg/vector"
+)
+
+func TestIVFSaveLoad(t *testing.T) {
+	vectors := testdata.GenerateClusteredVectors(500, 8, 5, 42)
+	idx, _ := NewIVFIndex(Config{Metric: distance.L2Distance, NumClusters: 8, NumProbes: 2})
+	if err := idx.Train(vectors); err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range vectors {
+		idx.Add(v)
+	}
+
+	var buf bytes.Buffer
+	if err := idx.Save(&buf); err != nil {
+		t.Fatalf("Save() failed: %v", err)
+	}
+	loaded, err := LoadIVFIndex(&buf, distance.L2Distance)
+	if err != nil {
+		t.Fatalf("LoadIVFIndex() failed: %v", err)
+	}
+
+	sizes, loadedSizes := idx.ListSizes(), loaded.ListSizes()
+	for i := range sizes {
+		if sizes[i] != loadedSizes[i] {
+			t.Fatalf("list sizes %v, want %v", loadedSizes, sizes)
+		}
+	}
+	for _, q := range testdata.GenerateRandomVectors(10, 8, 7) {
+		want, _ := idx.Search(q, 5)
+		got, _ := loaded.Search(q, 5)
+		for i := range want {
+			if got[i].Index != want[i].Index {
+				t.Fatalf("result %d = %d, want %d", i, got[i].Index, want[i].Index)
+			}
+		}
+	}
+
+	// New vectors continue the ID sequence: the duplicate of vector 0 is 500
+	loaded.Add(vectors[0])
+	res, _ := loaded.SearchWithParams(vectors[0], 2, SearchParams{NProbe: 8})
+	if len(res) != 2 || res[0].Index+res[1].Index != 500 {
+		t.Errorf("duplicate search = %+v, want IDs 0 and 500", res)
+	}
+
+	// An untrained index saves its configuration only
+	untrained, _ := NewIVFIndex(Config{Metric: distance.L2Distance, NumClusters: 4, NumProbes: 1})
+	buf.Reset()
+	untrained.Save(&buf)
+	restored, err := LoadIVFIndex(&buf, distance.L2Distance)
+	if err != nil {
+		t.Fatalf("untrained round trip: %v", err)
+	}
+	if err := restored.Add(vectors[0]); err == nil {
+		t.Error("loaded untrained index accepted Add()")
+	}
+}
+
+func TestIVFLoadRejectsCorruptLists(t *testing.T) {
+	var buf bytes.Buffer
+	gob.NewEncoder(&buf).Encode(ivfFile{
+		Version:   fileVersion,
+		NList:     2,
+		NProbe:    1,
+		Trained:   true,
+		Dimension: 2,
+		NextID:    1,
+		Centroids: testdata.GenerateRandomVectors(2, 2, 1),
+		Clusters:  [][]vector.Vector{testdata.GenerateRandomVectors(1, 2, 2), nil},
+		IDs:       [][]int{{5}, nil}, // ID beyond NextID
+		Radii:     []float64{1, 1},
+	})
+	if _, err := LoadIVFIndex(&buf, distance.L2Distance); err == nil {
+		t.Error("LoadIVFIndex() accepted an out-of-range ID")
+	}
+}
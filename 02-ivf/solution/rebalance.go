@@ -0,0 +1,375 @@
+package solution
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/tmdgusya/database-class/pkg/distance"
+	"github.com/tmdgusya/database-class/pkg/vector"
+)
+
+// splitNeighborLists is how many lists around a split list are checked for
+// vectors that should move to one of the two new centroids
+const splitNeighborLists = 4
+
+// ivfLayout is a copy of the cluster structure that can be rebuilt
+// without holding the index lock
+type ivfLayout struct {
+	centroids []vector.Vector
+	clusters  [][]vector.Vector
+	ids       [][]int
+	radii     []float64
+}
+
+// Retrain recomputes centroids from sample and reassigns every stored vector
+//
+// The expensive work (k-means and reassignment) runs on a snapshot of the
+// index without holding the write lock, so searches keep being served while
+// it runs. Vectors added during the rebuild are assigned to the new centroids
+// when the result is swapped in. Call it in its own goroutine to retrain in
+// the background.
+func (idx *IVFIndex) Retrain(sample []vector.Vector) error {
+	if len(sample) == 0 {
+		return fmt.Errorf("no training vectors provided")
+	}
+
+	idx.retrainMu.Lock()
+	defer idx.retrainMu.Unlock()
+
+	snap, err := idx.snapshotLayout()
+	if err != nil {
+		return err
+	}
+
+	nlist := len(snap.centroids)
+	if len(sample) < nlist {
+		return fmt.Errorf("insufficient training data: need at least %d vectors, got %d",
+			nlist, len(sample))
+	}
+	for i, v := range sample {
+		if err := v.Validate(); err != nil {
+			return fmt.Errorf("invalid vector at index %d: %w", i, err)
+		}
+		if v.Dimension() != snap.centroids[0].Dimension() {
+			return fmt.Errorf("dimension mismatch at vector %d: expected %d, got %d",
+				i, snap.centroids[0].Dimension(), v.Dimension())
+		}
+	}
+
+	centroids, err := KMeans(sample, nlist, 100, idx.metric)
+	if err != nil {
+		return fmt.Errorf("k-means clustering failed: %w", err)
+	}
+
+	next := ivfLayout{
+		centroids: centroids,
+		clusters:  make([][]vector.Vector, nlist),
+		ids:       make([][]int, nlist),
+		radii:     make([]float64, nlist),
+	}
+	for c := range snap.clusters {
+		if err := idx.assignAll(&next, snap.clusters[c], snap.ids[c]); err != nil {
+			return err
+		}
+	}
+
+	return idx.commitLayout(snap, next)
+}
+
+// Rebalance splits lists holding more than maxListSize vectors and merges
+// lists holding fewer than minListSize vectors into their neighbors
+//
+// Splitting runs 2-means on the oversized list and then checks the closest
+// neighboring lists for vectors that are now nearer to one of the two new
+// centroids (the local reassignment used by SPFresh). Merging removes the
+// undersized list's centroid and moves its vectors to their nearest remaining
+// centroid. Either bound can be disabled by passing 0. Like Retrain, the
+// rebuild runs without blocking readers.
+//
+// The list count changes for good: later Train and Retrain calls cluster
+// into the rebalanced nlist, not the one given in Config.
+func (idx *IVFIndex) Rebalance(maxListSize, minListSize int) error {
+	if maxListSize < 0 || minListSize < 0 {
+		return fmt.Errorf("list size bounds must be non-negative, got max=%d min=%d",
+			maxListSize, minListSize)
+	}
+	if maxListSize > 0 && minListSize >= maxListSize {
+		return fmt.Errorf("minListSize (%d) must be smaller than maxListSize (%d)",
+			minListSize, maxListSize)
+	}
+
+	idx.retrainMu.Lock()
+	defer idx.retrainMu.Unlock()
+
+	snap, err := idx.snapshotLayout()
+	if err != nil {
+		return err
+	}
+
+	next := ivfLayout{
+		centroids: append([]vector.Vector(nil), snap.centroids...),
+		clusters:  make([][]vector.Vector, len(snap.clusters)),
+		ids:       make([][]int, len(snap.ids)),
+		radii:     make([]float64, len(snap.clusters)),
+	}
+	for c := range snap.clusters {
+		next.clusters[c] = append([]vector.Vector(nil), snap.clusters[c]...)
+		next.ids[c] = append([]int(nil), snap.ids[c]...)
+	}
+
+	if maxListSize > 0 {
+		// Only the lists present before splitting are candidates; new
+		// halves are not split again in the same pass
+		numLists := len(next.clusters)
+		for c := 0; c < numLists; c++ {
+			if len(next.clusters[c]) > maxListSize {
+				if err := idx.splitList(&next, c); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if minListSize > 0 {
+		for len(next.clusters) > 1 {
+			smallest := 0
+			for c := range next.clusters {
+				if len(next.clusters[c]) < len(next.clusters[smallest]) {
+					smallest = c
+				}
+			}
+			if len(next.clusters[smallest]) >= minListSize {
+				break
+			}
+			if err := idx.mergeList(&next, smallest); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Lists changed wholesale above, so measure their radii from scratch
+	if err := idx.computeRadii(&next); err != nil {
+		return err
+	}
+
+	return idx.commitLayout(snap, next)
+}
+
+// ListSizes returns the number of vectors in each inverted list
+func (idx *IVFIndex) ListSizes() []int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	sizes := make([]int, len(idx.clusters))
+	for i, cluster := range idx.clusters {
+		sizes[i] = len(cluster)
+	}
+	return sizes
+}
+
+// splitList replaces list c with two lists built by 2-means
+func (idx *IVFIndex) splitList(layout *ivfLayout, c int) error {
+	vectors, ids := layout.clusters[c], layout.ids[c]
+
+	halves, err := KMeans(vectors, 2, 20, idx.metric)
+	if err != nil {
+		return fmt.Errorf("failed to split list %d: %w", c, err)
+	}
+
+	var left, right []vector.Vector
+	var leftIDs, rightIDs []int
+	for i, v := range vectors {
+		nearest, err := FindNearestCentroid(v, halves, idx.metric)
+		if err != nil {
+			return fmt.Errorf("failed to split list %d: %w", c, err)
+		}
+		if nearest == 0 {
+			left = append(left, v)
+			leftIDs = append(leftIDs, ids[i])
+		} else {
+			right = append(right, v)
+			rightIDs = append(rightIDs, ids[i])
+		}
+	}
+
+	// Duplicate vectors can produce a degenerate split; keep the list as is
+	if len(left) == 0 || len(right) == 0 {
+		return nil
+	}
+
+	// Pick neighbors before the centroid is replaced
+	neighbors, err := nearestLists(layout.centroids[c], layout.centroids, splitNeighborLists+1, idx.metric)
+	if err != nil {
+		return err
+	}
+
+	layout.centroids[c] = halves[0]
+	layout.clusters[c], layout.ids[c] = left, leftIDs
+	layout.centroids = append(layout.centroids, halves[1])
+	layout.clusters = append(layout.clusters, right)
+	layout.ids = append(layout.ids, rightIDs)
+	layout.radii = append(layout.radii, 0)
+	newList := len(layout.centroids) - 1
+
+	// Vectors in nearby lists may now be closer to one of the new centroids
+	for _, n := range neighbors {
+		if n == c {
+			continue
+		}
+		keep, keepIDs := layout.clusters[n][:0:0], layout.ids[n][:0:0]
+		for i, v := range layout.clusters[n] {
+			target, err := closerList(v, n, []int{c, newList}, layout.centroids, idx.metric)
+			if err != nil {
+				return err
+			}
+			if target == n {
+				keep = append(keep, v)
+				keepIDs = append(keepIDs, layout.ids[n][i])
+				continue
+			}
+			layout.clusters[target] = append(layout.clusters[target], v)
+			layout.ids[target] = append(layout.ids[target], layout.ids[n][i])
+		}
+		layout.clusters[n], layout.ids[n] = keep, keepIDs
+	}
+
+	return nil
+}
+
+// mergeList removes list c and moves its vectors to their nearest remaining list
+func (idx *IVFIndex) mergeList(layout *ivfLayout, c int) error {
+	vectors, ids := layout.clusters[c], layout.ids[c]
+
+	layout.centroids = append(layout.centroids[:c:c], layout.centroids[c+1:]...)
+	layout.clusters = append(layout.clusters[:c:c], layout.clusters[c+1:]...)
+	layout.ids = append(layout.ids[:c:c], layout.ids[c+1:]...)
+	layout.radii = append(layout.radii[:c:c], layout.radii[c+1:]...)
+
+	return idx.assignAll(layout, vectors, ids)
+}
+
+// assignAll appends vectors to the list of their nearest centroid in layout
+func (idx *IVFIndex) assignAll(layout *ivfLayout, vectors []vector.Vector, ids []int) error {
+	for i, v := range vectors {
+		nearest, dist, err := nearestCentroid(v, layout.centroids, idx.metric)
+		if err != nil {
+			return fmt.Errorf("failed to find nearest centroid: %w", err)
+		}
+		layout.clusters[nearest] = append(layout.clusters[nearest], v)
+		layout.ids[nearest] = append(layout.ids[nearest], ids[i])
+		if dist > layout.radii[nearest] {
+			layout.radii[nearest] = dist
+		}
+	}
+	return nil
+}
+
+// computeRadii recomputes the radius of every list in layout
+func (idx *IVFIndex) computeRadii(layout *ivfLayout) error {
+	for c, cluster := range layout.clusters {
+		layout.radii[c] = 0
+		for _, v := range cluster {
+			dist, err := idx.measure(v, layout.centroids[c])
+			if err != nil {
+				return fmt.Errorf("distance calculation failed: %w", err)
+			}
+			if dist > layout.radii[c] {
+				layout.radii[c] = dist
+			}
+		}
+	}
+	return nil
+}
+
+// snapshotLayout copies the current list headers under the read lock
+//
+// Add only appends to lists, so the snapshot stays valid while new vectors
+// arrive; commitLayout picks those up from the tail of each list.
+func (idx *IVFIndex) snapshotLayout() (ivfLayout, error) {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	if idx.mapped != nil {
+		return ivfLayout{}, errReadOnly
+	}
+	if !idx.trained {
+		return ivfLayout{}, fmt.Errorf("index not trained: call Train() first")
+	}
+
+	return ivfLayout{
+		centroids: append([]vector.Vector(nil), idx.centroids...),
+		clusters:  append([][]vector.Vector(nil), idx.clusters...),
+		ids:       append([][]int(nil), idx.ids...),
+		radii:     append([]float64(nil), idx.radii...),
+	}, nil
+}
+
+// commitLayout swaps in next, assigning vectors added since snap was taken
+func (idx *IVFIndex) commitLayout(snap, next ivfLayout) error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	for c := range idx.clusters {
+		added := idx.clusters[c][len(snap.clusters[c]):]
+		addedIDs := idx.ids[c][len(snap.ids[c]):]
+		if err := idx.assignAll(&next, added, addedIDs); err != nil {
+			return err
+		}
+	}
+
+	idx.centroids = next.centroids
+	idx.clusters = next.clusters
+	idx.ids = next.ids
+	idx.radii = next.radii
+	idx.shared = false
+	idx.version++
+	idx.nlist = len(next.centroids)
+	if idx.nprobe > idx.nlist {
+		idx.nprobe = idx.nlist
+	}
+
+	return nil
+}
+
+// nearestLists returns the n lists whose centroids are closest to v
+func nearestLists(v vector.Vector, centroids []vector.Vector, n int, metric distance.Metric) ([]int, error) {
+	order := make([]int, len(centroids))
+	dists := make([]float64, len(centroids))
+	for i, centroid := range centroids {
+		dist, err := metric(v, centroid)
+		if err != nil {
+			return nil, fmt.Errorf("distance calculation failed: %w", err)
+		}
+		order[i], dists[i] = i, dist
+	}
+
+	sort.Slice(order, func(i, j int) bool {
+		return dists[order[i]] < dists[order[j]]
+	})
+
+	if n > len(order) {
+		n = len(order)
+	}
+	return order[:n], nil
+}
+
+// closerList returns whichever of current and candidates has the nearest centroid
+func closerList(v vector.Vector, current int, candidates []int, centroids []vector.Vector, metric distance.Metric) (int, error) {
+	best := current
+	bestDist, err := metric(v, centroids[current])
+	if err != nil {
+		return 0, fmt.Errorf("distance calculation failed: %w", err)
+	}
+
+	for _, c := range candidates {
+		dist, err := metric(v, centroids[c])
+		if err != nil {
+			return 0, fmt.Errorf("distance calculation failed: %w", err)
+		}
+		if dist < bestDist {
+			best, bestDist = c, dist
+		}
+	}
+
+	return best, nil
+}
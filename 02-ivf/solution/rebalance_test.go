@@ -0,0 +1,209 @@
+package solution
+
+import (
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/tmdgusya/database-class/pkg/distance"
+	"github.com/tmdgusya/database-class/pkg/testdata"
+	"github.com/tmdgusya/database-class/pkg/vector"
+)
+
+func buildTrainedIVF(t *testing.T, train, add []vector.Vector, nlist, nprobe int) *IVFIndex {
+	t.Helper()
+
+	idx, err := NewIVFIndex(Config{
+		Metric:      distance.L2Distance,
+		NumClusters: nlist,
+		NumProbes:   nprobe,
+	})
+	if err != nil {
+		t.Fatalf("NewIVFIndex() failed: %v", err)
+	}
+	if err := idx.Train(train); err != nil {
+		t.Fatalf("Train() failed: %v", err)
+	}
+	for _, v := range add {
+		if err := idx.Add(v); err != nil {
+			t.Fatalf("Add() failed: %v", err)
+		}
+	}
+	return idx
+}
+
+// collectIDs returns every stored ID, sorted
+func collectIDs(idx *IVFIndex) []int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	var ids []int
+	for _, list := range idx.ids {
+		ids = append(ids, list...)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+func TestSearchReturnsInsertionIDs(t *testing.T) {
+	vectors := testdata.GenerateClusteredVectors(200, 8, 4, 42)
+	idx := buildTrainedIVF(t, vectors, vectors, 4, 4)
+
+	// With nprobe == nlist every vector is reachable, so each one must find itself
+	for i, v := range vectors[:20] {
+		results, err := idx.Search(v, 1)
+		if err != nil {
+			t.Fatalf("Search() failed: %v", err)
+		}
+		if results[0].Index != i {
+			t.Errorf("Search(vectors[%d]) returned Index %d", i, results[0].Index)
+		}
+	}
+}
+
+func TestRetrainKeepsAllVectors(t *testing.T) {
+	// Train on one distribution, then add data from a different one (drift)
+	oldData := testdata.GenerateClusteredVectors(200, 8, 4, 1)
+	newData := testdata.GenerateClusteredVectors(400, 8, 8, 2)
+	idx := buildTrainedIVF(t, oldData, newData, 8, 8)
+
+	if err := idx.Retrain(newData); err != nil {
+		t.Fatalf("Retrain() failed: %v", err)
+	}
+
+	if idx.Size() != len(newData) {
+		t.Fatalf("Size() = %d after Retrain, want %d", idx.Size(), len(newData))
+	}
+
+	ids := collectIDs(idx)
+	for i, id := range ids {
+		if id != i {
+			t.Fatalf("IDs after Retrain are not 0..n-1: got %d at position %d", id, i)
+		}
+	}
+
+	// IDs must still identify the same vectors
+	results, err := idx.Search(newData[123], 1)
+	if err != nil {
+		t.Fatalf("Search() failed: %v", err)
+	}
+	if results[0].Index != 123 || results[0].Distance > 1e-9 {
+		t.Errorf("Search(newData[123]) = %d (dist %f), want 123", results[0].Index, results[0].Distance)
+	}
+}
+
+func TestRetrainConcurrentWithReadersAndWriters(t *testing.T) {
+	vectors := testdata.GenerateClusteredVectors(600, 8, 6, 3)
+	idx := buildTrainedIVF(t, vectors[:300], vectors[:300], 6, 2)
+
+	var wg sync.WaitGroup
+	wg.Add(3)
+
+	go func() {
+		defer wg.Done()
+		if err := idx.Retrain(vectors); err != nil {
+			t.Errorf("Retrain() failed: %v", err)
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+		for _, v := range vectors[300:] {
+			if err := idx.Add(v); err != nil {
+				t.Errorf("Add() failed: %v", err)
+				return
+			}
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+		for _, q := range vectors[:100] {
+			if _, err := idx.Search(q, 5); err != nil {
+				t.Errorf("Search() failed: %v", err)
+				return
+			}
+		}
+	}()
+
+	wg.Wait()
+
+	// Vectors added while retraining must not be lost
+	if idx.Size() != len(vectors) {
+		t.Errorf("Size() = %d, want %d", idx.Size(), len(vectors))
+	}
+}
+
+func TestRetrainNotTrained(t *testing.T) {
+	idx, _ := NewIVFIndex(Config{
+		Metric:      distance.L2Distance,
+		NumClusters: 4,
+		NumProbes:   1,
+	})
+
+	if err := idx.Retrain(testdata.GenerateRandomVectors(10, 4, 1)); err == nil {
+		t.Error("Retrain() should fail when index not trained")
+	}
+}
+
+func TestRebalanceSplitsOversizedLists(t *testing.T) {
+	// Train with 2 lists on data that really has 8 clusters
+	vectors := testdata.GenerateClusteredVectors(400, 8, 8, 4)
+	idx := buildTrainedIVF(t, vectors, vectors, 2, 2)
+
+	if err := idx.Rebalance(100, 0); err != nil {
+		t.Fatalf("Rebalance() failed: %v", err)
+	}
+
+	sizes := idx.ListSizes()
+	if len(sizes) <= 2 {
+		t.Errorf("Rebalance() did not split any list: sizes = %v", sizes)
+	}
+
+	total := 0
+	for _, size := range sizes {
+		total += size
+	}
+	if total != len(vectors) {
+		t.Errorf("Rebalance() lost vectors: %d stored, want %d", total, len(vectors))
+	}
+}
+
+func TestRebalanceMergesUndersizedLists(t *testing.T) {
+	vectors := testdata.GenerateClusteredVectors(300, 8, 3, 5)
+	idx := buildTrainedIVF(t, vectors, vectors, 3, 3)
+
+	// Every list is below the minimum, so all of them collapse into one
+	if err := idx.Rebalance(0, 1000); err != nil {
+		t.Fatalf("Rebalance() failed: %v", err)
+	}
+
+	sizes := idx.ListSizes()
+	if len(sizes) != 1 {
+		t.Errorf("Rebalance() should merge everything into one list, got sizes %v", sizes)
+	}
+	if sizes[0] != len(vectors) {
+		t.Errorf("merged list has %d vectors, want %d", sizes[0], len(vectors))
+	}
+
+	// nprobe must be clamped to the new nlist
+	results, err := idx.Search(vectors[7], 1)
+	if err != nil {
+		t.Fatalf("Search() after merge failed: %v", err)
+	}
+	if results[0].Index != 7 {
+		t.Errorf("Search(vectors[7]) = %d, want 7", results[0].Index)
+	}
+}
+
+func TestRebalanceInvalidBounds(t *testing.T) {
+	vectors := testdata.GenerateRandomVectors(50, 4, 6)
+	idx := buildTrainedIVF(t, vectors, vectors, 2, 1)
+
+	if err := idx.Rebalance(10, 20); err == nil {
+		t.Error("Rebalance() should fail when min >= max")
+	}
+	if err := idx.Rebalance(-1, 0); err == nil {
+		t.Error("Rebalance() should fail with negative bounds")
+	}
+}
@@ -0,0 +1,171 @@
+package solution
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+
+	"github.com/tmdgusya/database-class/pkg/index"
+	"github.com/tmdgusya/database-class/pkg/vector"
+)
+
+// ErrReleased is returned by the methods of a Snapshot after Release
+var ErrReleased = errors.New("snapshot released")
+
+// Snapshot is a read-only view of an IVFIndex pinned to one version
+//
+// Searches on a snapshot see exactly the vectors, centroids and settings
+// the index had when Snapshot was called, however many vectors are added,
+// or however often the index is retrained, afterwards. A batch of queries,
+// an export or an evaluation run against one snapshot is therefore
+// repeatable while ingestion continues on the index.
+//
+// A Snapshot is safe for concurrent use. Call Release when done so the
+// lists it pins can be garbage collected once the index has moved on.
+type Snapshot struct {
+	mu      sync.RWMutex
+	parent  *IVFIndex
+	view    *IVFIndex // Private index sharing the pinned lists; nil once released
+	version uint64
+}
+
+// Compile-time check that a snapshot can stand in for a read-only index
+var _ index.OptionSearcher = (*Snapshot)(nil)
+
+// Snapshot returns a view of the index as it is now
+//
+// Taking a snapshot is O(1): the view shares the index's list arrays.
+// Writers copy on write instead: Add only appends past the end every
+// snapshot sees, and the first Add after a snapshot copies the per-list
+// headers and radii (O(nlist)) before changing them. Retrain and Rebalance
+// build new lists anyway, so they never disturb a snapshot.
+func (idx *IVFIndex) Snapshot() (*Snapshot, error) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if !idx.trained {
+		return nil, fmt.Errorf("index not trained: call Train() first")
+	}
+
+	idx.shared = true
+	idx.pinned++
+	return &Snapshot{
+		parent: idx,
+		view: &IVFIndex{
+			centroids: idx.centroids,
+			clusters:  idx.clusters,
+			ids:       idx.ids,
+			radii:     idx.radii,
+			nextID:    idx.nextID,
+			metric:    idx.metric,
+			fast:      idx.fast,
+			nlist:     idx.nlist,
+			nprobe:    idx.nprobe,
+			trained:   true,
+			dimension: idx.dimension,
+			mapped:    idx.mapped,
+		},
+		version: idx.version,
+	}, nil
+}
+
+// Version counts the changes made to the index: every Add, Train, Retrain
+// and Rebalance increments it
+func (idx *IVFIndex) Version() uint64 {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	return idx.version
+}
+
+// unshare gives the index its own list headers and radii if a snapshot
+// shares them; idx.mu must be held for writing
+func (idx *IVFIndex) unshare() {
+	if !idx.shared {
+		return
+	}
+	idx.clusters = slices.Clone(idx.clusters)
+	idx.ids = slices.Clone(idx.ids)
+	idx.radii = slices.Clone(idx.radii)
+	idx.shared = false
+}
+
+// Version returns the index version the snapshot is pinned to
+func (s *Snapshot) Version() uint64 {
+	return s.version
+}
+
+// Release drops the snapshot's references to the pinned lists
+// Later calls to the snapshot return ErrReleased; releasing twice is a no-op.
+func (s *Snapshot) Release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.view == nil {
+		return
+	}
+	s.view = nil
+
+	s.parent.mu.Lock()
+	s.parent.pinned--
+	s.parent.mu.Unlock()
+}
+
+// Add always fails: snapshots are read-only
+func (s *Snapshot) Add(v vector.Vector) error {
+	return fmt.Errorf("cannot add to a snapshot")
+}
+
+// Search performs approximate k-NN search on the pinned version
+func (s *Snapshot) Search(query vector.Vector, k int) ([]SearchResult, error) {
+	return s.SearchWithOptions(query, k, index.DefaultSearchOptions())
+}
+
+// SearchWithOptions performs approximate k-NN search on the pinned version
+// with per-call settings (see IVFIndex.SearchWithOptions)
+func (s *Snapshot) SearchWithOptions(query vector.Vector, k int, opts index.SearchOptions) ([]SearchResult, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.view == nil {
+		return nil, ErrReleased
+	}
+	return s.view.SearchWithOptions(query, k, opts)
+}
+
+// Size returns the number of vectors in the pinned version
+// It returns 0 after Release.
+func (s *Snapshot) Size() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.view == nil {
+		return 0
+	}
+	return s.view.Size()
+}
+
+// ListSizes returns the number of vectors in each list of the pinned version
+func (s *Snapshot) ListSizes() ([]int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.view == nil {
+		return nil, ErrReleased
+	}
+	return s.view.ListSizes(), nil
+}
+
+// Save writes the pinned version in the format of IVFIndex.Save, so a
+// consistent export can be taken while the index keeps changing
+func (s *Snapshot) Save(w io.Writer) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.view == nil {
+		return ErrReleased
+	}
+	return s.view.Save(w)
+}
@@ -0,0 +1,193 @@
+package solution
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/tmdgusya/database-class/pkg/distance"
+	"github.com/tmdgusya/database-class/pkg/index"
+	"github.com/tmdgusya/database-class/pkg/testdata"
+)
+
+func TestSnapshotIsolation(t *testing.T) {
+	vectors := testdata.GenerateClusteredVectors(600, 8, 6, 42)
+	idx := buildTrainedIVF(t, vectors[:300], vectors[:300], 8, 8)
+
+	snap, err := idx.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer snap.Release()
+	if snap.Version() != idx.Version() {
+		t.Errorf("snapshot version %d, index version %d", snap.Version(), idx.Version())
+	}
+
+	queries := testdata.GenerateRandomVectors(10, 8, 7)
+	before := make([][]SearchResult, len(queries))
+	for i, q := range queries {
+		before[i], _ = snap.Search(q, 10)
+	}
+	sizes, _ := snap.ListSizes()
+
+	// Add a near copy of every query, retrain and rebalance: the index
+	// changes completely, the snapshot not at all
+	for _, q := range queries {
+		idx.Add(q)
+	}
+	for _, v := range vectors[300:] {
+		idx.Add(v)
+	}
+	if err := idx.Retrain(vectors); err != nil {
+		t.Fatal(err)
+	}
+	if err := idx.Rebalance(150, 20); err != nil {
+		t.Fatal(err)
+	}
+	if idx.Version() == snap.Version() {
+		t.Error("index version did not change")
+	}
+
+	if snap.Size() != 300 || idx.Size() != 610 {
+		t.Errorf("Size() = %d (snapshot), %d (index); want 300, 610", snap.Size(), idx.Size())
+	}
+	if got, _ := snap.ListSizes(); !reflect.DeepEqual(got, sizes) {
+		t.Errorf("snapshot ListSizes() changed: %v -> %v", sizes, got)
+	}
+	for i, q := range queries {
+		got, err := snap.Search(q, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, before[i]) {
+			t.Errorf("query %d: snapshot results changed", i)
+		}
+		now, _ := idx.Search(q, 1)
+		if now[0].Index < 300 {
+			t.Errorf("query %d: index does not see the added copy", i)
+		}
+	}
+}
+
+func TestSnapshotSaveAndRelease(t *testing.T) {
+	vectors := testdata.GenerateClusteredVectors(200, 4, 4, 1)
+	idx := buildTrainedIVF(t, vectors, vectors[:100], 4, 2)
+
+	snap, err := idx.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range vectors[100:] {
+		idx.Add(v)
+	}
+
+	var buf bytes.Buffer
+	if err := snap.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadIVFIndex(&buf, distance.L2Distance)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Size() != 100 || loaded.nextID != 100 {
+		t.Errorf("export holds %d vectors (next ID %d), want 100", loaded.Size(), loaded.nextID)
+	}
+
+	snap.Release()
+	snap.Release()
+	if _, err := snap.Search(vectors[0], 1); !errors.Is(err, ErrReleased) {
+		t.Errorf("Search after Release: %v", err)
+	}
+	if err := snap.Save(&buf); !errors.Is(err, ErrReleased) {
+		t.Errorf("Save after Release: %v", err)
+	}
+	if idx.pinned != 0 {
+		t.Errorf("pinned = %d after Release", idx.pinned)
+	}
+
+	untrained, _ := NewIVFIndex(Config{Metric: distance.L2Distance, NumClusters: 2, NumProbes: 1})
+	if _, err := untrained.Snapshot(); err == nil {
+		t.Error("Snapshot of an untrained index succeeded")
+	}
+}
+
+func TestSnapshotConcurrentWriters(t *testing.T) {
+	vectors := testdata.GenerateClusteredVectors(1000, 8, 5, 3)
+	idx := buildTrainedIVF(t, vectors, vectors[:200], 8, 3)
+
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		for _, v := range vectors[200:] {
+			if err := idx.Add(v); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	})
+	for range 4 {
+		wg.Go(func() {
+			for i := 0; i < 20; i++ {
+				snap, err := idx.Snapshot()
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				size := snap.Size()
+				var stats index.SearchStats
+				results, err := snap.SearchWithOptions(vectors[i], 5, index.SearchOptions{NProbe: 8, Stats: &stats})
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				// Probing every list scans exactly the pinned vectors
+				if stats.CandidatesScanned != size || len(results) != 5 {
+					t.Errorf("scanned %d of %d pinned vectors", stats.CandidatesScanned, size)
+				}
+				snap.Release()
+			}
+		})
+	}
+	wg.Wait()
+
+	if idx.Size() != 1000 || idx.pinned != 0 {
+		t.Errorf("Size() = %d, pinned = %d", idx.Size(), idx.pinned)
+	}
+}
+
+func TestSnapshotPinsMapping(t *testing.T) {
+	vectors := testdata.GenerateClusteredVectors(100, 4, 2, 5)
+	idx := buildTrainedIVF(t, vectors, vectors, 2, 1)
+
+	path := filepath.Join(t.TempDir(), "ivf.map")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := idx.WriteMapped(f); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	mapped, err := OpenMappedIVFIndex(path, distance.L2Distance)
+	if err != nil {
+		t.Fatal(err)
+	}
+	snap, err := mapped.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mapped.Close(); err == nil {
+		t.Fatal("Close() unmapped a file a snapshot still reads")
+	}
+	if results, err := snap.Search(vectors[0], 1); err != nil || results[0].Index != 0 {
+		t.Errorf("Search() = %v, %v", results, err)
+	}
+	snap.Release()
+	if err := mapped.Close(); err != nil {
+		t.Errorf("Close() after Release: %v", err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"sync"
 
 	"github.com/tmdgusya/database-class/pkg/distance"
+	"github.com/tmdgusya/database-class/pkg/index"
 	"github.com/tmdgusya/database-class/pkg/vector"
 )
 
@@ -36,12 +37,8 @@ type Config struct {
 	Ml             float64 // Level generation multiplier (default: 1/ln(2))
 }
 
-// SearchResult represents a search result
-type SearchResult struct {
-	Vector   vector.Vector
-	Distance float64
-	Index    int
-}
+// SearchResult is the result type shared by every index (see pkg/index)
+type SearchResult = index.SearchResult
 
 // NewHNSWIndex creates a new HNSW index
 func NewHNSWIndex(cfg Config) (*HNSWIndex, error) {
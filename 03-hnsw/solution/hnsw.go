@@ -0,0 +1,568 @@
+package solution
+
+import (
+	"container/heap"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"unsafe"
+
+	"github.com/tmdgusya/database-class/pkg/distance"
+	"github.com/tmdgusya/database-class/pkg/index"
+	"github.com/tmdgusya/database-class/pkg/mmapfile"
+	"github.com/tmdgusya/database-class/pkg/topk"
+	"github.com/tmdgusya/database-class/pkg/vector"
+)
+
+// maxLevel caps the level RandomLevel may assign to a node
+const maxLevel = 16
+
+// deadlineCheckInterval is how many expanded nodes pass between timeout checks
+const deadlineCheckInterval = 64
+
+// HNSWIndex implements Hierarchical Navigable Small World graph
+type HNSWIndex struct {
+	nodes          []*Node                  // All nodes in graph, indexed by ID
+	entryPoint     int                      // ID of entry node
+	maxLayer       int                      // Current max layer in graph
+	M              int                      // Max connections per layer
+	Mmax           int                      // Max connections at layer 0
+	efConstruction int                      // Construction-time ef
+	efSearch       int                      // Search-time ef
+	ml             float64                  // Level generation multiplier
+	metric         distance.Metric          // Distance function
+	fast           distance.UncheckedMetric // Validation-free metric (nil if custom)
+	dimension      int                      // Vector dimension (-1 until first Add)
+	mapped         *mmapfile.File           // Backing file if memory-mapped (read-only)
+	levels         *rand.Rand               // Level source if seeded (nil uses the global one)
+	mu             sync.RWMutex             // Thread safety
+}
+
+// Config holds HNSW parameters
+type Config struct {
+	Metric         distance.Metric
+	M              int     // Max bidirectional connections per layer
+	Mmax           int     // Max connections at layer 0 (typically M*2)
+	EfConstruction int     // Construction-time candidate list size
+	EfSearch       int     // Search-time candidate list size
+	Ml             float64 // Level generation multiplier (default: 1/ln(2))
+	Seed           int64   // Seeds node levels for a reproducible graph; 0 uses the global source
+}
+
+// SearchResult is the result type shared by every index (see pkg/index)
+type SearchResult = index.SearchResult
+
+// Compile-time checks that the index satisfies the shared interfaces
+var (
+	_ index.OptionSearcher = (*HNSWIndex)(nil)
+	_ index.MemoryReporter = (*HNSWIndex)(nil)
+)
+
+// NewHNSWIndex creates a new HNSW index
+func NewHNSWIndex(cfg Config) (*HNSWIndex, error) {
+	// Validate config
+	if cfg.Metric == nil {
+		return nil, fmt.Errorf("metric cannot be nil")
+	}
+	if cfg.M <= 0 {
+		return nil, fmt.Errorf("M must be positive, got %d", cfg.M)
+	}
+	if cfg.EfConstruction < cfg.M {
+		return nil, fmt.Errorf("EfConstruction (%d) must be >= M (%d)", cfg.EfConstruction, cfg.M)
+	}
+	if cfg.EfSearch <= 0 {
+		return nil, fmt.Errorf("EfSearch must be positive, got %d", cfg.EfSearch)
+	}
+	if cfg.Mmax == 0 {
+		cfg.Mmax = cfg.M * 2
+	}
+	if cfg.Mmax < cfg.M {
+		return nil, fmt.Errorf("Mmax (%d) must be >= M (%d)", cfg.Mmax, cfg.M)
+	}
+	if cfg.Ml == 0 {
+		cfg.Ml = DefaultMl()
+	}
+	if cfg.Ml < 0 {
+		return nil, fmt.Errorf("Ml must be positive, got %f", cfg.Ml)
+	}
+
+	fast, _ := distance.Unchecked(cfg.Metric)
+
+	var levels *rand.Rand
+	if cfg.Seed != 0 {
+		levels = rand.New(rand.NewSource(cfg.Seed))
+	}
+
+	return &HNSWIndex{
+		nodes:          make([]*Node, 0),
+		entryPoint:     -1,
+		M:              cfg.M,
+		Mmax:           cfg.Mmax,
+		efConstruction: cfg.EfConstruction,
+		efSearch:       cfg.EfSearch,
+		ml:             cfg.Ml,
+		metric:         cfg.Metric,
+		fast:           fast,
+		dimension:      -1, // -1 means not set yet
+		levels:         levels,
+	}, nil
+}
+
+// Add inserts a vector into the graph
+func (idx *HNSWIndex) Add(v vector.Vector) error {
+	// Validate vector
+	if err := v.Validate(); err != nil {
+		return fmt.Errorf("invalid vector: %w", err)
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if idx.mapped != nil {
+		return errReadOnly
+	}
+
+	// Check dimension consistency
+	if idx.dimension == -1 {
+		idx.dimension = v.Dimension()
+	} else if v.Dimension() != idx.dimension {
+		return fmt.Errorf("dimension mismatch: expected %d, got %d",
+			idx.dimension, v.Dimension())
+	}
+
+	level := RandomLevel(idx.ml, maxLevel)
+	if idx.levels != nil {
+		level = levelFor(idx.levels.Float64(), idx.ml, maxLevel)
+	}
+	node := NewNode(len(idx.nodes), v.Clone(), level)
+	idx.nodes = append(idx.nodes, node)
+
+	// First node becomes the entry point
+	if idx.entryPoint == -1 {
+		idx.entryPoint = node.ID
+		idx.maxLayer = level
+		return nil
+	}
+
+	// Greedy descent through the layers above the new node
+	currNearest := []int{idx.entryPoint}
+	for layer := idx.maxLayer; layer > level; layer-- {
+		found, err := idx.searchLayer(node.Vector, currNearest, 1, layer, nil, index.Deadline{}, nil)
+		if err != nil {
+			return err
+		}
+		currNearest = []int{found[0].nodeID}
+	}
+
+	// Insert and connect from min(level, maxLayer) down to 0
+	top := level
+	if top > idx.maxLayer {
+		top = idx.maxLayer
+	}
+	for layer := top; layer >= 0; layer-- {
+		candidates, err := idx.searchLayer(node.Vector, currNearest, idx.efConstruction, layer, nil, index.Deadline{}, nil)
+		if err != nil {
+			return err
+		}
+
+		neighbors, err := idx.selectNeighbors(candidates, idx.M, layer)
+		if err != nil {
+			return err
+		}
+
+		// Bidirectional connect, then prune neighbors that grew too large
+		for _, neighborID := range neighbors {
+			node.AddConnection(neighborID, layer)
+			idx.nodes[neighborID].AddConnection(node.ID, layer)
+
+			if len(idx.nodes[neighborID].Connections[layer]) > idx.maxConnections(layer) {
+				if err := idx.pruneConnections(neighborID, layer); err != nil {
+					return err
+				}
+			}
+		}
+
+		currNearest = make([]int, len(candidates))
+		for i, c := range candidates {
+			currNearest[i] = c.nodeID
+		}
+	}
+
+	// Update entry point if new node is higher
+	if level > idx.maxLayer {
+		idx.entryPoint = node.ID
+		idx.maxLayer = level
+	}
+
+	return nil
+}
+
+// Search performs k-NN search
+func (idx *HNSWIndex) Search(query vector.Vector, k int) ([]SearchResult, error) {
+	return idx.SearchWithOptions(query, k, index.DefaultSearchOptions())
+}
+
+// SearchWithOptions performs k-NN search with per-call settings
+// EfSearch overrides the index's efSearch for this call only. Filtered-out
+// nodes are still used for navigation but never returned. Stats and Tracer
+// separate the upper-layer descent from the layer-0 expansion.
+func (idx *HNSWIndex) SearchWithOptions(query vector.Vector, k int, opts index.SearchOptions) ([]SearchResult, error) {
+	tr := opts.StartTrace("hnsw.Search")
+	results, err := idx.search(query, k, opts, &tr)
+	tr.End(err)
+	return results, err
+}
+
+func (idx *HNSWIndex) search(query vector.Vector, k int, opts index.SearchOptions, tr *index.Trace) ([]SearchResult, error) {
+	// Validate query and options
+	if err := query.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid query: %w", err)
+	}
+	if k <= 0 {
+		return nil, fmt.Errorf("k must be positive, got %d", k)
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid search options: %w", err)
+	}
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	// Handle empty index
+	if len(idx.nodes) == 0 {
+		return []SearchResult{}, nil
+	}
+
+	// Check dimension match
+	if query.Dimension() != idx.dimension {
+		return nil, fmt.Errorf("query dimension mismatch: expected %d, got %d",
+			idx.dimension, query.Dimension())
+	}
+
+	ef := idx.efSearch
+	if opts.EfSearch > 0 {
+		ef = opts.EfSearch
+	}
+	// TRAP: efSearch < k cannot produce k results, so widen the beam to k
+	ef = max(ef, k, opts.Candidates(k))
+
+	deadline := opts.Deadline()
+	stats := tr.Stats()
+
+	// Greedy search through upper layers
+	tr.Phase(index.PhaseDescend)
+	currNearest := []int{idx.entryPoint}
+	for layer := idx.maxLayer; layer >= 1; layer-- {
+		found, err := idx.searchLayer(query, currNearest, 1, layer, nil, deadline, stats)
+		if err != nil {
+			return nil, err
+		}
+		currNearest = []int{found[0].nodeID}
+	}
+
+	// Precise search at layer 0
+	tr.Phase(index.PhaseExpand)
+	candidates, err := idx.searchLayer(query, currNearest, ef, 0, opts.Filter, deadline, stats)
+	if err != nil {
+		return nil, err
+	}
+
+	tr.Phase(index.PhaseSelect)
+
+	// Return top k
+	if k > len(candidates) {
+		k = len(candidates)
+	}
+
+	results := make([]SearchResult, k)
+	for i := 0; i < k; i++ {
+		results[i] = SearchResult{
+			Distance: candidates[i].distance,
+			Index:    candidates[i].nodeID,
+		}
+		if opts.IncludeVectors {
+			results[i].Vector = idx.nodes[candidates[i].nodeID].Vector
+		}
+	}
+
+	if idx.mapped != nil {
+		// Results outlive the search, and Close unmaps what they point at
+		results = index.CloneVectors(results)
+	}
+	return results, nil
+}
+
+// measure computes the distance between two vectors of the index's dimension
+// Dimensions are validated once on Add and Search, so built-in metrics skip
+// their per-pair checks.
+func (idx *HNSWIndex) measure(a, b vector.Vector) (float64, error) {
+	if idx.fast != nil {
+		return idx.fast(a, b), nil
+	}
+	return idx.metric(a, b)
+}
+
+// searchLayer performs greedy search within a single layer
+// Returns up to ef nodes sorted by distance. Nodes rejected by accept are
+// still expanded (so they keep the graph navigable) but are not returned.
+// Work done is added to stats unless it is nil. Caller must hold the lock.
+func (idx *HNSWIndex) searchLayer(
+	query vector.Vector,
+	entryPoints []int,
+	ef int,
+	layer int,
+	accept func(id int) bool,
+	deadline index.Deadline,
+	stats *index.SearchStats,
+) ([]nodeWithDistance, error) {
+	visited := make(map[int]bool) // Every node whose distance was computed
+	expandedNodes := 0
+	if stats != nil {
+		defer func() {
+			stats.DistanceComputations += len(visited)
+			stats.NodesVisited += expandedNodes
+		}()
+	}
+
+	candidates := &minHeap{}  // To explore
+	best := topk.New[int](ef) // Keep top ef
+
+	for _, ep := range entryPoints {
+		if visited[ep] {
+			continue
+		}
+		visited[ep] = true
+
+		dist, err := idx.measure(query, idx.nodes[ep].Vector)
+		if err != nil {
+			return nil, fmt.Errorf("distance calculation failed: %w", err)
+		}
+		heap.Push(candidates, nodeWithDistance{ep, dist})
+		if accept == nil || accept(ep) {
+			best.Push(ep, dist)
+		}
+	}
+
+	for expanded := 0; candidates.Len() > 0; expanded++ {
+		if expanded%deadlineCheckInterval == 0 && deadline.Expired() {
+			return nil, index.ErrTimeout
+		}
+
+		curr := heap.Pop(candidates).(nodeWithDistance)
+
+		// Can't improve once the closest unexplored node is worse than our worst
+		if best.Full() && curr.distance > best.Threshold() {
+			break
+		}
+
+		node := idx.nodes[curr.nodeID]
+		if layer > node.Level {
+			continue
+		}
+		expandedNodes++
+
+		for _, neighborID := range node.Connections[layer] {
+			if visited[neighborID] {
+				continue
+			}
+			visited[neighborID] = true
+
+			dist, err := idx.measure(query, idx.nodes[neighborID].Vector)
+			if err != nil {
+				return nil, fmt.Errorf("distance calculation failed: %w", err)
+			}
+
+			if best.Accepts(dist) {
+				heap.Push(candidates, nodeWithDistance{neighborID, dist})
+
+				if accept == nil || accept(neighborID) {
+					best.Push(neighborID, dist)
+				}
+			}
+		}
+	}
+
+	// Return best sorted by distance (closest first)
+	items := best.Sorted()
+	results := make([]nodeWithDistance, len(items))
+	for i, item := range items {
+		results[i] = nodeWithDistance{item.Value, item.Distance}
+	}
+
+	return results, nil
+}
+
+// selectNeighbors selects up to M neighbors from candidates
+// Uses the diversity heuristic from the HNSW paper: a candidate is kept only
+// if it is closer to the base node than to every neighbor kept so far. Slots
+// left over are filled with the closest discarded candidates.
+func (idx *HNSWIndex) selectNeighbors(
+	candidates []nodeWithDistance,
+	M int,
+	layer int,
+) ([]int, error) {
+	sorted := make([]nodeWithDistance, len(candidates))
+	copy(sorted, candidates)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].distance < sorted[j].distance
+	})
+
+	selected := make([]int, 0, M)
+	var discarded []int
+
+	for _, c := range sorted {
+		if len(selected) >= M {
+			break
+		}
+
+		diverse := true
+		for _, s := range selected {
+			dist, err := idx.measure(idx.nodes[c.nodeID].Vector, idx.nodes[s].Vector)
+			if err != nil {
+				return nil, fmt.Errorf("distance calculation failed: %w", err)
+			}
+			if dist < c.distance {
+				diverse = false
+				break
+			}
+		}
+
+		if diverse {
+			selected = append(selected, c.nodeID)
+		} else {
+			discarded = append(discarded, c.nodeID)
+		}
+	}
+
+	for _, id := range discarded {
+		if len(selected) >= M {
+			break
+		}
+		selected = append(selected, id)
+	}
+
+	return selected, nil
+}
+
+// pruneConnections shrinks a node's connections at layer back to the limit
+func (idx *HNSWIndex) pruneConnections(id int, layer int) error {
+	node := idx.nodes[id]
+
+	candidates := make([]nodeWithDistance, len(node.Connections[layer]))
+	for i, neighborID := range node.Connections[layer] {
+		dist, err := idx.measure(node.Vector, idx.nodes[neighborID].Vector)
+		if err != nil {
+			return fmt.Errorf("distance calculation failed: %w", err)
+		}
+		candidates[i] = nodeWithDistance{neighborID, dist}
+	}
+
+	kept, err := idx.selectNeighbors(candidates, idx.maxConnections(layer), layer)
+	if err != nil {
+		return err
+	}
+	node.Connections[layer] = kept
+
+	return nil
+}
+
+// maxConnections returns the connection limit for a layer
+func (idx *HNSWIndex) maxConnections(layer int) int {
+	if layer == 0 {
+		return idx.Mmax
+	}
+	return idx.M
+}
+
+// SetEfSearch updates efSearch parameter at runtime
+func (idx *HNSWIndex) SetEfSearch(ef int) error {
+	if ef <= 0 {
+		return fmt.Errorf("efSearch must be positive, got %d", ef)
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.efSearch = ef
+	return nil
+}
+
+// Size returns number of vectors
+func (idx *HNSWIndex) Size() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return len(idx.nodes)
+}
+
+// LayerStats describes one layer of the graph
+type LayerStats struct {
+	Layer int
+	Nodes int // Nodes present on the layer
+	Edges int // Directed links between them
+}
+
+// Layers returns statistics for every layer, bottom first
+func (idx *HNSWIndex) Layers() []LayerStats {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	if len(idx.nodes) == 0 {
+		return nil
+	}
+	layers := make([]LayerStats, idx.maxLayer+1)
+	for i := range layers {
+		layers[i].Layer = i
+	}
+	for _, node := range idx.nodes {
+		for layer := 0; layer <= node.Level; layer++ {
+			layers[layer].Nodes++
+			layers[layer].Edges += len(node.Connections[layer])
+		}
+	}
+	return layers
+}
+
+// MemoryUsage reports the bytes held by the index
+// Each node's neighbour lists (per-layer headers plus IDs) count as graph;
+// the rest of the Node structs and the node pointer table count as metadata.
+func (idx *HNSWIndex) MemoryUsage() index.MemoryBreakdown {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	m := index.MemoryBreakdown{
+		Metadata: uint64(unsafe.Sizeof(*idx)) + uint64(cap(idx.nodes))*uint64(unsafe.Sizeof((*Node)(nil))),
+	}
+	for _, node := range idx.nodes {
+		// The Vector and Connections headers live inside Node but are
+		// charged to their own categories
+		m.Vectors += index.SliceHeaderBytes + index.VectorBytes(node.Vector)
+		m.Graph += index.SliceHeaderBytes + uint64(cap(node.Connections))*index.SliceHeaderBytes
+		for _, neighbors := range node.Connections {
+			m.Graph += index.IntsBytes(neighbors)
+		}
+		m.Metadata += uint64(unsafe.Sizeof(*node)) - 2*index.SliceHeaderBytes
+	}
+	return m
+}
+
+// Helper type for search
+type nodeWithDistance struct {
+	nodeID   int
+	distance float64
+}
+
+// minHeap pops the closest node first
+type minHeap []nodeWithDistance
+
+func (h minHeap) Len() int            { return len(h) }
+func (h minHeap) Less(i, j int) bool  { return h[i].distance < h[j].distance }
+func (h minHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *minHeap) Push(x interface{}) { *h = append(*h, x.(nodeWithDistance)) }
+func (h *minHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[0 : n-1]
+	return x
+}
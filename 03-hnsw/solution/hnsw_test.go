@@ -0,0 +1,360 @@
+package solution
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tmdgusya/database-class/pkg/distance"
+	"github.com/tmdgusya/database-class/pkg/index"
+	"github.com/tmdgusya/database-class/pkg/testdata"
+	"github.com/tmdgusya/database-class/pkg/vector"
+)
+
+func newTestIndex(t *testing.T, vectors []vector.Vector) *HNSWIndex {
+	t.Helper()
+
+	idx, err := NewHNSWIndex(Config{
+		Metric:         distance.L2Distance,
+		M:              16,
+		EfConstruction: 100,
+		EfSearch:       50,
+	})
+	if err != nil {
+		t.Fatalf("NewHNSWIndex() failed: %v", err)
+	}
+	for _, v := range vectors {
+		if err := idx.Add(v); err != nil {
+			t.Fatalf("Add() failed: %v", err)
+		}
+	}
+	return idx
+}
+
+// bruteForce returns the IDs of the k nearest vectors
+func bruteForce(vectors []vector.Vector, query vector.Vector, k int) []int {
+	ids := make([]int, len(vectors))
+	dists := make([]float64, len(vectors))
+	for i, v := range vectors {
+		ids[i] = i
+		dists[i], _ = distance.L2Distance(query, v)
+	}
+	sort.Slice(ids, func(i, j int) bool { return dists[ids[i]] < dists[ids[j]] })
+	return ids[:k]
+}
+
+func measureRecall(t *testing.T, idx *HNSWIndex, vectors, queries []vector.Vector, k int) float64 {
+	t.Helper()
+
+	var total float64
+	for _, q := range queries {
+		results, err := idx.Search(q, k)
+		if err != nil {
+			t.Fatalf("Search() failed: %v", err)
+		}
+
+		truth := make(map[int]bool)
+		for _, id := range bruteForce(vectors, q, k) {
+			truth[id] = true
+		}
+		for _, r := range results {
+			if truth[r.Index] {
+				total++
+			}
+		}
+	}
+	return total / float64(len(queries)*k)
+}
+
+func TestNewHNSWIndex(t *testing.T) {
+	t.Run("valid config", func(t *testing.T) {
+		idx, err := NewHNSWIndex(Config{
+			Metric:         distance.L2Distance,
+			M:              16,
+			EfConstruction: 200,
+			EfSearch:       50,
+		})
+		if err != nil {
+			t.Fatalf("NewHNSWIndex() failed: %v", err)
+		}
+		if idx.Mmax != 32 {
+			t.Errorf("Mmax default = %d, want 32", idx.Mmax)
+		}
+	})
+
+	invalid := []struct {
+		name string
+		cfg  Config
+	}{
+		{"nil metric", Config{M: 16, EfConstruction: 200, EfSearch: 50}},
+		{"zero M", Config{Metric: distance.L2Distance, EfConstruction: 200, EfSearch: 50}},
+		{"efConstruction < M", Config{Metric: distance.L2Distance, M: 16, EfConstruction: 8, EfSearch: 50}},
+		{"zero efSearch", Config{Metric: distance.L2Distance, M: 16, EfConstruction: 200}},
+		{"Mmax < M", Config{Metric: distance.L2Distance, M: 16, Mmax: 8, EfConstruction: 200, EfSearch: 50}},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewHNSWIndex(tt.cfg); err == nil {
+				t.Error("NewHNSWIndex() should fail")
+			}
+		})
+	}
+}
+
+func TestHNSWBasic(t *testing.T) {
+	vectors := testdata.GenerateRandomVectors(200, 16, 42)
+	idx := newTestIndex(t, vectors)
+
+	if idx.Size() != len(vectors) {
+		t.Errorf("Size() = %d, want %d", idx.Size(), len(vectors))
+	}
+
+	results, err := idx.Search(vectors[17], 5)
+	if err != nil {
+		t.Fatalf("Search() failed: %v", err)
+	}
+	if len(results) != 5 {
+		t.Fatalf("got %d results, want 5", len(results))
+	}
+	if results[0].Index != 17 || results[0].Distance > 1e-9 {
+		t.Errorf("first result = %d (dist %f), want 17", results[0].Index, results[0].Distance)
+	}
+	for i := 1; i < len(results); i++ {
+		if results[i].Distance < results[i-1].Distance {
+			t.Errorf("results not sorted at %d", i)
+		}
+	}
+}
+
+func TestHNSWEmptyIndex(t *testing.T) {
+	idx := newTestIndex(t, nil)
+
+	results, err := idx.Search(vector.Vector{1, 2, 3}, 5)
+	if err != nil {
+		t.Fatalf("Search() on empty index should not error, got: %v", err)
+	}
+	if len(results) != 0 {
+		t.Errorf("got %d results, want 0", len(results))
+	}
+}
+
+func TestHNSWLevels(t *testing.T) {
+	// P(level >= 1) should be about 50% with the default ml
+	const n = 10000
+	upper := 0
+	for i := 0; i < n; i++ {
+		if RandomLevel(DefaultMl(), maxLevel) >= 1 {
+			upper++
+		}
+	}
+	ratio := float64(upper) / n
+	if ratio < 0.45 || ratio > 0.55 {
+		t.Errorf("P(level >= 1) = %.3f, want ~0.5", ratio)
+	}
+
+	if level := RandomLevel(100, 3); level > 3 {
+		t.Errorf("RandomLevel() = %d exceeds maxLevel 3", level)
+	}
+}
+
+func TestHNSWSeed(t *testing.T) {
+	// The same seed builds the same graph
+	vectors := testdata.GenerateRandomVectors(300, 8, 5)
+	build := func(seed int64) []LayerStats {
+		idx, err := NewHNSWIndex(Config{Metric: distance.L2Distance, M: 8, EfConstruction: 32, EfSearch: 32, Seed: seed})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, v := range vectors {
+			idx.Add(v)
+		}
+		return idx.Layers()
+	}
+	if a, b := build(7), build(7); !reflect.DeepEqual(a, b) {
+		t.Errorf("seed 7 built %+v, then %+v", a, b)
+	}
+}
+
+func TestHNSWRecall(t *testing.T) {
+	vectors := testdata.GenerateClusteredVectors(1000, 32, 10, 42)
+	queries := testdata.AddNoise(vectors[:20], 0.05, 123)
+	idx := newTestIndex(t, vectors)
+
+	recall := measureRecall(t, idx, vectors, queries, 10)
+	fmt.Printf("\n📊 HNSW recall@10 (M=16, efSearch=50): %.1f%%\n", recall*100)
+
+	if recall < 0.9 {
+		t.Errorf("recall@10 = %.1f%%, want >= 90%%", recall*100)
+	}
+}
+
+// 🔥 함정: efSearch < k
+func TestHNSWEfSearchTooSmall(t *testing.T) {
+	vectors := testdata.GenerateRandomVectors(100, 8, 42)
+	idx := newTestIndex(t, vectors)
+
+	if err := idx.SetEfSearch(5); err != nil {
+		t.Fatalf("SetEfSearch(5) failed: %v", err)
+	}
+
+	// A beam of 5 cannot hold 10 results; both the shared setting and a
+	// per-call override are widened to k
+	for _, opts := range []index.SearchOptions{{}, {EfSearch: 3}} {
+		results, err := idx.SearchWithOptions(vectors[0], 10, opts)
+		if err != nil {
+			t.Fatalf("SearchWithOptions(%+v) failed: %v", opts, err)
+		}
+		if len(results) != 10 {
+			t.Errorf("SearchWithOptions(%+v) got %d results, want 10", opts, len(results))
+		}
+	}
+}
+
+func TestHNSWDimensionMismatch(t *testing.T) {
+	idx := newTestIndex(t, testdata.GenerateRandomVectors(10, 8, 42))
+
+	if err := idx.Add(vector.Vector{1, 2, 3}); err == nil {
+		t.Error("Add() should fail with dimension mismatch")
+	}
+	if _, err := idx.Search(vector.Vector{1, 2}, 1); err == nil {
+		t.Error("Search() should fail with dimension mismatch")
+	}
+}
+
+func TestSetEfSearch(t *testing.T) {
+	idx := newTestIndex(t, nil)
+
+	if err := idx.SetEfSearch(100); err != nil {
+		t.Errorf("SetEfSearch(100) failed: %v", err)
+	}
+	if err := idx.SetEfSearch(0); err == nil {
+		t.Error("SetEfSearch(0) should fail")
+	}
+}
+
+func TestHNSWSearchWithOptionsFilter(t *testing.T) {
+	vectors := testdata.GenerateRandomVectors(300, 16, 42)
+	idx := newTestIndex(t, vectors)
+
+	// Only a tenth of the graph is eligible; we must still get k results
+	rare := func(id int) bool { return id%10 == 0 }
+	results, err := idx.SearchWithOptions(vectors[5], 10, index.SearchOptions{Filter: rare})
+	if err != nil {
+		t.Fatalf("SearchWithOptions() failed: %v", err)
+	}
+	if len(results) != 10 {
+		t.Fatalf("got %d results, want 10", len(results))
+	}
+	for _, r := range results {
+		if r.Index%10 != 0 {
+			t.Errorf("filtered search returned ID %d", r.Index)
+		}
+		if r.Vector != nil {
+			t.Error("IncludeVectors=false should leave Vector nil")
+		}
+	}
+}
+
+func TestHNSWSearchWithOptionsTimeout(t *testing.T) {
+	vectors := testdata.GenerateRandomVectors(300, 16, 42)
+	idx := newTestIndex(t, vectors)
+
+	var once sync.Once
+	slowFilter := func(id int) bool {
+		once.Do(func() { time.Sleep(5 * time.Millisecond) })
+		return true
+	}
+
+	_, err := idx.SearchWithOptions(vectors[0], 5, index.SearchOptions{
+		Filter:  slowFilter,
+		Timeout: time.Millisecond,
+	})
+	if !errors.Is(err, index.ErrTimeout) {
+		t.Errorf("SearchWithOptions() error = %v, want ErrTimeout", err)
+	}
+}
+
+func TestHNSWConcurrency(t *testing.T) {
+	vectors := testdata.GenerateRandomVectors(400, 16, 42)
+	idx := newTestIndex(t, vectors[:200])
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+		for _, v := range vectors[200:] {
+			if err := idx.Add(v); err != nil {
+				t.Errorf("Add() failed: %v", err)
+				return
+			}
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+		for _, q := range vectors[:100] {
+			if _, err := idx.SearchWithOptions(q, 5, index.SearchOptions{EfSearch: 10}); err != nil {
+				t.Errorf("Search() failed: %v", err)
+				return
+			}
+		}
+	}()
+
+	wg.Wait()
+
+	if idx.Size() != len(vectors) {
+		t.Errorf("Size() = %d, want %d", idx.Size(), len(vectors))
+	}
+}
+
+func TestHNSWMemoryUsage(t *testing.T) {
+	vectors := testdata.GenerateRandomVectors(200, 8, 42)
+	idx := newTestIndex(t, vectors)
+
+	m := idx.MemoryUsage()
+
+	var edges uint64
+	for _, node := range idx.nodes {
+		for _, neighbors := range node.Connections {
+			edges += uint64(len(neighbors))
+		}
+	}
+	if m.Graph < edges*8 {
+		t.Errorf("Graph = %d bytes for %d edges", m.Graph, edges)
+	}
+	if want := uint64(200 * (8*8 + index.SliceHeaderBytes)); m.Vectors != want {
+		t.Errorf("Vectors = %d, want %d", m.Vectors, want)
+	}
+	if m.Centroids != 0 || m.IDs != 0 || m.Codes != 0 || m.Metadata == 0 {
+		t.Errorf("unexpected breakdown %+v", m)
+	}
+}
+
+func TestHNSWSearchWithOptionsStats(t *testing.T) {
+	vectors := testdata.GenerateRandomVectors(500, 8, 42)
+	idx := newTestIndex(t, vectors)
+
+	var stats index.SearchStats
+	if _, err := idx.SearchWithOptions(vectors[0], 10, index.SearchOptions{Stats: &stats}); err != nil {
+		t.Fatalf("SearchWithOptions() failed: %v", err)
+	}
+
+	if stats.NodesVisited == 0 || stats.DistanceComputations < stats.NodesVisited {
+		t.Errorf("stats = %+v", stats)
+	}
+	if stats.ClustersProbed != 0 || stats.CandidatesScanned != 0 {
+		t.Errorf("HNSW should not report IVF/flat counters: %+v", stats)
+	}
+	var names []string
+	for _, p := range stats.Phases {
+		names = append(names, p.Name)
+	}
+	if len(names) != 3 || names[0] != index.PhaseDescend || names[1] != index.PhaseExpand || names[2] != index.PhaseSelect {
+		t.Errorf("phases = %v", names)
+	}
+}
@@ -0,0 +1,33 @@
+package solution
+
+import (
+	"math"
+	"math/rand"
+)
+
+// RandomLevel generates a random level for a new node
+// Uses exponential decay distribution: level = floor(-ln(U) * ml)
+//
+// With ml = 1/ln(2):
+// P(level=0) ≈ 50%
+// P(level=1) ≈ 25%
+// P(level=2) ≈ 12.5%
+// ...
+func RandomLevel(ml float64, maxLevel int) int {
+	return levelFor(rand.Float64(), ml, maxLevel)
+}
+
+// levelFor maps f, uniform in [0, 1), to a level as RandomLevel does
+func levelFor(f, ml float64, maxLevel int) int {
+	// 1 - f is in (0, 1], so the log is finite
+	level := int(math.Floor(-math.Log(1-f) * ml))
+	if level > maxLevel {
+		level = maxLevel
+	}
+	return level
+}
+
+// DefaultMl returns the typical ml value
+func DefaultMl() float64 {
+	return 1.0 / math.Log(2.0) // ≈ 1.44
+}
@@ -0,0 +1,165 @@
+package solution
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/tmdgusya/database-class/pkg/distance"
+	"github.com/tmdgusya/database-class/pkg/mmapfile"
+)
+
+// mappedMagic starts a file written by WriteMapped
+const mappedMagic = "VECHNSW1"
+
+// errReadOnly is returned by Add on a memory-mapped index
+var errReadOnly = errors.New("index is read-only (memory-mapped)")
+
+// WriteMapped writes the graph in the layout OpenMappedHNSWIndex maps:
+//
+//	magic "VECHNSW1", M, Mmax, efConstruction, efSearch, ml,
+//	entry point, max layer, dimension, node count n
+//	levels     n int64s
+//	vectors    n*dimension float64s
+//	adjacency  per node, per layer 0..level: neighbor count, neighbor IDs
+//
+// All values are little-endian 8-byte words (see pkg/mmapfile).
+func (idx *HNSWIndex) WriteMapped(w io.Writer) error {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	mw := mmapfile.NewWriter(w)
+	mw.Magic(mappedMagic)
+	mw.Int(idx.M)
+	mw.Int(idx.Mmax)
+	mw.Int(idx.efConstruction)
+	mw.Int(idx.efSearch)
+	mw.Float64(idx.ml)
+	mw.Int(idx.entryPoint)
+	mw.Int(idx.maxLayer)
+	mw.Int(idx.dimension)
+	mw.Int(len(idx.nodes))
+	for _, node := range idx.nodes {
+		mw.Int(node.Level)
+	}
+	for _, node := range idx.nodes {
+		mw.Float64s(node.Vector)
+	}
+	for _, node := range idx.nodes {
+		for _, neighbors := range node.Connections {
+			mw.Int(len(neighbors))
+			mw.Ints(neighbors)
+		}
+	}
+	return mw.Flush()
+}
+
+// OpenMappedHNSWIndex maps a file written by WriteMapped and searches it in
+// place
+// Vectors and neighbor lists are views into the mapping; only the nodes and
+// their slice headers are allocated, two slabs for the whole graph. The
+// index is read-only (Add fails). Vectors returned with IncludeVectors are
+// copied out of the mapping, so results stay valid after Close, which waits
+// for searches in progress.
+func OpenMappedHNSWIndex(path string, metric distance.Metric) (*HNSWIndex, error) {
+	f, err := mmapfile.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	idx, err := mapHNSW(f, metric)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s: corrupt mapped HNSW index: %w", path, err)
+	}
+	return idx, nil
+}
+
+func mapHNSW(f *mmapfile.File, metric distance.Metric) (*HNSWIndex, error) {
+	r := mmapfile.NewReader(f.Bytes())
+	r.Magic(mappedMagic)
+	cfg := Config{
+		Metric:         metric,
+		M:              r.Int("M", 1, math.MaxInt32),
+		Mmax:           r.Int("Mmax", 1, math.MaxInt32),
+		EfConstruction: r.Int("efConstruction", 1, math.MaxInt32),
+		EfSearch:       r.Int("efSearch", 1, math.MaxInt32),
+		Ml:             r.Float64(),
+	}
+	entryPoint := r.Int("entry point", -1, math.MaxInt)
+	maxLayer := r.Int("max layer", 0, maxLevel)
+	dim := r.Int("dimension", -1, math.MaxInt32)
+	n := r.Int("node count", 0, r.Remaining())
+	if err := r.Err(); err != nil {
+		return nil, err
+	}
+
+	idx, err := NewHNSWIndex(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return idx, r.Finish()
+	}
+	if entryPoint < 0 || entryPoint >= n {
+		return nil, fmt.Errorf("entry point %d outside [0, %d)", entryPoint, n)
+	}
+
+	levels := make([]int, n)
+	layers := 0
+	for i := range levels {
+		levels[i] = r.Int("level", 0, maxLayer)
+		layers += levels[i] + 1
+	}
+	if r.Err() == nil && levels[entryPoint] != maxLayer {
+		return nil, fmt.Errorf("entry point %d is not on the top layer %d", entryPoint, maxLayer)
+	}
+
+	nodes := make([]Node, n)
+	for i := range nodes {
+		nodes[i] = Node{ID: i, Vector: r.Float64s(dim), Level: levels[i]}
+	}
+	connections := make([][]int, layers)
+	for i := range nodes {
+		node := &nodes[i]
+		node.Connections, connections = connections[:node.Level+1:node.Level+1], connections[node.Level+1:]
+		for layer := range node.Connections {
+			neighbors := r.Ints(r.Int("neighbor count", 0, n))
+			for _, id := range neighbors {
+				if id < 0 || id >= n || levels[id] < layer {
+					return nil, fmt.Errorf("node %d links to %d, which is not on layer %d", i, id, layer)
+				}
+			}
+			node.Connections[layer] = neighbors
+		}
+	}
+	if err := r.Finish(); err != nil {
+		return nil, err
+	}
+
+	idx.nodes = make([]*Node, n)
+	for i := range nodes {
+		idx.nodes[i] = &nodes[i]
+	}
+	idx.mapped = f
+	idx.entryPoint = entryPoint
+	idx.maxLayer = maxLayer
+	idx.dimension = dim
+	return idx, nil
+}
+
+// Close releases the mapping of an index opened with OpenMappedHNSWIndex,
+// leaving it empty; for other indexes it does nothing
+func (idx *HNSWIndex) Close() error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if idx.mapped == nil {
+		return nil
+	}
+	idx.nodes = nil
+	idx.entryPoint = -1
+	idx.maxLayer = 0
+	idx.dimension = -1
+	return idx.mapped.Close()
+}
@@ -0,0 +1,96 @@
+package solution
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tmdgusya/database-class/pkg/distance"
+	"github.com/tmdgusya/database-class/pkg/index"
+	"github.com/tmdgusya/database-class/pkg/testdata"
+)
+
+func TestHNSWMapped(t *testing.T) {
+	idx, _ := NewHNSWIndex(Config{Metric: distance.L2Distance, M: 8, EfConstruction: 64, EfSearch: 32})
+	for _, v := range testdata.GenerateRandomVectors(300, 8, 42) {
+		idx.Add(v)
+	}
+
+	path := filepath.Join(t.TempDir(), "hnsw.map")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := idx.WriteMapped(f); err != nil {
+		t.Fatalf("WriteMapped() failed: %v", err)
+	}
+	f.Close()
+
+	mapped, err := OpenMappedHNSWIndex(path, distance.L2Distance)
+	if err != nil {
+		t.Fatalf("OpenMappedHNSWIndex() failed: %v", err)
+	}
+
+	layers, mappedLayers := idx.Layers(), mapped.Layers()
+	if len(layers) != len(mappedLayers) {
+		t.Fatalf("%d layers, want %d", len(mappedLayers), len(layers))
+	}
+	for i := range layers {
+		if layers[i] != mappedLayers[i] {
+			t.Errorf("layer %d = %+v, want %+v", i, mappedLayers[i], layers[i])
+		}
+	}
+
+	// Same graph, same parameters: the greedy search takes the same path
+	for _, q := range testdata.GenerateRandomVectors(10, 8, 7) {
+		want, _ := idx.Search(q, 5)
+		got, err := mapped.Search(q, 5)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range want {
+			if got[i].Index != want[i].Index || got[i].Distance != want[i].Distance {
+				t.Fatalf("result %d = %+v, want %+v", i, got[i], want[i])
+			}
+		}
+	}
+
+	if err := mapped.Add(testdata.GenerateRandomVectors(1, 8, 1)[0]); err == nil {
+		t.Error("Add() succeeded on a mapped index")
+	}
+	probe := testdata.GenerateRandomVectors(1, 8, 7)[0]
+	kept, _ := mapped.SearchWithOptions(probe, 1, index.SearchOptions{IncludeVectors: true})
+	want, _ := idx.SearchWithOptions(probe, 1, index.SearchOptions{IncludeVectors: true})
+	if err := mapped.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if mapped.Size() != 0 {
+		t.Errorf("Size() = %d after Close", mapped.Size())
+	}
+	// Result vectors were copied out of the mapping before it went away
+	if !kept[0].Vector.Equal(want[0].Vector, 0) {
+		t.Errorf("result vector after Close = %v, want %v", kept[0].Vector, want[0].Vector)
+	}
+
+	// An empty graph maps too
+	empty, _ := NewHNSWIndex(Config{Metric: distance.L2Distance, M: 8, EfConstruction: 64, EfSearch: 32})
+	emptyPath := filepath.Join(t.TempDir(), "empty.map")
+	f, _ = os.Create(emptyPath)
+	empty.WriteMapped(f)
+	f.Close()
+	if m, err := OpenMappedHNSWIndex(emptyPath, distance.L2Distance); err != nil || m.Size() != 0 {
+		t.Errorf("empty graph: %v, %v", m, err)
+	}
+
+	// A damaged neighbor list is caught before any search follows it
+	data, _ := os.ReadFile(path)
+	// The last word is a neighbor ID or an empty list's count; either way
+	// 2^40 is out of range (0xff in the low byte alone is a valid ID of 300)
+	binary.LittleEndian.PutUint64(data[len(data)-8:], 1<<40)
+	bad := filepath.Join(t.TempDir(), "bad.map")
+	os.WriteFile(bad, data, 0o644)
+	if _, err := OpenMappedHNSWIndex(bad, distance.L2Distance); err == nil {
+		t.Error("OpenMappedHNSWIndex() accepted a damaged neighbor list")
+	}
+}
@@ -0,0 +1,40 @@
+package solution
+
+import "github.com/tmdgusya/database-class/pkg/vector"
+
+// Node represents a vector in the HNSW graph
+type Node struct {
+	ID          int           // Unique ID
+	Vector      vector.Vector // The actual vector
+	Connections [][]int       // connections[layer] = list of neighbor IDs at that layer
+	Level       int           // Maximum layer this node exists in (0 to Level)
+}
+
+// NewNode creates a new node
+func NewNode(id int, v vector.Vector, level int) *Node {
+	connections := make([][]int, level+1)
+	for i := range connections {
+		connections[i] = make([]int, 0)
+	}
+
+	return &Node{
+		ID:          id,
+		Vector:      v,
+		Connections: connections,
+		Level:       level,
+	}
+}
+
+// AddConnection adds a one-directional connection at specified layer
+// The caller is responsible for also connecting the neighbor back
+func (n *Node) AddConnection(neighborID int, layer int) {
+	if layer > n.Level {
+		return
+	}
+	for _, id := range n.Connections[layer] {
+		if id == neighborID {
+			return
+		}
+	}
+	n.Connections[layer] = append(n.Connections[layer], neighborID)
+}
@@ -0,0 +1,121 @@
+package solution
+
+import (
+	"encoding/gob"
+	"fmt"
+	"io"
+
+	"github.com/tmdgusya/database-class/pkg/distance"
+)
+
+// fileVersion is bumped whenever hnswFile changes incompatibly
+const fileVersion = 1
+
+// hnswFile is the serialized form of an HNSWIndex
+type hnswFile struct {
+	Version        int
+	M              int
+	Mmax           int
+	EfConstruction int
+	EfSearch       int
+	Ml             float64
+	EntryPoint     int
+	MaxLayer       int
+	Dimension      int
+	Nodes          []*Node
+}
+
+// Save writes the graph to w with encoding/gob
+// The metric is a function and cannot be saved; pass it to LoadHNSWIndex.
+func (idx *HNSWIndex) Save(w io.Writer) error {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	return gob.NewEncoder(w).Encode(hnswFile{
+		Version:        fileVersion,
+		M:              idx.M,
+		Mmax:           idx.Mmax,
+		EfConstruction: idx.efConstruction,
+		EfSearch:       idx.efSearch,
+		Ml:             idx.ml,
+		EntryPoint:     idx.entryPoint,
+		MaxLayer:       idx.maxLayer,
+		Dimension:      idx.dimension,
+		Nodes:          idx.nodes,
+	})
+}
+
+// LoadHNSWIndex reads a graph written by Save
+func LoadHNSWIndex(r io.Reader, metric distance.Metric) (*HNSWIndex, error) {
+	var f hnswFile
+	if err := gob.NewDecoder(r).Decode(&f); err != nil {
+		return nil, fmt.Errorf("decode HNSW index: %w", err)
+	}
+	if f.Version != fileVersion {
+		return nil, fmt.Errorf("unsupported HNSW index version %d (want %d)", f.Version, fileVersion)
+	}
+
+	idx, err := NewHNSWIndex(Config{
+		Metric:         metric,
+		M:              f.M,
+		Mmax:           f.Mmax,
+		EfConstruction: f.EfConstruction,
+		EfSearch:       f.EfSearch,
+		Ml:             f.Ml,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("corrupt HNSW index: %w", err)
+	}
+	if err := f.check(); err != nil {
+		return nil, fmt.Errorf("corrupt HNSW index: %w", err)
+	}
+
+	if len(f.Nodes) > 0 {
+		idx.nodes = f.Nodes
+		idx.entryPoint = f.EntryPoint
+		idx.maxLayer = f.MaxLayer
+		idx.dimension = f.Dimension
+	}
+	return idx, nil
+}
+
+// check verifies that node IDs are positions and every edge stays in range,
+// so a damaged file cannot make Search index out of bounds
+func (f *hnswFile) check() error {
+	if len(f.Nodes) == 0 {
+		return nil
+	}
+	if f.EntryPoint < 0 || f.EntryPoint >= len(f.Nodes) || f.Nodes[f.EntryPoint].Level != f.MaxLayer {
+		return fmt.Errorf("entry point %d is not a node on the top layer %d", f.EntryPoint, f.MaxLayer)
+	}
+	for i, node := range f.Nodes {
+		if node == nil || node.ID != i {
+			return fmt.Errorf("node %d is missing or misnumbered", i)
+		}
+		if node.Vector.Dimension() != f.Dimension {
+			return fmt.Errorf("node %d has dimension %d, want %d", i, node.Vector.Dimension(), f.Dimension)
+		}
+		if node.Level < 0 || node.Level > f.MaxLayer {
+			return fmt.Errorf("node %d has level %d outside [0, %d]", i, node.Level, f.MaxLayer)
+		}
+
+		// gob drops empty slices; restore one list per layer
+		connections := make([][]int, node.Level+1)
+		copy(connections, node.Connections)
+		for layer, neighbors := range connections {
+			if neighbors == nil {
+				connections[layer] = make([]int, 0)
+			}
+			for _, id := range neighbors {
+				if id < 0 || id >= len(f.Nodes) || f.Nodes[id] == nil || f.Nodes[id].Level < layer {
+					return fmt.Errorf("node %d links to %d, which is not on layer %d", i, id, layer)
+				}
+			}
+		}
+		if len(node.Connections) > node.Level+1 {
+			return fmt.Errorf("node %d has %d layers of links for level %d", i, len(node.Connections), node.Level)
+		}
+		node.Connections = connections
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package solution
+
+import (
+	"bytes"
+	"encoding/gob"
+	"testing"
+
+	"github.com/tmdgusya/database-class/pkg/distance"
+	"github.com/tmdgusya/database-class/pkg/testdata"
+)
+
+func TestHNSWSaveLoad(t *testing.T) {
+	idx, _ := NewHNSWIndex(Config{Metric: distance.L2Distance, M: 8, EfConstruction: 64, EfSearch: 32})
+	for _, v := range testdata.GenerateRandomVectors(300, 8, 42) {
+		idx.Add(v)
+	}
+
+	var buf bytes.Buffer
+	if err := idx.Save(&buf); err != nil {
+		t.Fatalf("Save() failed: %v", err)
+	}
+	loaded, err := LoadHNSWIndex(&buf, distance.L2Distance)
+	if err != nil {
+		t.Fatalf("LoadHNSWIndex() failed: %v", err)
+	}
+
+	layers, loadedLayers := idx.Layers(), loaded.Layers()
+	if len(layers) != len(loadedLayers) {
+		t.Fatalf("%d layers after load, want %d", len(loadedLayers), len(layers))
+	}
+	for i := range layers {
+		if layers[i] != loadedLayers[i] {
+			t.Errorf("layer %d = %+v, want %+v", i, loadedLayers[i], layers[i])
+		}
+	}
+	if layers[0].Nodes != 300 {
+		t.Errorf("layer 0 has %d nodes, want all 300", layers[0].Nodes)
+	}
+
+	// Same graph, same greedy search
+	for _, q := range testdata.GenerateRandomVectors(10, 8, 7) {
+		want, _ := idx.Search(q, 5)
+		got, _ := loaded.Search(q, 5)
+		for i := range want {
+			if got[i].Index != want[i].Index {
+				t.Fatalf("result %d = %d, want %d", i, got[i].Index, want[i].Index)
+			}
+		}
+	}
+
+	// The loaded graph keeps growing
+	if err := loaded.Add(testdata.GenerateRandomVectors(1, 8, 9)[0]); err != nil || loaded.Size() != 301 {
+		t.Errorf("Add() after load: %v, size %d", err, loaded.Size())
+	}
+}
+
+func TestHNSWLoadRejectsDanglingEdges(t *testing.T) {
+	v := testdata.GenerateRandomVectors(1, 2, 1)[0]
+	var buf bytes.Buffer
+	gob.NewEncoder(&buf).Encode(hnswFile{
+		Version: fileVersion, M: 4, Mmax: 8, EfConstruction: 8, EfSearch: 8, Ml: DefaultMl(),
+		Dimension: 2,
+		Nodes:     []*Node{{ID: 0, Vector: v, Connections: [][]int{{7}}}},
+	})
+	if _, err := LoadHNSWIndex(&buf, distance.L2Distance); err == nil {
+		t.Error("LoadHNSWIndex() accepted an edge to a missing node")
+	}
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/tmdgusya/database-class/pkg/distance"
+	"github.com/tmdgusya/database-class/pkg/testdata"
+)
+
+func TestParseInts(t *testing.T) {
+	got, err := parseInts(" 1, 4,16 ,")
+	if err != nil {
+		t.Fatalf("parseInts() failed: %v", err)
+	}
+	want := []int{1, 4, 16}
+	if len(got) != len(want) {
+		t.Fatalf("parseInts() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("parseInts() = %v, want %v", got, want)
+		}
+	}
+
+	for _, bad := range []string{"", "1,x", "0", "-3"} {
+		if _, err := parseInts(bad); err == nil {
+			t.Errorf("parseInts(%q) should fail", bad)
+		}
+	}
+}
+
+func TestMarkPareto(t *testing.T) {
+	results := []result{
+		{Recall: 0.90, QPS: 1000}, // Frontier
+		{Recall: 0.80, QPS: 900},  // Dominated by the first
+		{Recall: 0.99, QPS: 200},  // Frontier: best recall
+		{Recall: 0.70, QPS: 5000}, // Frontier: fastest
+		{Recall: 0.90, QPS: 800},  // Same recall, slower
+	}
+	markPareto(results)
+
+	want := []bool{true, false, true, true, false}
+	for i, r := range results {
+		if r.Pareto != want[i] {
+			t.Errorf("result %d (recall %.2f, qps %.0f): pareto = %v, want %v",
+				i, r.Recall, r.QPS, r.Pareto, want[i])
+		}
+	}
+
+	front := frontier(results)
+	if len(front) != 3 || front[0].Recall != 0.70 || front[2].Recall != 0.99 {
+		t.Errorf("frontier() = %+v, want 3 points ordered by recall", front)
+	}
+}
+
+func smallOptions(t *testing.T, args ...string) *options {
+	t.Helper()
+	base := []string{"-n", "500", "-dim", "8", "-queries", "10", "-clusters", "5", "-memory=false"}
+	opts, err := parseFlags(append(base, args...))
+	if err != nil {
+		t.Fatalf("parseFlags() failed: %v", err)
+	}
+	return opts
+}
+
+func TestRunCSV(t *testing.T) {
+	opts := smallOptions(t, "-index", "ivf", "-nlist", "4,8", "-nprobe", "1,8")
+
+	var out bytes.Buffer
+	if err := run(opts, &out, io.Discard); err != nil {
+		t.Fatalf("run() failed: %v", err)
+	}
+
+	rows, err := csv.NewReader(&out).ReadAll()
+	if err != nil {
+		t.Fatalf("output is not CSV: %v", err)
+	}
+	// nprobe=8 is skipped for nlist=4: header + 3 rows
+	if len(rows) != 4 {
+		t.Fatalf("got %d CSV rows, want 4:\n%v", len(rows), rows)
+	}
+	if rows[0][0] != "index" || rows[1][0] != "ivf" {
+		t.Errorf("unexpected CSV layout: %v", rows[:2])
+	}
+}
+
+func TestRunJSON(t *testing.T) {
+	opts := smallOptions(t, "-index", "hnsw", "-M", "4", "-efc", "20", "-ef", "5,10,20", "-format", "json")
+
+	var out bytes.Buffer
+	if err := run(opts, &out, io.Discard); err != nil {
+		t.Fatalf("run() failed: %v", err)
+	}
+
+	var rep report
+	if err := json.Unmarshal(out.Bytes(), &rep); err != nil {
+		t.Fatalf("output is not JSON: %v", err)
+	}
+	// ef=5 < k=10 is skipped
+	if len(rep.Results) != 2 {
+		t.Errorf("got %d results, want 2", len(rep.Results))
+	}
+	if len(rep.Frontier) == 0 {
+		t.Error("Pareto frontier is empty")
+	}
+	if rep.Dataset.Size != 500 || rep.Dataset.K != 10 {
+		t.Errorf("dataset info = %+v", rep.Dataset)
+	}
+	for _, r := range rep.Results {
+		if r.Recall < 0.5 {
+			t.Errorf("%s recall = %.2f, suspiciously low", r.SearchParams, r.Recall)
+		}
+		// Computed ground truth has distances, so every ranking metric is filled in
+		if r.NDCG <= 0 || r.TieRecall < r.Recall || r.DistanceRatio < 1 {
+			t.Errorf("%s ranking metrics = ndcg %.2f, tie recall %.2f, distance ratio %.2f",
+				r.SearchParams, r.NDCG, r.TieRecall, r.DistanceRatio)
+		}
+	}
+}
+
+func TestRunFromFiles(t *testing.T) {
+	dir := t.TempDir()
+	base := testdata.GenerateClusteredVectors(300, 8, 4, 1)
+	queries := testdata.AddNoise(base[:12], 0.01, 2)
+	truth, err := testdata.ComputeGroundTruth(queries, base, 20, distance.L2Distance)
+	if err != nil {
+		t.Fatalf("ComputeGroundTruth() failed: %v", err)
+	}
+
+	basePath := filepath.Join(dir, "base.fvecs")
+	queryPath := filepath.Join(dir, "query.npy")
+	gtPath := filepath.Join(dir, "gt.ivecs")
+	if err := testdata.SaveFvecs(basePath, base); err != nil {
+		t.Fatal(err)
+	}
+	if err := testdata.SaveNpy(queryPath, queries, testdata.NpyFloat32); err != nil {
+		t.Fatal(err)
+	}
+	if err := testdata.SaveIvecs(gtPath, truth); err != nil {
+		t.Fatal(err)
+	}
+
+	opts, err := parseFlags([]string{
+		"-base", basePath, "-query", queryPath, "-gt", gtPath,
+		"-index", "flat", "-format", "json", "-memory=false",
+	})
+	if err != nil {
+		t.Fatalf("parseFlags() failed: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := run(opts, &out, io.Discard); err != nil {
+		t.Fatalf("run() failed: %v", err)
+	}
+	var rep report
+	if err := json.Unmarshal(out.Bytes(), &rep); err != nil {
+		t.Fatalf("output is not JSON: %v", err)
+	}
+	if rep.Dataset.Name != "base" || rep.Dataset.Size != 300 || rep.Dataset.Queries != 12 {
+		t.Errorf("dataset info = %+v", rep.Dataset)
+	}
+	// Ground truth came from float64 data, the index holds float32 copies:
+	// brute force must still agree on (almost) every neighbour
+	if len(rep.Results) != 1 || rep.Results[0].Recall < 0.95 {
+		t.Errorf("flat recall against file ground truth = %+v", rep.Results)
+	}
+
+	if _, err := parseFlags([]string{"-base", basePath}); err == nil {
+		t.Error("parseFlags() should require -query with -base")
+	}
+	if _, err := parseFlags([]string{"-base", basePath, "-query", queryPath, "-gt", gtPath, "-limit", "10"}); err == nil {
+		t.Error("parseFlags() should reject -gt with -limit")
+	}
+}
+
+func TestGroundTruthCacheFlag(t *testing.T) {
+	dir := t.TempDir()
+	args := []string{
+		"-dataset", "random", "-n", "200", "-dim", "8", "-queries", "10",
+		"-index", "flat", "-memory=false", "-gt-cache", dir, "-workers", "2",
+	}
+
+	for run := 0; run < 2; run++ {
+		opts, err := parseFlags(args)
+		if err != nil {
+			t.Fatalf("parseFlags() failed: %v", err)
+		}
+		ds, err := loadDataset(opts)
+		if err != nil {
+			t.Fatalf("run %d: loadDataset() failed: %v", run, err)
+		}
+		if len(ds.GroundTruth) != 10 || len(ds.GroundTruth[0]) != opts.k {
+			t.Fatalf("run %d: ground truth shape %dx%d", run, len(ds.GroundTruth), len(ds.GroundTruth[0]))
+		}
+	}
+
+	entries, err := filepath.Glob(filepath.Join(dir, "gt-*.gob"))
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("cache entries = %v (err %v), want exactly one", entries, err)
+	}
+
+	if _, err := parseFlags([]string{"-workers", "-1"}); err == nil {
+		t.Error("parseFlags() should reject negative -workers")
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	flat "github.com/tmdgusya/database-class/01-flat/solution"
+	ivf "github.com/tmdgusya/database-class/02-ivf/solution"
+	hnsw "github.com/tmdgusya/database-class/03-hnsw/solution"
+	"github.com/tmdgusya/database-class/pkg/index"
+)
+
+// param is one named value of a build or search configuration
+type param struct {
+	Name  string
+	Value int
+}
+
+// formatParams renders params as "name=value,name=value"
+func formatParams(params []param) string {
+	parts := make([]string, len(params))
+	for i, p := range params {
+		parts[i] = fmt.Sprintf("%s=%d", p.Name, p.Value)
+	}
+	return strings.Join(parts, ",")
+}
+
+// buildSpec is one build configuration and the search configurations to
+// sweep once it is built
+type buildSpec struct {
+	params  []param
+	queries []querySpec
+	build   func(ds *dataset, opts *options) (index.OptionSearcher, error)
+}
+
+// querySpec is one search-time configuration
+type querySpec struct {
+	params []param
+	search index.SearchOptions
+}
+
+// buildGrid expands the parameter grid for opts.index
+func buildGrid(opts *options) []buildSpec {
+	switch opts.index {
+	case "flat":
+		return []buildSpec{{
+			queries: []querySpec{{search: benchSearchOptions()}},
+			build:   buildFlat,
+		}}
+
+	case "ivf":
+		var specs []buildSpec
+		for _, nlist := range opts.nlist {
+			spec := buildSpec{
+				params: []param{{"nlist", nlist}},
+				build:  buildIVF(nlist),
+			}
+			for _, nprobe := range opts.nprobe {
+				if nprobe > nlist {
+					continue // Would be rejected by the index
+				}
+				search := benchSearchOptions()
+				search.NProbe = nprobe
+				spec.queries = append(spec.queries, querySpec{
+					params: []param{{"nprobe", nprobe}},
+					search: search,
+				})
+			}
+			specs = append(specs, spec)
+		}
+		return specs
+
+	case "hnsw":
+		var specs []buildSpec
+		for _, m := range opts.m {
+			for _, efc := range opts.efConstruction {
+				if efc < m {
+					continue // Rejected by NewHNSWIndex
+				}
+				spec := buildSpec{
+					params: []param{{"M", m}, {"efConstruction", efc}},
+					build:  buildHNSW(m, efc),
+				}
+				for _, ef := range opts.efSearch {
+					if ef < opts.k {
+						continue // efSearch < k cannot return k results
+					}
+					search := benchSearchOptions()
+					search.EfSearch = ef
+					spec.queries = append(spec.queries, querySpec{
+						params: []param{{"efSearch", ef}},
+						search: search,
+					})
+				}
+				specs = append(specs, spec)
+			}
+		}
+		return specs
+	}
+	return nil
+}
+
+// benchSearchOptions skips copying result vectors, which only adds noise to
+// the latency numbers
+func benchSearchOptions() index.SearchOptions {
+	opts := index.DefaultSearchOptions()
+	opts.IncludeVectors = false
+	return opts
+}
+
+func buildFlat(ds *dataset, _ *options) (index.OptionSearcher, error) {
+	idx, err := flat.NewFlatIndex(flat.Config{Metric: ds.Metric})
+	if err != nil {
+		return nil, err
+	}
+	return idx, addAll(idx, ds)
+}
+
+func buildIVF(nlist int) func(*dataset, *options) (index.OptionSearcher, error) {
+	return func(ds *dataset, opts *options) (index.OptionSearcher, error) {
+		idx, err := ivf.NewIVFIndex(ivf.Config{
+			Metric:      ds.Metric,
+			NumClusters: nlist,
+			NumProbes:   1,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		train := ds.Base
+		if opts.trainSize > 0 && opts.trainSize < len(train) {
+			train = train[:opts.trainSize]
+		}
+		if err := idx.Train(train); err != nil {
+			return nil, fmt.Errorf("train: %w", err)
+		}
+		return idx, addAll(idx, ds)
+	}
+}
+
+func buildHNSW(m, efc int) func(*dataset, *options) (index.OptionSearcher, error) {
+	return func(ds *dataset, _ *options) (index.OptionSearcher, error) {
+		idx, err := hnsw.NewHNSWIndex(hnsw.Config{
+			Metric:         ds.Metric,
+			M:              m,
+			EfConstruction: efc,
+			EfSearch:       efc,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return idx, addAll(idx, ds)
+	}
+}
+
+// addAll inserts the base set in order, so index IDs match ground truth IDs
+func addAll(idx index.Index, ds *dataset) error {
+	for i, v := range ds.Base {
+		if err := idx.Add(v); err != nil {
+			return fmt.Errorf("add vector %d: %w", i, err)
+		}
+	}
+	return nil
+}
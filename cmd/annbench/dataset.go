@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/tmdgusya/database-class/pkg/distance"
+	"github.com/tmdgusya/database-class/pkg/testdata"
+	"github.com/tmdgusya/database-class/pkg/vector"
+)
+
+// dataset is a base set, its queries and their exact neighbours
+type dataset struct {
+	Name        string
+	Base        []vector.Vector
+	Queries     []vector.Vector
+	GroundTruth [][]int // k exact neighbour IDs per query
+	// Distances of those neighbours; nil when ground truth came from a file
+	TrueDistances [][]float64
+	Metric        distance.Metric
+	MetricName    string
+}
+
+// datasetInfo describes a dataset in the JSON report
+type datasetInfo struct {
+	Name    string `json:"name"`
+	Size    int    `json:"size"`
+	Dim     int    `json:"dim"`
+	Queries int    `json:"queries"`
+	Metric  string `json:"metric"`
+	K       int    `json:"k"`
+}
+
+// loadDataset loads the files named by opts, or generates the synthetic
+// dataset, and fills in the ground truth
+func loadDataset(opts *options) (*dataset, error) {
+	metric, err := distance.ByName(opts.metric)
+	if err != nil {
+		return nil, err
+	}
+
+	ds := &dataset{
+		Name:       opts.dataset,
+		Metric:     metric,
+		MetricName: opts.metric,
+	}
+
+	if opts.base != "" {
+		err = ds.loadFiles(opts)
+	} else {
+		err = ds.generate(opts)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if ds.GroundTruth == nil {
+		var gt *testdata.GroundTruth
+		if opts.gtCache != "" {
+			cache := &testdata.GroundTruthCache{Dir: opts.gtCache}
+			gt, err = cache.GetOrCompute(ds.Queries, ds.Base, opts.k, opts.metric, metric, opts.workers)
+		} else {
+			gt, err = testdata.ComputeNeighbors(ds.Queries, ds.Base, opts.k, metric, opts.workers)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("ground truth: %w", err)
+		}
+		ds.GroundTruth = gt.Indices()
+		ds.TrueDistances = gt.Distances()
+	}
+	return ds, nil
+}
+
+// loadFiles reads the base, query and optional ground-truth files
+func (ds *dataset) loadFiles(opts *options) error {
+	var err error
+	ds.Name = strings.TrimSuffix(filepath.Base(opts.base), filepath.Ext(opts.base))
+
+	if ds.Base, err = testdata.LoadVectors(opts.base, opts.limit); err != nil {
+		return err
+	}
+	if ds.Queries, err = testdata.LoadVectors(opts.query, opts.queries); err != nil {
+		return err
+	}
+	if len(ds.Base) == 0 || len(ds.Queries) == 0 {
+		return fmt.Errorf("empty base or query file")
+	}
+	if dim := ds.Base[0].Dimension(); ds.Queries[0].Dimension() != dim {
+		return fmt.Errorf("query dimension %d does not match base dimension %d", ds.Queries[0].Dimension(), dim)
+	}
+	if opts.k > len(ds.Base) {
+		return fmt.Errorf("-k %d exceeds the %d base vectors", opts.k, len(ds.Base))
+	}
+
+	if opts.groundTruth != "" {
+		truth, err := testdata.LoadGroundTruth(opts.groundTruth, opts.k)
+		if err != nil {
+			return err
+		}
+		if len(truth) < len(ds.Queries) {
+			return fmt.Errorf("ground truth has %d rows for %d queries", len(truth), len(ds.Queries))
+		}
+		ds.GroundTruth = truth[:len(ds.Queries)]
+	}
+	return nil
+}
+
+// generate builds the synthetic dataset
+func (ds *dataset) generate(opts *options) error {
+	switch opts.dataset {
+	case "random":
+		ds.Base = testdata.GenerateRandomVectors(opts.n, opts.dim, opts.seed)
+	case "clustered":
+		ds.Base = testdata.GenerateClusteredVectors(opts.n, opts.dim, opts.clusters, opts.seed)
+	case "normalized":
+		ds.Base = testdata.GenerateNormalizedVectors(opts.n, opts.dim, opts.seed)
+	default:
+		return fmt.Errorf("unknown dataset %q (want random, clustered or normalized)", opts.dataset)
+	}
+
+	// Queries are perturbed base vectors so they fall where the data lives
+	sample := testdata.ShuffleVectors(ds.Base, opts.seed+1)
+	if opts.queries < len(sample) {
+		sample = sample[:opts.queries]
+	}
+	ds.Queries = testdata.AddNoise(sample, 0.01, opts.seed+2)
+	return nil
+}
+
+func (ds *dataset) info(k int) datasetInfo {
+	return datasetInfo{
+		Name:    ds.Name,
+		Size:    len(ds.Base),
+		Dim:     ds.Base[0].Dimension(),
+		Queries: len(ds.Queries),
+		Metric:  ds.MetricName,
+		K:       k,
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// options holds everything parsed from the command line
+type options struct {
+	// Dataset files (override the synthetic dataset when base is set)
+	base        string // .fvecs, .bvecs or .npy
+	query       string // Same formats; required with base
+	groundTruth string // .ivecs; computed when empty
+	limit       int    // Base vectors to load (0 = all)
+
+	// Ground truth computation
+	gtCache string // Cache directory ("" = no cache)
+	workers int    // Goroutines for brute force (0 = GOMAXPROCS)
+
+	// Synthetic dataset
+	dataset  string // random | clustered | normalized
+	n        int    // Base vectors
+	dim      int    // Dimension
+	queries  int    // Query vectors
+	clusters int    // Clusters for the clustered dataset
+	seed     int64
+	metric   string
+
+	// Benchmark
+	index         string // flat | ivf | hnsw
+	k             int
+	trainSize     int // IVF training sample (0 = whole base set)
+	measureMemory bool
+
+	// Parameter grid
+	nlist          []int
+	nprobe         []int
+	m              []int
+	efConstruction []int
+	efSearch       []int
+
+	// Output
+	format string // csv | json
+	out    string // File path ("" = stdout)
+}
+
+// parseFlags parses args into options and validates them
+func parseFlags(args []string) (*options, error) {
+	opts := &options{}
+	fs := flag.NewFlagSet("annbench", flag.ContinueOnError)
+
+	fs.StringVar(&opts.base, "base", "", "base vectors file (.fvecs, .bvecs or .npy); replaces the synthetic dataset")
+	fs.StringVar(&opts.query, "query", "", "query vectors file, required with -base")
+	fs.StringVar(&opts.groundTruth, "gt", "", "ground-truth .ivecs file (computed by brute force if empty)")
+	fs.IntVar(&opts.limit, "limit", 0, "load at most this many base vectors (0 = all)")
+	fs.StringVar(&opts.gtCache, "gt-cache", "", "directory caching computed ground truth between runs (disabled if empty)")
+	fs.IntVar(&opts.workers, "workers", 0, "goroutines computing ground truth (0 = GOMAXPROCS)")
+	fs.StringVar(&opts.dataset, "dataset", "clustered", "synthetic dataset: random, clustered or normalized")
+	fs.IntVar(&opts.n, "n", 10000, "number of base vectors")
+	fs.IntVar(&opts.dim, "dim", 64, "vector dimension")
+	fs.IntVar(&opts.queries, "queries", 100, "number of query vectors")
+	fs.IntVar(&opts.clusters, "clusters", 50, "clusters in the clustered dataset")
+	fs.Int64Var(&opts.seed, "seed", 42, "random seed")
+	fs.StringVar(&opts.metric, "metric", "l2", "distance metric: l2, l2sq, cosine or dot")
+
+	fs.StringVar(&opts.index, "index", "ivf", "index type: flat, ivf or hnsw")
+	fs.IntVar(&opts.k, "k", 10, "neighbours per query")
+	fs.IntVar(&opts.trainSize, "train", 0, "IVF training sample size (0 = all base vectors)")
+	fs.BoolVar(&opts.measureMemory, "memory", true, "measure heap growth while building (adds a GC pause per build)")
+
+	nlist := fs.String("nlist", "16,64", "IVF: comma-separated nlist values")
+	nprobe := fs.String("nprobe", "1,2,4,8,16", "IVF: comma-separated nprobe values")
+	m := fs.String("M", "8,16", "HNSW: comma-separated M values")
+	efc := fs.String("efc", "100", "HNSW: comma-separated efConstruction values")
+	ef := fs.String("ef", "10,20,40,80,160", "HNSW: comma-separated efSearch values")
+
+	fs.StringVar(&opts.format, "format", "csv", "output format: csv or json")
+	fs.StringVar(&opts.out, "out", "", "output file (default stdout)")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	var err error
+	grid := []struct {
+		name string
+		raw  string
+		dst  *[]int
+	}{
+		{"nlist", *nlist, &opts.nlist},
+		{"nprobe", *nprobe, &opts.nprobe},
+		{"M", *m, &opts.m},
+		{"efc", *efc, &opts.efConstruction},
+		{"ef", *ef, &opts.efSearch},
+	}
+	for _, g := range grid {
+		if *g.dst, err = parseInts(g.raw); err != nil {
+			return nil, fmt.Errorf("-%s: %w", g.name, err)
+		}
+	}
+
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	return opts, nil
+}
+
+func (o *options) validate() error {
+	if o.base != "" {
+		if o.query == "" {
+			return fmt.Errorf("-query is required with -base")
+		}
+		// Precomputed neighbours refer to the full base set
+		if o.groundTruth != "" && o.limit > 0 {
+			return fmt.Errorf("-gt cannot be combined with -limit")
+		}
+	} else if o.query != "" || o.groundTruth != "" {
+		return fmt.Errorf("-query and -gt need -base")
+	}
+	if o.workers < 0 {
+		return fmt.Errorf("-workers must not be negative, got %d", o.workers)
+	}
+	if o.n <= 0 || o.dim <= 0 || o.queries <= 0 {
+		return fmt.Errorf("-n, -dim and -queries must be positive")
+	}
+	if o.k <= 0 || (o.base == "" && o.k > o.n) {
+		return fmt.Errorf("-k must be in [1, %d], got %d", o.n, o.k)
+	}
+	switch o.index {
+	case "flat", "ivf", "hnsw":
+	default:
+		return fmt.Errorf("unknown index %q (want flat, ivf or hnsw)", o.index)
+	}
+	switch o.format {
+	case "csv", "json":
+	default:
+		return fmt.Errorf("unknown format %q (want csv or json)", o.format)
+	}
+	return nil
+}
+
+// parseInts parses a comma-separated list of positive integers
+func parseInts(s string) ([]int, error) {
+	var values []int
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		v, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid integer %q", field)
+		}
+		if v <= 0 {
+			return nil, fmt.Errorf("values must be positive, got %d", v)
+		}
+		values = append(values, v)
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("empty list")
+	}
+	return values, nil
+}
@@ -0,0 +1,40 @@
+// Command annbench measures recall against throughput for the course's
+// indexes over a grid of build and search parameters, in the spirit of
+// ann-benchmarks.
+//
+// Each build configuration (nlist for IVF, M/efConstruction for HNSW) is built
+// once and then searched with every search configuration (nprobe, efSearch).
+// For every run it reports build time, memory, recall@k and latency
+// percentiles, marks the runs on the recall/QPS Pareto frontier, and writes
+// CSV or JSON.
+//
+// Usage:
+//
+//	go run ./cmd/annbench -index ivf -nlist 32,64 -nprobe 1,2,4,8,16
+//	go run ./cmd/annbench -index hnsw -M 8,16 -ef 16,32,64,128 -format json
+//	go run ./cmd/annbench -base sift_base.fvecs -query sift_query.fvecs \
+//	    -gt sift_groundtruth.ivecs -index ivf -nlist 1024 -nprobe 8,32,128
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+)
+
+func main() {
+	opts, err := parseFlags(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "annbench: %v\n", err)
+		os.Exit(2)
+	}
+
+	if err := run(opts, os.Stdout, os.Stderr); err != nil {
+		fmt.Fprintf(os.Stderr, "annbench: %v\n", err)
+		os.Exit(1)
+	}
+}
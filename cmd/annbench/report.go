@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"sort"
+	"strconv"
+)
+
+// markPareto flags the results no other result beats on both recall and QPS
+func markPareto(results []result) {
+	order := make([]int, len(results))
+	for i := range order {
+		order[i] = i
+	}
+	// Best recall first; among equal recall, fastest first
+	sort.SliceStable(order, func(a, b int) bool {
+		ra, rb := results[order[a]], results[order[b]]
+		if ra.Recall != rb.Recall {
+			return ra.Recall > rb.Recall
+		}
+		return ra.QPS > rb.QPS
+	})
+
+	// Walking down in recall, a point is on the frontier only if it is
+	// strictly faster than everything with higher recall
+	bestQPS := -1.0
+	for _, i := range order {
+		results[i].Pareto = results[i].QPS > bestQPS
+		if results[i].Pareto {
+			bestQPS = results[i].QPS
+		}
+	}
+}
+
+// frontier returns the Pareto-optimal results ordered by recall
+func frontier(results []result) []result {
+	var front []result
+	for _, r := range results {
+		if r.Pareto {
+			front = append(front, r)
+		}
+	}
+	sort.Slice(front, func(i, j int) bool { return front[i].Recall < front[j].Recall })
+	return front
+}
+
+// writeCSV writes one row per result with a header
+func writeCSV(w io.Writer, results []result) error {
+	cw := csv.NewWriter(w)
+	header := []string{
+		"index", "build_params", "search_params", "build_seconds", "memory_bytes", "index_bytes",
+		"recall", "min_recall", "mrr", "ndcg", "map", "tie_recall", "distance_ratio", "qps", "mean_us", "p50_us", "p95_us", "p99_us", "pareto",
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	f := func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+	for _, r := range results {
+		row := []string{
+			r.Index, r.BuildParams, r.SearchParams, f(r.BuildSeconds),
+			strconv.FormatUint(r.MemoryBytes, 10), strconv.FormatUint(r.IndexBytes, 10), f(r.Recall), f(r.MinRecall),
+			f(r.MRR), f(r.NDCG), f(r.MAP), f(r.TieRecall), f(r.DistanceRatio), f(r.QPS),
+			f(r.MeanMicros), f(r.P50Micros), f(r.P95Micros), f(r.P99Micros),
+			strconv.FormatBool(r.Pareto),
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// report is the JSON document written by -format json
+type report struct {
+	Dataset  datasetInfo `json:"dataset"`
+	Results  []result    `json:"results"`
+	Frontier []result    `json:"pareto_frontier"`
+}
+
+// writeJSON writes the dataset description, every result and the frontier
+func writeJSON(w io.Writer, info datasetInfo, results []result) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report{
+		Dataset:  info,
+		Results:  results,
+		Frontier: frontier(results),
+	})
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/tmdgusya/database-class/pkg/index"
+	"github.com/tmdgusya/database-class/pkg/metrics"
+)
+
+// result is one (build config, search config) measurement
+type result struct {
+	Index         string  `json:"index"`
+	BuildParams   string  `json:"build_params"`
+	SearchParams  string  `json:"search_params"`
+	BuildSeconds  float64 `json:"build_seconds"`
+	MemoryBytes   uint64  `json:"memory_bytes"`
+	IndexBytes    uint64  `json:"index_bytes"` // From MemoryUsage: exact, excludes garbage
+	Recall        float64 `json:"recall"`
+	MinRecall     float64 `json:"min_recall"`
+	MRR           float64 `json:"mrr"`
+	NDCG          float64 `json:"ndcg"`
+	MAP           float64 `json:"map"`
+	TieRecall     float64 `json:"tie_recall"`     // 0 when ground truth came from a file
+	DistanceRatio float64 `json:"distance_ratio"` // 0 without positive distances
+	QPS           float64 `json:"qps"`
+	MeanMicros    float64 `json:"mean_us"`
+	P50Micros     float64 `json:"p50_us"`
+	P95Micros     float64 `json:"p95_us"`
+	P99Micros     float64 `json:"p99_us"`
+	Pareto        bool    `json:"pareto"`
+}
+
+// run executes the whole benchmark and writes the report
+// Progress goes to logw; the report goes to opts.out or, if unset, to stdout.
+func run(opts *options, stdout, logw io.Writer) error {
+	ds, err := loadDataset(opts)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(logw, "dataset %s: %d vectors, dim %d, %d queries, metric %s, k=%d\n",
+		ds.Name, len(ds.Base), ds.Base[0].Dimension(), len(ds.Queries), ds.MetricName, opts.k)
+
+	var results []result
+	for _, spec := range buildGrid(opts) {
+		buildParams := formatParams(spec.params)
+
+		idx, buildTime, memory, err := buildIndex(spec, ds, opts)
+		if err != nil {
+			fmt.Fprintf(logw, "skip %s %s: %v\n", opts.index, buildParams, err)
+			continue
+		}
+		var indexBytes uint64
+		if reporter, ok := idx.(index.MemoryReporter); ok {
+			indexBytes = reporter.MemoryUsage().Total()
+		}
+		fmt.Fprintf(logw, "built %s %s in %v\n", opts.index, buildParams, buildTime.Round(time.Millisecond))
+
+		for _, q := range spec.queries {
+			r, err := measureSearch(idx, q, ds, opts.k)
+			if err != nil {
+				fmt.Fprintf(logw, "skip %s %s %s: %v\n", opts.index, buildParams, formatParams(q.params), err)
+				continue
+			}
+			r.Index = opts.index
+			r.BuildParams = buildParams
+			r.BuildSeconds = buildTime.Seconds()
+			r.MemoryBytes = memory
+			r.IndexBytes = indexBytes
+			results = append(results, r)
+
+			fmt.Fprintf(logw, "  %-16s recall=%.4f qps=%.0f p99=%.0fµs\n",
+				r.SearchParams, r.Recall, r.QPS, r.P99Micros)
+		}
+	}
+	if len(results) == 0 {
+		return fmt.Errorf("no configuration produced results")
+	}
+
+	markPareto(results)
+
+	out := stdout
+	if opts.out != "" {
+		f, err := os.Create(opts.out)
+		if err != nil {
+			return fmt.Errorf("create output: %w", err)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	switch opts.format {
+	case "json":
+		err = writeJSON(out, ds.info(opts.k), results)
+	default:
+		err = writeCSV(out, results)
+	}
+	if err != nil {
+		return fmt.Errorf("write report: %w", err)
+	}
+	return nil
+}
+
+// buildIndex builds one configuration, timing it and, if enabled, measuring
+// how much the live heap grew
+func buildIndex(spec buildSpec, ds *dataset, opts *options) (index.OptionSearcher, time.Duration, uint64, error) {
+	var idx index.OptionSearcher
+	var buildTime time.Duration
+	build := func() error {
+		start := time.Now()
+		var err error
+		idx, err = spec.build(ds, opts)
+		buildTime = time.Since(start)
+		return err
+	}
+
+	if !opts.measureMemory {
+		if err := build(); err != nil {
+			return nil, 0, 0, err
+		}
+		return idx, buildTime, 0, nil
+	}
+
+	stats, err := metrics.MeasureIndexMemory(build)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	return idx, buildTime, stats.AllocBytes, nil
+}
+
+// tieEpsilon absorbs rounding between the index's distance and the ground
+// truth's for vectors tied at the k-th distance
+const tieEpsilon = 1e-9
+
+// measureSearch runs every query once for recall and once more for latency
+// The first pass doubles as a warm-up.
+func measureSearch(idx index.OptionSearcher, q querySpec, ds *dataset, k int) (result, error) {
+	searcher := index.WithOptions(idx, q.search)
+
+	found, distances, err := metrics.SearchBatchWithDistances(searcher, ds.Queries, k)
+	if err != nil {
+		return result{}, err
+	}
+	recall, err := metrics.CalculateDetailedRecall(found, ds.GroundTruth, k)
+	if err != nil {
+		return result{}, err
+	}
+	ranking, err := metrics.EvaluateRanking(found, ds.GroundTruth, distances, ds.TrueDistances, k, tieEpsilon)
+	if err != nil {
+		return result{}, err
+	}
+
+	latency, err := metrics.MeasureSearchLatency(searcher, ds.Queries, k)
+	if err != nil {
+		return result{}, err
+	}
+
+	r := result{
+		SearchParams:  formatParams(q.params),
+		Recall:        recall.Recall,
+		MinRecall:     recall.MinRecall,
+		MRR:           ranking.MRR,
+		NDCG:          ranking.NDCG,
+		MAP:           ranking.MAP,
+		TieRecall:     ranking.TieAwareRecall,
+		DistanceRatio: ranking.DistanceRatio,
+		MeanMicros:    micros(latency.Mean),
+		P50Micros:     micros(latency.Median),
+		P95Micros:     micros(latency.P95),
+		P99Micros:     micros(latency.P99),
+	}
+	if latency.Mean > 0 {
+		r.QPS = 1 / latency.Mean.Seconds()
+	}
+	return r, nil
+}
+
+func micros(d time.Duration) float64 {
+	return float64(d) / float64(time.Microsecond)
+}
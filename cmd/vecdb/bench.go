@@ -0,0 +1,107 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/tmdgusya/database-class/pkg/distance"
+	"github.com/tmdgusya/database-class/pkg/index"
+	"github.com/tmdgusya/database-class/pkg/metrics"
+	"github.com/tmdgusya/database-class/pkg/testdata"
+)
+
+func runBench(args []string, e env) error {
+	var indexPath, queries, gtPath, basePath string
+	var k, limit, workers int
+	var search index.SearchOptions
+	fs := flag.NewFlagSet("bench", flag.ContinueOnError)
+	fs.StringVar(&indexPath, "index", "", "index file written by build")
+	fs.StringVar(&queries, "queries", "", "query vectors (.fvecs, .bvecs, .npy or .csv)")
+	fs.StringVar(&gtPath, "gt", "", "ground-truth .ivecs file")
+	fs.StringVar(&basePath, "base", "", "the indexed vectors, to compute ground truth when -gt is not given")
+	fs.IntVar(&k, "k", 10, "neighbours per query")
+	fs.IntVar(&limit, "limit", 0, "use at most this many queries (0 = all)")
+	fs.IntVar(&workers, "workers", 0, "goroutines computing ground truth (0 = GOMAXPROCS)")
+	fs.IntVar(&search.NProbe, "nprobe", 0, "IVF lists to probe (0 = index default)")
+	fs.IntVar(&search.EfSearch, "ef", 0, "HNSW search beam (0 = index default)")
+	if err := parse(fs, args, e); err != nil {
+		return err
+	}
+	if err := required(fs, e, "index", "queries"); err != nil {
+		return err
+	}
+	if k <= 0 {
+		return fmt.Errorf("-k must be positive, got %d", k)
+	}
+
+	hdr, idx, err := loadIndex(indexPath)
+	if err != nil {
+		return err
+	}
+	qs, err := testdata.LoadVectors(queries, limit)
+	if err != nil {
+		return err
+	}
+	if len(qs) == 0 {
+		return fmt.Errorf("%s: no queries", queries)
+	}
+
+	var truth [][]int
+	switch {
+	case gtPath != "":
+		if truth, err = testdata.LoadGroundTruth(gtPath, k); err != nil {
+			return err
+		}
+		if len(truth) < len(qs) {
+			return fmt.Errorf("%s: %d rows for %d queries", gtPath, len(truth), len(qs))
+		}
+		truth = truth[:len(qs)]
+	case basePath != "":
+		metric, err := distance.ByName(hdr.Metric)
+		if err != nil {
+			return err
+		}
+		base, err := testdata.LoadVectors(basePath, hdr.Size)
+		if err != nil {
+			return err
+		}
+		gt, err := testdata.ComputeNeighbors(qs, base, k, metric, workers)
+		if err != nil {
+			return fmt.Errorf("ground truth: %w", err)
+		}
+		truth = gt.Indices()
+	}
+
+	searcher := index.WithOptions(idx, search)
+
+	w := e.stdout
+	fmt.Fprintf(w, "index:    %s (%s, %d vectors, dim %d)\n", indexPath, hdr.Index, idx.Size(), hdr.Dimension)
+	fmt.Fprintf(w, "queries:  %d, k=%d\n", len(qs), k)
+
+	if truth != nil {
+		found, err := metrics.SearchBatch(searcher, qs, k)
+		if err != nil {
+			return err
+		}
+		recall, err := metrics.CalculateDetailedRecall(found, truth, k)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "recall@%d: %.4f (min %.4f, max %.4f)\n", k, recall.Recall, recall.MinRecall, recall.MaxRecall)
+	}
+
+	latency, err := metrics.MeasureSearchLatency(searcher, qs, k)
+	if err != nil {
+		return err
+	}
+	qps := 0.0
+	if latency.Mean > 0 {
+		qps = 1 / latency.Mean.Seconds()
+	}
+	fmt.Fprintf(w, "qps:      %.0f\n", qps)
+	fmt.Fprintf(w, "latency:  mean %s, p50 %s, p95 %s, p99 %s, max %s\n",
+		metrics.FormatDuration(latency.Mean), metrics.FormatDuration(latency.Median),
+		metrics.FormatDuration(latency.P95), metrics.FormatDuration(latency.P99),
+		metrics.FormatDuration(latency.Max))
+	return nil
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	flat "github.com/tmdgusya/database-class/01-flat/solution"
+	ivf "github.com/tmdgusya/database-class/02-ivf/solution"
+	hnsw "github.com/tmdgusya/database-class/03-hnsw/solution"
+	"github.com/tmdgusya/database-class/pkg/autotune"
+	"github.com/tmdgusya/database-class/pkg/distance"
+	"github.com/tmdgusya/database-class/pkg/index"
+	"github.com/tmdgusya/database-class/pkg/testdata"
+	"github.com/tmdgusya/database-class/pkg/vector"
+)
+
+// buildOptions holds the build subcommand's flags
+type buildOptions struct {
+	in, out   string
+	index     string
+	metric    string
+	limit     int
+	nlist     int // 0 = recommend from the data
+	nprobe    int
+	trainSize int // 0 = every vector
+	m         int // 0 = recommend from the data
+	efc       int // 0 = recommend from the data
+	efSearch  int
+}
+
+func runBuild(args []string, e env) error {
+	var o buildOptions
+	fs := flag.NewFlagSet("build", flag.ContinueOnError)
+	fs.StringVar(&o.in, "in", "", "vectors to index (.fvecs, .bvecs, .npy or .csv)")
+	fs.StringVar(&o.out, "out", "", "index file to write")
+	fs.StringVar(&o.index, "index", "flat", "index type: flat, ivf or hnsw")
+	fs.StringVar(&o.metric, "metric", "l2", fmt.Sprintf("distance metric %v", distance.Names()))
+	fs.IntVar(&o.limit, "limit", 0, "index at most this many vectors (0 = all)")
+	fs.IntVar(&o.nlist, "nlist", 0, "IVF lists (0 = recommended for the data)")
+	fs.IntVar(&o.nprobe, "nprobe", 8, "IVF default lists probed per search (capped at nlist)")
+	fs.IntVar(&o.trainSize, "train-size", 0, "IVF training sample (0 = all vectors)")
+	fs.IntVar(&o.m, "M", 0, "HNSW connections per layer (0 = recommended for the data)")
+	fs.IntVar(&o.efc, "ef-construction", 0, "HNSW construction beam (0 = recommended for the data)")
+	fs.IntVar(&o.efSearch, "ef-search", 64, "HNSW default search beam")
+	if err := parse(fs, args, e); err != nil {
+		return err
+	}
+	if err := required(fs, e, "in", "out"); err != nil {
+		return err
+	}
+
+	metric, err := distance.ByName(o.metric)
+	if err != nil {
+		return err
+	}
+	vectors, err := testdata.LoadVectors(o.in, o.limit)
+	if err != nil {
+		return err
+	}
+	if len(vectors) == 0 {
+		return fmt.Errorf("%s: no vectors", o.in)
+	}
+
+	start := time.Now()
+	idx, params, err := buildIndex(&o, vectors, metric, e)
+	if err != nil {
+		return err
+	}
+	buildTime := time.Since(start)
+
+	hdr := indexHeader{
+		Index:     o.index,
+		Metric:    o.metric,
+		Dimension: vectors[0].Dimension(),
+		Size:      idx.Size(),
+		Params:    params,
+	}
+	if err := saveIndex(o.out, hdr, idx.(saver)); err != nil {
+		return err
+	}
+	fmt.Fprintf(e.stderr, "built %s index of %d vectors (dim %d) in %v -> %s\n",
+		o.index, hdr.Size, hdr.Dimension, buildTime.Round(time.Millisecond), o.out)
+	return nil
+}
+
+// buildIndex creates, trains and fills the index o describes
+// It returns the build parameters actually used, including recommended ones.
+func buildIndex(o *buildOptions, vectors []vector.Vector, metric distance.Metric, e env) (index.Index, map[string]int, error) {
+	needsRecommendation := (o.index == "ivf" && o.nlist == 0) ||
+		(o.index == "hnsw" && (o.m == 0 || o.efc == 0))
+	if needsRecommendation {
+		rec, err := autotune.RecommendBuild(vectors, len(vectors))
+		if err != nil {
+			return nil, nil, fmt.Errorf("recommend parameters: %w", err)
+		}
+		fmt.Fprintf(e.stderr, "intrinsic dimension ≈ %.1f\n", rec.IntrinsicDim)
+		if o.nlist == 0 {
+			o.nlist = rec.NList
+		}
+		if o.m == 0 {
+			o.m = rec.M
+		}
+		if o.efc == 0 {
+			o.efc = max(rec.EfConstruction, o.m)
+		}
+	}
+
+	var idx index.Index
+	var params map[string]int
+	var err error
+	switch o.index {
+	case "flat":
+		idx, err = flat.NewFlatIndex(flat.Config{Metric: metric})
+	case "ivf":
+		nprobe := min(o.nprobe, o.nlist)
+		params = map[string]int{"nlist": o.nlist, "nprobe": nprobe}
+		idx, err = ivf.NewIVFIndex(ivf.Config{Metric: metric, NumClusters: o.nlist, NumProbes: nprobe})
+	case "hnsw":
+		params = map[string]int{"M": o.m, "efConstruction": o.efc, "efSearch": o.efSearch}
+		idx, err = hnsw.NewHNSWIndex(hnsw.Config{
+			Metric:         metric,
+			M:              o.m,
+			EfConstruction: o.efc,
+			EfSearch:       o.efSearch,
+		})
+	default:
+		return nil, nil, fmt.Errorf("unknown index type %q (want flat, ivf or hnsw)", o.index)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	train := vectors
+	if o.trainSize > 0 && o.trainSize < len(train) {
+		train = testdata.ShuffleVectors(vectors, 1)[:o.trainSize]
+	}
+	if err := index.Train(idx, train); err != nil {
+		return nil, nil, fmt.Errorf("train: %w", err)
+	}
+	for i, v := range vectors {
+		if err := idx.Add(v); err != nil {
+			return nil, nil, fmt.Errorf("add vector %d: %w", i, err)
+		}
+	}
+	return idx, params, nil
+}
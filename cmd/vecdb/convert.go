@@ -0,0 +1,36 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/tmdgusya/database-class/pkg/testdata"
+)
+
+func runConvert(args []string, e env) error {
+	var in, out string
+	var limit int
+	fs := flag.NewFlagSet("convert", flag.ContinueOnError)
+	fs.StringVar(&in, "in", "", "input vectors (.fvecs, .bvecs, .npy or .csv)")
+	fs.StringVar(&out, "out", "", "output file; its extension picks the format (.npy is written as float32)")
+	fs.IntVar(&limit, "limit", 0, "convert at most this many vectors (0 = all)")
+	if err := parse(fs, args, e); err != nil {
+		return err
+	}
+	if err := required(fs, e, "in", "out"); err != nil {
+		return err
+	}
+
+	vectors, err := testdata.LoadVectors(in, limit)
+	if err != nil {
+		return err
+	}
+	if len(vectors) == 0 {
+		return fmt.Errorf("%s: no vectors", in)
+	}
+	if err := testdata.SaveVectors(out, vectors); err != nil {
+		return err
+	}
+	fmt.Fprintf(e.stderr, "converted %d vectors (dim %d): %s -> %s\n", len(vectors), vectors[0].Dimension(), in, out)
+	return nil
+}
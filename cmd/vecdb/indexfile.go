@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bufio"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	flat "github.com/tmdgusya/database-class/01-flat/solution"
+	ivf "github.com/tmdgusya/database-class/02-ivf/solution"
+	hnsw "github.com/tmdgusya/database-class/03-hnsw/solution"
+	"github.com/tmdgusya/database-class/pkg/distance"
+	"github.com/tmdgusya/database-class/pkg/index"
+)
+
+// indexMagic starts every index file written by vecdb
+const indexMagic = "VECDBIDX"
+
+// indexHeader describes the index that follows it in the file
+// The index body is whatever the index's own Save method writes; the header
+// adds what Save cannot record, such as the metric's name.
+type indexHeader struct {
+	Index     string         // flat | ivf | hnsw
+	Metric    string         // Name accepted by distance.ByName
+	Dimension int            // Vector dimension
+	Size      int            // Vectors at save time
+	Params    map[string]int // Build parameters, for inspect
+}
+
+// saver is implemented by every index vecdb can write
+type saver interface {
+	Save(w io.Writer) error
+}
+
+// saveIndex writes hdr and idx to path atomically: readers see either the
+// old file or the complete new one
+func saveIndex(path string, hdr indexHeader, idx saver) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // No-op after the rename
+
+	w := bufio.NewWriterSize(tmp, 1<<20)
+	w.WriteString(indexMagic)
+	if err := gob.NewEncoder(w).Encode(hdr); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write header: %w", err)
+	}
+	if err := idx.Save(w); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write index: %w", err)
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// readHeader opens path and reads its header, leaving r at the index body
+func readHeader(path string) (*os.File, *bufio.Reader, indexHeader, error) {
+	var hdr indexHeader
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, hdr, err
+	}
+
+	// gob reads from an io.ByteReader without buffering ahead, so the header
+	// and body decoders can share r
+	r := bufio.NewReaderSize(f, 1<<20)
+	magic := make([]byte, len(indexMagic))
+	if _, err := io.ReadFull(r, magic); err != nil || string(magic) != indexMagic {
+		f.Close()
+		return nil, nil, hdr, fmt.Errorf("%s: not a vecdb index file", path)
+	}
+	if err := gob.NewDecoder(r).Decode(&hdr); err != nil {
+		f.Close()
+		return nil, nil, hdr, fmt.Errorf("%s: read header: %w", path, err)
+	}
+	return f, r, hdr, nil
+}
+
+// loadIndex reads an index file written by saveIndex
+func loadIndex(path string) (indexHeader, index.OptionSearcher, error) {
+	f, r, hdr, err := readHeader(path)
+	if err != nil {
+		return hdr, nil, err
+	}
+	defer f.Close()
+
+	metric, err := distance.ByName(hdr.Metric)
+	if err != nil {
+		return hdr, nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	var idx index.OptionSearcher
+	switch hdr.Index {
+	case "flat":
+		idx, err = flat.LoadFlatIndex(r, metric)
+	case "ivf":
+		idx, err = ivf.LoadIVFIndex(r, metric)
+	case "hnsw":
+		idx, err = hnsw.LoadHNSWIndex(r, metric)
+	default:
+		err = fmt.Errorf("unknown index type %q", hdr.Index)
+	}
+	if err != nil {
+		return hdr, nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return hdr, idx, nil
+}
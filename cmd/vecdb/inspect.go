@@ -0,0 +1,131 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strings"
+
+	ivf "github.com/tmdgusya/database-class/02-ivf/solution"
+	hnsw "github.com/tmdgusya/database-class/03-hnsw/solution"
+	"github.com/tmdgusya/database-class/pkg/index"
+	"github.com/tmdgusya/database-class/pkg/metrics"
+)
+
+// histogramBins is the number of rows in the IVF list-size histogram
+const histogramBins = 10
+
+func runInspect(args []string, e env) error {
+	fs := flag.NewFlagSet("inspect", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: vecdb inspect <index file>")
+	}
+	fs.SetOutput(e.stderr)
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return errUsage
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errUsage
+	}
+	path := fs.Arg(0)
+
+	hdr, idx, err := loadIndex(path)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	w := e.stdout
+	fmt.Fprintf(w, "file:       %s (%s)\n", path, metrics.FormatBytes(uint64(info.Size())))
+	fmt.Fprintf(w, "index:      %s\n", hdr.Index)
+	fmt.Fprintf(w, "metric:     %s\n", hdr.Metric)
+	fmt.Fprintf(w, "dimension:  %d\n", hdr.Dimension)
+	fmt.Fprintf(w, "size:       %d vectors\n", idx.Size())
+	if len(hdr.Params) > 0 {
+		names := make([]string, 0, len(hdr.Params))
+		for name := range hdr.Params {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		parts := make([]string, len(names))
+		for i, name := range names {
+			parts[i] = fmt.Sprintf("%s=%d", name, hdr.Params[name])
+		}
+		fmt.Fprintf(w, "params:     %s\n", strings.Join(parts, " "))
+	}
+
+	if reporter, ok := idx.(index.MemoryReporter); ok {
+		m := reporter.MemoryUsage()
+		fmt.Fprintf(w, "memory:     %s (vectors %s, centroids %s, graph %s, ids %s, metadata %s)\n",
+			metrics.FormatBytes(m.Total()), metrics.FormatBytes(m.Vectors), metrics.FormatBytes(m.Centroids),
+			metrics.FormatBytes(m.Graph), metrics.FormatBytes(m.IDs), metrics.FormatBytes(m.Metadata))
+	}
+
+	switch idx := idx.(type) {
+	case *ivf.IVFIndex:
+		printListHistogram(w, idx.ListSizes())
+	case *hnsw.HNSWIndex:
+		printLayers(w, idx.Layers())
+	}
+	return nil
+}
+
+// printListHistogram summarizes IVF list sizes; skewed lists mean some
+// probes scan far more vectors than others
+func printListHistogram(w io.Writer, sizes []int) {
+	if len(sizes) == 0 {
+		fmt.Fprintln(w, "lists:      untrained")
+		return
+	}
+
+	lo, hi, total := sizes[0], sizes[0], 0
+	for _, s := range sizes {
+		lo, hi = min(lo, s), max(hi, s)
+		total += s
+	}
+	mean := float64(total) / float64(len(sizes))
+	var variance float64
+	for _, s := range sizes {
+		variance += (float64(s) - mean) * (float64(s) - mean)
+	}
+	stddev := math.Sqrt(variance / float64(len(sizes)))
+	fmt.Fprintf(w, "lists:      %d (min %d, max %d, mean %.1f, stddev %.1f)\n", len(sizes), lo, hi, mean, stddev)
+
+	width := max(1, (hi-lo+histogramBins)/histogramBins)
+	counts := make([]int, (hi-lo)/width+1)
+	peak := 0
+	for _, s := range sizes {
+		b := (s - lo) / width
+		counts[b]++
+		peak = max(peak, counts[b])
+	}
+	for b, count := range counts {
+		bar := strings.Repeat("#", (count*40+peak-1)/peak)
+		fmt.Fprintf(w, "  %6d-%-6d %5d %s\n", lo+b*width, lo+(b+1)*width-1, count, bar)
+	}
+}
+
+// printLayers shows how many nodes each HNSW layer holds and their degree
+func printLayers(w io.Writer, layers []hnsw.LayerStats) {
+	fmt.Fprintf(w, "layers:     %d\n", len(layers))
+	fmt.Fprintf(w, "  %5s %10s %10s %10s\n", "layer", "nodes", "edges", "avg_degree")
+	for i := len(layers) - 1; i >= 0; i-- {
+		l := layers[i]
+		degree := 0.0
+		if l.Nodes > 0 {
+			degree = float64(l.Edges) / float64(l.Nodes)
+		}
+		fmt.Fprintf(w, "  %5d %10d %10d %10.2f\n", l.Layer, l.Nodes, l.Edges, degree)
+	}
+}
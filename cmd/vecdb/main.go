@@ -0,0 +1,125 @@
+// Command vecdb builds, queries and inspects saved indexes from the shell,
+// replacing throwaway main.go files.
+//
+// Subcommands:
+//
+//	build    build a Flat, IVF or HNSW index from an .fvecs/.bvecs/.npy/.csv file
+//	query    k-NN search for vectors read from a file or stdin
+//	inspect  print an index file's dimension, size, metric, memory and structure
+//	bench    measure recall and latency of a saved index
+//	convert  convert vectors between .fvecs, .bvecs, .npy and .csv
+//
+// Usage:
+//
+//	go run ./cmd/vecdb build -in sift_base.fvecs -index ivf -out sift.idx
+//	echo "0.1 0.2 ..." | go run ./cmd/vecdb query -index sift.idx -k 5
+//	go run ./cmd/vecdb inspect sift.idx
+//	go run ./cmd/vecdb bench -index sift.idx -queries sift_query.fvecs -gt sift_groundtruth.ivecs
+//	go run ./cmd/vecdb convert -in base.npy -out base.fvecs
+//	go run ./cmd/vecdb <command> -h
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+// env is the process's standard streams, replaced in tests
+type env struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// command is one subcommand
+type command struct {
+	name    string
+	summary string
+	run     func(args []string, e env) error
+}
+
+var commands = []command{
+	{"build", "build an index from a vector file", runBuild},
+	{"query", "search an index for vectors from a file or stdin", runQuery},
+	{"inspect", "describe an index file", runInspect},
+	{"bench", "measure recall and latency of an index", runBench},
+	{"convert", "convert a vector file to another format", runConvert},
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], env{os.Stdin, os.Stdout, os.Stderr}))
+}
+
+// run dispatches to a subcommand and returns the exit code
+func run(args []string, e env) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "-help" || args[0] == "help" {
+		usage(e.stderr)
+		return 2
+	}
+
+	for _, cmd := range commands {
+		if cmd.name != args[0] {
+			continue
+		}
+		err := cmd.run(args[1:], e)
+		switch {
+		case errors.Is(err, flag.ErrHelp):
+			return 0
+		case errors.Is(err, errUsage):
+			return 2
+		case err != nil:
+			fmt.Fprintf(e.stderr, "vecdb %s: %v\n", cmd.name, err)
+			return 1
+		}
+		return 0
+	}
+
+	fmt.Fprintf(e.stderr, "vecdb: unknown command %q\n", args[0])
+	usage(e.stderr)
+	return 2
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: vecdb <command> [flags]")
+	fmt.Fprintln(w)
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-8s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, `Run "vecdb <command> -h" for the command's flags.`)
+}
+
+// errUsage reports a flag error already printed by the flag set
+var errUsage = errors.New("usage error")
+
+// parse parses args with fs, which must use ContinueOnError
+// Flag errors are printed by fs itself and come back as errUsage.
+func parse(fs *flag.FlagSet, args []string, e env) error {
+	fs.SetOutput(e.stderr)
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return errUsage
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(e.stderr, "unexpected arguments: %v\n", fs.Args())
+		return errUsage
+	}
+	return nil
+}
+
+// required fails with a usage error if any of the named flags is empty
+func required(fs *flag.FlagSet, e env, names ...string) error {
+	for _, name := range names {
+		if fs.Lookup(name).Value.String() == "" {
+			fmt.Fprintf(e.stderr, "-%s is required\n", name)
+			fs.Usage()
+			return errUsage
+		}
+	}
+	return nil
+}
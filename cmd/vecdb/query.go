@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tmdgusya/database-class/pkg/index"
+	"github.com/tmdgusya/database-class/pkg/testdata"
+	"github.com/tmdgusya/database-class/pkg/vector"
+)
+
+func runQuery(args []string, e env) error {
+	var indexPath, queries, format string
+	var k, limit int
+	var search index.SearchOptions
+	fs := flag.NewFlagSet("query", flag.ContinueOnError)
+	fs.StringVar(&indexPath, "index", "", "index file written by build")
+	fs.StringVar(&queries, "q", "-", `query vectors (.fvecs, .bvecs, .npy or .csv); "-" reads text lines from stdin`)
+	fs.IntVar(&k, "k", 10, "neighbours per query")
+	fs.IntVar(&limit, "limit", 0, "answer at most this many queries (0 = all)")
+	fs.IntVar(&search.NProbe, "nprobe", 0, "IVF lists to probe (0 = index default)")
+	fs.IntVar(&search.EfSearch, "ef", 0, "HNSW search beam (0 = index default)")
+	fs.StringVar(&format, "format", "text", "output format: text (tab-separated) or json")
+	if err := parse(fs, args, e); err != nil {
+		return err
+	}
+	if err := required(fs, e, "index"); err != nil {
+		return err
+	}
+	if k <= 0 {
+		return fmt.Errorf("-k must be positive, got %d", k)
+	}
+	if format != "text" && format != "json" {
+		return fmt.Errorf("unknown format %q (want text or json)", format)
+	}
+
+	_, idx, err := loadIndex(indexPath)
+	if err != nil {
+		return err
+	}
+
+	// Queries are answered as they are read, so stdin can be a pipe
+	read, closeQueries, err := openQueries(queries, e.stdin)
+	if err != nil {
+		return err
+	}
+	defer closeQueries()
+
+	enc := json.NewEncoder(e.stdout)
+	for n := 0; limit <= 0 || n < limit; n++ {
+		q, err := read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", queries, err)
+		}
+
+		results, err := idx.SearchWithOptions(q, k, search)
+		if err != nil {
+			return fmt.Errorf("query %d: %w", n, err)
+		}
+
+		if format == "json" {
+			type hit struct {
+				ID       int     `json:"id"`
+				Distance float64 `json:"distance"`
+			}
+			hits := make([]hit, len(results))
+			for i, r := range results {
+				hits[i] = hit{r.Index, r.Distance}
+			}
+			if err := enc.Encode(map[string]any{"query": n, "results": hits}); err != nil {
+				return err
+			}
+			continue
+		}
+		for rank, r := range results {
+			if _, err := fmt.Fprintf(e.stdout, "%d\t%d\t%d\t%g\n", n, rank+1, r.Index, r.Distance); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// openQueries returns a reader for one query vector at a time
+// "-" is stdin as text lines; files are read whole, in their own format.
+func openQueries(path string, stdin io.Reader) (func() (vector.Vector, error), func(), error) {
+	if path == "-" {
+		return testdata.NewCSVReader(stdin).Read, func() {}, nil
+	}
+	if strings.ToLower(filepath.Ext(path)) == ".csv" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		return testdata.NewCSVReader(f).Read, func() { f.Close() }, nil
+	}
+
+	vectors, err := testdata.LoadVectors(path, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	next := func() (vector.Vector, error) {
+		if len(vectors) == 0 {
+			return nil, io.EOF
+		}
+		v := vectors[0]
+		vectors = vectors[1:]
+		return v, nil
+	}
+	return next, func() {}, nil
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tmdgusya/database-class/pkg/distance"
+	"github.com/tmdgusya/database-class/pkg/testdata"
+)
+
+// vecdb runs the command with stdin and returns its stdout
+func vecdb(t *testing.T, stdin string, args ...string) string {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	if code := run(args, env{strings.NewReader(stdin), &stdout, &stderr}); code != 0 {
+		t.Fatalf("vecdb %v: exit %d\n%s", args, code, stderr.String())
+	}
+	return stdout.String()
+}
+
+func TestBuildQueryInspect(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "base.fvecs")
+	vectors := testdata.GenerateClusteredVectors(500, 8, 5, 1)
+	if err := testdata.SaveFvecs(base, vectors); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, kind := range []string{"flat", "ivf", "hnsw"} {
+		t.Run(kind, func(t *testing.T) {
+			out := filepath.Join(dir, kind+".idx")
+			vecdb(t, "", "build", "-in", base, "-out", out, "-index", kind, "-nlist", "8", "-nprobe", "8")
+
+			// Every indexed vector should be its own nearest neighbour
+			var stdin strings.Builder
+			for _, i := range []int{0, 123, 499} {
+				for d, x := range vectors[i] {
+					if d > 0 {
+						stdin.WriteByte(' ')
+					}
+					fmt.Fprintf(&stdin, "%v", x)
+				}
+				stdin.WriteByte('\n')
+			}
+			lines := strings.Split(strings.TrimSpace(vecdb(t, stdin.String(), "query", "-index", out, "-k", "3")), "\n")
+			if len(lines) != 9 {
+				t.Fatalf("got %d result lines, want 9:\n%s", len(lines), strings.Join(lines, "\n"))
+			}
+			for q, want := range []int{0, 123, 499} {
+				if got := strings.Fields(lines[q*3]); got[0] != fmt.Sprint(q) || got[1] != "1" || got[2] != fmt.Sprint(want) {
+					t.Errorf("query %d: top result %v, want id %d", q, got, want)
+				}
+			}
+
+			info := vecdb(t, "", "inspect", out)
+			for _, want := range []string{"index:      " + kind, "dimension:  8", "size:       500 vectors"} {
+				if !strings.Contains(info, want) {
+					t.Errorf("inspect output lacks %q:\n%s", want, info)
+				}
+			}
+			switch kind {
+			case "ivf":
+				if !strings.Contains(info, "lists:      8") {
+					t.Errorf("inspect output lacks the list histogram:\n%s", info)
+				}
+			case "hnsw":
+				if !strings.Contains(info, "layers:") {
+					t.Errorf("inspect output lacks the layer table:\n%s", info)
+				}
+			}
+		})
+	}
+}
+
+func TestQueryJSON(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "base.csv")
+	if err := testdata.SaveCSV(base, testdata.GenerateRandomVectors(50, 4, 2)); err != nil {
+		t.Fatal(err)
+	}
+	idx := filepath.Join(dir, "flat.idx")
+	vecdb(t, "", "build", "-in", base, "-out", idx)
+
+	out := vecdb(t, "0 0 0 0\n1,1,1,1\n", "query", "-index", idx, "-k", "2", "-format", "json")
+	dec := json.NewDecoder(strings.NewReader(out))
+	for n := 0; n < 2; n++ {
+		var res struct {
+			Query   int `json:"query"`
+			Results []struct {
+				ID       int     `json:"id"`
+				Distance float64 `json:"distance"`
+			} `json:"results"`
+		}
+		if err := dec.Decode(&res); err != nil {
+			t.Fatalf("decode result %d: %v\n%s", n, err, out)
+		}
+		if res.Query != n || len(res.Results) != 2 {
+			t.Errorf("result %d: %+v", n, res)
+		}
+	}
+}
+
+func TestBench(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "base.fvecs")
+	queries := filepath.Join(dir, "queries.fvecs")
+	gt := filepath.Join(dir, "gt.ivecs")
+	vectors := testdata.GenerateRandomVectors(300, 8, 3)
+	qs := testdata.GenerateRandomVectors(20, 8, 4)
+	truth, err := testdata.ComputeNeighbors(qs, vectors, 5, distance.L2Distance, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, err := range []error{
+		testdata.SaveFvecs(base, vectors),
+		testdata.SaveFvecs(queries, qs),
+		testdata.SaveIvecs(gt, truth.Indices()),
+	} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	idx := filepath.Join(dir, "flat.idx")
+	vecdb(t, "", "build", "-in", base, "-out", idx)
+
+	// Flat search is exact, whether ground truth is loaded or computed
+	for _, args := range [][]string{{"-gt", gt}, {"-base", base}} {
+		out := vecdb(t, "", append([]string{"bench", "-index", idx, "-queries", queries, "-k", "5"}, args...)...)
+		if !strings.Contains(out, "recall@5: 1.0000") {
+			t.Errorf("bench %v: want perfect recall:\n%s", args, out)
+		}
+		if !strings.Contains(out, "latency:") {
+			t.Errorf("bench %v: no latency line:\n%s", args, out)
+		}
+	}
+}
+
+func TestConvert(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "v.fvecs")
+	if err := testdata.SaveFvecs(src, testdata.GenerateRandomVectors(10, 3, 5)); err != nil {
+		t.Fatal(err)
+	}
+	vectors, err := testdata.LoadVectors(src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// fvecs -> npy -> csv -> fvecs must preserve every float32
+	prev := src
+	for _, ext := range []string{".npy", ".csv", ".fvecs"} {
+		next := filepath.Join(dir, "out"+ext)
+		vecdb(t, "", "convert", "-in", prev, "-out", next)
+		prev = next
+	}
+	got, err := testdata.LoadVectors(prev, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(vectors) {
+		t.Fatalf("got %d vectors, want %d", len(got), len(vectors))
+	}
+	for i := range vectors {
+		for d := range vectors[i] {
+			if got[i][d] != vectors[i][d] {
+				t.Fatalf("vector %d[%d] = %v, want %v", i, d, got[i][d], vectors[i][d])
+			}
+		}
+	}
+}
+
+func TestUsageErrors(t *testing.T) {
+	for _, args := range [][]string{
+		nil,
+		{"frobnicate"},
+		{"build", "-in", "x.fvecs"},
+		{"query", "-bogus"},
+		{"inspect"},
+	} {
+		var stderr bytes.Buffer
+		if code := run(args, env{strings.NewReader(""), &bytes.Buffer{}, &stderr}); code != 2 {
+			t.Errorf("vecdb %v: exit %d, want 2", args, code)
+		}
+	}
+
+	var stderr bytes.Buffer
+	if code := run([]string{"inspect", filepath.Join(t.TempDir(), "missing.idx")}, env{nil, &bytes.Buffer{}, &stderr}); code != 1 {
+		t.Errorf("inspect of a missing file: exit %d, want 1", code)
+	}
+}
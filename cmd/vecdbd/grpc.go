@@ -0,0 +1,269 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/tmdgusya/database-class/pkg/collection"
+	"github.com/tmdgusya/database-class/pkg/vecdbpb"
+	"github.com/tmdgusya/database-class/pkg/vector"
+)
+
+// grpcServer serves the same collections as the HTTP handlers over gRPC
+type grpcServer struct {
+	vecdbpb.UnimplementedVectorDBServer
+	s *server
+}
+
+// grpcService returns a gRPC server with the VectorDB service registered
+func (s *server) grpcService(opts ...grpc.ServerOption) *grpc.Server {
+	gs := grpc.NewServer(opts...)
+	vecdbpb.RegisterVectorDBServer(gs, &grpcServer{s: s})
+	return gs
+}
+
+// grpcError maps an error to a status the way statusOf maps it to HTTP
+func grpcError(err error) error {
+	switch {
+	case errors.Is(err, collection.ErrInvalid):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, collection.ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, collection.ErrExists):
+		return status.Error(codes.AlreadyExists, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+func (g *grpcServer) CreateCollection(_ context.Context, req *vecdbpb.CreateCollectionRequest) (*vecdbpb.CollectionInfo, error) {
+	c, err := g.s.collections.Create(configFromProto(req.GetConfig()))
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return infoToProto(c.Info()), nil
+}
+
+func (g *grpcServer) GetCollection(_ context.Context, req *vecdbpb.GetCollectionRequest) (*vecdbpb.CollectionInfo, error) {
+	info, err := g.s.collections.Describe(req.GetName())
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return infoToProto(info), nil
+}
+
+func (g *grpcServer) ListCollections(context.Context, *vecdbpb.ListCollectionsRequest) (*vecdbpb.ListCollectionsResponse, error) {
+	return &vecdbpb.ListCollectionsResponse{Names: g.s.collections.List()}, nil
+}
+
+func (g *grpcServer) DropCollection(_ context.Context, req *vecdbpb.DropCollectionRequest) (*vecdbpb.DropCollectionResponse, error) {
+	if err := g.s.collections.Drop(req.GetName()); err != nil {
+		return nil, grpcError(err)
+	}
+	return &vecdbpb.DropCollectionResponse{}, nil
+}
+
+// Upsert applies each batch as it arrives, so memory stays bounded by one
+// batch however long the stream runs
+func (g *grpcServer) Upsert(stream vecdbpb.VectorDB_UpsertServer) error {
+	var upserted int64
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return stream.SendAndClose(&vecdbpb.UpsertResponse{Upserted: upserted})
+		}
+		if err != nil {
+			return err
+		}
+
+		c, err := g.s.collections.Get(req.GetCollection())
+		if err != nil {
+			return grpcError(err)
+		}
+		points := make([]collection.Point, len(req.GetPoints()))
+		for i, p := range req.GetPoints() {
+			points[i] = collection.Point{ID: p.GetId(), Vector: fromFloat32(p.GetVector()), Payload: p.GetPayload().AsMap()}
+		}
+		if err := c.Upsert(points); err != nil {
+			return grpcError(err)
+		}
+		upserted += int64(len(points))
+	}
+}
+
+// Delete removes the given IDs, ignoring those that are absent
+func (g *grpcServer) Delete(_ context.Context, req *vecdbpb.DeleteRequest) (*vecdbpb.DeleteResponse, error) {
+	c, err := g.s.collections.Get(req.GetCollection())
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	var deleted int64
+	for _, id := range req.GetIds() {
+		err := c.Delete(id)
+		switch {
+		case err == nil:
+			deleted++
+		case !errors.Is(err, collection.ErrNotFound):
+			return nil, grpcError(err)
+		}
+	}
+	return &vecdbpb.DeleteResponse{Deleted: deleted}, nil
+}
+
+func (g *grpcServer) Search(_ context.Context, req *vecdbpb.SearchRequest) (*vecdbpb.SearchResponse, error) {
+	resp, err := g.search(req)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return resp, nil
+}
+
+// SearchStream answers requests one at a time, in order; the first failing
+// request ends the stream with its error
+func (g *grpcServer) SearchStream(stream vecdbpb.VectorDB_SearchStreamServer) error {
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		resp, err := g.search(req)
+		if err != nil {
+			return grpcError(err)
+		}
+		if err := stream.Send(resp); err != nil {
+			return err
+		}
+	}
+}
+
+func (g *grpcServer) search(req *vecdbpb.SearchRequest) (*vecdbpb.SearchResponse, error) {
+	c, err := g.s.collections.Get(req.GetCollection())
+	if err != nil {
+		return nil, err
+	}
+	filter := make(map[string]any, len(req.GetFilter()))
+	for key, v := range req.GetFilter() {
+		filter[key] = v.AsInterface()
+	}
+
+	hits, err := c.Search(collection.SearchRequest{
+		Vector:         fromFloat32(req.GetVector()),
+		K:              int(req.GetK()),
+		Filter:         filter,
+		NProbe:         int(req.GetNprobe()),
+		EfSearch:       int(req.GetEfSearch()),
+		IncludeVectors: req.GetIncludeVectors(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &vecdbpb.SearchResponse{Hits: make([]*vecdbpb.Hit, len(hits))}
+	for i, h := range hits {
+		payload, err := payloadToProto(h.Payload)
+		if err != nil {
+			return nil, err
+		}
+		resp.Hits[i] = &vecdbpb.Hit{
+			Id:       h.ID,
+			Distance: h.Distance,
+			Payload:  payload,
+			Vector:   toFloat32(h.Vector),
+		}
+	}
+	return resp, nil
+}
+
+func configFromProto(cfg *vecdbpb.CollectionConfig) collection.Config {
+	var schema collection.Schema
+	if len(cfg.GetSchema()) > 0 {
+		schema = make(collection.Schema, len(cfg.GetSchema()))
+		for name, f := range cfg.GetSchema() {
+			schema[name] = collection.Field{Type: collection.FieldType(f.GetType()), Required: f.GetRequired()}
+		}
+	}
+	return collection.Config{
+		Name:           cfg.GetName(),
+		Dimension:      int(cfg.GetDimension()),
+		Metric:         cfg.GetMetric(),
+		Index:          cfg.GetIndex(),
+		NList:          int(cfg.GetNlist()),
+		NProbe:         int(cfg.GetNprobe()),
+		TrainSize:      int(cfg.GetTrainSize()),
+		M:              int(cfg.GetM()),
+		EfConstruction: int(cfg.GetEfConstruction()),
+		EfSearch:       int(cfg.GetEfSearch()),
+		Schema:         schema,
+	}
+}
+
+func infoToProto(s collection.Info) *vecdbpb.CollectionInfo {
+	info := &vecdbpb.CollectionInfo{
+		Config: &vecdbpb.CollectionConfig{
+			Name:           s.Config.Name,
+			Dimension:      int32(s.Config.Dimension),
+			Metric:         s.Config.Metric,
+			Index:          s.Config.Index,
+			Nlist:          int32(s.Config.NList),
+			Nprobe:         int32(s.Config.NProbe),
+			TrainSize:      int32(s.Config.TrainSize),
+			M:              int32(s.Config.M),
+			EfConstruction: int32(s.Config.EfConstruction),
+			EfSearch:       int32(s.Config.EfSearch),
+		},
+		Points:  int64(s.Points),
+		Deleted: int64(s.Deleted),
+		Trained: s.Trained,
+	}
+	if len(s.Config.Schema) > 0 {
+		info.Config.Schema = make(map[string]*vecdbpb.FieldSchema, len(s.Config.Schema))
+		for name, f := range s.Config.Schema {
+			info.Config.Schema[name] = &vecdbpb.FieldSchema{Type: string(f.Type), Required: f.Required}
+		}
+	}
+	if s.Memory != nil {
+		info.MemoryBytes = s.Memory.Total()
+	}
+	return info
+}
+
+// payloadToProto converts a stored payload back to a Struct
+// Payloads came from JSON or from a Struct, so conversion only fails on a
+// bug; it is reported as an internal error.
+func payloadToProto(payload map[string]any) (*structpb.Struct, error) {
+	if payload == nil {
+		return nil, nil
+	}
+	return structpb.NewStruct(payload)
+}
+
+func fromFloat32(values []float32) vector.Vector {
+	if values == nil {
+		return nil
+	}
+	v := make(vector.Vector, len(values))
+	for i, x := range values {
+		v[i] = float64(x)
+	}
+	return v
+}
+
+func toFloat32(v vector.Vector) []float32 {
+	if v == nil {
+		return nil
+	}
+	values := make([]float32, len(v))
+	for i, x := range v {
+		values[i] = float32(x)
+	}
+	return values
+}
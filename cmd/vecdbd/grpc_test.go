@@ -0,0 +1,205 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/tmdgusya/database-class/pkg/testdata"
+	"github.com/tmdgusya/database-class/pkg/vecdbpb"
+)
+
+// newTestClient serves s over an in-memory listener
+func newTestClient(t *testing.T, s *server) vecdbpb.VectorDBClient {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	gs := s.grpcService()
+	go gs.Serve(lis)
+	t.Cleanup(gs.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return vecdbpb.NewVectorDBClient(conn)
+}
+
+func TestGRPCBulkUpsertAndSearch(t *testing.T) {
+	s := newServer(1 << 20)
+	client := newTestClient(t, s)
+	ctx := context.Background()
+
+	info, err := client.CreateCollection(ctx, &vecdbpb.CreateCollectionRequest{
+		Config: &vecdbpb.CollectionConfig{Name: "emb", Dimension: 8, Index: "hnsw", M: 8},
+	})
+	if err != nil {
+		t.Fatalf("CreateCollection() failed: %v", err)
+	}
+	if info.Config.Metric != "l2" || info.Config.EfConstruction != 200 {
+		t.Errorf("defaults not filled in: %+v", info.Config)
+	}
+
+	// Bulk load in batches over one stream
+	vectors := testdata.GenerateRandomVectors(300, 8, 42)
+	stream, err := client.Upsert(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for start := 0; start < len(vectors); start += 100 {
+		req := &vecdbpb.UpsertRequest{Collection: "emb"}
+		for i := start; i < start+100; i++ {
+			payload, _ := structpb.NewStruct(map[string]any{"bucket": float64(i % 3)})
+			req.Points = append(req.Points, &vecdbpb.Point{
+				Id: fmt.Sprint(i), Vector: toFloat32(vectors[i]), Payload: payload,
+			})
+		}
+		if err := stream.Send(req); err != nil {
+			t.Fatalf("Send() failed: %v", err)
+		}
+	}
+	resp, err := stream.CloseAndRecv()
+	if err != nil {
+		t.Fatalf("CloseAndRecv() failed: %v", err)
+	}
+	if resp.Upserted != 300 {
+		t.Errorf("Upserted = %d, want 300", resp.Upserted)
+	}
+
+	// Unary search with a filter
+	res, err := client.Search(ctx, &vecdbpb.SearchRequest{
+		Collection: "emb",
+		Vector:     toFloat32(vectors[7]),
+		K:          3,
+		Filter:     map[string]*structpb.Value{"bucket": structpb.NewNumberValue(1)},
+	})
+	if err != nil {
+		t.Fatalf("Search() failed: %v", err)
+	}
+	if len(res.Hits) != 3 || res.Hits[0].Id != "7" {
+		t.Fatalf("Search() = %v, want point 7 first", res.Hits)
+	}
+	for _, h := range res.Hits {
+		if h.Payload.Fields["bucket"].GetNumberValue() != 1 {
+			t.Errorf("hit %s does not match the filter", h.Id)
+		}
+	}
+
+	// Bidirectional streaming: one response per request, in order
+	search, err := client.SearchStream(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	queries := []int{0, 150, 299}
+	go func() {
+		for _, q := range queries {
+			search.Send(&vecdbpb.SearchRequest{Collection: "emb", Vector: toFloat32(vectors[q]), K: 1})
+		}
+		search.CloseSend()
+	}()
+	for _, q := range queries {
+		res, err := search.Recv()
+		if err != nil {
+			t.Fatalf("Recv() failed: %v", err)
+		}
+		if len(res.Hits) != 1 || res.Hits[0].Id != fmt.Sprint(q) {
+			t.Errorf("query %d: hits %v", q, res.Hits)
+		}
+	}
+	if _, err := search.Recv(); err != io.EOF {
+		t.Errorf("stream not closed after the last response: %v", err)
+	}
+
+	del, err := client.Delete(ctx, &vecdbpb.DeleteRequest{Collection: "emb", Ids: []string{"7", "7", "missing"}})
+	if err != nil || del.Deleted != 1 {
+		t.Fatalf("Delete() = %v, %v", del, err)
+	}
+	info, _ = client.GetCollection(ctx, &vecdbpb.GetCollectionRequest{Name: "emb"})
+	if info.Points != 299 || info.Deleted != 1 || info.MemoryBytes == 0 {
+		t.Errorf("GetCollection() = %+v", info)
+	}
+
+	// The HTTP API sees the same collection
+	names := s.collections.List()
+	if len(names) != 1 || names[0] != "emb" {
+		t.Errorf("names() = %v", names)
+	}
+}
+
+func TestGRPCErrorCodes(t *testing.T) {
+	client := newTestClient(t, newServer(1<<20))
+	ctx := context.Background()
+
+	code := func(err error) codes.Code { return status.Code(err) }
+
+	_, err := client.CreateCollection(ctx, &vecdbpb.CreateCollectionRequest{
+		Config: &vecdbpb.CollectionConfig{Name: "c", Dimension: 2, Metric: "hamming"},
+	})
+	if code(err) != codes.InvalidArgument {
+		t.Errorf("bad metric: %v", err)
+	}
+
+	cfg := &vecdbpb.CollectionConfig{Name: "c", Dimension: 2}
+	client.CreateCollection(ctx, &vecdbpb.CreateCollectionRequest{Config: cfg})
+	if _, err := client.CreateCollection(ctx, &vecdbpb.CreateCollectionRequest{Config: cfg}); code(err) != codes.AlreadyExists {
+		t.Errorf("duplicate: %v", err)
+	}
+
+	if _, err := client.Search(ctx, &vecdbpb.SearchRequest{Collection: "c", Vector: []float32{1}, K: 1}); code(err) != codes.InvalidArgument {
+		t.Errorf("dimension mismatch: %v", err)
+	}
+	if _, err := client.Search(ctx, &vecdbpb.SearchRequest{Collection: "c", Vector: []float32{1, 2}, K: math.MaxInt32}); code(err) != codes.InvalidArgument {
+		t.Errorf("huge k: %v", err)
+	}
+	if _, err := client.Search(ctx, &vecdbpb.SearchRequest{Collection: "nope", Vector: []float32{1, 2}, K: 1}); code(err) != codes.NotFound {
+		t.Errorf("unknown collection: %v", err)
+	}
+
+	stream, _ := client.Upsert(ctx)
+	stream.Send(&vecdbpb.UpsertRequest{Collection: "c", Points: []*vecdbpb.Point{{Id: "a", Vector: []float32{1, 2, 3}}}})
+	if _, err := stream.CloseAndRecv(); code(err) != codes.InvalidArgument {
+		t.Errorf("upsert dimension mismatch: %v", err)
+	}
+
+	if _, err := client.DropCollection(ctx, &vecdbpb.DropCollectionRequest{Name: "nope"}); code(err) != codes.NotFound {
+		t.Errorf("drop unknown: %v", err)
+	}
+}
+
+func TestGRPCSchema(t *testing.T) {
+	client := newTestClient(t, newServer(1<<20))
+	ctx := context.Background()
+
+	info, err := client.CreateCollection(ctx, &vecdbpb.CreateCollectionRequest{
+		Config: &vecdbpb.CollectionConfig{Name: "c", Dimension: 1, Schema: map[string]*vecdbpb.FieldSchema{
+			"lang": {Type: "string", Required: true},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f := info.GetConfig().GetSchema()["lang"]; f.GetType() != "string" || !f.GetRequired() {
+		t.Errorf("schema not echoed back: %v", info.GetConfig().GetSchema())
+	}
+
+	stream, _ := client.Upsert(ctx)
+	stream.Send(&vecdbpb.UpsertRequest{Collection: "c", Points: []*vecdbpb.Point{{Id: "a", Vector: []float32{1}}}})
+	if _, err := stream.CloseAndRecv(); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("missing required field: %v", err)
+	}
+}
@@ -0,0 +1,112 @@
+// Command vecdbd serves the course's indexes as a vector database over
+// HTTP/JSON and gRPC, so services written in any language can use them.
+//
+// Each named collection declares its dimension, metric and index type (flat,
+// ivf or hnsw with their build parameters). Points carry a string ID and an
+// optional JSON payload, checked against the collection's schema if it has
+// one; searches can require payload fields to match. The collections
+// themselves are pkg/collection; this command only speaks the protocols.
+// Everything lives in memory and is lost when the process exits.
+//
+// The gRPC API (pkg/vecdbpb) serves the same collections and adds streaming
+// bulk upserts and streaming search; vectors travel as packed float32.
+//
+// Usage:
+//
+//	go run ./cmd/vecdbd -addr :8080 -grpc-addr :9090
+//
+//	curl -X POST localhost:8080/collections \
+//	    -d '{"name":"docs","dimension":3,"metric":"cosine","index":"hnsw"}'
+//	curl -X POST localhost:8080/collections/docs/points \
+//	    -d '{"points":[{"id":"a","vector":[1,0,0],"payload":{"lang":"ko"}}]}'
+//	curl -X POST localhost:8080/collections/docs/search \
+//	    -d '{"vector":[1,0.1,0],"k":5,"filter":{"lang":"ko"}}'
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+func main() {
+	fs := flag.NewFlagSet("vecdbd", flag.ContinueOnError)
+	addr := fs.String("addr", ":8080", "HTTP listen address")
+	grpcAddr := fs.String("grpc-addr", ":9090", "gRPC listen address (disabled if empty)")
+	maxBody := fs.Int64("max-body", 64<<20, "largest accepted request body in bytes")
+	err := fs.Parse(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		os.Exit(2)
+	}
+	if *maxBody <= 0 {
+		fmt.Fprintf(os.Stderr, "vecdbd: -max-body must be positive, got %d\n", *maxBody)
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := serve(ctx, *addr, *grpcAddr, newServer(*maxBody)); err != nil {
+		fmt.Fprintf(os.Stderr, "vecdbd: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// serve runs the HTTP and gRPC servers until ctx is cancelled or either
+// fails, then drains in-flight requests
+func serve(ctx context.Context, addr, grpcAddr string, s *server) error {
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           s.handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errc := make(chan error, 2)
+	go func() {
+		log.Printf("vecdbd listening on %s (HTTP)", addr)
+		errc <- srv.ListenAndServe()
+	}()
+
+	var gs *grpc.Server
+	if grpcAddr != "" {
+		lis, err := net.Listen("tcp", grpcAddr)
+		if err != nil {
+			srv.Close()
+			return err
+		}
+		gs = s.grpcService(grpc.MaxRecvMsgSize(int(s.maxBody)))
+		go func() {
+			log.Printf("vecdbd listening on %s (gRPC)", grpcAddr)
+			errc <- gs.Serve(lis)
+		}()
+	}
+
+	var err error
+	select {
+	case err = <-errc:
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if gs != nil {
+		gs.GracefulStop()
+	}
+	if shutdownErr := srv.Shutdown(shutdownCtx); err == nil {
+		err = shutdownErr
+	}
+	return err
+}
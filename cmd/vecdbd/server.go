@@ -0,0 +1,206 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/tmdgusya/database-class/pkg/collection"
+)
+
+// server routes HTTP requests to the collections
+// grpc.go serves the same collections over gRPC.
+type server struct {
+	maxBody     int64 // Request body (and gRPC message) limit in bytes
+	collections *collection.Manager
+}
+
+func newServer(maxBody int64) *server {
+	return &server{maxBody: maxBody, collections: collection.NewManager()}
+}
+
+// handler returns the REST API
+//
+//	GET    /collections                     list collection names
+//	POST   /collections                     create (body: collection.Config)
+//	GET    /collections/{name}              describe
+//	DELETE /collections/{name}              drop
+//	POST   /collections/{name}/points       upsert (body: {"points": [...]})
+//	GET    /collections/{name}/points/{id}  fetch a point's payload
+//	DELETE /collections/{name}/points/{id}  delete a point
+//	POST   /collections/{name}/search       k-NN search (body: collection.SearchRequest)
+func (s *server) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /collections", s.handleList)
+	mux.HandleFunc("POST /collections", s.handleCreate)
+	mux.HandleFunc("GET /collections/{name}", s.handleDescribe)
+	mux.HandleFunc("DELETE /collections/{name}", s.handleDrop)
+	mux.HandleFunc("POST /collections/{name}/points", s.handleUpsert)
+	mux.HandleFunc("GET /collections/{name}/points/{id}", s.handleGet)
+	mux.HandleFunc("DELETE /collections/{name}/points/{id}", s.handleDelete)
+	mux.HandleFunc("POST /collections/{name}/search", s.handleSearch)
+	return mux
+}
+
+// collection looks up the collection named in the request path
+func (s *server) collection(r *http.Request) (*collection.Collection, error) {
+	return s.collections.Get(r.PathValue("name"))
+}
+
+func (s *server) handleList(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{"collections": s.collections.List()})
+}
+
+func (s *server) handleCreate(w http.ResponseWriter, r *http.Request) {
+	var cfg collection.Config
+	if err := s.decode(w, r, &cfg); err != nil {
+		writeError(w, err)
+		return
+	}
+	c, err := s.collections.Create(cfg)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, c.Info())
+}
+
+func (s *server) handleDescribe(w http.ResponseWriter, r *http.Request) {
+	info, err := s.collections.Describe(r.PathValue("name"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, info)
+}
+
+func (s *server) handleDrop(w http.ResponseWriter, r *http.Request) {
+	if err := s.collections.Drop(r.PathValue("name")); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *server) handleUpsert(w http.ResponseWriter, r *http.Request) {
+	c, err := s.collection(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	var body struct {
+		Points []collection.Point `json:"points"`
+	}
+	if err := s.decode(w, r, &body); err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := c.Upsert(body.Points); err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]int{"upserted": len(body.Points)})
+}
+
+func (s *server) handleGet(w http.ResponseWriter, r *http.Request) {
+	c, err := s.collection(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	p, err := c.Get(r.PathValue("id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, p)
+}
+
+func (s *server) handleDelete(w http.ResponseWriter, r *http.Request) {
+	c, err := s.collection(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := c.Delete(r.PathValue("id")); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	c, err := s.collection(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	var req collection.SearchRequest
+	if err := s.decode(w, r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	hits, err := c.Search(req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"results": hits})
+}
+
+// decode reads a JSON body into v, rejecting unknown fields, trailing data
+// and bodies over the size limit
+func (s *server) decode(w http.ResponseWriter, r *http.Request, v any) error {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, s.maxBody))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return &statusError{http.StatusRequestEntityTooLarge,
+				fmt.Errorf("request body exceeds %d bytes", tooLarge.Limit)}
+		}
+		return &statusError{http.StatusBadRequest, fmt.Errorf("invalid JSON body: %w", err)}
+	}
+	if dec.More() {
+		return &statusError{http.StatusBadRequest, errors.New("invalid JSON body: unexpected data after the object")}
+	}
+	return nil
+}
+
+// statusError carries an explicit HTTP status
+type statusError struct {
+	status int
+	err    error
+}
+
+func (e *statusError) Error() string { return e.err.Error() }
+func (e *statusError) Unwrap() error { return e.err }
+
+// statusOf maps an error to the HTTP status reported to the client
+// Anything not known to be the client's fault is a 500.
+func statusOf(err error) int {
+	var status *statusError
+	switch {
+	case errors.As(err, &status):
+		return status.status
+	case errors.Is(err, collection.ErrInvalid):
+		return http.StatusBadRequest
+	case errors.Is(err, collection.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, collection.ErrExists):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	writeJSON(w, statusOf(err), map[string]string{"error": err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
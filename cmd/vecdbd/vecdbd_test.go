@@ -0,0 +1,280 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/tmdgusya/database-class/pkg/collection"
+	"github.com/tmdgusya/database-class/pkg/testdata"
+)
+
+// call sends a JSON request and decodes the response into out, if given
+func call(t *testing.T, srv *httptest.Server, method, path string, body any, out any) int {
+	t.Helper()
+	var reader *bytes.Reader
+	switch b := body.(type) {
+	case nil:
+		reader = bytes.NewReader(nil)
+	case string:
+		reader = bytes.NewReader([]byte(b))
+	default:
+		data, err := json.Marshal(b)
+		if err != nil {
+			t.Fatalf("marshal: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, srv.URL+path, reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	if out != nil && resp.StatusCode < 300 {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatalf("%s %s: decode: %v", method, path, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(newServer(1 << 20).handler())
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+type searchResponse struct {
+	Results []collection.Hit `json:"results"`
+}
+
+func TestCollectionLifecycle(t *testing.T) {
+	srv := newTestServer(t)
+
+	cfg := map[string]any{"name": "docs", "dimension": 2}
+	if code := call(t, srv, "POST", "/collections", cfg, nil); code != http.StatusCreated {
+		t.Fatalf("create: status %d", code)
+	}
+	if code := call(t, srv, "POST", "/collections", cfg, nil); code != http.StatusConflict {
+		t.Errorf("duplicate create: status %d, want 409", code)
+	}
+
+	points := map[string]any{"points": []map[string]any{
+		{"id": "a", "vector": []float64{0, 0}, "payload": map[string]any{"lang": "ko"}},
+		{"id": "b", "vector": []float64{1, 0}, "payload": map[string]any{"lang": "en"}},
+		{"id": "c", "vector": []float64{5, 5}, "payload": map[string]any{"lang": "ko"}},
+	}}
+	if code := call(t, srv, "POST", "/collections/docs/points", points, nil); code != http.StatusOK {
+		t.Fatalf("upsert: status %d", code)
+	}
+
+	var res searchResponse
+	call(t, srv, "POST", "/collections/docs/search", map[string]any{"vector": []float64{0.9, 0}, "k": 2}, &res)
+	if len(res.Results) != 2 || res.Results[0].ID != "b" || res.Results[1].ID != "a" {
+		t.Fatalf("search = %+v, want b then a", res.Results)
+	}
+
+	// Payload filter
+	call(t, srv, "POST", "/collections/docs/search",
+		map[string]any{"vector": []float64{0.9, 0}, "k": 2, "filter": map[string]any{"lang": "ko"}}, &res)
+	if len(res.Results) != 2 || res.Results[0].ID != "a" || res.Results[1].ID != "c" {
+		t.Fatalf("filtered search = %+v, want a then c", res.Results)
+	}
+
+	// Upserting "a" far away replaces it; deleting "b" hides it
+	call(t, srv, "POST", "/collections/docs/points",
+		map[string]any{"points": []map[string]any{{"id": "a", "vector": []float64{9, 9}}}}, nil)
+	if code := call(t, srv, "DELETE", "/collections/docs/points/b", nil, nil); code != http.StatusNoContent {
+		t.Fatalf("delete: status %d", code)
+	}
+	if code := call(t, srv, "DELETE", "/collections/docs/points/b", nil, nil); code != http.StatusNotFound {
+		t.Errorf("second delete: status %d, want 404", code)
+	}
+	res = searchResponse{} // Decode would merge into the old hits' payload maps
+	call(t, srv, "POST", "/collections/docs/search", map[string]any{"vector": []float64{0, 0}, "k": 3}, &res)
+	if len(res.Results) != 2 || res.Results[0].ID != "c" || res.Results[1].ID != "a" {
+		t.Fatalf("search after upsert/delete = %+v, want c then a", res.Results)
+	}
+	if res.Results[1].Payload != nil {
+		t.Errorf("replaced point kept its old payload: %v", res.Results[1].Payload)
+	}
+
+	var p collection.Point
+	if code := call(t, srv, "GET", "/collections/docs/points/c", nil, &p); code != http.StatusOK || p.Payload["lang"] != "ko" {
+		t.Errorf("get = %d %+v", code, p)
+	}
+
+	var stats collection.Info
+	call(t, srv, "GET", "/collections/docs", nil, &stats)
+	if stats.Points != 2 || stats.Deleted != 2 || stats.Config.Metric != "l2" || stats.Memory == nil {
+		t.Errorf("stats = %+v", stats)
+	}
+	var raw struct {
+		Memory map[string]uint64 `json:"memory"`
+	}
+	call(t, srv, "GET", "/collections/docs", nil, &raw)
+	if raw.Memory["vectors"] == 0 {
+		t.Errorf("memory = %v, want snake_case keys", raw.Memory)
+	}
+
+	var list struct{ Collections []string }
+	call(t, srv, "GET", "/collections", nil, &list)
+	if len(list.Collections) != 1 || list.Collections[0] != "docs" {
+		t.Errorf("list = %v", list.Collections)
+	}
+
+	if code := call(t, srv, "DELETE", "/collections/docs", nil, nil); code != http.StatusNoContent {
+		t.Fatalf("drop: status %d", code)
+	}
+	if code := call(t, srv, "GET", "/collections/docs", nil, nil); code != http.StatusNotFound {
+		t.Errorf("stats after drop: status %d, want 404", code)
+	}
+}
+
+// Validation errors from the request map to 4xx, never 500
+func TestValidationErrors(t *testing.T) {
+	srv := newTestServer(t)
+	call(t, srv, "POST", "/collections", map[string]any{"name": "v", "dimension": 3, "index": "ivf", "nlist": 2}, nil)
+	call(t, srv, "POST", "/collections", map[string]any{"name": "s", "dimension": 1,
+		"schema": map[string]any{"lang": map[string]any{"type": "string", "required": true}}}, nil)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   any
+		want   int
+	}{
+		{"bad metric", "POST", "/collections", map[string]any{"name": "x", "dimension": 3, "metric": "hamming"}, 400},
+		{"bad index", "POST", "/collections", map[string]any{"name": "x", "dimension": 3, "index": "lsh"}, 400},
+		{"no dimension", "POST", "/collections", map[string]any{"name": "x"}, 400},
+		{"hnsw efC < M", "POST", "/collections", map[string]any{"name": "x", "dimension": 3, "index": "hnsw", "m": 16, "ef_construction": 4}, 400},
+		{"ivf nprobe > nlist", "POST", "/collections", map[string]any{"name": "x", "dimension": 3, "index": "ivf", "nlist": 2, "nprobe": 3}, 400},
+		{"unknown field", "POST", "/collections", map[string]any{"name": "x", "dimension": 3, "dims": 3}, 400},
+		{"malformed JSON", "POST", "/collections/v/points", `{"points": [`, 400},
+		{"trailing data", "POST", "/collections/v/search", `{"vector":[1,2,3],"k":1} {}`, 400},
+		{"dimension mismatch", "POST", "/collections/v/points", map[string]any{"points": []map[string]any{{"id": "a", "vector": []float64{1, 2}}}}, 400},
+		{"empty vector", "POST", "/collections/v/points", map[string]any{"points": []map[string]any{{"id": "a", "vector": []float64{}}}}, 400},
+		{"missing id", "POST", "/collections/v/points", map[string]any{"points": []map[string]any{{"vector": []float64{1, 2, 3}}}}, 400},
+		{"query dimension", "POST", "/collections/v/search", map[string]any{"vector": []float64{1}, "k": 1}, 400},
+		{"k zero", "POST", "/collections/v/search", map[string]any{"vector": []float64{1, 2, 3}, "k": 0}, 400},
+		{"negative ef", "POST", "/collections/v/search", map[string]any{"vector": []float64{1, 2, 3}, "k": 1, "ef_search": -1}, 400},
+		{"nprobe > nlist", "POST", "/collections/v/search", map[string]any{"vector": []float64{1, 2, 3}, "k": 1, "nprobe": 3}, 400},
+		{"object filter", "POST", "/collections/v/search", map[string]any{"vector": []float64{1, 2, 3}, "k": 1, "filter": map[string]any{"a": map[string]any{}}}, 400},
+		{"bad schema type", "POST", "/collections", map[string]any{"name": "x", "dimension": 3, "schema": map[string]any{"a": map[string]any{"type": "date"}}}, 400},
+		{"missing required field", "POST", "/collections/s/points", map[string]any{"points": []map[string]any{{"id": "a", "vector": []float64{1}}}}, 400},
+		{"undeclared field", "POST", "/collections/s/points", map[string]any{"points": []map[string]any{{"id": "a", "vector": []float64{1}, "payload": map[string]any{"lang": "ko", "year": 1}}}}, 400},
+		{"undeclared filter", "POST", "/collections/s/search", map[string]any{"vector": []float64{1}, "k": 1, "filter": map[string]any{"year": 1}}, 400},
+		{"unknown collection", "POST", "/collections/nope/search", map[string]any{"vector": []float64{1, 2, 3}, "k": 1}, 404},
+		{"unknown point", "GET", "/collections/v/points/nope", nil, 404},
+		{"wrong method", "PUT", "/collections/v", nil, 405},
+	}
+	for _, tt := range tests {
+		if code := call(t, srv, tt.method, tt.path, tt.body, nil); code != tt.want {
+			t.Errorf("%s: status %d, want %d", tt.name, code, tt.want)
+		}
+	}
+
+	// A rejected batch stores nothing
+	var stats collection.Info
+	call(t, srv, "GET", "/collections/v", nil, &stats)
+	if stats.Points != 0 {
+		t.Errorf("%d points stored by rejected requests", stats.Points)
+	}
+}
+
+func TestBodyLimit(t *testing.T) {
+	srv := httptest.NewServer(newServer(64).handler())
+	defer srv.Close()
+
+	body := `{"name":"` + strings.Repeat("x", 100) + `","dimension":2}`
+	if code := call(t, srv, "POST", "/collections", body, nil); code != http.StatusRequestEntityTooLarge {
+		t.Errorf("status %d, want 413", code)
+	}
+}
+
+// IVF collections serve exact results until train_size points arrive, then
+// switch to the trained index without renumbering
+func TestIVFCollectionTrainsOnce(t *testing.T) {
+	srv := newTestServer(t)
+	call(t, srv, "POST", "/collections",
+		map[string]any{"name": "ivf", "dimension": 8, "index": "ivf", "nlist": 4, "nprobe": 4, "train_size": 100}, nil)
+
+	vectors := testdata.GenerateClusteredVectors(200, 8, 4, 42)
+	upload := func(from, to int) {
+		var points []map[string]any
+		for i := from; i < to; i++ {
+			points = append(points, map[string]any{"id": fmt.Sprint(i), "vector": vectors[i]})
+		}
+		if code := call(t, srv, "POST", "/collections/ivf/points", map[string]any{"points": points}, nil); code != http.StatusOK {
+			t.Fatalf("upsert %d..%d: status %d", from, to, code)
+		}
+	}
+
+	var stats collection.Info
+	upload(0, 50)
+	call(t, srv, "GET", "/collections/ivf", nil, &stats)
+	if stats.Trained {
+		t.Fatal("trained before train_size points")
+	}
+
+	upload(50, 200)
+	call(t, srv, "GET", "/collections/ivf", nil, &stats)
+	if !stats.Trained || stats.Points != 200 || stats.Memory.Centroids == 0 {
+		t.Fatalf("stats after training = %+v", stats)
+	}
+
+	// Probing every list is exact, so each point finds itself
+	for _, i := range []int{0, 75, 199} {
+		var res searchResponse
+		call(t, srv, "POST", "/collections/ivf/search", map[string]any{"vector": vectors[i], "k": 1}, &res)
+		if len(res.Results) != 1 || res.Results[0].ID != fmt.Sprint(i) {
+			t.Errorf("point %d: search = %+v", i, res.Results)
+		}
+	}
+}
+
+func TestHNSWCollection(t *testing.T) {
+	srv := newTestServer(t)
+	call(t, srv, "POST", "/collections",
+		map[string]any{"name": "h", "dimension": 4, "index": "hnsw", "metric": "cosine", "m": 8}, nil)
+
+	vectors := testdata.GenerateRandomVectors(100, 4, 42)
+	var points []map[string]any
+	for i, v := range vectors {
+		points = append(points, map[string]any{"id": fmt.Sprint(i), "vector": v, "payload": map[string]any{"even": i%2 == 0}})
+	}
+	call(t, srv, "POST", "/collections/h/points", map[string]any{"points": points}, nil)
+
+	// Without ef_search the beam widens to k; an explicit one below k is the
+	// client's mistake, not a 500
+	if code := call(t, srv, "POST", "/collections/h/search", map[string]any{"vector": vectors[3], "k": 80}, nil); code != 200 {
+		t.Errorf("k above the configured ef_search: status %d, want 200", code)
+	}
+	if code := call(t, srv, "POST", "/collections/h/search", map[string]any{"vector": vectors[3], "k": 80, "ef_search": 20}, nil); code != 400 {
+		t.Errorf("k > ef_search: status %d, want 400", code)
+	}
+
+	var res searchResponse
+	call(t, srv, "POST", "/collections/h/search",
+		map[string]any{"vector": vectors[3], "k": 5, "filter": map[string]any{"even": true}, "include_vectors": true}, &res)
+	if len(res.Results) != 5 {
+		t.Fatalf("got %d results", len(res.Results))
+	}
+	for _, h := range res.Results {
+		if h.Payload["even"] != true || len(h.Vector) != 4 {
+			t.Errorf("hit %+v does not match the filter or lacks its vector", h)
+		}
+	}
+}
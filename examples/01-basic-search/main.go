@@ -7,6 +7,7 @@ import (
 	flatSolution "github.com/tmdgusya/database-class/01-flat/solution"
 	ivfSolution "github.com/tmdgusya/database-class/02-ivf/solution"
 	"github.com/tmdgusya/database-class/pkg/distance"
+	"github.com/tmdgusya/database-class/pkg/metrics"
 	"github.com/tmdgusya/database-class/pkg/testdata"
 )
 
@@ -83,8 +84,8 @@ func main() {
 
 	fmt.Printf("Search time: %v (%.2f ms/query)\n", searchTime, avgLatency)
 
-	// Calculate approximate recall (simple method)
-	recall := calculateSimpleRecall(ivfIdx, flatIdx, queries[:10], k)
+	// Calculate approximate recall against the exact flat index
+	recall, _ := metrics.CompareRecall(ivfIdx, flatIdx, queries[:10], k)
 	fmt.Printf("Recall: ~%.1f%%\n", recall*100)
 	fmt.Println("")
 
@@ -101,35 +102,6 @@ func main() {
 	fmt.Println("Speedup: ~8-10x with IVF!")
 	fmt.Println("")
 	fmt.Println("Note: HNSW would be even faster (~0.1ms) with similar recall")
-}
-
-func calculateSimpleRecall(ivfIdx *ivfSolution.IVFIndex, flatIdx *flatSolution.FlatIndex, queries []testdata.Vector, k int) float64 {
-	totalRecall := 0.0
-
-	for _, query := range queries {
-		// Get IVF results
-		ivfResults, _ := ivfIdx.Search(query, k)
-
-		// Get ground truth from Flat
-		flatResults, _ := flatIdx.Search(query, k)
-
-		// Build set of ground truth indices
-		truthSet := make(map[int]bool)
-		for _, r := range flatResults {
-			truthSet[r.Index] = true
-		}
-
-		// Count matches
-		matches := 0
-		for _, r := range ivfResults {
-			if truthSet[r.Index] {
-				matches++
-			}
-		}
-
-		recall := float64(matches) / float64(k)
-		totalRecall += recall
-	}
-
-	return totalRecall / float64(len(queries))
+	fmt.Println("")
+	fmt.Println("For full recall-vs-QPS curves, run: go run ./cmd/annbench")
 }
@@ -0,0 +1,225 @@
+package autotune
+
+import (
+	"math/rand"
+	"testing"
+
+	ivf "github.com/tmdgusya/database-class/02-ivf/solution"
+	hnsw "github.com/tmdgusya/database-class/03-hnsw/solution"
+	"github.com/tmdgusya/database-class/pkg/distance"
+	"github.com/tmdgusya/database-class/pkg/index"
+	"github.com/tmdgusya/database-class/pkg/metrics"
+	"github.com/tmdgusya/database-class/pkg/testdata"
+	"github.com/tmdgusya/database-class/pkg/vector"
+)
+
+func buildIVF(t *testing.T, vectors []vector.Vector, nlist int) *ivf.IVFIndex {
+	t.Helper()
+	idx, err := ivf.NewIVFIndex(ivf.Config{Metric: distance.L2Distance, NumClusters: nlist, NumProbes: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := idx.Train(vectors); err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range vectors {
+		if err := idx.Add(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return idx
+}
+
+func TestTuneSearchIVF(t *testing.T) {
+	vectors := testdata.GenerateRandomVectors(2000, 16, 42)
+	queries := testdata.GenerateRandomVectors(30, 16, 7)
+	truth, err := testdata.ComputeGroundTruth(queries, vectors, 10, distance.L2Distance)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx := buildIVF(t, vectors, 32)
+
+	knob, err := DefaultKnob(idx, 10)
+	if err != nil || knob.Name != "nprobe" || knob.Max != 32 {
+		t.Fatalf("DefaultKnob() = %+v, %v", knob, err)
+	}
+
+	tuning, err := TuneSearch(idx, queries, truth, 10, 0.9, knob, index.SearchOptions{})
+	if err != nil {
+		t.Fatalf("TuneSearch() failed: %v", err)
+	}
+	if !tuning.Reached || tuning.Recall < 0.9 || tuning.Options.NProbe != tuning.Value {
+		t.Fatalf("tuning = %+v", tuning)
+	}
+	if tuning.Latency <= 0 {
+		t.Error("latency was not measured")
+	}
+
+	// The chosen value is the smallest that works: one less falls short
+	if tuning.Value > knob.Min {
+		opts := index.SearchOptions{NProbe: tuning.Value - 1}
+		found, err := metrics.SearchBatch(index.WithOptions(idx, opts), queries, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		recall, _ := metrics.CalculateRecall(found, truth, 10)
+		if recall >= 0.9 {
+			t.Errorf("nprobe=%d already reaches %.3f; %d is not minimal", tuning.Value-1, recall, tuning.Value)
+		}
+	}
+
+	// Binary search, not a sweep
+	if len(tuning.Trials) > 7 {
+		t.Errorf("%d trials for 32 values", len(tuning.Trials))
+	}
+}
+
+func TestTuneSearchUnreachable(t *testing.T) {
+	vectors := testdata.GenerateRandomVectors(500, 8, 42)
+	queries := testdata.GenerateRandomVectors(10, 8, 7)
+	truth, _ := testdata.ComputeGroundTruth(queries, vectors, 5, distance.L2Distance)
+	idx := buildIVF(t, vectors, 8)
+
+	// Capping nprobe at 1 of 8 lists cannot reach perfect recall
+	tuning, err := TuneSearch(idx, queries, truth, 5, 1, NProbe(1), index.SearchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tuning.Reached || tuning.Value != 1 || len(tuning.Trials) != 1 {
+		t.Errorf("tuning = %+v", tuning)
+	}
+
+	if _, err := TuneSearch(idx, queries, truth, 5, 1.5, NProbe(8), index.SearchOptions{}); err == nil {
+		t.Error("expected error for target > 1")
+	}
+	if _, err := TuneSearch(idx, queries, truth[:3], 5, 0.9, NProbe(8), index.SearchOptions{}); err == nil {
+		t.Error("expected error for mismatched ground truth")
+	}
+}
+
+func TestTuneSearchHNSW(t *testing.T) {
+	vectors := testdata.GenerateRandomVectors(1000, 8, 42)
+	queries := testdata.GenerateRandomVectors(20, 8, 7)
+	truth, _ := testdata.ComputeGroundTruth(queries, vectors, 10, distance.L2Distance)
+
+	idx, err := hnsw.NewHNSWIndex(hnsw.Config{Metric: distance.L2Distance, M: 8, EfConstruction: 64, EfSearch: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range vectors {
+		idx.Add(v)
+	}
+
+	knob, err := DefaultKnob(idx, 10)
+	if err != nil || knob.Name != "efSearch" || knob.Min != 10 {
+		t.Fatalf("DefaultKnob() = %+v, %v", knob, err)
+	}
+	tuning, err := TuneSearch(idx, queries, truth, 10, 0.95, knob, index.SearchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !tuning.Reached || tuning.Options.EfSearch < 10 {
+		t.Errorf("tuning = %+v", tuning)
+	}
+
+	if _, err := DefaultKnob(&unknownIndex{}, 10); err == nil {
+		t.Error("expected error for an index without a default knob")
+	}
+
+	// A large index does not make the first trial an exhaustive search
+	knob, err = DefaultKnob(largeGraph{}, 10)
+	if err != nil || knob.Max != maxDefaultEfSearch {
+		t.Errorf("DefaultKnob() for a large graph = %+v, %v", knob, err)
+	}
+	if knob, _ = DefaultKnob(largeGraph{}, 5000); knob.Max != 5000 {
+		t.Errorf("DefaultKnob() with k above the cap: Max = %d, want 5000", knob.Max)
+	}
+}
+
+type unknownIndex struct{}
+
+func (unknownIndex) Add(vector.Vector) error { return nil }
+func (unknownIndex) Search(vector.Vector, int) ([]index.SearchResult, error) {
+	return nil, nil
+}
+func (unknownIndex) Size() int { return 0 }
+
+// largeGraph poses as an HNSW index with ten million vectors
+type largeGraph struct{ unknownIndex }
+
+func (largeGraph) Size() int             { return 10_000_000 }
+func (largeGraph) SetEfSearch(int) error { return nil }
+
+// embed places low-dimensional random points in a higher-dimensional space
+// through a fixed random linear map
+func embed(count, intrinsic, ambient int, seed int64) []vector.Vector {
+	rng := rand.New(rand.NewSource(seed))
+	basis := make([][]float64, intrinsic)
+	for i := range basis {
+		basis[i] = make([]float64, ambient)
+		for j := range basis[i] {
+			basis[i][j] = rng.NormFloat64()
+		}
+	}
+	out := make([]vector.Vector, count)
+	for n := range out {
+		v := make(vector.Vector, ambient)
+		for i := 0; i < intrinsic; i++ {
+			c := rng.Float64()
+			for j := range v {
+				v[j] += c * basis[i][j]
+			}
+		}
+		out[n] = v
+	}
+	return out
+}
+
+func TestIntrinsicDimension(t *testing.T) {
+	low, err := IntrinsicDimension(embed(1000, 3, 64, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if low < 2 || low > 4.5 {
+		t.Errorf("3-d data in 64-d: estimate %.2f", low)
+	}
+
+	high, err := IntrinsicDimension(testdata.GenerateRandomVectors(1000, 16, 2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if high < 2*low {
+		t.Errorf("16-d data estimated at %.2f, barely above 3-d data at %.2f", high, low)
+	}
+}
+
+func TestRecommendBuild(t *testing.T) {
+	sample := testdata.GenerateRandomVectors(2000, 16, 3)
+
+	rec, err := RecommendBuild(sample, 1_000_000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 4·√1e6 = 4000, but 2000 samples only support 2000/39 = 51 lists
+	if rec.NList != 51 {
+		t.Errorf("NList = %d, want 51", rec.NList)
+	}
+	if rec.M < 8 || rec.M > 48 || rec.EfConstruction < 100 || rec.EfConstruction > 500 {
+		t.Errorf("recommendation out of range: %+v", rec)
+	}
+
+	flat, err := RecommendBuild(embed(2000, 2, 16, 4), 2000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if flat.M > rec.M {
+		t.Errorf("2-d data got M=%d, more than 16-d data's %d", flat.M, rec.M)
+	}
+	if want := 4 * 45; flat.NList > want { // 4·√2000 ≈ 179
+		t.Errorf("NList = %d for 2000 vectors", flat.NList)
+	}
+
+	if _, err := RecommendBuild(sample[:5], 100); err == nil {
+		t.Error("expected error for a tiny sample")
+	}
+}
@@ -0,0 +1,122 @@
+package autotune
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/tmdgusya/database-class/pkg/distance"
+	"github.com/tmdgusya/database-class/pkg/testdata"
+	"github.com/tmdgusya/database-class/pkg/vector"
+)
+
+// Build-parameter heuristics
+const (
+	// minPointsPerList keeps k-means stable: below ~39 training points per
+	// centroid the centroids are mostly noise (the same bound Faiss warns at)
+	minPointsPerList = 39
+
+	// idNeighbors is the neighbourhood size for the intrinsic dimension estimate
+	idNeighbors = 10
+
+	// maxIDSample bounds the O(n²) neighbour search behind the estimate
+	maxIDSample = 1000
+)
+
+// BuildRecommendation suggests build parameters for a dataset
+type BuildRecommendation struct {
+	NList          int     // IVF lists
+	M              int     // HNSW connections per layer
+	EfConstruction int     // HNSW construction beam
+	IntrinsicDim   float64 // Estimated intrinsic dimension of the sample
+}
+
+// RecommendBuild suggests build parameters for totalSize vectors that look
+// like sample
+//
+// nlist follows the usual 4·√n rule, capped so that the sample gives every
+// list at least 39 training points. M grows with the intrinsic dimension of
+// the data (not its nominal dimension: 768-d embeddings often lie near a
+// 20-d manifold), since graphs over high-dimensional data need more edges to
+// stay navigable; efConstruction scales with M. These are starting points
+// for TuneSearch, not guarantees.
+func RecommendBuild(sample []vector.Vector, totalSize int) (*BuildRecommendation, error) {
+	if len(sample) <= idNeighbors {
+		return nil, fmt.Errorf("need more than %d sample vectors, got %d", idNeighbors, len(sample))
+	}
+	if totalSize < len(sample) {
+		totalSize = len(sample)
+	}
+
+	id, err := IntrinsicDimension(sample)
+	if err != nil {
+		return nil, err
+	}
+
+	nlist := int(math.Round(4 * math.Sqrt(float64(totalSize))))
+	nlist = min(nlist, len(sample)/minPointsPerList)
+	nlist = max(nlist, 1)
+
+	m := clamp(2*int(math.Ceil(id)), 8, 48)
+	efConstruction := clamp(int(12.5*float64(m)), 100, 500)
+
+	return &BuildRecommendation{
+		NList:          nlist,
+		M:              m,
+		EfConstruction: efConstruction,
+		IntrinsicDim:   id,
+	}, nil
+}
+
+// IntrinsicDimension estimates the intrinsic dimension of vectors with the
+// Levina–Bickel maximum-likelihood estimator over 10-NN L2 distances
+// At most 1000 vectors are used. Points with duplicate neighbours (zero
+// distances) carry no information and are skipped.
+func IntrinsicDimension(vectors []vector.Vector) (float64, error) {
+	if len(vectors) <= idNeighbors {
+		return 0, fmt.Errorf("need more than %d vectors, got %d", idNeighbors, len(vectors))
+	}
+	if len(vectors) > maxIDSample {
+		vectors = testdata.ShuffleVectors(vectors, 1)[:maxIDSample]
+	}
+
+	// k+1 neighbours: the nearest is the point itself
+	gt, err := testdata.ComputeNeighbors(vectors, vectors, idNeighbors+1, distance.L2Distance, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	// Average the inverse estimates (MacKay & Ghahramani's correction),
+	// then invert once
+	var sum float64
+	used := 0
+	for i, row := range gt.Neighbors {
+		dists := make([]float64, 0, idNeighbors)
+		for _, n := range row {
+			if n.Index != i {
+				dists = append(dists, n.Distance)
+			}
+		}
+		if len(dists) > idNeighbors {
+			dists = dists[:idNeighbors]
+		}
+		if len(dists) < 2 || dists[0] <= 0 {
+			continue
+		}
+
+		tk := dists[len(dists)-1]
+		var logs float64
+		for _, d := range dists[:len(dists)-1] {
+			logs += math.Log(tk / d)
+		}
+		sum += logs / float64(len(dists)-1)
+		used++
+	}
+	if used == 0 || sum == 0 {
+		return 0, fmt.Errorf("cannot estimate intrinsic dimension: sample has no distinct neighbours")
+	}
+	return float64(used) / sum, nil
+}
+
+func clamp(v, lo, hi int) int {
+	return max(lo, min(hi, v))
+}
@@ -0,0 +1,166 @@
+// Package autotune picks index parameters for a recall target
+// TuneSearch finds the cheapest search setting (nprobe, efSearch) that
+// reaches a recall@k goal on validation queries; RecommendBuild suggests
+// build parameters (nlist, M, efConstruction) from a sample of the data.
+package autotune
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/tmdgusya/database-class/pkg/index"
+	"github.com/tmdgusya/database-class/pkg/metrics"
+	"github.com/tmdgusya/database-class/pkg/vector"
+)
+
+// Knob is one integer search parameter to tune
+// Raising it must never lower recall or latency, as for nprobe and
+// efSearch: the smallest value reaching the target is then also the fastest.
+type Knob struct {
+	Name  string                                 // For reports, e.g. "nprobe"
+	Min   int                                    // Smallest value to try
+	Max   int                                    // Largest value to try
+	Apply func(opts *index.SearchOptions, v int) // Sets the parameter
+}
+
+// NProbe tunes IVF's nprobe over [1, nlist]
+func NProbe(nlist int) Knob {
+	return Knob{
+		Name: "nprobe", Min: 1, Max: nlist,
+		Apply: func(opts *index.SearchOptions, v int) { opts.NProbe = v },
+	}
+}
+
+// EfSearch tunes HNSW's efSearch over [k, max]
+func EfSearch(k, max int) Knob {
+	return Knob{
+		Name: "efSearch", Min: k, Max: max,
+		Apply: func(opts *index.SearchOptions, v int) { opts.EfSearch = v },
+	}
+}
+
+// listSizer and efSearcher identify IVF and HNSW without importing them
+type listSizer interface{ ListSizes() []int }
+type efSearcher interface{ SetEfSearch(ef int) error }
+
+// maxDefaultEfSearch bounds the efSearch range DefaultKnob tries
+// TuneSearch starts with the largest value, and a beam as wide as a large
+// index walks the whole graph with an index-sized heap per query.
+const maxDefaultEfSearch = 1024
+
+// DefaultKnob returns the knob for a known index type
+// IVF is tuned over every nprobe; HNSW over efSearch from k up to the index
+// size or maxDefaultEfSearch, whichever is smaller. Pass EfSearch explicitly
+// to search further.
+func DefaultKnob(idx index.Index, k int) (Knob, error) {
+	switch i := idx.(type) {
+	case listSizer:
+		return NProbe(len(i.ListSizes())), nil
+	case efSearcher:
+		return EfSearch(k, max(k, min(idx.Size(), maxDefaultEfSearch))), nil
+	}
+	return Knob{}, fmt.Errorf("no default knob for %T; pass one explicitly", idx)
+}
+
+// Trial is one evaluated setting
+type Trial struct {
+	Value   int
+	Recall  float64
+	Latency time.Duration // Mean; only measured for the chosen setting
+}
+
+// Tuning is the outcome of TuneSearch
+type Tuning struct {
+	Knob    string
+	Value   int                 // Chosen setting
+	Options index.SearchOptions // base options with the setting applied
+	Recall  float64             // Recall@k at Value
+	Latency time.Duration       // Mean latency at Value
+	Reached bool                // Whether Recall meets the target; if not, Value is Max
+	Trials  []Trial             // Every setting evaluated, in order
+}
+
+// TuneSearch finds the smallest knob value whose recall@k on queries meets
+// target, by binary search over [knob.Min, knob.Max]
+// groundTruth holds the true neighbours of each query, as returned by
+// testdata.ComputeGroundTruth. base supplies every other option (filters,
+// rerank factor) and is not modified. The index is only searched, never
+// reconfigured, so it can keep serving traffic while being tuned.
+func TuneSearch(
+	idx index.OptionSearcher,
+	queries []vector.Vector,
+	groundTruth [][]int,
+	k int,
+	target float64,
+	knob Knob,
+	base index.SearchOptions,
+) (*Tuning, error) {
+	if len(queries) == 0 || len(queries) != len(groundTruth) {
+		return nil, fmt.Errorf("need one ground truth row per query, got %d queries and %d rows",
+			len(queries), len(groundTruth))
+	}
+	if k <= 0 {
+		return nil, fmt.Errorf("k must be positive, got %d", k)
+	}
+	if target <= 0 || target > 1 {
+		return nil, fmt.Errorf("target recall must be in (0, 1], got %v", target)
+	}
+	if knob.Apply == nil || knob.Min <= 0 || knob.Max < knob.Min {
+		return nil, fmt.Errorf("invalid knob %q range [%d, %d]", knob.Name, knob.Min, knob.Max)
+	}
+
+	tuning := &Tuning{Knob: knob.Name}
+	recallAt := func(v int) (float64, error) {
+		opts := base
+		knob.Apply(&opts, v)
+		found, err := metrics.SearchBatch(index.WithOptions(idx, opts), queries, k)
+		if err != nil {
+			return 0, fmt.Errorf("%s=%d: %w", knob.Name, v, err)
+		}
+		recall, err := metrics.CalculateRecall(found, groundTruth, k)
+		if err != nil {
+			return 0, fmt.Errorf("%s=%d: %w", knob.Name, v, err)
+		}
+		tuning.Trials = append(tuning.Trials, Trial{Value: v, Recall: recall})
+		return recall, nil
+	}
+
+	// Invariant: recall(hi) >= target, or hi == Max and nothing reaches it
+	best, err := recallAt(knob.Max)
+	if err != nil {
+		return nil, err
+	}
+	lo, hi := knob.Min, knob.Max
+	tuning.Reached = best >= target
+	if tuning.Reached {
+		for lo < hi {
+			mid := lo + (hi-lo)/2
+			recall, err := recallAt(mid)
+			if err != nil {
+				return nil, err
+			}
+			if recall >= target {
+				hi, best = mid, recall
+			} else {
+				lo = mid + 1
+			}
+		}
+	}
+
+	tuning.Value = hi
+	tuning.Recall = best
+	tuning.Options = base
+	knob.Apply(&tuning.Options, hi)
+
+	latency, err := metrics.MeasureSearchLatency(index.WithOptions(idx, tuning.Options), queries, k)
+	if err != nil {
+		return nil, fmt.Errorf("%s=%d: %w", knob.Name, hi, err)
+	}
+	tuning.Latency = latency.Mean
+	for i := range tuning.Trials {
+		if tuning.Trials[i].Value == hi {
+			tuning.Trials[i].Latency = latency.Mean
+		}
+	}
+	return tuning, nil
+}
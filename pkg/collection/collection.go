@@ -0,0 +1,384 @@
+// Package collection manages named collections of points: vectors with a
+// string ID and a JSON-like payload, stored in one of the course's indexes
+//
+// Each collection declares its dimension, metric, index type and, optionally,
+// a payload schema. The Manager creates, drops, lists and describes them;
+// servers such as cmd/vecdbd translate their requests into Manager and
+// Collection calls and map the returned errors with errors.Is:
+//
+//	ErrInvalid   the request is malformed (bad dimension, schema violation, ...)
+//	ErrNotFound  no such collection or point
+//	ErrExists    a collection with that name already exists
+//
+// Everything lives in memory.
+package collection
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	flat "github.com/tmdgusya/database-class/01-flat/solution"
+	ivf "github.com/tmdgusya/database-class/02-ivf/solution"
+	hnsw "github.com/tmdgusya/database-class/03-hnsw/solution"
+	"github.com/tmdgusya/database-class/pkg/distance"
+	"github.com/tmdgusya/database-class/pkg/index"
+	"github.com/tmdgusya/database-class/pkg/vector"
+)
+
+var (
+	ErrInvalid  = errors.New("invalid request")
+	ErrNotFound = errors.New("not found")
+	ErrExists   = errors.New("already exists")
+)
+
+// invalidError marks an error caused by the request rather than the server
+// It matches ErrInvalid without adding that text to the message.
+type invalidError struct{ err error }
+
+func (e *invalidError) Error() string        { return e.err.Error() }
+func (e *invalidError) Unwrap() error        { return e.err }
+func (e *invalidError) Is(target error) bool { return target == ErrInvalid }
+
+// invalid wraps a client mistake so that it matches ErrInvalid
+func invalid(format string, args ...any) error {
+	return &invalidError{fmt.Errorf(format, args...)}
+}
+
+// Point is one stored vector with its external ID and payload
+type Point struct {
+	ID      string         `json:"id"`
+	Vector  vector.Vector  `json:"vector"`
+	Payload map[string]any `json:"payload,omitempty"`
+}
+
+// Collection is a named index plus the bookkeeping the indexes lack
+// The indexes are append-only and number vectors by insertion order, so
+// external IDs map to those positions, and upserts and deletes leave
+// tombstones that every search filters out. IVF collections are served by a
+// flat index until TrainSize points have arrived, then trained on them.
+type Collection struct {
+	cfg    Config
+	metric distance.Metric
+
+	mu       sync.RWMutex
+	idx      index.OptionSearcher
+	trained  bool            // False only while an IVF collection buffers
+	buffered []vector.Vector // Points an untrained IVF collection will train on
+	ids      []string        // Internal ID -> external ID
+	payloads []map[string]any
+	live     map[string]int // External ID -> internal ID of its current version
+}
+
+// Info describes a collection
+type Info struct {
+	Config  Config                 `json:"config"`
+	Points  int                    `json:"points"`  // Live points
+	Deleted int                    `json:"deleted"` // Tombstones still in the index
+	Trained bool                   `json:"trained"`
+	Memory  *index.MemoryBreakdown `json:"memory,omitempty"`
+}
+
+// New validates cfg and creates an empty collection
+// Most callers create collections through a Manager instead.
+func New(cfg Config) (*Collection, error) {
+	cfg, err := cfg.withDefaults()
+	if err != nil {
+		return nil, err
+	}
+	metric, err := distance.ByName(cfg.Metric)
+	if err != nil {
+		return nil, &invalidError{err}
+	}
+
+	c := &Collection{cfg: cfg, metric: metric, trained: true, live: make(map[string]int)}
+	switch cfg.Index {
+	case "ivf":
+		// Reject bad IVF settings now rather than once TrainSize is reached
+		if _, err := ivf.NewIVFIndex(c.ivfConfig()); err != nil {
+			return nil, &invalidError{err}
+		}
+		c.trained = false
+		c.idx, err = flat.NewFlatIndex(flat.Config{Metric: metric})
+	case "hnsw":
+		c.idx, err = hnsw.NewHNSWIndex(hnsw.Config{
+			Metric:         metric,
+			M:              cfg.M,
+			EfConstruction: cfg.EfConstruction,
+			EfSearch:       cfg.EfSearch,
+		})
+	default:
+		c.idx, err = flat.NewFlatIndex(flat.Config{Metric: metric})
+	}
+	if err != nil {
+		return nil, &invalidError{err}
+	}
+	return c, nil
+}
+
+// Name returns the collection's name
+func (c *Collection) Name() string {
+	return c.cfg.Name
+}
+
+// Config returns the configuration with defaults filled in
+func (c *Collection) Config() Config {
+	return c.cfg
+}
+
+func (c *Collection) ivfConfig() ivf.Config {
+	return ivf.Config{Metric: c.metric, NumClusters: c.cfg.NList, NumProbes: c.cfg.NProbe}
+}
+
+// prepare validates a point and returns it with a normalized payload
+func (c *Collection) prepare(p Point) (Point, error) {
+	if p.ID == "" {
+		return p, invalid("point id is required")
+	}
+	if err := p.Vector.Validate(); err != nil {
+		return p, invalid("point %q: invalid vector: %w", p.ID, err)
+	}
+	if p.Vector.Dimension() != c.cfg.Dimension {
+		return p, invalid("point %q: dimension mismatch: expected %d, got %d",
+			p.ID, c.cfg.Dimension, p.Vector.Dimension())
+	}
+	if c.cfg.Metric == "cosine" && isZero(p.Vector) {
+		// The cosine scan would fail on it for every later search
+		return p, invalid("point %q: zero vector has no direction for cosine distance", p.ID)
+	}
+	payload, err := normalizePayload(p.Payload)
+	if err == nil {
+		err = c.cfg.Schema.checkPayload(payload)
+	}
+	if err != nil {
+		return p, invalid("point %q: %w", p.ID, err)
+	}
+	p.Payload = payload
+	return p, nil
+}
+
+// Upsert stores points, replacing any existing point with the same ID
+// All points are validated first, so a bad request changes nothing.
+func (c *Collection) Upsert(points []Point) error {
+	prepared := make([]Point, len(points))
+	for i, p := range points {
+		var err error
+		if prepared[i], err = c.prepare(p); err != nil {
+			return err
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, p := range prepared {
+		if err := c.idx.Add(p.Vector); err != nil {
+			return fmt.Errorf("add point %q: %w", p.ID, err)
+		}
+		if !c.trained {
+			c.buffered = append(c.buffered, p.Vector)
+		}
+
+		// The old version, if any, becomes a tombstone
+		if old, ok := c.live[p.ID]; ok {
+			c.payloads[old] = nil
+		}
+		c.live[p.ID] = len(c.ids)
+		c.ids = append(c.ids, p.ID)
+		c.payloads = append(c.payloads, p.Payload)
+	}
+
+	if !c.trained && len(c.buffered) >= c.cfg.TrainSize {
+		return c.train()
+	}
+	return nil
+}
+
+// train replaces the buffering flat index with a trained IVF index
+// Re-adding the buffered points in order keeps internal IDs unchanged.
+// Caller must hold the write lock.
+func (c *Collection) train() error {
+	idx, err := ivf.NewIVFIndex(c.ivfConfig())
+	if err != nil {
+		return err
+	}
+	if err := idx.Train(c.buffered); err != nil {
+		return fmt.Errorf("train: %w", err)
+	}
+	for i, v := range c.buffered {
+		if err := idx.Add(v); err != nil {
+			return fmt.Errorf("add point %q: %w", c.ids[i], err)
+		}
+	}
+	c.idx = idx
+	c.trained = true
+	c.buffered = nil
+	return nil
+}
+
+// Delete tombstones the point with the given ID
+func (c *Collection) Delete(id string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	internal, ok := c.live[id]
+	if !ok {
+		return fmt.Errorf("point %q: %w", id, ErrNotFound)
+	}
+	delete(c.live, id)
+	c.payloads[internal] = nil
+	return nil
+}
+
+// Get returns the current version of a point
+// The indexes have no lookup by ID, so only the ID and payload come back.
+func (c *Collection) Get(id string) (Point, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	internal, ok := c.live[id]
+	if !ok {
+		return Point{}, fmt.Errorf("point %q: %w", id, ErrNotFound)
+	}
+	return Point{ID: id, Payload: c.payloads[internal]}, nil
+}
+
+// maxK bounds k and ef_search in a search request
+// Both size per-query buffers in the index, so a value far beyond any real
+// use is rejected rather than allowed to exhaust memory.
+const maxK = 10_000
+
+// SearchRequest is a k-NN query
+// K and EfSearch may not exceed 10000; larger values than the collection
+// can use are clamped to its size.
+type SearchRequest struct {
+	Vector         vector.Vector  `json:"vector"`
+	K              int            `json:"k"`
+	Filter         map[string]any `json:"filter,omitempty"` // Payload fields that must match exactly
+	NProbe         int            `json:"nprobe,omitempty"`
+	EfSearch       int            `json:"ef_search,omitempty"`
+	IncludeVectors bool           `json:"include_vectors,omitempty"`
+}
+
+// Hit is one search result
+// Payload is shared with the collection and must not be modified.
+type Hit struct {
+	ID       string         `json:"id"`
+	Distance float64        `json:"distance"`
+	Payload  map[string]any `json:"payload,omitempty"`
+	Vector   vector.Vector  `json:"vector,omitempty"`
+}
+
+// Search runs a k-NN query, skipping tombstones and filtered-out points
+func (c *Collection) Search(req SearchRequest) ([]Hit, error) {
+	if err := req.Vector.Validate(); err != nil {
+		return nil, invalid("invalid query: %w", err)
+	}
+	if req.Vector.Dimension() != c.cfg.Dimension {
+		return nil, invalid("query dimension mismatch: expected %d, got %d",
+			c.cfg.Dimension, req.Vector.Dimension())
+	}
+	if c.cfg.Metric == "cosine" && isZero(req.Vector) {
+		return nil, invalid("zero query vector has no direction for cosine distance")
+	}
+	if req.K <= 0 || req.K > maxK {
+		return nil, invalid("k must be between 1 and %d, got %d", maxK, req.K)
+	}
+	if req.EfSearch > maxK {
+		return nil, invalid("ef_search cannot exceed %d, got %d", maxK, req.EfSearch)
+	}
+	filter, err := normalizeFilter(req.Filter)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.cfg.Schema.checkFilter(filter); err != nil {
+		return nil, err
+	}
+
+	opts := index.SearchOptions{
+		NProbe:         req.NProbe,
+		EfSearch:       req.EfSearch,
+		IncludeVectors: req.IncludeVectors,
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, &invalidError{err}
+	}
+	if c.cfg.Index == "ivf" && req.NProbe > c.cfg.NList {
+		return nil, invalid("nprobe (%d) cannot exceed nlist (%d)", req.NProbe, c.cfg.NList)
+	}
+	if c.cfg.Index == "hnsw" && req.EfSearch > 0 && req.EfSearch < req.K {
+		return nil, invalid("ef_search (%d) cannot be smaller than k (%d)", req.EfSearch, req.K)
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	// No more results than live points can come back, nor can a beam be
+	// wider than the index
+	k := min(req.K, len(c.live))
+	if k == 0 {
+		return []Hit{}, nil
+	}
+	if c.cfg.Index == "hnsw" && opts.EfSearch == 0 {
+		// The configured beam may be narrower than this request's k
+		opts.EfSearch = max(k, c.cfg.EfSearch)
+	}
+	opts.EfSearch = min(opts.EfSearch, c.idx.Size())
+
+	opts.Filter = func(id int) bool {
+		return c.isLive(id) && matches(c.payloads[id], filter)
+	}
+	results, err := c.idx.SearchWithOptions(req.Vector, k, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	hits := make([]Hit, len(results))
+	for i, r := range results {
+		hits[i] = Hit{
+			ID:       c.ids[r.Index],
+			Distance: r.Distance,
+			Payload:  c.payloads[r.Index],
+			Vector:   r.Vector,
+		}
+	}
+	return hits, nil
+}
+
+// isZero reports whether every component of v is zero
+func isZero(v vector.Vector) bool {
+	for _, x := range v {
+		if x != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// isLive reports whether internal ID id is the current version of its point
+// Caller must hold the lock.
+func (c *Collection) isLive(id int) bool {
+	if id < 0 || id >= len(c.ids) {
+		return false
+	}
+	current, ok := c.live[c.ids[id]]
+	return ok && current == id
+}
+
+// Info snapshots the collection's configuration and counters
+func (c *Collection) Info() Info {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	info := Info{
+		Config:  c.cfg,
+		Points:  len(c.live),
+		Deleted: len(c.ids) - len(c.live),
+		Trained: c.trained,
+	}
+	if reporter, ok := c.idx.(index.MemoryReporter); ok {
+		m := reporter.MemoryUsage()
+		info.Memory = &m
+	}
+	return info
+}
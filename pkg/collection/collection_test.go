@@ -0,0 +1,273 @@
+package collection
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/tmdgusya/database-class/pkg/testdata"
+	"github.com/tmdgusya/database-class/pkg/vector"
+)
+
+func TestManager(t *testing.T) {
+	m := NewManager()
+	for _, name := range []string{"b", "a"} {
+		if _, err := m.Create(Config{Name: name, Dimension: 2}); err != nil {
+			t.Fatalf("create %s: %v", name, err)
+		}
+	}
+	if _, err := m.Create(Config{Name: "a", Dimension: 3}); !errors.Is(err, ErrExists) {
+		t.Errorf("duplicate create: got %v, want ErrExists", err)
+	}
+	if got := m.List(); !slices.Equal(got, []string{"a", "b"}) {
+		t.Errorf("List = %v, want [a b]", got)
+	}
+
+	info, err := m.Describe("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Config.Metric != "l2" || info.Config.Index != "flat" || info.Points != 0 {
+		t.Errorf("Describe(a) = %+v, want defaults and no points", info)
+	}
+
+	if err := m.Drop("a"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Get("a"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get after Drop: got %v, want ErrNotFound", err)
+	}
+	if err := m.Drop("a"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("second Drop: got %v, want ErrNotFound", err)
+	}
+}
+
+func TestInvalidConfig(t *testing.T) {
+	for _, cfg := range []Config{
+		{Dimension: 2},
+		{Name: "x"},
+		{Name: "x", Dimension: 2, Metric: "manhattan"},
+		{Name: "x", Dimension: 2, Index: "lsh"},
+		{Name: "x", Dimension: 2, Index: "ivf", NList: 8, TrainSize: 4},
+		{Name: "x", Dimension: 2, Schema: Schema{"n": {Type: "float"}}},
+		{Name: "x", Dimension: 2, Schema: Schema{"": {Type: TypeString}}},
+	} {
+		if _, err := New(cfg); !errors.Is(err, ErrInvalid) {
+			t.Errorf("New(%+v): got %v, want ErrInvalid", cfg, err)
+		}
+	}
+}
+
+func TestUpsertSearchDelete(t *testing.T) {
+	for _, kind := range []string{"flat", "ivf", "hnsw"} {
+		t.Run(kind, func(t *testing.T) {
+			c, err := New(Config{Name: "c", Dimension: 4, Index: kind, NList: 4, NProbe: 4, TrainSize: 40})
+			if err != nil {
+				t.Fatal(err)
+			}
+			vectors := testdata.GenerateRandomVectors(100, 4, 1)
+			points := make([]Point, len(vectors))
+			for i, v := range vectors {
+				points[i] = Point{ID: fmt.Sprint(i), Vector: v, Payload: map[string]any{"even": i%2 == 0}}
+			}
+			if err := c.Upsert(points); err != nil {
+				t.Fatal(err)
+			}
+			if info := c.Info(); !info.Trained || info.Points != 100 {
+				t.Fatalf("Info = %+v, want 100 trained points", info)
+			}
+
+			hits, err := c.Search(SearchRequest{Vector: vectors[7], K: 1})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(hits) != 1 || hits[0].ID != "7" {
+				t.Fatalf("search for point 7: got %+v", hits)
+			}
+
+			// Overwriting moves the point; the old version must not come back
+			if err := c.Upsert([]Point{{ID: "7", Vector: vectors[8]}}); err != nil {
+				t.Fatal(err)
+			}
+			hits, _ = c.Search(SearchRequest{Vector: vectors[7], K: 3})
+			for _, h := range hits {
+				if h.ID == "7" && h.Distance == 0 {
+					t.Errorf("stale version of point 7 returned: %+v", h)
+				}
+			}
+
+			if err := c.Delete("8"); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := c.Get("8"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Get after Delete: got %v, want ErrNotFound", err)
+			}
+			hits, _ = c.Search(SearchRequest{Vector: vectors[8], K: 10, Filter: map[string]any{"even": true}})
+			for _, h := range hits {
+				if h.ID == "8" || h.Payload["even"] != true {
+					t.Errorf("hit %+v is deleted or fails the filter", h)
+				}
+			}
+			if info := c.Info(); info.Points != 99 || info.Deleted != 2 {
+				t.Errorf("Info = %+v, want 99 points and 2 tombstones", info)
+			}
+		})
+	}
+}
+
+func TestUpsertIsAtomic(t *testing.T) {
+	c, err := New(Config{Name: "c", Dimension: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = c.Upsert([]Point{
+		{ID: "ok", Vector: vector.Vector{1, 2}},
+		{ID: "bad", Vector: vector.Vector{1, 2, 3}},
+	})
+	if !errors.Is(err, ErrInvalid) {
+		t.Fatalf("got %v, want ErrInvalid", err)
+	}
+	if info := c.Info(); info.Points != 0 {
+		t.Errorf("a rejected batch stored %d points", info.Points)
+	}
+}
+
+func TestSchema(t *testing.T) {
+	c, err := New(Config{Name: "c", Dimension: 2, Schema: Schema{
+		"lang":  {Type: TypeString, Required: true},
+		"year":  {Type: TypeInteger},
+		"score": {Type: TypeNumber},
+		"draft": {Type: TypeBool},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := vector.Vector{1, 0}
+
+	for _, payload := range []map[string]any{
+		{"lang": "ko"},
+		{"lang": "en", "year": 2024, "score": float32(0.5), "draft": false},
+		{"lang": "ko", "year": 2024.0, "score": nil},
+	} {
+		if err := c.Upsert([]Point{{ID: "ok", Vector: v, Payload: payload}}); err != nil {
+			t.Errorf("payload %v: %v", payload, err)
+		}
+	}
+	for _, payload := range []map[string]any{
+		nil,
+		{"year": 2024},
+		{"lang": nil},
+		{"lang": 3},
+		{"lang": "ko", "year": 2024.5},
+		{"lang": "ko", "draft": "no"},
+		{"lang": "ko", "author": "kim"},
+		{"lang": "ko", "score": []any{1.0}},
+	} {
+		if err := c.Upsert([]Point{{ID: "bad", Vector: v, Payload: payload}}); !errors.Is(err, ErrInvalid) {
+			t.Errorf("payload %v: got %v, want ErrInvalid", payload, err)
+		}
+	}
+
+	// Go ints are stored as float64, so an int filter matches them
+	hits, err := c.Search(SearchRequest{Vector: v, K: 1, Filter: map[string]any{"year": 2024}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hits) != 1 || hits[0].Payload["year"] != 2024.0 {
+		t.Errorf("filter on year: got %+v", hits)
+	}
+	for _, filter := range []map[string]any{
+		{"langauge": "ko"},
+		{"year": "2024"},
+		{"lang": []any{"ko"}},
+	} {
+		if _, err := c.Search(SearchRequest{Vector: v, K: 1, Filter: filter}); !errors.Is(err, ErrInvalid) {
+			t.Errorf("filter %v: got %v, want ErrInvalid", filter, err)
+		}
+	}
+}
+
+func TestSearchBounds(t *testing.T) {
+	c, err := New(Config{Name: "h", Dimension: 4, Index: "hnsw", M: 8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	vectors := testdata.GenerateRandomVectors(20, 4, 1)
+	var points []Point
+	for i, v := range vectors {
+		points = append(points, Point{ID: fmt.Sprint(i), Vector: v})
+	}
+	if err := c.Upsert(points); err != nil {
+		t.Fatal(err)
+	}
+
+	// Values the collection cannot use are clamped to its size
+	hits, err := c.Search(SearchRequest{Vector: vectors[0], K: 50, EfSearch: 5000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hits) != 20 {
+		t.Errorf("got %d hits, want all 20 points", len(hits))
+	}
+
+	// Without ef_search the beam widens to k; only an explicit one below k
+	// is the client's mistake
+	if hits, err := c.Search(SearchRequest{Vector: vectors[0], K: 18}); err != nil || len(hits) != 18 {
+		t.Errorf("k above the configured ef_search: %d hits, %v", len(hits), err)
+	}
+
+	for _, req := range []SearchRequest{
+		{Vector: vectors[0], K: 1 << 60},
+		{Vector: vectors[0], K: 1, EfSearch: 1 << 60},
+		{Vector: vectors[0], K: 10, EfSearch: 5},
+	} {
+		if _, err := c.Search(req); !errors.Is(err, ErrInvalid) {
+			t.Errorf("k=%d ef=%d: got %v, want ErrInvalid", req.K, req.EfSearch, err)
+		}
+	}
+
+	empty, _ := New(Config{Name: "e", Dimension: 4})
+	if hits, err := empty.Search(SearchRequest{Vector: vectors[0], K: 3}); err != nil || len(hits) != 0 {
+		t.Errorf("search of an empty collection = %v, %v", hits, err)
+	}
+}
+
+func TestCosineZeroVector(t *testing.T) {
+	c, err := New(Config{Name: "c", Dimension: 2, Metric: "cosine"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Upsert([]Point{{ID: "zero", Vector: vector.Vector{0, 0}}}); !errors.Is(err, ErrInvalid) {
+		t.Errorf("zero vector upsert: got %v, want ErrInvalid", err)
+	}
+	if err := c.Upsert([]Point{{ID: "a", Vector: vector.Vector{1, 0}}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Search(SearchRequest{Vector: vector.Vector{1, 1}, K: 1}); err != nil {
+		t.Errorf("search after a rejected zero vector: %v", err)
+	}
+	if _, err := c.Search(SearchRequest{Vector: vector.Vector{0, 0}, K: 1}); !errors.Is(err, ErrInvalid) {
+		t.Errorf("zero query: got %v, want ErrInvalid", err)
+	}
+}
+
+func TestPayloadIsCopied(t *testing.T) {
+	c, err := New(Config{Name: "c", Dimension: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload := map[string]any{"tag": "a"}
+	if err := c.Upsert([]Point{{ID: "p", Vector: vector.Vector{1}, Payload: payload}}); err != nil {
+		t.Fatal(err)
+	}
+	payload["tag"] = "b"
+
+	p, err := c.Get("p")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Payload["tag"] != "a" {
+		t.Errorf("stored payload changed with the caller's map: %v", p.Payload)
+	}
+}
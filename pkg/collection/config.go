@@ -0,0 +1,75 @@
+package collection
+
+import "maps"
+
+// Config declares a collection: its vectors, its index and its payloads
+type Config struct {
+	Name      string `json:"name"`
+	Dimension int    `json:"dimension"`
+	Metric    string `json:"metric"` // distance.ByName; default "l2"
+	Index     string `json:"index"`  // flat | ivf | hnsw; default "flat"
+
+	// IVF
+	NList     int `json:"nlist,omitempty"`
+	NProbe    int `json:"nprobe,omitempty"`
+	TrainSize int `json:"train_size,omitempty"` // Points buffered before k-means runs
+
+	// HNSW
+	M              int `json:"m,omitempty"`
+	EfConstruction int `json:"ef_construction,omitempty"`
+	EfSearch       int `json:"ef_search,omitempty"`
+
+	// Schema, if set, lists the only payload fields points may carry
+	Schema Schema `json:"schema,omitempty"`
+}
+
+// withDefaults fills unset fields and rejects impossible settings
+func (c Config) withDefaults() (Config, error) {
+	if c.Name == "" {
+		return c, invalid("name is required")
+	}
+	if c.Dimension <= 0 {
+		return c, invalid("dimension must be positive, got %d", c.Dimension)
+	}
+	if c.Metric == "" {
+		c.Metric = "l2"
+	}
+	if c.Index == "" {
+		c.Index = "flat"
+	}
+
+	switch c.Index {
+	case "flat":
+	case "ivf":
+		if c.NList == 0 {
+			c.NList = 16
+		}
+		if c.NProbe == 0 {
+			c.NProbe = 1
+		}
+		if c.TrainSize == 0 {
+			c.TrainSize = 39 * c.NList // k-means wants ~39 points per centroid
+		}
+		if c.TrainSize < c.NList {
+			return c, invalid("train_size (%d) must be at least nlist (%d)", c.TrainSize, c.NList)
+		}
+	case "hnsw":
+		if c.M == 0 {
+			c.M = 16
+		}
+		if c.EfConstruction == 0 {
+			c.EfConstruction = 200
+		}
+		if c.EfSearch == 0 {
+			c.EfSearch = 64
+		}
+	default:
+		return c, invalid("unknown index %q (want flat, ivf or hnsw)", c.Index)
+	}
+
+	if err := c.Schema.validate(); err != nil {
+		return c, err
+	}
+	c.Schema = maps.Clone(c.Schema) // The caller's map stays theirs
+	return c, nil
+}
@@ -0,0 +1,82 @@
+package collection
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Manager holds collections by name; it is safe for concurrent use
+type Manager struct {
+	mu          sync.RWMutex
+	collections map[string]*Collection
+}
+
+// NewManager returns a manager with no collections
+func NewManager() *Manager {
+	return &Manager{collections: make(map[string]*Collection)}
+}
+
+// Create adds a collection; names must be unique
+func (m *Manager) Create(cfg Config) (*Collection, error) {
+	c, err := New(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.collections[c.Name()]; ok {
+		return nil, fmt.Errorf("collection %q: %w", c.Name(), ErrExists)
+	}
+	m.collections[c.Name()] = c
+	return c, nil
+}
+
+// Get returns the collection called name
+func (m *Manager) Get(name string) (*Collection, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	c, ok := m.collections[name]
+	if !ok {
+		return nil, fmt.Errorf("collection %q: %w", name, ErrNotFound)
+	}
+	return c, nil
+}
+
+// Drop removes the collection called name
+// Callers still holding the collection can keep using it.
+func (m *Manager) Drop(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.collections[name]; !ok {
+		return fmt.Errorf("collection %q: %w", name, ErrNotFound)
+	}
+	delete(m.collections, name)
+	return nil
+}
+
+// List returns the collection names, sorted
+func (m *Manager) List() []string {
+	m.mu.RLock()
+	names := make([]string, 0, len(m.collections))
+	for name := range m.collections {
+		names = append(names, name)
+	}
+	m.mu.RUnlock()
+
+	sort.Strings(names)
+	return names
+}
+
+// Describe returns the Info of the collection called name
+func (m *Manager) Describe(name string) (Info, error) {
+	c, err := m.Get(name)
+	if err != nil {
+		return Info{}, err
+	}
+	return c.Info(), nil
+}
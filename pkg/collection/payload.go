@@ -0,0 +1,120 @@
+package collection
+
+import (
+	"math"
+)
+
+// normalizePayload copies a payload into the form JSON decoding produces:
+// numbers become float64, slices []any and objects map[string]any
+// Payloads from encoding/json and structpb are already in this form; Go
+// callers may pass ints and typed slices. Stored payloads are never shared
+// with the caller, and any two equal numbers compare equal in filters.
+func normalizePayload(payload map[string]any) (map[string]any, error) {
+	if payload == nil {
+		return nil, nil
+	}
+	out := make(map[string]any, len(payload))
+	for key, v := range payload {
+		n, err := normalizeValue(v)
+		if err != nil {
+			return nil, invalid("payload field %q: %w", key, err)
+		}
+		out[key] = n
+	}
+	return out, nil
+}
+
+func normalizeValue(v any) (any, error) {
+	switch v := v.(type) {
+	case nil, string, bool:
+		return v, nil
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, invalid("%v is not a JSON number", v)
+		}
+		return v, nil
+	case float32:
+		return normalizeValue(float64(v))
+	case int:
+		return float64(v), nil
+	case int32:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case uint32:
+		return float64(v), nil
+	case uint64:
+		return float64(v), nil
+	case []any:
+		out := make([]any, len(v))
+		for i, e := range v {
+			n, err := normalizeValue(e)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = n
+		}
+		return out, nil
+	case []string:
+		out := make([]any, len(v))
+		for i, e := range v {
+			out[i] = e
+		}
+		return out, nil
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for key, e := range v {
+			n, err := normalizeValue(e)
+			if err != nil {
+				return nil, err
+			}
+			out[key] = n
+		}
+		return out, nil
+	default:
+		return nil, invalid("unsupported value of type %T", v)
+	}
+}
+
+// normalizeFilter normalizes filter values, which must be scalars so that
+// they compare with ==
+func normalizeFilter(filter map[string]any) (map[string]any, error) {
+	if filter == nil {
+		return nil, nil
+	}
+	out := make(map[string]any, len(filter))
+	for _, key := range sortedKeys(filter) {
+		n, err := normalizeValue(filter[key])
+		if err != nil {
+			return nil, invalid("filter %q: %w", key, err)
+		}
+		switch n.(type) {
+		case string, float64, bool, nil:
+		default:
+			return nil, invalid("filter %q: only strings, numbers, booleans and null can be matched", key)
+		}
+		out[key] = n
+	}
+	return out, nil
+}
+
+// matches reports whether payload has every filter field with an equal value
+// Filter values are scalars (see normalizeFilter); a non-scalar payload value
+// never equals one.
+func matches(payload, filter map[string]any) bool {
+	for key, want := range filter {
+		got, ok := payload[key]
+		if !ok {
+			return false
+		}
+		switch got.(type) {
+		case string, float64, bool, nil:
+			if got != want {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,134 @@
+package collection
+
+import (
+	"math"
+	"sort"
+)
+
+// FieldType is the JSON type a payload field must have
+type FieldType string
+
+const (
+	TypeString  FieldType = "string"
+	TypeNumber  FieldType = "number"
+	TypeInteger FieldType = "integer" // A number without a fractional part
+	TypeBool    FieldType = "bool"
+)
+
+// Field declares one payload field
+type Field struct {
+	Type     FieldType `json:"type"`
+	Required bool      `json:"required,omitempty"`
+}
+
+// Schema maps payload field names to their declarations
+// A collection without a schema accepts any JSON payload. With one, points
+// may only carry the declared fields, with the declared types, and must carry
+// every required field; null counts as absent. Filters may only name declared
+// fields, so a typo in a filter fails instead of silently matching nothing.
+type Schema map[string]Field
+
+// validate rejects unnamed fields and unknown types
+func (s Schema) validate() error {
+	for _, name := range s.names() {
+		if name == "" {
+			return invalid("schema: field name is required")
+		}
+		switch s[name].Type {
+		case TypeString, TypeNumber, TypeInteger, TypeBool:
+		default:
+			return invalid("schema: field %q: unknown type %q (want string, number, integer or bool)", name, s[name].Type)
+		}
+	}
+	return nil
+}
+
+// names returns the field names sorted, so errors are deterministic
+func (s Schema) names() []string {
+	names := make([]string, 0, len(s))
+	for name := range s {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// checkPayload enforces the schema on a normalized payload
+func (s Schema) checkPayload(payload map[string]any) error {
+	if s == nil {
+		return nil
+	}
+	for _, name := range sortedKeys(payload) {
+		field, ok := s[name]
+		if !ok {
+			return invalid("payload field %q is not in the schema", name)
+		}
+		if v := payload[name]; v != nil && !field.Type.accepts(v) {
+			return invalid("payload field %q: expected %s, got %s", name, field.Type, typeName(v))
+		}
+	}
+	for _, name := range s.names() {
+		if s[name].Required && payload[name] == nil {
+			return invalid("payload field %q is required", name)
+		}
+	}
+	return nil
+}
+
+// checkFilter enforces the schema on a normalized filter
+func (s Schema) checkFilter(filter map[string]any) error {
+	if s == nil {
+		return nil
+	}
+	for _, name := range sortedKeys(filter) {
+		field, ok := s[name]
+		if !ok {
+			return invalid("filter field %q is not in the schema", name)
+		}
+		if v := filter[name]; v != nil && !field.Type.accepts(v) {
+			return invalid("filter field %q: expected %s, got %s", name, field.Type, typeName(v))
+		}
+	}
+	return nil
+}
+
+// accepts reports whether a normalized value has type t
+func (t FieldType) accepts(v any) bool {
+	switch v := v.(type) {
+	case string:
+		return t == TypeString
+	case bool:
+		return t == TypeBool
+	case float64:
+		return t == TypeNumber || (t == TypeInteger && v == math.Trunc(v))
+	default:
+		return false
+	}
+}
+
+// typeName names a normalized JSON value's type for error messages
+func typeName(v any) string {
+	switch v.(type) {
+	case string:
+		return "string"
+	case bool:
+		return "bool"
+	case float64:
+		return "number"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		return "null"
+	}
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -3,6 +3,7 @@ package distance
 import (
 	"fmt"
 	"math"
+	"reflect"
 
 	"github.com/tmdgusya/database-class/pkg/vector"
 )
@@ -21,12 +22,7 @@ func L2Distance(a, b vector.Vector) (float64, error) {
 		return 0, fmt.Errorf("cannot calculate distance for empty vectors")
 	}
 
-	var sum float64
-	for i := range a {
-		diff := a[i] - b[i]
-		sum += diff * diff
-	}
-	return math.Sqrt(sum), nil
+	return math.Sqrt(l2SquaredKernel(a, b)), nil
 }
 
 // L2DistanceSquared calculates squared Euclidean distance
@@ -41,12 +37,7 @@ func L2DistanceSquared(a, b vector.Vector) (float64, error) {
 		return 0, fmt.Errorf("cannot calculate distance for empty vectors")
 	}
 
-	var sum float64
-	for i := range a {
-		diff := a[i] - b[i]
-		sum += diff * diff
-	}
-	return sum, nil
+	return l2SquaredKernel(a, b), nil
 }
 
 // CosineDistance calculates cosine distance (1 - cosine similarity)
@@ -60,27 +51,14 @@ func CosineDistance(a, b vector.Vector) (float64, error) {
 		return 0, fmt.Errorf("cannot calculate distance for empty vectors")
 	}
 
-	var dotProduct, normA, normB float64
-	for i := range a {
-		dotProduct += a[i] * b[i]
-		normA += a[i] * a[i]
-		normB += b[i] * b[i]
-	}
-
-	normA = math.Sqrt(normA)
-	normB = math.Sqrt(normB)
+	dotProduct, normA, normB := cosineKernel(a, b)
 
 	// Handle zero vectors
 	if normA == 0 || normB == 0 {
 		return 0, fmt.Errorf("cannot calculate cosine distance for zero vector")
 	}
 
-	// Cosine similarity is in [-1, 1], so distance is in [0, 2]
-	similarity := dotProduct / (normA * normB)
-	// Clamp to [-1, 1] to handle floating point errors
-	similarity = math.Max(-1.0, math.Min(1.0, similarity))
-
-	return 1.0 - similarity, nil
+	return cosineFromParts(dotProduct, normA, normB), nil
 }
 
 // DotProduct calculates negative dot product
@@ -95,17 +73,36 @@ func DotProduct(a, b vector.Vector) (float64, error) {
 		return 0, fmt.Errorf("cannot calculate distance for empty vectors")
 	}
 
-	var sum float64
-	for i := range a {
-		sum += a[i] * b[i]
-	}
-	return -sum, nil
+	return -dotKernel(a, b), nil
+}
+
+// cosineFromParts turns a dot product and two squared norms into a cosine distance
+func cosineFromParts(dotProduct, normA, normB float64) float64 {
+	// Cosine similarity is in [-1, 1], so distance is in [0, 2]
+	similarity := dotProduct / (math.Sqrt(normA) * math.Sqrt(normB))
+	// Clamp to [-1, 1] to handle floating point errors
+	similarity = math.Max(-1.0, math.Min(1.0, similarity))
+
+	return 1.0 - similarity
 }
 
 // InnerProduct is an alias for DotProduct (negative dot product)
 // Some systems use this terminology
 var InnerProduct = DotProduct
 
+// Same reports whether a and b are the same metric function
+// Funcs are not comparable with ==, so this compares their code pointers.
+func Same(a, b Metric) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return funcPointer(a) == funcPointer(b)
+}
+
+func funcPointer(m Metric) uintptr {
+	return reflect.ValueOf(m).Pointer()
+}
+
 // ValidatePair checks if two vectors can be used together for distance calculation
 func ValidatePair(a, b vector.Vector) error {
 	if err := a.Validate(); err != nil {
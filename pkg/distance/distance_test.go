@@ -1,6 +1,7 @@
 package distance
 
 import (
+	"fmt"
 	"math"
 	"testing"
 
@@ -291,6 +292,15 @@ func BenchmarkL2Distance(b *testing.B) {
 	}
 }
 
+func TestSame(t *testing.T) {
+	if !Same(L2Distance, L2Distance) {
+		t.Error("Same(L2Distance, L2Distance) = false")
+	}
+	if Same(L2Distance, L2DistanceSquared) || Same(L2Distance, nil) {
+		t.Error("Same() matched different metrics")
+	}
+}
+
 func BenchmarkCosineDistance(b *testing.B) {
 	dims := []int{128, 512, 1024}
 	for _, dim := range dims {
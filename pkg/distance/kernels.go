@@ -0,0 +1,92 @@
+package distance
+
+// Kernels are the inner loops behind every metric. They assume len(a) == len(b)
+// and never allocate. The package-level variables below start as the portable
+// Go versions; architecture files replace them in init() when the CPU supports
+// a faster implementation.
+var (
+	dotKernel       = dotGeneric
+	l2SquaredKernel = l2SquaredGeneric
+	cosineKernel    = cosineGeneric
+
+	// kernelName reports which implementation is active (see Implementation)
+	kernelName = "generic"
+)
+
+// Implementation reports which kernel set the distance functions use:
+// "avx512", "avx2", "neon" or "generic"
+func Implementation() string {
+	return kernelName
+}
+
+// dotGeneric computes sum(a[i] * b[i])
+// Unrolled by four with independent accumulators so the additions do not
+// wait on each other.
+func dotGeneric(a, b []float64) float64 {
+	b = b[:len(a)] // Bounds-check hint
+	var s0, s1, s2, s3 float64
+	i := 0
+	for ; i+4 <= len(a); i += 4 {
+		s0 += a[i] * b[i]
+		s1 += a[i+1] * b[i+1]
+		s2 += a[i+2] * b[i+2]
+		s3 += a[i+3] * b[i+3]
+	}
+	for ; i < len(a); i++ {
+		s0 += a[i] * b[i]
+	}
+	return (s0 + s1) + (s2 + s3)
+}
+
+// l2SquaredGeneric computes sum((a[i] - b[i])^2)
+func l2SquaredGeneric(a, b []float64) float64 {
+	b = b[:len(a)] // Bounds-check hint
+	var s0, s1, s2, s3 float64
+	i := 0
+	for ; i+4 <= len(a); i += 4 {
+		d0 := a[i] - b[i]
+		d1 := a[i+1] - b[i+1]
+		d2 := a[i+2] - b[i+2]
+		d3 := a[i+3] - b[i+3]
+		s0 += d0 * d0
+		s1 += d1 * d1
+		s2 += d2 * d2
+		s3 += d3 * d3
+	}
+	for ; i < len(a); i++ {
+		d := a[i] - b[i]
+		s0 += d * d
+	}
+	return (s0 + s1) + (s2 + s3)
+}
+
+// cosineGeneric returns dot(a, b), dot(a, a) and dot(b, b) in a single pass
+func cosineGeneric(a, b []float64) (dot, normA, normB float64) {
+	b = b[:len(a)] // Bounds-check hint
+	var d0, d1, na0, na1, nb0, nb1 float64
+	i := 0
+	for ; i+2 <= len(a); i += 2 {
+		x0, x1 := a[i], a[i+1]
+		y0, y1 := b[i], b[i+1]
+		d0 += x0 * y0
+		d1 += x1 * y1
+		na0 += x0 * x0
+		na1 += x1 * x1
+		nb0 += y0 * y0
+		nb1 += y1 * y1
+	}
+	for ; i < len(a); i++ {
+		d0 += a[i] * b[i]
+		na0 += a[i] * a[i]
+		nb0 += b[i] * b[i]
+	}
+	return d0 + d1, na0 + na1, nb0 + nb1
+}
+
+// cosineFromDot builds the cosine kernel out of a SIMD dot kernel
+// Three vectorised passes still beat one scalar pass.
+func cosineFromDot(dot func(a, b []float64) float64) func(a, b []float64) (float64, float64, float64) {
+	return func(a, b []float64) (float64, float64, float64) {
+		return dot(a, b), dot(a, a), dot(b, b)
+	}
+}
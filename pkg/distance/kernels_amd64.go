@@ -0,0 +1,78 @@
+//go:build amd64 && !purego
+
+package distance
+
+// Assembly kernels in kernels_amd64.s. Each handles any length, finishing
+// the tail that does not fill a vector register with scalar FMAs.
+
+//go:noescape
+func dotAVX2(a, b []float64) float64
+
+//go:noescape
+func l2SquaredAVX2(a, b []float64) float64
+
+//go:noescape
+func dotAVX512(a, b []float64) float64
+
+//go:noescape
+func l2SquaredAVX512(a, b []float64) float64
+
+// CPU feature detection, in cpu_amd64.s
+
+func cpuid(eaxArg, ecxArg uint32) (eax, ebx, ecx, edx uint32)
+
+func xgetbv() (eax, edx uint32)
+
+func init() {
+	hasAVX2, hasAVX512 := detectFeatures()
+	switch {
+	case hasAVX512:
+		dotKernel = dotAVX512
+		l2SquaredKernel = l2SquaredAVX512
+		cosineKernel = cosineFromDot(dotAVX512)
+		kernelName = "avx512"
+	case hasAVX2:
+		dotKernel = dotAVX2
+		l2SquaredKernel = l2SquaredAVX2
+		cosineKernel = cosineFromDot(dotAVX2)
+		kernelName = "avx2"
+	}
+}
+
+// detectFeatures reports whether AVX2+FMA and AVX-512F are usable
+// The CPU advertising an extension is not enough: the OS must also save the
+// wider registers on context switch, which XGETBV reports in XCR0.
+func detectFeatures() (hasAVX2, hasAVX512 bool) {
+	maxID, _, _, _ := cpuid(0, 0)
+	if maxID < 7 {
+		return false, false
+	}
+
+	_, _, ecx1, _ := cpuid(1, 0)
+	const (
+		fmaBit     = 1 << 12
+		osxsaveBit = 1 << 27
+		avxBit     = 1 << 28
+	)
+	if ecx1&osxsaveBit == 0 || ecx1&avxBit == 0 || ecx1&fmaBit == 0 {
+		return false, false
+	}
+
+	xcr0, _ := xgetbv()
+	const (
+		ymmState = 0x6  // XMM and upper YMM halves
+		zmmState = 0xe0 // Opmask, upper ZMM0-15 and ZMM16-31
+	)
+	if xcr0&ymmState != ymmState {
+		return false, false
+	}
+
+	_, ebx7, _, _ := cpuid(7, 0)
+	const (
+		avx2Bit    = 1 << 5
+		avx512FBit = 1 << 16
+	)
+	hasAVX2 = ebx7&avx2Bit != 0
+	hasAVX512 = hasAVX2 && ebx7&avx512FBit != 0 && xcr0&zmmState == zmmState
+	return hasAVX2, hasAVX512
+}
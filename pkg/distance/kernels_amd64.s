@@ -0,0 +1,261 @@
+//go:build amd64 && !purego
+
+#include "textflag.h"
+
+// Register use in every kernel:
+//   SI, DI  pointers into a and b
+//   CX      elements left
+//   0-3     four independent accumulators (hides FMA latency)
+//   4-7     loaded values
+
+// REDUCE_Y0 sums the four lanes of Y0 into the low lane of X0
+#define REDUCE_Y0 \
+	VEXTRACTF128 $1, Y0, X1 \
+	VADDPD       X1, X0, X0 \
+	VHADDPD      X0, X0, X0
+
+// func dotAVX2(a, b []float64) float64
+TEXT ·dotAVX2(SB), NOSPLIT, $0-56
+	MOVQ a_base+0(FP), SI
+	MOVQ a_len+8(FP), CX
+	MOVQ b_base+24(FP), DI
+	VXORPD Y0, Y0, Y0
+	VXORPD Y1, Y1, Y1
+	VXORPD Y2, Y2, Y2
+	VXORPD Y3, Y3, Y3
+
+dot16:
+	CMPQ CX, $16
+	JL   dot4
+	VMOVUPD     (SI), Y4
+	VMOVUPD     32(SI), Y5
+	VMOVUPD     64(SI), Y6
+	VMOVUPD     96(SI), Y7
+	VFMADD231PD (DI), Y4, Y0
+	VFMADD231PD 32(DI), Y5, Y1
+	VFMADD231PD 64(DI), Y6, Y2
+	VFMADD231PD 96(DI), Y7, Y3
+	ADDQ        $128, SI
+	ADDQ        $128, DI
+	SUBQ        $16, CX
+	JMP         dot16
+
+dot4:
+	CMPQ CX, $4
+	JL   dotreduce
+	VMOVUPD     (SI), Y4
+	VFMADD231PD (DI), Y4, Y0
+	ADDQ        $32, SI
+	ADDQ        $32, DI
+	SUBQ        $4, CX
+	JMP         dot4
+
+dotreduce:
+	VADDPD Y1, Y0, Y0
+	VADDPD Y3, Y2, Y2
+	VADDPD Y2, Y0, Y0
+	REDUCE_Y0
+
+dottail:
+	TESTQ CX, CX
+	JZ    dotdone
+	VMOVSD      (SI), X4
+	VFMADD231SD (DI), X4, X0
+	ADDQ        $8, SI
+	ADDQ        $8, DI
+	DECQ        CX
+	JMP         dottail
+
+dotdone:
+	VZEROUPPER
+	MOVSD X0, ret+48(FP)
+	RET
+
+// func l2SquaredAVX2(a, b []float64) float64
+TEXT ·l2SquaredAVX2(SB), NOSPLIT, $0-56
+	MOVQ a_base+0(FP), SI
+	MOVQ a_len+8(FP), CX
+	MOVQ b_base+24(FP), DI
+	VXORPD Y0, Y0, Y0
+	VXORPD Y1, Y1, Y1
+	VXORPD Y2, Y2, Y2
+	VXORPD Y3, Y3, Y3
+
+l2x16:
+	CMPQ CX, $16
+	JL   l2x4
+	VMOVUPD     (SI), Y4
+	VMOVUPD     32(SI), Y5
+	VMOVUPD     64(SI), Y6
+	VMOVUPD     96(SI), Y7
+	VSUBPD      (DI), Y4, Y4
+	VSUBPD      32(DI), Y5, Y5
+	VSUBPD      64(DI), Y6, Y6
+	VSUBPD      96(DI), Y7, Y7
+	VFMADD231PD Y4, Y4, Y0
+	VFMADD231PD Y5, Y5, Y1
+	VFMADD231PD Y6, Y6, Y2
+	VFMADD231PD Y7, Y7, Y3
+	ADDQ        $128, SI
+	ADDQ        $128, DI
+	SUBQ        $16, CX
+	JMP         l2x16
+
+l2x4:
+	CMPQ CX, $4
+	JL   l2reduce
+	VMOVUPD     (SI), Y4
+	VSUBPD      (DI), Y4, Y4
+	VFMADD231PD Y4, Y4, Y0
+	ADDQ        $32, SI
+	ADDQ        $32, DI
+	SUBQ        $4, CX
+	JMP         l2x4
+
+l2reduce:
+	VADDPD Y1, Y0, Y0
+	VADDPD Y3, Y2, Y2
+	VADDPD Y2, Y0, Y0
+	REDUCE_Y0
+
+l2tail:
+	TESTQ CX, CX
+	JZ    l2done
+	VMOVSD      (SI), X4
+	VSUBSD      (DI), X4, X4
+	VFMADD231SD X4, X4, X0
+	ADDQ        $8, SI
+	ADDQ        $8, DI
+	DECQ        CX
+	JMP         l2tail
+
+l2done:
+	VZEROUPPER
+	MOVSD X0, ret+48(FP)
+	RET
+
+// REDUCE_Z0 folds the eight lanes of Z0 into the low lane of X0
+#define REDUCE_Z0 \
+	VEXTRACTF64X4 $1, Z0, Y1 \
+	VADDPD        Y1, Y0, Y0 \
+	REDUCE_Y0
+
+// func dotAVX512(a, b []float64) float64
+TEXT ·dotAVX512(SB), NOSPLIT, $0-56
+	MOVQ a_base+0(FP), SI
+	MOVQ a_len+8(FP), CX
+	MOVQ b_base+24(FP), DI
+	VPXORQ Z0, Z0, Z0
+	VPXORQ Z1, Z1, Z1
+	VPXORQ Z2, Z2, Z2
+	VPXORQ Z3, Z3, Z3
+
+dot32:
+	CMPQ CX, $32
+	JL   dot8
+	VMOVUPD     (SI), Z4
+	VMOVUPD     64(SI), Z5
+	VMOVUPD     128(SI), Z6
+	VMOVUPD     192(SI), Z7
+	VFMADD231PD (DI), Z4, Z0
+	VFMADD231PD 64(DI), Z5, Z1
+	VFMADD231PD 128(DI), Z6, Z2
+	VFMADD231PD 192(DI), Z7, Z3
+	ADDQ        $256, SI
+	ADDQ        $256, DI
+	SUBQ        $32, CX
+	JMP         dot32
+
+dot8:
+	CMPQ CX, $8
+	JL   dot512reduce
+	VMOVUPD     (SI), Z4
+	VFMADD231PD (DI), Z4, Z0
+	ADDQ        $64, SI
+	ADDQ        $64, DI
+	SUBQ        $8, CX
+	JMP         dot8
+
+dot512reduce:
+	VADDPD Z1, Z0, Z0
+	VADDPD Z3, Z2, Z2
+	VADDPD Z2, Z0, Z0
+	REDUCE_Z0
+
+dot512tail:
+	TESTQ CX, CX
+	JZ    dot512done
+	VMOVSD      (SI), X4
+	VFMADD231SD (DI), X4, X0
+	ADDQ        $8, SI
+	ADDQ        $8, DI
+	DECQ        CX
+	JMP         dot512tail
+
+dot512done:
+	VZEROUPPER
+	MOVSD X0, ret+48(FP)
+	RET
+
+// func l2SquaredAVX512(a, b []float64) float64
+TEXT ·l2SquaredAVX512(SB), NOSPLIT, $0-56
+	MOVQ a_base+0(FP), SI
+	MOVQ a_len+8(FP), CX
+	MOVQ b_base+24(FP), DI
+	VPXORQ Z0, Z0, Z0
+	VPXORQ Z1, Z1, Z1
+	VPXORQ Z2, Z2, Z2
+	VPXORQ Z3, Z3, Z3
+
+l2x32:
+	CMPQ CX, $32
+	JL   l2x8
+	VMOVUPD     (SI), Z4
+	VMOVUPD     64(SI), Z5
+	VMOVUPD     128(SI), Z6
+	VMOVUPD     192(SI), Z7
+	VSUBPD      (DI), Z4, Z4
+	VSUBPD      64(DI), Z5, Z5
+	VSUBPD      128(DI), Z6, Z6
+	VSUBPD      192(DI), Z7, Z7
+	VFMADD231PD Z4, Z4, Z0
+	VFMADD231PD Z5, Z5, Z1
+	VFMADD231PD Z6, Z6, Z2
+	VFMADD231PD Z7, Z7, Z3
+	ADDQ        $256, SI
+	ADDQ        $256, DI
+	SUBQ        $32, CX
+	JMP         l2x32
+
+l2x8:
+	CMPQ CX, $8
+	JL   l2512reduce
+	VMOVUPD     (SI), Z4
+	VSUBPD      (DI), Z4, Z4
+	VFMADD231PD Z4, Z4, Z0
+	ADDQ        $64, SI
+	ADDQ        $64, DI
+	SUBQ        $8, CX
+	JMP         l2x8
+
+l2512reduce:
+	VADDPD Z1, Z0, Z0
+	VADDPD Z3, Z2, Z2
+	VADDPD Z2, Z0, Z0
+	REDUCE_Z0
+
+l2512tail:
+	TESTQ CX, CX
+	JZ    l2512done
+	VMOVSD      (SI), X4
+	VSUBSD      (DI), X4, X4
+	VFMADD231SD X4, X4, X0
+	ADDQ        $8, SI
+	ADDQ        $8, DI
+	DECQ        CX
+	JMP         l2512tail
+
+l2512done:
+	VZEROUPPER
+	MOVSD X0, ret+48(FP)
+	RET
@@ -0,0 +1,19 @@
+//go:build amd64 && !purego
+
+package distance
+
+import "testing"
+
+// Test each instruction set the CPU supports, not only the one init() chose
+func TestAMD64Kernels(t *testing.T) {
+	hasAVX2, hasAVX512 := detectFeatures()
+	if !hasAVX2 {
+		t.Skip("CPU lacks AVX2+FMA")
+	}
+	checkKernel(t, kernel{"avx2", dotAVX2, l2SquaredAVX2})
+
+	if !hasAVX512 {
+		t.Skip("CPU lacks AVX-512F")
+	}
+	checkKernel(t, kernel{"avx512", dotAVX512, l2SquaredAVX512})
+}
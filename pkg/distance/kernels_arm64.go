@@ -0,0 +1,19 @@
+//go:build arm64 && !purego
+
+package distance
+
+// Assembly kernels in kernels_arm64.s. NEON (Advanced SIMD) is mandatory on
+// arm64, so unlike amd64 there is nothing to detect at runtime.
+
+//go:noescape
+func dotNEON(a, b []float64) float64
+
+//go:noescape
+func l2SquaredNEON(a, b []float64) float64
+
+func init() {
+	dotKernel = dotNEON
+	l2SquaredKernel = l2SquaredNEON
+	cosineKernel = cosineFromDot(dotNEON)
+	kernelName = "neon"
+}
@@ -0,0 +1,128 @@
+//go:build arm64 && !purego
+
+#include "textflag.h"
+
+// Register use in both kernels:
+//   R0, R1  pointers into a and b
+//   R2      elements left
+//   V0-V3   four independent accumulators, two lanes each
+//   V4-V7   values from a, V16-V19 values from b
+//   F8      scalar sum
+
+// REDUCE folds the eight accumulator lanes into F8
+#define REDUCE \
+	VMOV  V0.D[1], R3 \
+	FMOVD R3, F9      \
+	FADDD F9, F0      \
+	VMOV  V1.D[1], R3 \
+	FMOVD R3, F9      \
+	FADDD F9, F1      \
+	VMOV  V2.D[1], R3 \
+	FMOVD R3, F9      \
+	FADDD F9, F2      \
+	VMOV  V3.D[1], R3 \
+	FMOVD R3, F9      \
+	FADDD F9, F3      \
+	FADDD F1, F0      \
+	FADDD F3, F2      \
+	FADDD F2, F0      \
+	FMOVD F0, F8
+
+// func dotNEON(a, b []float64) float64
+TEXT ·dotNEON(SB), NOSPLIT, $0-56
+	MOVD a_base+0(FP), R0
+	MOVD a_len+8(FP), R2
+	MOVD b_base+24(FP), R1
+	VEOR V0.B16, V0.B16, V0.B16
+	VEOR V1.B16, V1.B16, V1.B16
+	VEOR V2.B16, V2.B16, V2.B16
+	VEOR V3.B16, V3.B16, V3.B16
+
+dot8:
+	CMP    $8, R2
+	BLT    dot2
+	VLD1.P 64(R0), [V4.D2, V5.D2, V6.D2, V7.D2]
+	VLD1.P 64(R1), [V16.D2, V17.D2, V18.D2, V19.D2]
+	VFMLA  V4.D2, V16.D2, V0.D2
+	VFMLA  V5.D2, V17.D2, V1.D2
+	VFMLA  V6.D2, V18.D2, V2.D2
+	VFMLA  V7.D2, V19.D2, V3.D2
+	SUB    $8, R2
+	B      dot8
+
+dot2:
+	CMP    $2, R2
+	BLT    dotreduce
+	VLD1.P 16(R0), [V4.D2]
+	VLD1.P 16(R1), [V16.D2]
+	VFMLA  V4.D2, V16.D2, V0.D2
+	SUB    $2, R2
+	B      dot2
+
+dotreduce:
+	REDUCE
+
+	CBZ     R2, dotdone
+	FMOVD   (R0), F4
+	FMOVD   (R1), F5
+	FMULD   F4, F5
+	FADDD   F5, F8
+
+dotdone:
+	FMOVD F8, ret+48(FP)
+	RET
+
+// func l2SquaredNEON(a, b []float64) float64
+TEXT ·l2SquaredNEON(SB), NOSPLIT, $0-56
+	MOVD a_base+0(FP), R0
+	MOVD a_len+8(FP), R2
+	MOVD b_base+24(FP), R1
+	VEOR V0.B16, V0.B16, V0.B16
+	VEOR V1.B16, V1.B16, V1.B16
+	VEOR V2.B16, V2.B16, V2.B16
+	VEOR V3.B16, V3.B16, V3.B16
+
+	// V20 = {1, 1}: a - b is computed as a - b*1 with VFMLS, which rounds
+	// exactly like a plain subtraction
+	FMOVD $1.0, F20
+	VDUP  V20.D[0], V20.D2
+
+l2x8:
+	CMP    $8, R2
+	BLT    l2x2
+	VLD1.P 64(R0), [V4.D2, V5.D2, V6.D2, V7.D2]
+	VLD1.P 64(R1), [V16.D2, V17.D2, V18.D2, V19.D2]
+	VFMLS  V16.D2, V20.D2, V4.D2
+	VFMLS  V17.D2, V20.D2, V5.D2
+	VFMLS  V18.D2, V20.D2, V6.D2
+	VFMLS  V19.D2, V20.D2, V7.D2
+	VFMLA  V4.D2, V4.D2, V0.D2
+	VFMLA  V5.D2, V5.D2, V1.D2
+	VFMLA  V6.D2, V6.D2, V2.D2
+	VFMLA  V7.D2, V7.D2, V3.D2
+	SUB    $8, R2
+	B      l2x8
+
+l2x2:
+	CMP    $2, R2
+	BLT    l2reduce
+	VLD1.P 16(R0), [V4.D2]
+	VLD1.P 16(R1), [V16.D2]
+	VFMLS  V16.D2, V20.D2, V4.D2
+	VFMLA  V4.D2, V4.D2, V0.D2
+	SUB    $2, R2
+	B      l2x2
+
+l2reduce:
+	REDUCE
+
+	CBZ   R2, l2done
+	FMOVD (R0), F4
+	FMOVD (R1), F5
+	FSUBD F5, F4
+	FMULD F4, F4
+	FADDD F4, F8
+
+l2done:
+	FMOVD F8, ret+48(FP)
+	RET
@@ -0,0 +1,167 @@
+package distance
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/tmdgusya/database-class/pkg/vector"
+)
+
+// kernel is one implementation under test
+type kernel struct {
+	name      string
+	dot       func(a, b []float64) float64
+	l2Squared func(a, b []float64) float64
+}
+
+// kernelLengths covers every tail length of the widest unrolled loop
+func kernelLengths() []int {
+	lengths := make([]int, 0, 80)
+	for n := 0; n <= 70; n++ {
+		lengths = append(lengths, n)
+	}
+	return append(lengths, 127, 128, 129, 1000, 1023)
+}
+
+func randomSlices(n int, seed int64) ([]float64, []float64) {
+	rng := rand.New(rand.NewSource(seed))
+	a := make([]float64, n)
+	b := make([]float64, n)
+	for i := range a {
+		a[i] = rng.Float64()*2 - 1
+		b[i] = rng.Float64()*2 - 1
+	}
+	return a, b
+}
+
+func referenceDot(a, b []float64) float64 {
+	var sum float64
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return sum
+}
+
+func referenceL2Squared(a, b []float64) float64 {
+	var sum float64
+	for i := range a {
+		d := a[i] - b[i]
+		sum += d * d
+	}
+	return sum
+}
+
+func checkKernel(t *testing.T, k kernel) {
+	t.Helper()
+
+	for _, n := range kernelLengths() {
+		a, b := randomSlices(n, int64(n))
+		// Summation order differs between kernels, so allow rounding error
+		tol := 1e-12 * float64(n+1)
+
+		if got, want := k.dot(a, b), referenceDot(a, b); math.Abs(got-want) > tol {
+			t.Errorf("%s dot(n=%d) = %v, want %v", k.name, n, got, want)
+		}
+		if got, want := k.l2Squared(a, b), referenceL2Squared(a, b); math.Abs(got-want) > tol {
+			t.Errorf("%s l2Squared(n=%d) = %v, want %v", k.name, n, got, want)
+		}
+	}
+}
+
+func TestGenericKernels(t *testing.T) {
+	checkKernel(t, kernel{"generic", dotGeneric, l2SquaredGeneric})
+
+	for _, n := range kernelLengths() {
+		a, b := randomSlices(n, int64(n))
+		dot, normA, normB := cosineGeneric(a, b)
+		tol := 1e-12 * float64(n+1)
+		if math.Abs(dot-referenceDot(a, b)) > tol ||
+			math.Abs(normA-referenceDot(a, a)) > tol ||
+			math.Abs(normB-referenceDot(b, b)) > tol {
+			t.Errorf("cosineGeneric(n=%d) = (%v, %v, %v)", n, dot, normA, normB)
+		}
+	}
+}
+
+func TestActiveKernels(t *testing.T) {
+	t.Logf("distance kernels: %s", Implementation())
+	checkKernel(t, kernel{Implementation(), dotKernel, l2SquaredKernel})
+}
+
+func TestUnchecked(t *testing.T) {
+	a := vector.Vector{1, 2, 3, 4, 5}
+	b := vector.Vector{5, 4, 3, 2, 1}
+
+	tests := []struct {
+		name   string
+		metric Metric
+		wantOK bool
+	}{
+		{"L2Distance", L2Distance, true},
+		{"L2DistanceSquared", L2DistanceSquared, true},
+		{"DotProduct", DotProduct, true},
+		{"InnerProduct", InnerProduct, true},
+		{"CosineDistance", CosineDistance, false},
+		{"custom", func(a, b vector.Vector) (float64, error) { return 0, nil }, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fast, ok := Unchecked(tt.metric)
+			if ok != tt.wantOK {
+				t.Fatalf("Unchecked() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			want, err := tt.metric(a, b)
+			if err != nil {
+				t.Fatalf("metric failed: %v", err)
+			}
+			if got := fast(a, b); math.Abs(got-want) > 1e-12 {
+				t.Errorf("unchecked = %v, checked = %v", got, want)
+			}
+		})
+	}
+
+	if _, ok := Unchecked(nil); ok {
+		t.Error("Unchecked(nil) should not be ok")
+	}
+}
+
+func TestCosineDistanceUnchecked(t *testing.T) {
+	a := vector.Vector{1, 2, 3}
+	b := vector.Vector{-2, 0.5, 4}
+
+	want, _ := CosineDistance(a, b)
+	if got := CosineDistanceUnchecked(a, b); math.Abs(got-want) > 1e-12 {
+		t.Errorf("CosineDistanceUnchecked() = %v, want %v", got, want)
+	}
+	if got := CosineDistanceUnchecked(a, vector.Vector{0, 0, 0}); got != 1 {
+		t.Errorf("CosineDistanceUnchecked() with zero vector = %v, want 1", got)
+	}
+}
+
+func BenchmarkKernels(b *testing.B) {
+	kernels := []kernel{
+		{"generic", dotGeneric, l2SquaredGeneric},
+		{Implementation(), dotKernel, l2SquaredKernel},
+	}
+	for _, dim := range []int{128, 768, 1536} {
+		x, y := randomSlices(dim, 1)
+		for _, k := range kernels {
+			b.Run(fmt.Sprintf("dot/%s/dim=%d", k.name, dim), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					_ = k.dot(x, y)
+				}
+			})
+			b.Run(fmt.Sprintf("l2/%s/dim=%d", k.name, dim), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					_ = k.l2Squared(x, y)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package distance
+
+import (
+	"fmt"
+	"sort"
+)
+
+// metricsByName maps the short names used by command-line tools and config
+// files to the built-in metrics
+var metricsByName = map[string]Metric{
+	"l2":     L2Distance,
+	"l2sq":   L2DistanceSquared,
+	"cosine": CosineDistance,
+	"dot":    DotProduct,
+}
+
+// ByName returns the built-in metric with the given short name
+// Known names: "l2", "l2sq", "cosine" and "dot".
+func ByName(name string) (Metric, error) {
+	m, ok := metricsByName[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown metric %q (want one of %v)", name, Names())
+	}
+	return m, nil
+}
+
+// Names lists the short names accepted by ByName, sorted
+func Names() []string {
+	names := make([]string, 0, len(metricsByName))
+	for name := range metricsByName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
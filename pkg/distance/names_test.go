@@ -0,0 +1,14 @@
+package distance
+
+import "testing"
+
+func TestByName(t *testing.T) {
+	for _, name := range Names() {
+		if _, err := ByName(name); err != nil {
+			t.Errorf("ByName(%q) failed: %v", name, err)
+		}
+	}
+	if _, err := ByName("manhattan"); err == nil {
+		t.Error("ByName() should fail for an unknown metric")
+	}
+}
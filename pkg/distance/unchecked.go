@@ -0,0 +1,63 @@
+package distance
+
+import (
+	"math"
+
+	"github.com/tmdgusya/database-class/pkg/vector"
+)
+
+// UncheckedMetric is a distance function that skips all validation
+// The caller guarantees both vectors are non-empty and of equal dimension;
+// a shorter b panics rather than reading past its end. Indexes check
+// dimensions once on Add and Search, so the per-pair checks in Metric are
+// pure overhead there.
+type UncheckedMetric func(a, b vector.Vector) float64
+
+// L2DistanceUnchecked is L2Distance without validation
+func L2DistanceUnchecked(a, b vector.Vector) float64 {
+	b = b[:len(a)] // The assembly kernels trust len(a)
+	return math.Sqrt(l2SquaredKernel(a, b))
+}
+
+// L2DistanceSquaredUnchecked is L2DistanceSquared without validation
+func L2DistanceSquaredUnchecked(a, b vector.Vector) float64 {
+	b = b[:len(a)] // The assembly kernels trust len(a)
+	return l2SquaredKernel(a, b)
+}
+
+// DotProductUnchecked is DotProduct without validation
+func DotProductUnchecked(a, b vector.Vector) float64 {
+	b = b[:len(a)] // The assembly kernels trust len(a)
+	return -dotKernel(a, b)
+}
+
+// CosineDistanceUnchecked is CosineDistance without validation
+// A zero vector has no direction; it is reported as orthogonal (distance 1)
+// instead of failing.
+func CosineDistanceUnchecked(a, b vector.Vector) float64 {
+	b = b[:len(a)] // The assembly kernels trust len(a)
+	dotProduct, normA, normB := cosineKernel(a, b)
+	if normA == 0 || normB == 0 {
+		return 1
+	}
+	return cosineFromParts(dotProduct, normA, normB)
+}
+
+// uncheckedByMetric maps each built-in Metric to its unchecked twin
+// Keyed by function pointer, since funcs are not comparable.
+var uncheckedByMetric = map[uintptr]UncheckedMetric{
+	funcPointer(L2Distance):        L2DistanceUnchecked,
+	funcPointer(L2DistanceSquared): L2DistanceSquaredUnchecked,
+	funcPointer(DotProduct):        DotProductUnchecked,
+}
+
+// Unchecked returns the validation-free version of a built-in metric
+// ok is false for custom metrics and for CosineDistance, whose zero-vector
+// error is part of its contract; callers then keep using the checked Metric.
+func Unchecked(m Metric) (UncheckedMetric, bool) {
+	if m == nil {
+		return nil, false
+	}
+	fast, ok := uncheckedByMetric[funcPointer(m)]
+	return fast, ok
+}
@@ -0,0 +1,57 @@
+// Package index holds the types every vector index in this course shares
+// Flat, IVF and HNSW all return SearchResult and satisfy Index, so metrics,
+// benchmarks and tools work with any of them without adapters.
+package index
+
+import "github.com/tmdgusya/database-class/pkg/vector"
+
+// SearchResult represents a single search result
+// Vector usually shares memory with the index and must not be modified. It
+// stays valid as long as the caller holds it, even after the index is closed:
+// memory-mapped indexes return a copy rather than a view into the mapping.
+type SearchResult struct {
+	Vector   vector.Vector // The stored vector (nil when not requested)
+	Distance float64       // Distance to the query
+	Index    int           // ID of the vector inside its index
+}
+
+// CloneVectors replaces each result's vector with a copy, in place
+// Memory-mapped indexes call it so results do not point into a mapping that
+// Close may release.
+func CloneVectors(results []SearchResult) []SearchResult {
+	for i := range results {
+		if results[i].Vector != nil {
+			results[i].Vector = results[i].Vector.Clone()
+		}
+	}
+	return results
+}
+
+// Index is implemented by every vector index
+type Index interface {
+	Add(v vector.Vector) error
+	Search(query vector.Vector, k int) ([]SearchResult, error)
+	Size() int
+}
+
+// Trainable is an index that must learn its structure before Add
+// IVF clusters a training sample; Flat and HNSW need no training.
+type Trainable interface {
+	Index
+	Train(vectors []vector.Vector) error
+}
+
+// OptionSearcher is an index that accepts per-call SearchOptions
+type OptionSearcher interface {
+	Index
+	SearchWithOptions(query vector.Vector, k int, opts SearchOptions) ([]SearchResult, error)
+}
+
+// Train trains idx on vectors if it is Trainable and does nothing otherwise
+// Lets generic build code treat every index the same way.
+func Train(idx Index, vectors []vector.Vector) error {
+	if t, ok := idx.(Trainable); ok {
+		return t.Train(vectors)
+	}
+	return nil
+}
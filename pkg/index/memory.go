@@ -0,0 +1,66 @@
+package index
+
+import (
+	"unsafe"
+
+	"github.com/tmdgusya/database-class/pkg/vector"
+)
+
+// MemoryBreakdown reports the bytes an index holds, by kind of data
+// Sizes are computed from the data structures themselves (slice capacities,
+// struct sizes), not sampled from the runtime, so they are exact for
+// everything the index owns and identical on every run. Allocator overhead
+// and size-class rounding are not included.
+type MemoryBreakdown struct {
+	Vectors   uint64 `json:"vectors"`   // Raw float64 vectors, including their slice headers
+	Codes     uint64 `json:"codes"`     // Quantized codes (0 for indexes storing raw vectors)
+	Centroids uint64 `json:"centroids"` // Cluster centroids
+	Graph     uint64 `json:"graph"`     // Adjacency lists
+	IDs       uint64 `json:"ids"`       // Explicit vector IDs
+	Metadata  uint64 `json:"metadata"`  // Everything else: index and node structs, radii, bookkeeping
+}
+
+// Total returns the sum of every category
+func (m MemoryBreakdown) Total() uint64 {
+	return m.Vectors + m.Codes + m.Centroids + m.Graph + m.IDs + m.Metadata
+}
+
+// Add returns the category-wise sum of m and other
+func (m MemoryBreakdown) Add(other MemoryBreakdown) MemoryBreakdown {
+	return MemoryBreakdown{
+		Vectors:   m.Vectors + other.Vectors,
+		Codes:     m.Codes + other.Codes,
+		Centroids: m.Centroids + other.Centroids,
+		Graph:     m.Graph + other.Graph,
+		IDs:       m.IDs + other.IDs,
+		Metadata:  m.Metadata + other.Metadata,
+	}
+}
+
+// MemoryReporter is an index that can account for its own memory
+type MemoryReporter interface {
+	MemoryUsage() MemoryBreakdown
+}
+
+// SliceHeaderBytes is the size of a slice header (pointer, length, capacity)
+const SliceHeaderBytes = uint64(unsafe.Sizeof([]int(nil)))
+
+// VectorBytes returns the backing array size of v, excluding its header
+func VectorBytes(v vector.Vector) uint64 {
+	return uint64(cap(v)) * uint64(unsafe.Sizeof(float64(0)))
+}
+
+// VectorsBytes returns the memory behind a slice of vectors: the outer
+// array of headers plus every vector's backing array
+func VectorsBytes(vs []vector.Vector) uint64 {
+	total := uint64(cap(vs)) * SliceHeaderBytes
+	for _, v := range vs {
+		total += VectorBytes(v)
+	}
+	return total
+}
+
+// IntsBytes returns the backing array size of ids, excluding its header
+func IntsBytes(ids []int) uint64 {
+	return uint64(cap(ids)) * uint64(unsafe.Sizeof(int(0)))
+}
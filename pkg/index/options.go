@@ -0,0 +1,123 @@
+package index
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/tmdgusya/database-class/pkg/vector"
+)
+
+// ErrTimeout is returned when a search exceeds SearchOptions.Timeout
+var ErrTimeout = errors.New("search timed out")
+
+// SearchOptions holds per-call search settings
+// The zero value means "use the index defaults" for every knob, so one
+// shared index can serve callers with different speed/recall trade-offs
+// without mutating global state via SetNumProbes or SetEfSearch.
+type SearchOptions struct {
+	NProbe   int // IVF: clusters to probe; 0 uses the index default
+	EfSearch int // HNSW: candidate list size; 0 uses the index default
+
+	// Adaptive and MaxCandidates select IVF's early-terminating search
+	// (see the IVF SearchParams documentation)
+	Adaptive      bool
+	MaxCandidates int
+
+	// RerankFactor asks the index to gather at least k*RerankFactor
+	// candidates before the final ranking: HNSW widens its beam and IVF
+	// probes extra clusters until enough vectors were scanned.
+	// 0 or 1 disables it; the flat index is exact and ignores it.
+	RerankFactor int
+
+	// Filter, if set, restricts results to IDs for which it returns true
+	Filter func(id int) bool
+
+	// Timeout aborts the search with ErrTimeout; 0 means no limit
+	Timeout time.Duration
+
+	// IncludeVectors fills SearchResult.Vector; otherwise it is left nil
+	IncludeVectors bool
+
+	// Stats, if set, is overwritten with counters and per-phase timings
+	// for this search (see SearchStats)
+	Stats *SearchStats
+
+	// Tracer, if set, receives a span for the search and one per phase,
+	// children of any span carried by Context (nil = context.Background())
+	Tracer  Tracer
+	Context context.Context
+}
+
+// DefaultSearchOptions returns the options used by the plain Search methods
+func DefaultSearchOptions() SearchOptions {
+	return SearchOptions{IncludeVectors: true}
+}
+
+// Validate checks that no option is out of range
+// Index-specific bounds (such as NProbe <= nlist) are checked by the index.
+func (o SearchOptions) Validate() error {
+	if o.NProbe < 0 {
+		return fmt.Errorf("NProbe must be non-negative, got %d", o.NProbe)
+	}
+	if o.EfSearch < 0 {
+		return fmt.Errorf("EfSearch must be non-negative, got %d", o.EfSearch)
+	}
+	if o.MaxCandidates < 0 {
+		return fmt.Errorf("MaxCandidates must be non-negative, got %d", o.MaxCandidates)
+	}
+	if o.RerankFactor < 0 {
+		return fmt.Errorf("RerankFactor must be non-negative, got %d", o.RerankFactor)
+	}
+	if o.Timeout < 0 {
+		return fmt.Errorf("Timeout must be non-negative, got %v", o.Timeout)
+	}
+	return nil
+}
+
+// Candidates returns how many candidates to gather for k results
+func (o SearchOptions) Candidates(k int) int {
+	if o.RerankFactor > 1 {
+		return k * o.RerankFactor
+	}
+	return k
+}
+
+// Accept reports whether id passes the filter
+func (o SearchOptions) Accept(id int) bool {
+	return o.Filter == nil || o.Filter(id)
+}
+
+// Deadline is the point in time a search must finish by
+// The zero Deadline never expires.
+type Deadline time.Time
+
+// Deadline returns the deadline for a search starting now
+func (o SearchOptions) Deadline() Deadline {
+	if o.Timeout == 0 {
+		return Deadline{}
+	}
+	return Deadline(time.Now().Add(o.Timeout))
+}
+
+// Expired reports whether the deadline has passed
+func (d Deadline) Expired() bool {
+	t := time.Time(d)
+	return !t.IsZero() && time.Now().After(t)
+}
+
+// WithOptions presents idx searched with fixed opts as a plain Index, so
+// helpers that only call Search (recall, latency) can drive any setting
+func WithOptions(idx OptionSearcher, opts SearchOptions) Index {
+	return withOptions{idx, opts}
+}
+
+type withOptions struct {
+	OptionSearcher
+	opts SearchOptions
+}
+
+func (w withOptions) Search(query vector.Vector, k int) ([]SearchResult, error) {
+	return w.SearchWithOptions(query, k, w.opts)
+}
@@ -0,0 +1,52 @@
+package index
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSearchOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    SearchOptions
+		wantErr bool
+	}{
+		{"zero value", SearchOptions{}, false},
+		{"all set", SearchOptions{NProbe: 4, EfSearch: 64, RerankFactor: 2, Timeout: time.Second}, false},
+		{"negative nprobe", SearchOptions{NProbe: -1}, true},
+		{"negative efSearch", SearchOptions{EfSearch: -1}, true},
+		{"negative budget", SearchOptions{MaxCandidates: -1}, true},
+		{"negative rerank", SearchOptions{RerankFactor: -2}, true},
+		{"negative timeout", SearchOptions{Timeout: -time.Millisecond}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.opts.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSearchOptionsCandidates(t *testing.T) {
+	if got := (SearchOptions{}).Candidates(10); got != 10 {
+		t.Errorf("Candidates(10) without rerank = %d, want 10", got)
+	}
+	if got := (SearchOptions{RerankFactor: 4}).Candidates(10); got != 40 {
+		t.Errorf("Candidates(10) with RerankFactor=4 = %d, want 40", got)
+	}
+}
+
+func TestDeadline(t *testing.T) {
+	if (SearchOptions{}).Deadline().Expired() {
+		t.Error("zero Timeout should never expire")
+	}
+
+	d := SearchOptions{Timeout: time.Millisecond}.Deadline()
+	time.Sleep(2 * time.Millisecond)
+	if !d.Expired() {
+		t.Error("deadline should have expired")
+	}
+}
@@ -0,0 +1,162 @@
+package index
+
+import (
+	"context"
+	"time"
+)
+
+// Search phases reported in SearchStats.Phases and as span names
+const (
+	PhaseScan          = "scan"           // Flat: score every vector; IVF: scan the probed lists
+	PhaseRankCentroids = "rank_centroids" // IVF: order lists by centroid distance
+	PhaseDescend       = "descend"        // HNSW: greedy search through the upper layers
+	PhaseExpand        = "expand"         // HNSW: beam search on layer 0
+	PhaseSelect        = "select"         // Order the final candidates and build results
+)
+
+// SearchStats describes the work done by one search
+// Counters an index has no use for stay 0 (HNSW probes no clusters, the
+// flat index visits no graph nodes).
+type SearchStats struct {
+	DistanceComputations int // Every metric evaluation, centroids included
+	NodesVisited         int // HNSW: nodes expanded on all layers
+	ClustersProbed       int // IVF: inverted lists scanned
+	CandidatesScanned    int // Flat, IVF: vectors that passed the filter and were scored
+
+	Phases []Phase       // In execution order
+	Total  time.Duration // Whole search, including validation and locking
+}
+
+// Phase is the time spent in one step of a search
+type Phase struct {
+	Name     string
+	Duration time.Duration
+}
+
+// PhaseDuration returns the total time spent in phases called name
+func (s *SearchStats) PhaseDuration(name string) time.Duration {
+	var total time.Duration
+	for _, p := range s.Phases {
+		if p.Name == name {
+			total += p.Duration
+		}
+	}
+	return total
+}
+
+// Tracer starts spans, mirroring OpenTelemetry's trace.Tracer
+// An OpenTelemetry tracer fits with a two-line adapter:
+//
+//	func (a otelTracer) Start(ctx context.Context, name string) (context.Context, index.Span) {
+//		ctx, span := a.Tracer.Start(ctx, name)
+//		return ctx, otelSpan{span}
+//	}
+type Tracer interface {
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is one timed operation, mirroring OpenTelemetry's trace.Span
+type Span interface {
+	SetAttributes(attrs ...Attribute)
+	RecordError(err error)
+	End()
+}
+
+// Attribute is a key/value pair attached to a span
+// Values are int, string or bool.
+type Attribute struct {
+	Key   string
+	Value any
+}
+
+// Trace records one search into SearchOptions.Stats and Tracer
+// Indexes count work into Stats() and mark phase boundaries with Phase; when
+// neither Stats nor Tracer is set, no clock is read and nothing allocates.
+type Trace struct {
+	enabled bool
+	stats   SearchStats
+	out     *SearchStats
+	tracer  Tracer
+	ctx     context.Context
+	root    Span
+	span    Span // Current phase
+	phase   string
+	start   time.Time
+	mark    time.Time // Start of the current phase
+}
+
+// StartTrace begins tracing a search; name is the root span name
+func (o SearchOptions) StartTrace(name string) Trace {
+	t := Trace{out: o.Stats, tracer: o.Tracer}
+	if o.Stats == nil && o.Tracer == nil {
+		return t
+	}
+	t.enabled = true
+	t.start = time.Now()
+	if t.tracer != nil {
+		t.ctx = o.Context
+		if t.ctx == nil {
+			t.ctx = context.Background()
+		}
+		t.ctx, t.root = t.tracer.Start(t.ctx, name)
+	}
+	return t
+}
+
+// Stats returns the counters to increment
+// Always non-nil, so indexes can count unconditionally.
+func (t *Trace) Stats() *SearchStats {
+	return &t.stats
+}
+
+// Phase ends the current phase, if any, and starts the one called name
+func (t *Trace) Phase(name string) {
+	if !t.enabled {
+		return
+	}
+	now := t.endPhase()
+	t.phase, t.mark = name, now
+	if t.tracer != nil {
+		_, t.span = t.tracer.Start(t.ctx, name)
+	}
+}
+
+// End closes the trace: it copies the counters to SearchOptions.Stats and
+// ends every open span, recording err on the root span
+func (t *Trace) End(err error) {
+	if !t.enabled {
+		return
+	}
+	now := t.endPhase()
+	t.stats.Total = now.Sub(t.start)
+	if t.out != nil {
+		*t.out = t.stats
+	}
+	if t.root != nil {
+		t.root.SetAttributes(
+			Attribute{"distance_computations", t.stats.DistanceComputations},
+			Attribute{"nodes_visited", t.stats.NodesVisited},
+			Attribute{"clusters_probed", t.stats.ClustersProbed},
+			Attribute{"candidates_scanned", t.stats.CandidatesScanned},
+		)
+		if err != nil {
+			t.root.RecordError(err)
+		}
+		t.root.End()
+	}
+	t.enabled = false
+}
+
+// endPhase records the current phase and returns the time it ended
+func (t *Trace) endPhase() time.Time {
+	now := time.Now()
+	if t.phase != "" {
+		t.stats.Phases = append(t.stats.Phases, Phase{t.phase, now.Sub(t.mark)})
+		t.phase = ""
+	}
+	if t.span != nil {
+		t.span.End()
+		t.span = nil
+	}
+	return now
+}
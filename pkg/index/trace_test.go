@@ -0,0 +1,112 @@
+package index
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// recordingTracer keeps every span it started, in order
+type recordingTracer struct {
+	spans []*recordingSpan
+}
+
+type ctxKey struct{}
+
+type recordingSpan struct {
+	name   string
+	parent string
+	attrs  map[string]any
+	err    error
+	ended  bool
+}
+
+func (r *recordingTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	span := &recordingSpan{name: name, attrs: map[string]any{}}
+	if parent, ok := ctx.Value(ctxKey{}).(*recordingSpan); ok {
+		span.parent = parent.name
+	}
+	r.spans = append(r.spans, span)
+	return context.WithValue(ctx, ctxKey{}, span), span
+}
+
+func (s *recordingSpan) SetAttributes(attrs ...Attribute) {
+	for _, a := range attrs {
+		s.attrs[a.Key] = a.Value
+	}
+}
+
+func (s *recordingSpan) RecordError(err error) { s.err = err }
+func (s *recordingSpan) End()                  { s.ended = true }
+
+func TestTraceRecordsPhasesAndSpans(t *testing.T) {
+	var stats SearchStats
+	tracer := &recordingTracer{}
+	opts := SearchOptions{Stats: &stats, Tracer: tracer}
+
+	tr := opts.StartTrace("test.Search")
+	tr.Phase(PhaseRankCentroids)
+	tr.Stats().DistanceComputations += 4
+	tr.Phase(PhaseScan)
+	tr.Stats().ClustersProbed = 2
+	tr.Phase(PhaseSelect)
+	tr.End(nil)
+
+	if stats.DistanceComputations != 4 || stats.ClustersProbed != 2 {
+		t.Errorf("counters not copied out: %+v", stats)
+	}
+	var names []string
+	for _, p := range stats.Phases {
+		names = append(names, p.Name)
+	}
+	if len(names) != 3 || names[0] != PhaseRankCentroids || names[1] != PhaseScan || names[2] != PhaseSelect {
+		t.Errorf("phases = %v", names)
+	}
+	if stats.Total < stats.PhaseDuration(PhaseScan) {
+		t.Errorf("Total %v shorter than one phase", stats.Total)
+	}
+
+	if len(tracer.spans) != 4 {
+		t.Fatalf("got %d spans, want root + 3 phases", len(tracer.spans))
+	}
+	root := tracer.spans[0]
+	if root.name != "test.Search" || root.attrs["distance_computations"] != 4 {
+		t.Errorf("root span = %+v", root)
+	}
+	for _, span := range tracer.spans {
+		if !span.ended {
+			t.Errorf("span %s was not ended", span.name)
+		}
+		if span != root && span.parent != root.name {
+			t.Errorf("phase span %s has parent %q", span.name, span.parent)
+		}
+	}
+}
+
+func TestTraceRecordsError(t *testing.T) {
+	tracer := &recordingTracer{}
+	tr := SearchOptions{Tracer: tracer}.StartTrace("test.Search")
+	tr.Phase(PhaseScan)
+	tr.End(ErrTimeout)
+
+	if !errors.Is(tracer.spans[0].err, ErrTimeout) {
+		t.Errorf("root span error = %v", tracer.spans[0].err)
+	}
+}
+
+func TestTraceDisabled(t *testing.T) {
+	tr := SearchOptions{}.StartTrace("test.Search")
+	tr.Phase(PhaseScan)
+	tr.Stats().DistanceComputations++ // Counting must be safe when disabled
+	tr.End(nil)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		tr := SearchOptions{}.StartTrace("test.Search")
+		tr.Phase(PhaseScan)
+		tr.Phase(PhaseSelect)
+		tr.End(nil)
+	})
+	if allocs != 0 {
+		t.Errorf("disabled trace allocated %v times per search", allocs)
+	}
+}
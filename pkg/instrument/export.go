@@ -0,0 +1,228 @@
+package instrument
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/tmdgusya/database-class/pkg/index"
+)
+
+// ContentType is the media type of the Prometheus text format, version 0.0.4
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// listSizeQuantiles are the points of the IVF list-size distribution exported
+var listSizeQuantiles = []float64{0, 0.5, 0.9, 0.99, 1}
+
+// Registry holds the instrumented indexes of one process and serves their
+// metrics over HTTP
+//
+//	reg := instrument.NewRegistry()
+//	idx := instrument.Wrap(ivfIndex, "products")
+//	reg.Register(idx)
+//	http.Handle("/metrics", reg)
+type Registry struct {
+	mu      sync.RWMutex
+	indexes []*Index // Sorted by name, so output is stable
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register adds idx; names must be unique
+func (r *Registry) Register(idx *Index) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := sort.Search(len(r.indexes), func(i int) bool { return r.indexes[i].name >= idx.name })
+	if i < len(r.indexes) && r.indexes[i].name == idx.name {
+		return fmt.Errorf("index %q is already registered", idx.name)
+	}
+	r.indexes = append(r.indexes, nil)
+	copy(r.indexes[i+1:], r.indexes[i:])
+	r.indexes[i] = idx
+	return nil
+}
+
+// Unregister removes the index called name, if present
+func (r *Registry) Unregister(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, idx := range r.indexes {
+		if idx.name == name {
+			r.indexes = append(r.indexes[:i], r.indexes[i+1:]...)
+			return
+		}
+	}
+}
+
+// ServeHTTP writes every metric in the text exposition format
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", ContentType)
+	// A write error means the scraper went away; there is no one to tell
+	_, _ = r.WriteTo(w)
+}
+
+// WriteTo writes every metric in the text exposition format
+// Size, memory and list-size gauges are read from the indexes now, so a
+// scrape costs one Size/MemoryUsage/ListSizes call per index.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.RLock()
+	indexes := append([]*Index(nil), r.indexes...)
+	r.mu.RUnlock()
+
+	var buf bytes.Buffer
+	e := &encoder{buf: &buf}
+
+	e.family("vecdb_adds_total", "counter", "Add calls.")
+	for _, idx := range indexes {
+		e.sample("vecdb_adds_total", idx.labels(), float64(idx.adds.Load()))
+	}
+	e.family("vecdb_add_errors_total", "counter", "Add calls that returned an error.")
+	for _, idx := range indexes {
+		e.sample("vecdb_add_errors_total", idx.labels(), float64(idx.addErrors.Load()))
+	}
+	e.family("vecdb_searches_total", "counter", "Search calls.")
+	for _, idx := range indexes {
+		e.sample("vecdb_searches_total", idx.labels(), float64(idx.searches.Load()))
+	}
+	e.family("vecdb_search_errors_total", "counter", "Search calls that returned an error.")
+	for _, idx := range indexes {
+		e.sample("vecdb_search_errors_total", idx.labels(), float64(idx.searchErrors.Load()))
+	}
+
+	e.family("vecdb_add_duration_seconds", "histogram", "Add latency.")
+	for _, idx := range indexes {
+		e.histogram("vecdb_add_duration_seconds", idx.labels(), idx.addLatency.snapshot())
+	}
+	e.family("vecdb_search_duration_seconds", "histogram", "Search latency.")
+	for _, idx := range indexes {
+		e.histogram("vecdb_search_duration_seconds", idx.labels(), idx.searchLat.snapshot())
+	}
+
+	e.family("vecdb_index_size", "gauge", "Vectors stored.")
+	for _, idx := range indexes {
+		e.sample("vecdb_index_size", idx.labels(), float64(idx.Size()))
+	}
+
+	var reporters []*Index
+	for _, idx := range indexes {
+		if _, ok := idx.inner.(index.MemoryReporter); ok {
+			reporters = append(reporters, idx)
+		}
+	}
+	if len(reporters) > 0 {
+		e.family("vecdb_index_memory_bytes", "gauge", "Bytes held by the index, by kind of data.")
+		for _, idx := range reporters {
+			m := idx.inner.(index.MemoryReporter).MemoryUsage()
+			for _, kind := range []struct {
+				name  string
+				bytes uint64
+			}{
+				{"vectors", m.Vectors}, {"codes", m.Codes}, {"centroids", m.Centroids},
+				{"graph", m.Graph}, {"ids", m.IDs}, {"metadata", m.Metadata},
+			} {
+				e.sample("vecdb_index_memory_bytes", idx.labels("kind", kind.name), float64(kind.bytes))
+			}
+		}
+	}
+
+	var listed []*Index
+	listSizes := map[*Index][]int{}
+	for _, idx := range indexes {
+		if sizer, ok := idx.inner.(ListSizer); ok {
+			listed = append(listed, idx)
+			listSizes[idx] = sizer.ListSizes()
+		}
+	}
+	if len(listed) > 0 {
+		e.family("vecdb_ivf_lists", "gauge", "Inverted lists.")
+		for _, idx := range listed {
+			e.sample("vecdb_ivf_lists", idx.labels(), float64(len(listSizes[idx])))
+		}
+		e.family("vecdb_ivf_list_size", "gauge", "Vectors per inverted list, by quantile over lists.")
+		for _, idx := range listed {
+			sizes := append([]int(nil), listSizes[idx]...)
+			if len(sizes) == 0 {
+				continue
+			}
+			sort.Ints(sizes)
+			for _, q := range listSizeQuantiles {
+				size := sizes[int(q*float64(len(sizes)-1))]
+				e.sample("vecdb_ivf_list_size", idx.labels("quantile", formatFloat(q)), float64(size))
+			}
+		}
+	}
+
+	return buf.WriteTo(w)
+}
+
+// labels returns the index label plus extra key/value pairs
+func (w *Index) labels(extra ...string) []string {
+	return append([]string{"index", w.name}, extra...)
+}
+
+// encoder writes the text exposition format
+type encoder struct {
+	buf *bytes.Buffer
+}
+
+func (e *encoder) family(name, kind, help string) {
+	fmt.Fprintf(e.buf, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+// sample writes one line; labels alternate key, value
+func (e *encoder) sample(name string, labels []string, value float64) {
+	e.buf.WriteString(name)
+	if len(labels) > 0 {
+		e.buf.WriteByte('{')
+		for i := 0; i < len(labels); i += 2 {
+			if i > 0 {
+				e.buf.WriteByte(',')
+			}
+			fmt.Fprintf(e.buf, "%s=\"%s\"", labels[i], escapeLabel(labels[i+1]))
+		}
+		e.buf.WriteByte('}')
+	}
+	e.buf.WriteByte(' ')
+	e.buf.WriteString(formatFloat(value))
+	e.buf.WriteByte('\n')
+}
+
+func (e *encoder) histogram(name string, labels []string, s histogramSnapshot) {
+	// Cap labels so each append copies instead of sharing a backing array
+	labels = labels[:len(labels):len(labels)]
+	for i, bound := range s.bounds {
+		e.sample(name+"_bucket", append(labels, "le", formatFloat(bound)), float64(s.cumulative[i]))
+	}
+	e.sample(name+"_bucket", append(labels, "le", "+Inf"), float64(s.cumulative[len(s.bounds)]))
+	e.sample(name+"_sum", labels, s.sum)
+	e.sample(name+"_count", labels, float64(s.count))
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(v string) string {
+	return labelEscaper.Replace(v)
+}
@@ -0,0 +1,78 @@
+package instrument
+
+import (
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultBuckets are the latency bucket upper bounds, in seconds
+// They span 50µs to 10s, which covers a small flat index as well as a
+// cold scan over millions of vectors.
+var DefaultBuckets = []float64{
+	0.00005, 0.0001, 0.00025, 0.0005,
+	0.001, 0.0025, 0.005, 0.01, 0.025, 0.05,
+	0.1, 0.25, 0.5, 1, 2.5, 5, 10,
+}
+
+// histogram is a Prometheus-style histogram with fixed bucket bounds
+// It is safe for concurrent use without locks: each observation is one
+// atomic increment plus a CAS loop on the sum.
+type histogram struct {
+	bounds []float64       // Upper bounds in seconds, ascending
+	counts []atomic.Uint64 // counts[i] = observations in (bounds[i-1], bounds[i]]; last is +Inf
+	count  atomic.Uint64
+	sum    atomic.Uint64 // float64 bits, seconds
+}
+
+func newHistogram(bounds []float64) *histogram {
+	return &histogram{
+		bounds: bounds,
+		counts: make([]atomic.Uint64, len(bounds)+1),
+	}
+}
+
+func (h *histogram) observe(d time.Duration) {
+	v := d.Seconds()
+
+	i := 0
+	for i < len(h.bounds) && v > h.bounds[i] {
+		i++
+	}
+	h.counts[i].Add(1)
+	h.count.Add(1)
+
+	for {
+		old := h.sum.Load()
+		next := math.Float64bits(math.Float64frombits(old) + v)
+		if h.sum.CompareAndSwap(old, next) {
+			return
+		}
+	}
+}
+
+// histogramSnapshot is a consistent-enough copy for one scrape
+// Buckets are cumulative, as the exposition format requires.
+type histogramSnapshot struct {
+	bounds     []float64
+	cumulative []uint64 // One per bound, then +Inf
+	count      uint64
+	sum        float64
+}
+
+func (h *histogram) snapshot() histogramSnapshot {
+	s := histogramSnapshot{
+		bounds:     h.bounds,
+		cumulative: make([]uint64, len(h.counts)),
+	}
+	var running uint64
+	for i := range h.counts {
+		running += h.counts[i].Load()
+		s.cumulative[i] = running
+	}
+	// The +Inf bucket must equal count; use the bucket total so a scrape
+	// racing an observation never reports count < +Inf
+	s.count = running
+	s.sum = math.Float64frombits(h.sum.Load())
+	return s
+}
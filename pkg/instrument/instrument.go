@@ -0,0 +1,116 @@
+// Package instrument wraps any index with operation counters and latency
+// histograms and serves them, with size and memory gauges, in the
+// Prometheus text exposition format
+// It only needs net/http: Prometheus scrapes the handler directly, and no
+// client library is pulled into programs that use the indexes.
+package instrument
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/tmdgusya/database-class/pkg/index"
+	"github.com/tmdgusya/database-class/pkg/vector"
+)
+
+// ListSizer is an index with inverted lists, such as IVF
+type ListSizer interface {
+	ListSizes() []int
+}
+
+// Index counts and times every operation on the index it wraps
+// It satisfies index.Index, index.OptionSearcher and index.Trainable, so
+// it can replace the wrapped index anywhere; Train and SearchWithOptions
+// fail if the wrapped index does not support them.
+type Index struct {
+	name  string
+	inner index.Index
+
+	adds         atomic.Uint64
+	addErrors    atomic.Uint64
+	searches     atomic.Uint64
+	searchErrors atomic.Uint64
+	addLatency   *histogram
+	searchLat    *histogram
+}
+
+// Compile-time checks that the wrapper satisfies the shared interfaces
+var (
+	_ index.OptionSearcher = (*Index)(nil)
+	_ index.Trainable      = (*Index)(nil)
+)
+
+// Wrap instruments idx; name becomes the index label on every series
+func Wrap(idx index.Index, name string) *Index {
+	return &Index{
+		name:       name,
+		inner:      idx,
+		addLatency: newHistogram(DefaultBuckets),
+		searchLat:  newHistogram(DefaultBuckets),
+	}
+}
+
+// Name returns the index label
+func (w *Index) Name() string {
+	return w.name
+}
+
+// Unwrap returns the wrapped index
+func (w *Index) Unwrap() index.Index {
+	return w.inner
+}
+
+// Add adds a vector to the wrapped index
+func (w *Index) Add(v vector.Vector) error {
+	start := time.Now()
+	err := w.inner.Add(v)
+	w.addLatency.observe(time.Since(start))
+	w.adds.Add(1)
+	if err != nil {
+		w.addErrors.Add(1)
+	}
+	return err
+}
+
+// Search searches the wrapped index
+func (w *Index) Search(query vector.Vector, k int) ([]index.SearchResult, error) {
+	start := time.Now()
+	results, err := w.inner.Search(query, k)
+	w.observeSearch(time.Since(start), err)
+	return results, err
+}
+
+// SearchWithOptions searches the wrapped index with per-call settings
+func (w *Index) SearchWithOptions(query vector.Vector, k int, opts index.SearchOptions) ([]index.SearchResult, error) {
+	searcher, ok := w.inner.(index.OptionSearcher)
+	if !ok {
+		return nil, fmt.Errorf("index %q does not support search options", w.name)
+	}
+	start := time.Now()
+	results, err := searcher.SearchWithOptions(query, k, opts)
+	w.observeSearch(time.Since(start), err)
+	return results, err
+}
+
+func (w *Index) observeSearch(d time.Duration, err error) {
+	w.searchLat.observe(d)
+	w.searches.Add(1)
+	if err != nil {
+		w.searchErrors.Add(1)
+	}
+}
+
+// Train trains the wrapped index
+func (w *Index) Train(vectors []vector.Vector) error {
+	trainable, ok := w.inner.(index.Trainable)
+	if !ok {
+		return fmt.Errorf("index %q does not need training", w.name)
+	}
+	return trainable.Train(vectors)
+}
+
+// Size returns the wrapped index's size
+func (w *Index) Size() int {
+	return w.inner.Size()
+}
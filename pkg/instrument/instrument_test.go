@@ -0,0 +1,197 @@
+package instrument
+
+import (
+	"bufio"
+	"errors"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	ivf "github.com/tmdgusya/database-class/02-ivf/solution"
+	"github.com/tmdgusya/database-class/pkg/distance"
+	"github.com/tmdgusya/database-class/pkg/index"
+	"github.com/tmdgusya/database-class/pkg/testdata"
+	"github.com/tmdgusya/database-class/pkg/vector"
+)
+
+// failingIndex rejects every vector whose first component is negative
+type failingIndex struct {
+	mu   sync.Mutex
+	size int
+}
+
+func (f *failingIndex) Add(v vector.Vector) error {
+	if v[0] < 0 {
+		return errors.New("negative")
+	}
+	f.mu.Lock()
+	f.size++
+	f.mu.Unlock()
+	return nil
+}
+
+func (f *failingIndex) Search(query vector.Vector, k int) ([]index.SearchResult, error) {
+	if query[0] < 0 {
+		return nil, errors.New("negative")
+	}
+	return nil, nil
+}
+
+func (f *failingIndex) Size() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.size
+}
+
+// scrape serves reg over HTTP and parses every sample line into a map
+// keyed by "name{labels}"
+func scrape(t *testing.T, reg *Registry) (map[string]float64, string) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	reg.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	if ct := rec.Header().Get("Content-Type"); ct != ContentType {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	body := rec.Body.String()
+
+	samples := map[string]float64{}
+	sc := bufio.NewScanner(strings.NewReader(body))
+	for sc.Scan() {
+		line := sc.Text()
+		if strings.HasPrefix(line, "#") || line == "" {
+			continue
+		}
+		i := strings.LastIndexByte(line, ' ')
+		v, err := strconv.ParseFloat(line[i+1:], 64)
+		if err != nil {
+			t.Fatalf("bad sample line %q: %v", line, err)
+		}
+		samples[line[:i]] = v
+	}
+	return samples, body
+}
+
+func TestCountersAndHistograms(t *testing.T) {
+	idx := Wrap(&failingIndex{}, "plain")
+	reg := NewRegistry()
+	if err := reg.Register(idx); err != nil {
+		t.Fatal(err)
+	}
+	if err := reg.Register(Wrap(&failingIndex{}, "plain")); err == nil {
+		t.Error("duplicate name should be rejected")
+	}
+
+	idx.Add(vector.Vector{1})
+	idx.Add(vector.Vector{-1})
+	idx.Search(vector.Vector{1}, 1)
+	idx.Search(vector.Vector{1}, 1)
+	idx.Search(vector.Vector{-1}, 1)
+	if _, err := idx.SearchWithOptions(vector.Vector{1}, 1, index.SearchOptions{}); err == nil {
+		t.Error("SearchWithOptions should fail for an index without options")
+	}
+
+	samples, body := scrape(t, reg)
+	want := map[string]float64{
+		`vecdb_adds_total{index="plain"}`:                               2,
+		`vecdb_add_errors_total{index="plain"}`:                         1,
+		`vecdb_searches_total{index="plain"}`:                           3,
+		`vecdb_search_errors_total{index="plain"}`:                      1,
+		`vecdb_index_size{index="plain"}`:                               1,
+		`vecdb_search_duration_seconds_count{index="plain"}`:            3,
+		`vecdb_search_duration_seconds_bucket{index="plain",le="+Inf"}`: 3,
+		`vecdb_add_duration_seconds_count{index="plain"}`:               2,
+	}
+	for key, v := range want {
+		if samples[key] != v {
+			t.Errorf("%s = %v, want %v", key, samples[key], v)
+		}
+	}
+
+	// Buckets are cumulative
+	prev := -1.0
+	for _, b := range DefaultBuckets {
+		key := `vecdb_search_duration_seconds_bucket{index="plain",le="` + formatFloat(b) + `"}`
+		v, ok := samples[key]
+		if !ok || v < prev {
+			t.Fatalf("%s = %v (present %v), previous bucket %v", key, v, ok, prev)
+		}
+		prev = v
+	}
+
+	// No memory or list gauges for an index that cannot report them
+	if strings.Contains(body, "vecdb_index_memory_bytes") || strings.Contains(body, "vecdb_ivf_lists") {
+		t.Error("unsupported gauges were exported")
+	}
+	if strings.Count(body, "# TYPE vecdb_adds_total counter") != 1 {
+		t.Error("family header missing or repeated")
+	}
+
+	reg.Unregister("plain")
+	if samples, _ := scrape(t, reg); len(samples) != 0 {
+		t.Errorf("unregistered index still exported: %v", samples)
+	}
+}
+
+func TestIVFGauges(t *testing.T) {
+	inner, err := ivf.NewIVFIndex(ivf.Config{Metric: distance.L2Distance, NumClusters: 4, NumProbes: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	vectors := testdata.GenerateClusteredVectors(200, 8, 4, 42)
+	idx := Wrap(inner, `weird "name"`)
+	if err := index.Train(idx, vectors); err != nil {
+		t.Fatalf("Train() through the wrapper failed: %v", err)
+	}
+	for _, v := range vectors {
+		if err := idx.Add(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := idx.SearchWithOptions(vectors[0], 5, index.SearchOptions{NProbe: 4}); err != nil {
+		t.Fatal(err)
+	}
+
+	reg := NewRegistry()
+	reg.Register(idx)
+	samples, _ := scrape(t, reg)
+
+	label := `index="weird \"name\""`
+	if samples[`vecdb_ivf_lists{`+label+`}`] != 4 {
+		t.Errorf("vecdb_ivf_lists = %v, want 4", samples[`vecdb_ivf_lists{`+label+`}`])
+	}
+	minSize := samples[`vecdb_ivf_list_size{`+label+`,quantile="0"}`]
+	maxSize := samples[`vecdb_ivf_list_size{`+label+`,quantile="1"}`]
+	if minSize > maxSize || maxSize == 0 {
+		t.Errorf("list size min %v, max %v", minSize, maxSize)
+	}
+	want := float64(inner.MemoryUsage().Centroids)
+	if got := samples[`vecdb_index_memory_bytes{`+label+`,kind="centroids"}`]; got != want || got == 0 {
+		t.Errorf("centroid bytes = %v, want %v", got, want)
+	}
+	if samples[`vecdb_searches_total{`+label+`}`] != 1 {
+		t.Error("SearchWithOptions was not counted")
+	}
+}
+
+func TestConcurrentObservations(t *testing.T) {
+	idx := Wrap(&failingIndex{}, "c")
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				idx.Search(vector.Vector{1}, 1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	s := idx.searchLat.snapshot()
+	if s.count != 8000 || idx.searches.Load() != 8000 {
+		t.Errorf("count = %d, searches = %d, want 8000", s.count, idx.searches.Load())
+	}
+}
@@ -0,0 +1,146 @@
+package lsm
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/tmdgusya/database-class/pkg/vector"
+)
+
+// run is the background worker: it seals frozen memtables, oldest first,
+// and compacts whenever no memtable is waiting
+// Builds run without e.mu held, so inserts, deletes and searches continue
+// meanwhile; only the swap of old segments for new takes the write lock.
+func (e *Engine) run() {
+	defer close(e.done)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for !e.closed {
+		if len(e.frozen) > 0 {
+			e.busy = true
+			e.seal(e.frozen[0])
+			continue
+		}
+		if olds := e.pickCompaction(); olds != nil {
+			e.busy = true
+			e.compact(olds)
+			continue
+		}
+		e.busy = false
+		e.idle.Broadcast()
+		e.wake.Wait()
+	}
+	e.busy = false
+}
+
+// seal builds the sealed index for frozen memtable m and moves it to the
+// segments; e.mu is held on entry and exit
+// The segment keeps the memtable's ids and bitmap, so deletes made during
+// the build carry over without any bookkeeping.
+func (e *Engine) seal(m *segment) {
+	e.mu.Unlock()
+	idx, kind, nlist, err := buildSegment(e.cfg, m.vectors)
+	e.mu.Lock()
+
+	s := &segment{kind: kind, idx: idx, nlist: nlist, ids: m.ids, vectors: m.vectors, deleted: m.deleted}
+	if err != nil {
+		// The memtable's FlatIndex is complete; keep serving from it
+		e.fail(fmt.Errorf("seal segment: %w", err))
+		s.kind, s.idx, s.nlist = "flat", m.idx, 0
+	}
+	e.frozen = e.frozen[1:]
+	e.segments = append(e.segments, s)
+}
+
+// pickCompaction returns the oldest CompactionFanIn segments of the lowest
+// size tier that has that many, or nil; e.mu must be held
+// Segments left without live vectors are returned on their own, to be
+// dropped. Nothing is compacted after a background failure.
+func (e *Engine) pickCompaction() []*segment {
+	if e.err != nil {
+		return nil
+	}
+	tiers := make(map[int][]*segment)
+	lowest := -1
+	for _, s := range e.segments {
+		if s.live() == 0 {
+			return []*segment{s}
+		}
+		t := tier(s.live(), e.cfg.MemtableSize, e.cfg.CompactionFanIn)
+		tiers[t] = append(tiers[t], s)
+		if len(tiers[t]) == e.cfg.CompactionFanIn && (lowest < 0 || t < lowest) {
+			lowest = t
+		}
+	}
+	if lowest < 0 {
+		return nil
+	}
+	return tiers[lowest]
+}
+
+// compact merges olds into one segment of their live vectors; e.mu is held
+// on entry and exit
+func (e *Engine) compact(olds []*segment) {
+	// Collect live vectors in ID order, so the merged ids stay sorted
+	type entry struct {
+		id  int
+		v   vector.Vector
+		old *segment
+		pos int
+	}
+	var live []entry
+	for _, s := range olds {
+		for pos, id := range s.ids {
+			if !s.deleted.test(pos) {
+				live = append(live, entry{id, s.vectors[pos], s, pos})
+			}
+		}
+	}
+	slices.SortFunc(live, func(a, b entry) int { return a.id - b.id })
+
+	merged := &segment{
+		ids:     make([]int, len(live)),
+		vectors: make([]vector.Vector, len(live)),
+		deleted: newBitmap(len(live)),
+	}
+	for i, en := range live {
+		merged.ids[i], merged.vectors[i] = en.id, en.v
+	}
+
+	if len(live) > 0 {
+		e.mu.Unlock()
+		idx, kind, nlist, err := buildSegment(e.cfg, merged.vectors)
+		e.mu.Lock()
+		if err != nil {
+			e.fail(fmt.Errorf("compact %d segments: %w", len(olds), err))
+			return
+		}
+		merged.idx, merged.kind, merged.nlist = idx, kind, nlist
+
+		// Carry over deletes made while the build ran
+		for i, en := range live {
+			if en.old.deleted.test(en.pos) {
+				merged.deleted.set(i)
+			}
+		}
+	}
+
+	segments := e.segments[:0:0]
+	for _, s := range e.segments {
+		if !slices.Contains(olds, s) {
+			segments = append(segments, s)
+		}
+	}
+	if len(live) > 0 {
+		segments = append(segments, merged)
+	}
+	e.segments = segments
+}
+
+// fail records the first background error; e.mu must be held
+func (e *Engine) fail(err error) {
+	if e.err == nil {
+		e.err = err
+	}
+}
@@ -0,0 +1,409 @@
+// Package lsm stores a mutable vector collection as a log-structured merge
+// tree of indexes
+//
+// Inserts go to the memtable, a small FlatIndex that is exact and needs no
+// training. A full memtable is frozen and a background worker seals it into
+// an immutable IVF or HNSW segment while a fresh memtable takes new writes.
+// Deletes only set a bit in the owning segment's bitmap. Searches fan out to
+// the memtable and every segment concurrently and merge the results.
+//
+// Size-tiered compaction keeps the fan-out small: once CompactionFanIn
+// segments of similar size exist, the worker merges them into one, dropping
+// deleted vectors, so the segment count grows with the logarithm of the
+// collection size.
+//
+//	eng, err := lsm.New(lsm.Config{Metric: distance.L2Distance, Segment: "hnsw"})
+//	id, err := eng.Insert(v)
+//	err = eng.Delete(id)
+//	results, err := eng.Search(query, 10)
+package lsm
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/tmdgusya/database-class/pkg/distance"
+	"github.com/tmdgusya/database-class/pkg/index"
+	"github.com/tmdgusya/database-class/pkg/topk"
+	"github.com/tmdgusya/database-class/pkg/vector"
+)
+
+var (
+	// ErrNotFound is returned by Delete for IDs never inserted or already deleted
+	ErrNotFound = errors.New("vector not found")
+
+	// ErrClosed is returned by every method after Close
+	ErrClosed = errors.New("engine closed")
+)
+
+// Config configures an Engine
+type Config struct {
+	Metric       distance.Metric
+	MemtableSize int    // Vectors per memtable before it is sealed; default 1024
+	Segment      string // Sealed segment index: flat | ivf | hnsw; default "hnsw"
+
+	// IVF segments
+	NList  int // Lists per segment; 0 picks about 4√n for each segment
+	NProbe int // Default lists probed; 0 means nlist/4
+
+	// HNSW segments
+	M              int   // default 16
+	EfConstruction int   // default 200
+	EfSearch       int   // default 64
+	Seed           int64 // Seeds node levels so segments build reproducibly; 0 is random
+
+	// CompactionFanIn is how many segments of one size tier are merged
+	// together; default 4
+	CompactionFanIn int
+}
+
+// withDefaults fills unset fields and rejects impossible settings
+func (c Config) withDefaults() (Config, error) {
+	if c.Metric == nil {
+		return c, fmt.Errorf("metric cannot be nil")
+	}
+	if c.MemtableSize == 0 {
+		c.MemtableSize = 1024
+	}
+	if c.MemtableSize < 0 {
+		return c, fmt.Errorf("MemtableSize must be positive, got %d", c.MemtableSize)
+	}
+	if c.Segment == "" {
+		c.Segment = "hnsw"
+	}
+	switch c.Segment {
+	case "flat":
+	case "ivf":
+		if c.NList < 0 {
+			return c, fmt.Errorf("NList must be non-negative, got %d", c.NList)
+		}
+		if c.NProbe < 0 {
+			return c, fmt.Errorf("NProbe must be non-negative, got %d", c.NProbe)
+		}
+	case "hnsw":
+		if c.M == 0 {
+			c.M = 16
+		}
+		if c.EfConstruction == 0 {
+			c.EfConstruction = 200
+		}
+		if c.EfSearch == 0 {
+			c.EfSearch = 64
+		}
+	default:
+		return c, fmt.Errorf("unknown segment index %q (want flat, ivf or hnsw)", c.Segment)
+	}
+	if c.CompactionFanIn == 0 {
+		c.CompactionFanIn = 4
+	}
+	if c.CompactionFanIn < 2 {
+		return c, fmt.Errorf("CompactionFanIn must be at least 2, got %d", c.CompactionFanIn)
+	}
+	return c, nil
+}
+
+// Engine is a mutable vector index made of a memtable and sealed segments
+// IDs are assigned by Insert in increasing order and never reused. Every
+// method is safe for concurrent use; one background goroutine seals frozen
+// memtables and runs compactions until Close.
+type Engine struct {
+	cfg Config
+
+	mu        sync.RWMutex
+	nextID    int
+	dimension int        // 0 until the first insert
+	memtable  *segment   // Takes new inserts
+	frozen    []*segment // Full memtables waiting to be sealed, oldest first
+	segments  []*segment // Sealed segments
+	busy      bool       // The worker is sealing or compacting
+	err       error      // First background failure, reported by Flush
+	closed    bool
+	wake      *sync.Cond // Signals the worker: new frozen memtable or Close
+	idle      *sync.Cond // Signals Flush: the worker ran out of work
+	done      chan struct{}
+}
+
+// New returns an empty engine and starts its background worker
+func New(cfg Config) (*Engine, error) {
+	cfg, err := cfg.withDefaults()
+	if err != nil {
+		return nil, err
+	}
+	// Catch segment settings the indexes reject now, not in the worker
+	if _, _, _, err := buildSegment(cfg, nil); err != nil {
+		return nil, err
+	}
+	memtable, err := newMemtable(cfg, cfg.MemtableSize)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &Engine{cfg: cfg, memtable: memtable, done: make(chan struct{})}
+	e.wake = sync.NewCond(&e.mu)
+	e.idle = sync.NewCond(&e.mu)
+	go e.run()
+	return e, nil
+}
+
+// Insert adds v to the memtable and returns its ID
+func (e *Engine) Insert(v vector.Vector) (int, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.closed {
+		return 0, ErrClosed
+	}
+	if e.dimension != 0 && v.Dimension() != e.dimension {
+		return 0, fmt.Errorf("dimension mismatch: expected %d, got %d", e.dimension, v.Dimension())
+	}
+	if err := e.memtable.idx.Add(v); err != nil {
+		return 0, err
+	}
+	e.dimension = v.Dimension()
+
+	id := e.nextID
+	e.nextID++
+	e.memtable.ids = append(e.memtable.ids, id)
+	e.memtable.vectors = append(e.memtable.vectors, v.Clone())
+	if len(e.memtable.ids) == e.cfg.MemtableSize {
+		e.freeze()
+	}
+	return id, nil
+}
+
+// Add inserts v, so the engine satisfies index.Index; use Insert to learn
+// the new vector's ID
+func (e *Engine) Add(v vector.Vector) error {
+	_, err := e.Insert(v)
+	return err
+}
+
+// freeze hands a non-empty memtable to the worker; e.mu must be held
+func (e *Engine) freeze() {
+	if len(e.memtable.ids) == 0 {
+		return
+	}
+	e.frozen = append(e.frozen, e.memtable)
+	// Only the metric can fail, and New checked it
+	e.memtable, _ = newMemtable(e.cfg, e.cfg.MemtableSize)
+	e.wake.Signal()
+}
+
+// Delete removes the vector with the given ID
+// The vector stays in its segment, hidden by the deletion bitmap, until a
+// compaction rewrites the segment.
+func (e *Engine) Delete(id int) error {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if e.closed {
+		return ErrClosed
+	}
+	for _, s := range e.sources() {
+		if pos, ok := s.find(id); ok {
+			if !s.deleted.set(pos) {
+				break
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %d", ErrNotFound, id)
+}
+
+// sources returns every searchable segment; e.mu must be held
+// The memtable's ids slice is cut to its current length, so later inserts
+// stay invisible to a search that already started.
+func (e *Engine) sources() []*segment {
+	out := make([]*segment, 0, 1+len(e.frozen)+len(e.segments))
+	m := *e.memtable
+	m.ids = m.ids[:len(m.ids):len(m.ids)]
+	out = append(out, &m)
+	out = append(out, e.frozen...)
+	return append(out, e.segments...)
+}
+
+// Search returns the k nearest live vectors with default options
+func (e *Engine) Search(query vector.Vector, k int) ([]index.SearchResult, error) {
+	return e.SearchWithOptions(query, k, index.DefaultSearchOptions())
+}
+
+// SearchWithOptions searches the memtable and every segment concurrently
+// and merges their results
+// NProbe is capped at each IVF segment's list count, and Filter sees the
+// engine's IDs. Stats sums the work of every segment; the scan phase covers
+// the concurrent fan-out and the select phase the merge.
+func (e *Engine) SearchWithOptions(query vector.Vector, k int, opts index.SearchOptions) ([]index.SearchResult, error) {
+	tr := opts.StartTrace("lsm.Search")
+	results, err := e.search(query, k, opts, &tr)
+	tr.End(err)
+	return results, err
+}
+
+func (e *Engine) search(query vector.Vector, k int, opts index.SearchOptions, tr *index.Trace) ([]index.SearchResult, error) {
+	if err := query.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid query: %w", err)
+	}
+	if k <= 0 {
+		return nil, fmt.Errorf("k must be positive, got %d", k)
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid search options: %w", err)
+	}
+
+	e.mu.RLock()
+	if e.closed {
+		e.mu.RUnlock()
+		return nil, ErrClosed
+	}
+	if e.dimension != 0 && query.Dimension() != e.dimension {
+		e.mu.RUnlock()
+		return nil, fmt.Errorf("query dimension mismatch: expected %d, got %d", e.dimension, query.Dimension())
+	}
+	sources := e.sources()
+	e.mu.RUnlock()
+
+	tr.Phase(index.PhaseScan)
+	type part struct {
+		results []index.SearchResult
+		stats   index.SearchStats
+		err     error
+	}
+	parts := make([]part, len(sources))
+	var wg sync.WaitGroup
+	for i, s := range sources {
+		if s.live() == 0 {
+			continue
+		}
+		wg.Go(func() {
+			sub := opts
+			sub.Tracer, sub.Stats = nil, &parts[i].stats
+			if s.nlist > 0 {
+				sub.NProbe = min(sub.NProbe, s.nlist)
+			}
+			if s.kind == "hnsw" {
+				// A beam narrower than k cannot return k live results
+				sub.EfSearch = max(k, opts.EfSearch, e.cfg.EfSearch)
+			}
+			ids, deleted := s.ids, s.deleted
+			sub.Filter = func(local int) bool {
+				return local < len(ids) && !deleted.test(local) && opts.Accept(ids[local])
+			}
+			parts[i].results, parts[i].err = s.idx.SearchWithOptions(query, k, sub)
+		})
+	}
+	wg.Wait()
+
+	tr.Phase(index.PhaseSelect)
+	stats := tr.Stats()
+	best := topk.New[index.SearchResult](k)
+	for i, p := range parts {
+		if p.err != nil {
+			return nil, fmt.Errorf("%s segment: %w", sources[i].kind, p.err)
+		}
+		stats.DistanceComputations += p.stats.DistanceComputations
+		stats.NodesVisited += p.stats.NodesVisited
+		stats.ClustersProbed += p.stats.ClustersProbed
+		stats.CandidatesScanned += p.stats.CandidatesScanned
+		for _, r := range p.results {
+			r.Index = sources[i].ids[r.Index]
+			best.Push(r, r.Distance)
+		}
+	}
+
+	items := best.Sorted()
+	results := make([]index.SearchResult, len(items))
+	for i, item := range items {
+		results[i] = item.Value
+	}
+	return results, nil
+}
+
+// Size returns the number of live vectors
+func (e *Engine) Size() int {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	n := 0
+	for _, s := range e.sources() {
+		n += s.live()
+	}
+	return n
+}
+
+// Flush seals the memtable and waits until the worker has sealed every
+// frozen memtable and finished all due compactions
+// It returns the first background error, if any; a memtable that failed
+// to seal stays searchable as a flat segment.
+func (e *Engine) Flush() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.closed {
+		return ErrClosed
+	}
+	e.freeze()
+	e.wake.Signal() // Deletes may have made a compaction due
+	for !e.closed && (e.busy || len(e.frozen) > 0 || e.pickCompaction() != nil) {
+		e.idle.Wait()
+	}
+	if e.closed {
+		return ErrClosed
+	}
+	return e.err
+}
+
+// Close stops the worker, waiting for a running build to finish
+// Vectors still in memtables are discarded along with the segments.
+func (e *Engine) Close() error {
+	e.mu.Lock()
+	if e.closed {
+		e.mu.Unlock()
+		return ErrClosed
+	}
+	e.closed = true
+	e.wake.Broadcast()
+	e.idle.Broadcast()
+	e.mu.Unlock()
+
+	<-e.done
+	return nil
+}
+
+// Stats describes the engine's layout
+type Stats struct {
+	Memtable int            // Vectors in the memtable
+	Frozen   int            // Memtables waiting to be sealed
+	Segments []SegmentStats // Sealed segments
+	Live     int            // Vectors searchable
+	Deleted  int            // Deleted vectors not yet compacted away
+}
+
+// SegmentStats describes one sealed segment
+type SegmentStats struct {
+	Kind    string // flat | ivf | hnsw
+	Size    int    // Vectors stored, deleted ones included
+	Deleted int
+	Tier    int // Size tier for compaction
+}
+
+// Stats returns a snapshot of the engine's layout
+func (e *Engine) Stats() Stats {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	st := Stats{Memtable: len(e.memtable.ids), Frozen: len(e.frozen)}
+	for _, s := range e.sources() {
+		st.Live += s.live()
+		st.Deleted += s.deleted.len()
+	}
+	for _, s := range e.segments {
+		st.Segments = append(st.Segments, SegmentStats{
+			Kind:    s.kind,
+			Size:    len(s.ids),
+			Deleted: s.deleted.len(),
+			Tier:    tier(s.live(), e.cfg.MemtableSize, e.cfg.CompactionFanIn),
+		})
+	}
+	return st
+}
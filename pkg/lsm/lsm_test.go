@@ -0,0 +1,354 @@
+package lsm
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/tmdgusya/database-class/pkg/distance"
+	"github.com/tmdgusya/database-class/pkg/index"
+	"github.com/tmdgusya/database-class/pkg/testdata"
+	"github.com/tmdgusya/database-class/pkg/vector"
+)
+
+func newEngine(t *testing.T, cfg Config) *Engine {
+	t.Helper()
+	cfg.Metric = distance.L2Distance
+	e, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	t.Cleanup(func() { e.Close() })
+	return e
+}
+
+func insertAll(t *testing.T, e *Engine, vectors []vector.Vector) {
+	t.Helper()
+	for i, v := range vectors {
+		id, err := e.Insert(v)
+		if err != nil {
+			t.Fatalf("Insert(%d) failed: %v", i, err)
+		}
+		if id != i {
+			t.Fatalf("Insert(%d) returned ID %d", i, id)
+		}
+	}
+}
+
+// bruteForce returns the IDs of the k nearest vectors not in deleted
+func bruteForce(vectors []vector.Vector, deleted map[int]bool, query vector.Vector, k int) []int {
+	var ids []int
+	dists := make(map[int]float64)
+	for id, v := range vectors {
+		if !deleted[id] {
+			ids = append(ids, id)
+			dists[id], _ = distance.L2Distance(query, v)
+		}
+	}
+	sort.SliceStable(ids, func(a, b int) bool { return dists[ids[a]] < dists[ids[b]] })
+	return ids[:min(k, len(ids))]
+}
+
+func resultIDs(results []index.SearchResult) []int {
+	ids := make([]int, len(results))
+	for i, r := range results {
+		ids[i] = r.Index
+	}
+	return ids
+}
+
+func TestSearchAcrossMemtableAndSegments(t *testing.T) {
+	e := newEngine(t, Config{MemtableSize: 50, Segment: "flat", CompactionFanIn: 100})
+	vectors := testdata.GenerateRandomVectors(230, 8, 1)
+	insertAll(t, e, vectors)
+	if err := e.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	more := testdata.GenerateRandomVectors(20, 8, 2)
+	for _, v := range more {
+		e.Insert(v)
+	}
+	vectors = append(vectors, more...)
+
+	st := e.Stats()
+	if len(st.Segments) != 5 || st.Memtable != 20 || st.Live != 250 {
+		t.Fatalf("Stats() = %+v, want 5 segments and 20 memtable vectors", st)
+	}
+
+	deleted := map[int]bool{}
+	for _, id := range []int{3, 49, 50, 120, 229, 240} {
+		if err := e.Delete(id); err != nil {
+			t.Fatalf("Delete(%d) failed: %v", id, err)
+		}
+		deleted[id] = true
+	}
+	if err := e.Delete(3); !errors.Is(err, ErrNotFound) {
+		t.Errorf("second Delete(3): got %v, want ErrNotFound", err)
+	}
+	if err := e.Delete(1000); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Delete(1000): got %v, want ErrNotFound", err)
+	}
+	if e.Size() != 244 {
+		t.Errorf("Size() = %d, want 244", e.Size())
+	}
+
+	for _, q := range testdata.GenerateRandomVectors(20, 8, 3) {
+		results, err := e.Search(q, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, want := resultIDs(results), bruteForce(vectors, deleted, q, 10)
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("Search() = %v, want %v", got, want)
+			}
+		}
+		for _, r := range results {
+			if !r.Vector.Equal(vectors[r.Index], 1e-12) {
+				t.Fatalf("result %d carries the wrong vector", r.Index)
+			}
+		}
+	}
+
+	// A deleted vector is not found even by an exact query
+	results, _ := e.Search(vectors[120], 1)
+	if results[0].Index == 120 {
+		t.Error("deleted vector returned")
+	}
+}
+
+func TestSearchOptions(t *testing.T) {
+	e := newEngine(t, Config{MemtableSize: 40, Segment: "flat"})
+	vectors := testdata.GenerateRandomVectors(100, 4, 4)
+	insertAll(t, e, vectors)
+
+	var stats index.SearchStats
+	results, err := e.SearchWithOptions(vectors[0], 5, index.SearchOptions{
+		Filter: func(id int) bool { return id%2 == 1 },
+		Stats:  &stats,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range results {
+		if r.Index%2 == 0 {
+			t.Errorf("filtered ID %d returned", r.Index)
+		}
+		if r.Vector != nil {
+			t.Error("vector returned without IncludeVectors")
+		}
+	}
+	if stats.CandidatesScanned != 50 || stats.PhaseDuration(index.PhaseScan) == 0 {
+		t.Errorf("stats = %+v, want 50 candidates scanned", stats)
+	}
+
+	if _, err := e.Search(vector.Vector{1, 2}, 1); err == nil {
+		t.Error("dimension mismatch not rejected")
+	}
+	if _, err := e.Insert(vector.Vector{1, 2}); err == nil {
+		t.Error("Insert with the wrong dimension succeeded")
+	}
+	if _, err := e.Search(vectors[0], 0); err == nil {
+		t.Error("k = 0 not rejected")
+	}
+}
+
+func TestCompaction(t *testing.T) {
+	e := newEngine(t, Config{MemtableSize: 20, Segment: "flat", CompactionFanIn: 3})
+	vectors := testdata.GenerateRandomVectors(180, 4, 5)
+	insertAll(t, e, vectors)
+	if err := e.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 9 sealed memtables -> 3 tier-1 segments -> 1 tier-2 segment
+	st := e.Stats()
+	if len(st.Segments) != 1 || st.Segments[0].Size != 180 || st.Segments[0].Tier != 2 {
+		t.Fatalf("Stats() after compaction = %+v", st)
+	}
+
+	// Compaction drops deleted vectors and keeps IDs stable
+	deleted := map[int]bool{}
+	for id := 0; id < 180; id += 2 {
+		e.Delete(id)
+		deleted[id] = true
+	}
+	for _, v := range testdata.GenerateRandomVectors(20, 4, 6) {
+		e.Insert(v)
+		vectors = append(vectors, v)
+	}
+	if err := e.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	st = e.Stats()
+	if st.Live != 110 || st.Deleted != 90 {
+		t.Errorf("Stats() = %+v, want 110 live and 90 deleted", st)
+	}
+
+	for id := 1; id < 180; id += 2 {
+		e.Delete(id)
+		deleted[id] = true
+	}
+	if err := e.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	st = e.Stats()
+	if len(st.Segments) != 1 || st.Deleted != 0 || st.Live != 20 {
+		t.Errorf("empty segment not dropped: %+v", st)
+	}
+
+	q := testdata.GenerateRandomVectors(1, 4, 7)[0]
+	results, _ := e.Search(q, 5)
+	got, want := resultIDs(results), bruteForce(vectors, deleted, q, 5)
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Search() after compaction = %v, want %v", got, want)
+		}
+	}
+	if err := e.Delete(181); err != nil {
+		t.Errorf("Delete() after compaction: %v", err)
+	}
+}
+
+func TestApproximateSegments(t *testing.T) {
+	vectors := testdata.GenerateClusteredVectors(2000, 16, 10, 8)
+	queries := testdata.GenerateClusteredVectors(50, 16, 10, 9)
+
+	for _, kind := range []string{"ivf", "hnsw"} {
+		t.Run(kind, func(t *testing.T) {
+			// A fixed seed keeps the HNSW graphs, and so recall, the same every run
+			e := newEngine(t, Config{MemtableSize: 400, Segment: kind, NProbe: 8, M: 8, EfConstruction: 64, Seed: 1})
+			insertAll(t, e, vectors)
+			if err := e.Flush(); err != nil {
+				t.Fatal(err)
+			}
+			for _, s := range e.Stats().Segments {
+				if s.Kind != kind {
+					t.Errorf("segment kind = %s, want %s", s.Kind, kind)
+				}
+			}
+
+			hits := 0
+			for _, q := range queries {
+				results, err := e.Search(q, 10)
+				if err != nil {
+					t.Fatal(err)
+				}
+				want := bruteForce(vectors, nil, q, 10)
+				for _, id := range resultIDs(results) {
+					for _, w := range want {
+						if id == w {
+							hits++
+						}
+					}
+				}
+			}
+			if recall := float64(hits) / float64(10*len(queries)); recall < 0.9 {
+				t.Errorf("recall = %.2f, want >= 0.9", recall)
+			}
+		})
+	}
+}
+
+func TestHNSWSegmentsWideK(t *testing.T) {
+	// k is above the default efSearch of 64; each segment must still return
+	// every live point it holds
+	e := newEngine(t, Config{MemtableSize: 100, Segment: "hnsw", M: 8, EfConstruction: 32, Seed: 1})
+	vectors := testdata.GenerateRandomVectors(300, 8, 12)
+	insertAll(t, e, vectors)
+	for id := 0; id < len(vectors); id += 3 {
+		if err := e.Delete(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := e.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := e.Search(vectors[1], 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 100 {
+		t.Fatalf("got %d results, want 100", len(results))
+	}
+	for _, r := range results {
+		if r.Index%3 == 0 {
+			t.Errorf("deleted ID %d returned", r.Index)
+		}
+	}
+}
+
+func TestConcurrentWrites(t *testing.T) {
+	e := newEngine(t, Config{MemtableSize: 32, Segment: "hnsw", M: 4, EfConstruction: 16, CompactionFanIn: 2})
+	vectors := testdata.GenerateRandomVectors(600, 8, 10)
+
+	var wg sync.WaitGroup
+	for w := range 4 {
+		wg.Go(func() {
+			for i := w; i < len(vectors); i += 4 {
+				id, err := e.Insert(vectors[i])
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if id%3 == 0 {
+					if err := e.Delete(id); err != nil {
+						t.Error(err)
+						return
+					}
+				}
+				if _, err := e.Search(vectors[i], 5); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		})
+	}
+	wg.Wait()
+	if err := e.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if e.Size() != 400 {
+		t.Errorf("Size() = %d, want 400", e.Size())
+	}
+	results, _ := e.SearchWithOptions(vectors[0], 50, index.SearchOptions{EfSearch: 200})
+	for _, r := range results {
+		if r.Index%3 == 0 {
+			t.Errorf("deleted ID %d returned", r.Index)
+		}
+	}
+}
+
+func TestClose(t *testing.T) {
+	e := newEngine(t, Config{MemtableSize: 10})
+	insertAll(t, e, testdata.GenerateRandomVectors(25, 4, 11))
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.Insert(vector.Vector{1, 2, 3, 4}); !errors.Is(err, ErrClosed) {
+		t.Errorf("Insert after Close: %v", err)
+	}
+	if _, err := e.Search(vector.Vector{1, 2, 3, 4}, 1); !errors.Is(err, ErrClosed) {
+		t.Errorf("Search after Close: %v", err)
+	}
+	if err := e.Flush(); !errors.Is(err, ErrClosed) {
+		t.Errorf("Flush after Close: %v", err)
+	}
+}
+
+func TestConfigErrors(t *testing.T) {
+	for _, cfg := range []Config{
+		{},
+		{Metric: distance.L2Distance, Segment: "lsh"},
+		{Metric: distance.L2Distance, MemtableSize: -1},
+		{Metric: distance.L2Distance, CompactionFanIn: 1},
+		{Metric: distance.L2Distance, Segment: "hnsw", M: 16, EfConstruction: 8},
+	} {
+		if e, err := New(cfg); err == nil {
+			e.Close()
+			t.Errorf("New(%+v) succeeded", cfg)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package lsm
+
+import (
+	"math"
+	"math/bits"
+	"sort"
+	"sync/atomic"
+
+	flat "github.com/tmdgusya/database-class/01-flat/solution"
+	ivf "github.com/tmdgusya/database-class/02-ivf/solution"
+	hnsw "github.com/tmdgusya/database-class/03-hnsw/solution"
+	"github.com/tmdgusya/database-class/pkg/index"
+	"github.com/tmdgusya/database-class/pkg/vector"
+)
+
+// bitmap marks deleted positions of a segment
+// Bits are set atomically, so deletes need only the engine's read lock and
+// searches can test bits while a delete runs. Its size is fixed at creation.
+type bitmap struct {
+	words []atomic.Uint64
+	count atomic.Int64 // Bits set
+}
+
+func newBitmap(n int) *bitmap {
+	return &bitmap{words: make([]atomic.Uint64, (n+63)/64)}
+}
+
+// set marks position i and reports whether it was clear
+func (b *bitmap) set(i int) bool {
+	w, mask := &b.words[i/64], uint64(1)<<(i%64)
+	for {
+		old := w.Load()
+		if old&mask != 0 {
+			return false
+		}
+		if w.CompareAndSwap(old, old|mask) {
+			b.count.Add(1)
+			return true
+		}
+	}
+}
+
+func (b *bitmap) test(i int) bool {
+	return b.words[i/64].Load()&(uint64(1)<<(i%64)) != 0
+}
+
+func (b *bitmap) len() int {
+	return int(b.count.Load())
+}
+
+// segment is one searchable unit: the memtable, a memtable being sealed,
+// or a sealed segment
+// ids maps positions to global IDs in ascending order, so Delete can find a
+// vector by binary search. vectors keeps the inputs because the indexes
+// cannot hand them back, and compaction needs them to rebuild. Only the
+// memtable grows; every other segment is immutable apart from its bitmap.
+type segment struct {
+	kind    string // memtable | flat | ivf | hnsw
+	idx     index.OptionSearcher
+	nlist   int // IVF lists, to cap NProbe; 0 otherwise
+	ids     []int
+	vectors []vector.Vector
+	deleted *bitmap
+}
+
+// live returns the vectors not deleted
+func (s *segment) live() int {
+	return len(s.ids) - s.deleted.len()
+}
+
+// find returns the position of global ID id
+func (s *segment) find(id int) (int, bool) {
+	i := sort.SearchInts(s.ids, id)
+	return i, i < len(s.ids) && s.ids[i] == id
+}
+
+// newMemtable returns an empty memtable that holds up to size vectors
+func newMemtable(cfg Config, size int) (*segment, error) {
+	idx, err := flat.NewFlatIndex(flat.Config{Metric: cfg.Metric})
+	if err != nil {
+		return nil, err
+	}
+	return &segment{
+		kind:    "memtable",
+		idx:     idx,
+		ids:     make([]int, 0, size),
+		vectors: make([]vector.Vector, 0, size),
+		deleted: newBitmap(size),
+	}, nil
+}
+
+// buildSegment builds the sealed index for vectors
+// It returns the index's kind, which falls back to flat for segments too
+// small to train IVF on.
+func buildSegment(cfg Config, vectors []vector.Vector) (index.OptionSearcher, string, int, error) {
+	kind := cfg.Segment
+	nlist := 0
+	if kind == "ivf" {
+		nlist = segmentNList(cfg, len(vectors))
+		if nlist < 2 {
+			kind = "flat"
+		}
+	}
+
+	var idx index.OptionSearcher
+	var err error
+	switch kind {
+	case "ivf":
+		nprobe := cfg.NProbe
+		if nprobe == 0 {
+			nprobe = max(1, nlist/4)
+		}
+		var ivfIdx *ivf.IVFIndex
+		if ivfIdx, err = ivf.NewIVFIndex(ivf.Config{Metric: cfg.Metric, NumClusters: nlist, NumProbes: min(nprobe, nlist)}); err == nil {
+			err = ivfIdx.Train(vectors)
+		}
+		idx = ivfIdx
+	case "hnsw":
+		idx, err = hnsw.NewHNSWIndex(hnsw.Config{
+			Metric:         cfg.Metric,
+			M:              cfg.M,
+			EfConstruction: cfg.EfConstruction,
+			EfSearch:       cfg.EfSearch,
+			Seed:           cfg.Seed,
+		})
+	default:
+		idx, err = flat.NewFlatIndex(flat.Config{Metric: cfg.Metric})
+	}
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	for _, v := range vectors {
+		if err := idx.Add(v); err != nil {
+			return nil, "", 0, err
+		}
+	}
+	if kind != "ivf" {
+		nlist = 0
+	}
+	return idx, kind, nlist, nil
+}
+
+// segmentNList picks the IVF list count for a segment of n vectors
+// Config.NList if set, otherwise about 4√n with at least 39 training points
+// per list; never more lists than vectors.
+func segmentNList(cfg Config, n int) int {
+	if cfg.NList > 0 {
+		return min(cfg.NList, n)
+	}
+	return min(int(4*math.Sqrt(float64(n))), n/39)
+}
+
+// tier is a segment's size class for compaction: segments of up to
+// memtableSize live vectors are tier 0, up to memtableSize*fanIn tier 1, and
+// so on. Merging fanIn segments of one tier yields a segment of the next.
+func tier(live, memtableSize, fanIn int) int {
+	t := 0
+	for size := memtableSize; live > size && t < bits.UintSize; size *= fanIn {
+		t++
+	}
+	return t
+}
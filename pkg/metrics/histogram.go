@@ -0,0 +1,147 @@
+package metrics
+
+import (
+	"math"
+	"math/bits"
+	"time"
+)
+
+// Histogram records latencies in HDR-style log-linear buckets
+// Every power of two is split into histogramSubBuckets/2 equal buckets, so a
+// recorded value is off by less than 1% however large it is, and the whole
+// int64 nanosecond range fits in a fixed ~29 KB array. Recording is O(1) and
+// never allocates, which keeps the recorder out of the latencies it measures.
+//
+// A Histogram is not safe for concurrent use: give each goroutine its own
+// and Merge them afterwards.
+type Histogram struct {
+	counts [histogramBuckets]uint64
+	total  uint64
+	sum    float64 // Nanoseconds, for Mean
+	min    int64
+	max    int64
+}
+
+const (
+	histogramPrecision  = 7 // Bits of precision per power of two
+	histogramSubBuckets = 1 << histogramPrecision
+	histogramHalf       = histogramSubBuckets / 2
+	histogramBuckets    = (63-histogramPrecision)*histogramHalf + histogramSubBuckets
+)
+
+// NewHistogram returns an empty histogram
+func NewHistogram() *Histogram {
+	h := &Histogram{}
+	h.Reset()
+	return h
+}
+
+// Record adds one latency; negative values are recorded as 0
+func (h *Histogram) Record(d time.Duration) {
+	v := int64(d)
+	if v < 0 {
+		v = 0
+	}
+	h.counts[bucketOf(v)]++
+	h.total++
+	h.sum += float64(v)
+	if v < h.min {
+		h.min = v
+	}
+	if v > h.max {
+		h.max = v
+	}
+}
+
+// Count returns the number of recorded values
+func (h *Histogram) Count() uint64 {
+	return h.total
+}
+
+// Min returns the smallest recorded value (0 if empty)
+func (h *Histogram) Min() time.Duration {
+	if h.total == 0 {
+		return 0
+	}
+	return time.Duration(h.min)
+}
+
+// Max returns the largest recorded value (0 if empty)
+func (h *Histogram) Max() time.Duration {
+	return time.Duration(h.max)
+}
+
+// Mean returns the exact mean of the recorded values
+func (h *Histogram) Mean() time.Duration {
+	if h.total == 0 {
+		return 0
+	}
+	return time.Duration(h.sum / float64(h.total))
+}
+
+// Quantile returns the value below which a fraction q of recordings fall,
+// e.g. 0.99 for p99
+// The result is the midpoint of the bucket holding that rank, clamped to
+// the observed min and max.
+func (h *Histogram) Quantile(q float64) time.Duration {
+	if h.total == 0 {
+		return 0
+	}
+	q = math.Max(0, math.Min(1, q))
+	rank := uint64(math.Ceil(q * float64(h.total)))
+	if rank == 0 {
+		rank = 1
+	}
+
+	var seen uint64
+	for i, c := range h.counts {
+		seen += c
+		if seen >= rank {
+			lo, hi := bucketBounds(i)
+			mid := lo + (hi-lo)/2
+			return time.Duration(max(h.min, min(h.max, mid)))
+		}
+	}
+	return time.Duration(h.max)
+}
+
+// Merge adds every recording in other to h
+func (h *Histogram) Merge(other *Histogram) {
+	if other.total == 0 {
+		return
+	}
+	for i, c := range other.counts {
+		h.counts[i] += c
+	}
+	h.total += other.total
+	h.sum += other.sum
+	h.min = min(h.min, other.min)
+	h.max = max(h.max, other.max)
+}
+
+// Reset empties the histogram
+func (h *Histogram) Reset() {
+	*h = Histogram{min: math.MaxInt64}
+}
+
+// bucketOf maps a non-negative value to its bucket
+// Values below histogramSubBuckets get a bucket each; above that the top
+// histogramPrecision bits select the bucket within the value's power of two.
+func bucketOf(v int64) int {
+	if v < histogramSubBuckets {
+		return int(v)
+	}
+	shift := bits.Len64(uint64(v)) - histogramPrecision
+	return shift*histogramHalf + int(v>>shift)
+}
+
+// bucketBounds returns the half-open value range [lo, hi) of bucket i
+func bucketBounds(i int) (lo, hi int64) {
+	if i < histogramSubBuckets {
+		return int64(i), int64(i) + 1
+	}
+	shift := i/histogramHalf - 1
+	sub := int64(i - shift*histogramHalf)
+	lo = sub << shift
+	return lo, lo + 1<<shift
+}
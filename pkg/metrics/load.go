@@ -0,0 +1,205 @@
+package metrics
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/tmdgusya/database-class/pkg/index"
+	"github.com/tmdgusya/database-class/pkg/vector"
+)
+
+// LoadConfig describes a mixed read/write workload
+//
+// With a positive rate the generator is open-loop: operations are scheduled
+// at fixed intervals whether or not earlier ones have finished, and latency
+// is measured from the scheduled start. A stalled index therefore shows up
+// as queueing delay in the tail instead of silently lowering the request
+// rate (coordinated omission). A zero rate runs closed-loop, each goroutine
+// issuing its next operation as soon as the previous one returns; that
+// measures peak throughput but understates tail latency under contention.
+type LoadConfig struct {
+	Readers   int           // Goroutines calling Search
+	Writers   int           // Goroutines calling Add
+	ReadRate  float64       // Target searches per second across all readers (0 = closed-loop)
+	WriteRate float64       // Target adds per second across all writers (0 = closed-loop)
+	Duration  time.Duration // How long to schedule operations for
+	K         int           // Neighbours per search
+}
+
+// OperationStats summarizes one operation type
+type OperationStats struct {
+	Operations uint64        // Operations completed
+	QPS        float64       // Completed operations per second of wall time
+	Mean       time.Duration // Mean latency
+	P50        time.Duration
+	P95        time.Duration
+	P99        time.Duration
+	P999       time.Duration
+	Max        time.Duration
+	Histogram  *Histogram // All latencies, for further quantiles
+}
+
+// LoadResult holds the outcome of RunLoad
+type LoadResult struct {
+	Duration time.Duration  // Wall time until every goroutine finished
+	Reads    OperationStats // Search latencies
+	Writes   OperationStats // Add latencies
+}
+
+// RunLoad runs cfg.Readers goroutines searching idx with queries and
+// cfg.Writers goroutines adding inserts to it, all at once, for cfg.Duration
+// Readers cycle through queries and writers through inserts, so the index
+// keeps growing while it is searched. The first failing operation stops the
+// run and its error is returned. idx must be safe for concurrent use, as
+// every index in this repository is.
+func RunLoad(idx index.Index, queries, inserts []vector.Vector, cfg LoadConfig) (*LoadResult, error) {
+	if cfg.Readers < 0 || cfg.Writers < 0 || cfg.Readers+cfg.Writers == 0 {
+		return nil, fmt.Errorf("need at least one reader or writer, got %d readers and %d writers",
+			cfg.Readers, cfg.Writers)
+	}
+	if cfg.ReadRate < 0 || cfg.WriteRate < 0 {
+		return nil, fmt.Errorf("rates must not be negative")
+	}
+	if cfg.Duration <= 0 {
+		return nil, fmt.Errorf("duration must be positive, got %v", cfg.Duration)
+	}
+	if cfg.Readers > 0 {
+		if len(queries) == 0 {
+			return nil, fmt.Errorf("readers need queries")
+		}
+		if cfg.K <= 0 {
+			return nil, fmt.Errorf("k must be positive, got %d", cfg.K)
+		}
+	}
+	if cfg.Writers > 0 && len(inserts) == 0 {
+		return nil, fmt.Errorf("writers need vectors to insert")
+	}
+
+	var (
+		wg       sync.WaitGroup
+		failed   atomic.Bool
+		errOnce  sync.Once
+		firstErr error
+		nextRead atomic.Int64 // Shared cursors so goroutines spread over the inputs
+		nextAdd  atomic.Int64
+	)
+	fail := func(err error) {
+		errOnce.Do(func() { firstErr = err })
+		failed.Store(true)
+	}
+
+	readHists := make([]*Histogram, cfg.Readers)
+	writeHists := make([]*Histogram, cfg.Writers)
+
+	start := time.Now()
+	deadline := start.Add(cfg.Duration)
+
+	for r := range readHists {
+		readHists[r] = NewHistogram()
+		wg.Add(1)
+		go func(h *Histogram, offset int) {
+			defer wg.Done()
+			runWorker(h, start, deadline, cfg.ReadRate, cfg.Readers, offset, &failed, func() error {
+				i := int(nextRead.Add(1)-1) % len(queries)
+				if _, err := idx.Search(queries[i], cfg.K); err != nil {
+					return fmt.Errorf("search failed at query %d: %w", i, err)
+				}
+				return nil
+			}, fail)
+		}(readHists[r], r)
+	}
+	for w := range writeHists {
+		writeHists[w] = NewHistogram()
+		wg.Add(1)
+		go func(h *Histogram, offset int) {
+			defer wg.Done()
+			runWorker(h, start, deadline, cfg.WriteRate, cfg.Writers, offset, &failed, func() error {
+				i := int(nextAdd.Add(1)-1) % len(inserts)
+				if err := idx.Add(inserts[i]); err != nil {
+					return fmt.Errorf("add failed at vector %d: %w", i, err)
+				}
+				return nil
+			}, fail)
+		}(writeHists[w], w)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	elapsed := time.Since(start)
+	return &LoadResult{
+		Duration: elapsed,
+		Reads:    summarize(readHists, elapsed),
+		Writes:   summarize(writeHists, elapsed),
+	}, nil
+}
+
+// runWorker issues op until the deadline, recording each latency in h
+// With a positive total rate, worker offset of n fires at
+// start + (offset + i*n) / rate, which interleaves the workers evenly.
+func runWorker(
+	h *Histogram,
+	start, deadline time.Time,
+	rate float64,
+	n, offset int,
+	failed *atomic.Bool,
+	op func() error,
+	fail func(error),
+) {
+	var interval time.Duration
+	if rate > 0 {
+		interval = time.Duration(float64(time.Second) / rate)
+	}
+
+	for i := 0; !failed.Load(); i++ {
+		var scheduled time.Time
+		if interval > 0 {
+			scheduled = start.Add(time.Duration(offset+i*n) * interval)
+			if !scheduled.Before(deadline) {
+				return
+			}
+			if wait := time.Until(scheduled); wait > 0 {
+				time.Sleep(wait)
+			}
+		} else {
+			scheduled = time.Now()
+			if !scheduled.Before(deadline) {
+				return
+			}
+		}
+
+		if err := op(); err != nil {
+			fail(err)
+			return
+		}
+		// From the scheduled start: time spent queued behind a slow
+		// operation counts, as it would for a real client
+		h.Record(time.Since(scheduled))
+	}
+}
+
+func summarize(hists []*Histogram, elapsed time.Duration) OperationStats {
+	merged := NewHistogram()
+	for _, h := range hists {
+		merged.Merge(h)
+	}
+
+	stats := OperationStats{
+		Operations: merged.Count(),
+		Mean:       merged.Mean(),
+		P50:        merged.Quantile(0.50),
+		P95:        merged.Quantile(0.95),
+		P99:        merged.Quantile(0.99),
+		P999:       merged.Quantile(0.999),
+		Max:        merged.Max(),
+		Histogram:  merged,
+	}
+	if elapsed > 0 {
+		stats.QPS = float64(stats.Operations) / elapsed.Seconds()
+	}
+	return stats
+}
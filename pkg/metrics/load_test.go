@@ -0,0 +1,175 @@
+package metrics
+
+import (
+	"errors"
+	"math/rand"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tmdgusya/database-class/pkg/index"
+	"github.com/tmdgusya/database-class/pkg/vector"
+)
+
+func TestHistogramQuantiles(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	h := NewHistogram()
+	values := make([]time.Duration, 10000)
+	for i := range values {
+		// Spread over several orders of magnitude, 1µs to ~1s
+		values[i] = time.Duration(float64(time.Microsecond) * (1 + rng.ExpFloat64()*1e4))
+		h.Record(values[i])
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+
+	if h.Count() != uint64(len(values)) {
+		t.Fatalf("Count() = %d, want %d", h.Count(), len(values))
+	}
+	if h.Min() != values[0] || h.Max() != values[len(values)-1] {
+		t.Errorf("Min/Max = %v/%v, want %v/%v", h.Min(), h.Max(), values[0], values[len(values)-1])
+	}
+	for _, q := range []float64{0.5, 0.95, 0.99, 0.999} {
+		want := values[int(q*float64(len(values)))-1]
+		got := h.Quantile(q)
+		if diff := float64(got-want) / float64(want); diff > 0.01 || diff < -0.01 {
+			t.Errorf("Quantile(%v) = %v, want %v within 1%%", q, got, want)
+		}
+	}
+}
+
+func TestHistogramBuckets(t *testing.T) {
+	if got := bucketOf(1<<63 - 1); got != histogramBuckets-1 {
+		t.Fatalf("largest value maps to bucket %d, want %d", got, histogramBuckets-1)
+	}
+
+	// Bucket ranges must tile the value space with no gaps or overlaps
+	// (the last bucket's upper bound, 2^63, does not fit in an int64)
+	var prevHi int64
+	for i := 0; i < histogramBuckets-1; i++ {
+		lo, hi := bucketBounds(i)
+		if lo != prevHi || hi <= lo {
+			t.Fatalf("bucket %d = [%d, %d), previous ended at %d", i, lo, hi, prevHi)
+		}
+		if bucketOf(lo) != i || bucketOf(hi-1) != i {
+			t.Fatalf("bucket %d bounds map to %d and %d", i, bucketOf(lo), bucketOf(hi-1))
+		}
+		prevHi = hi
+	}
+}
+
+func TestHistogramMerge(t *testing.T) {
+	a, b := NewHistogram(), NewHistogram()
+	for i := 1; i <= 100; i++ {
+		a.Record(time.Duration(i) * time.Millisecond)
+		b.Record(time.Duration(i+100) * time.Millisecond)
+	}
+	a.Merge(b)
+	a.Merge(NewHistogram())
+
+	if a.Count() != 200 || a.Min() != time.Millisecond || a.Max() != 200*time.Millisecond {
+		t.Errorf("merged count/min/max = %d/%v/%v", a.Count(), a.Min(), a.Max())
+	}
+	if mean := a.Mean(); mean < 100*time.Millisecond || mean > 101*time.Millisecond {
+		t.Errorf("merged mean = %v, want ~100.5ms", mean)
+	}
+}
+
+// sleepIndex takes a fixed time per operation and counts them
+type sleepIndex struct {
+	mu       sync.Mutex
+	delay    time.Duration
+	searches int
+	adds     int
+	failAt   int // Fail the n-th search (0 = never)
+}
+
+func (s *sleepIndex) Add(v vector.Vector) error {
+	time.Sleep(s.delay)
+	s.mu.Lock()
+	s.adds++
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *sleepIndex) Search(query vector.Vector, k int) ([]index.SearchResult, error) {
+	time.Sleep(s.delay)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.searches++
+	if s.searches == s.failAt {
+		return nil, errors.New("boom")
+	}
+	return nil, nil
+}
+
+func (s *sleepIndex) Size() int { return s.adds }
+
+func TestRunLoadOpenLoopRate(t *testing.T) {
+	idx := &sleepIndex{}
+	vectors := []vector.Vector{{1}, {2}}
+
+	result, err := RunLoad(idx, vectors, vectors, LoadConfig{
+		Readers: 2, Writers: 1,
+		ReadRate: 400, WriteRate: 100,
+		Duration: 250 * time.Millisecond,
+		K:        1,
+	})
+	if err != nil {
+		t.Fatalf("RunLoad() failed: %v", err)
+	}
+
+	// The schedule fixes the counts exactly: rate * duration
+	if result.Reads.Operations != 100 || result.Writes.Operations != 25 {
+		t.Errorf("operations = %d reads, %d writes; want 100 and 25",
+			result.Reads.Operations, result.Writes.Operations)
+	}
+	if idx.searches != 100 || idx.adds != 25 {
+		t.Errorf("index saw %d searches and %d adds", idx.searches, idx.adds)
+	}
+	if result.Reads.P50 > result.Reads.P99 || result.Reads.P99 > result.Reads.P999 {
+		t.Errorf("quantiles out of order: %+v", result.Reads)
+	}
+}
+
+func TestRunLoadCountsQueueing(t *testing.T) {
+	// Each search takes 5ms but one reader is asked for one every 1ms: an
+	// open-loop generator must report the growing backlog, not just 5ms
+	idx := &sleepIndex{delay: 5 * time.Millisecond}
+	result, err := RunLoad(idx, []vector.Vector{{1}}, nil, LoadConfig{
+		Readers: 1, ReadRate: 1000, Duration: 100 * time.Millisecond, K: 1,
+	})
+	if err != nil {
+		t.Fatalf("RunLoad() failed: %v", err)
+	}
+	if result.Reads.Max < 50*time.Millisecond {
+		t.Errorf("max latency = %v; queueing delay was not counted", result.Reads.Max)
+	}
+}
+
+func TestRunLoadClosedLoopAndErrors(t *testing.T) {
+	idx := &sleepIndex{}
+	result, err := RunLoad(idx, []vector.Vector{{1}}, nil, LoadConfig{
+		Readers: 2, Duration: 20 * time.Millisecond, K: 1,
+	})
+	if err != nil {
+		t.Fatalf("RunLoad() failed: %v", err)
+	}
+	if result.Reads.Operations == 0 || result.Reads.QPS <= 0 || result.Writes.Operations != 0 {
+		t.Errorf("closed-loop result = %+v / %+v", result.Reads, result.Writes)
+	}
+
+	failing := &sleepIndex{failAt: 3}
+	if _, err := RunLoad(failing, []vector.Vector{{1}}, nil, LoadConfig{
+		Readers: 1, Duration: time.Second, K: 1,
+	}); err == nil {
+		t.Error("expected the failing search to stop the run")
+	}
+
+	if _, err := RunLoad(idx, nil, nil, LoadConfig{Readers: 1, Duration: time.Second, K: 1}); err == nil {
+		t.Error("expected error for readers without queries")
+	}
+	if _, err := RunLoad(idx, nil, nil, LoadConfig{Duration: time.Second}); err == nil {
+		t.Error("expected error with no goroutines")
+	}
+}
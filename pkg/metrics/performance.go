@@ -1,10 +1,11 @@
-package performance
+package metrics
 
 import (
 	"fmt"
 	"runtime"
 	"time"
 
+	"github.com/tmdgusya/database-class/pkg/index"
 	"github.com/tmdgusya/database-class/pkg/vector"
 )
 
@@ -18,22 +19,9 @@ type LatencyResult struct {
 	Max    time.Duration // Maximum latency
 }
 
-// Index interface (same as in recall.go but defined here for independence)
-type Index interface {
-	Add(v vector.Vector) error
-	Search(query vector.Vector, k int) ([]SearchResult, error)
-	Size() int
-}
-
-type SearchResult struct {
-	Vector   vector.Vector
-	Distance float64
-	Index    int
-}
-
 // MeasureSearchLatency measures search latency for a set of queries
 func MeasureSearchLatency(
-	index Index,
+	idx index.Index,
 	queries []vector.Vector,
 	k int,
 ) (*LatencyResult, error) {
@@ -45,7 +33,7 @@ func MeasureSearchLatency(
 
 	for i, query := range queries {
 		start := time.Now()
-		_, err := index.Search(query, k)
+		_, err := idx.Search(query, k)
 		latencies[i] = time.Since(start)
 
 		if err != nil {
@@ -81,7 +69,7 @@ type ThroughputResult struct {
 
 // MeasureThroughput measures query throughput over a duration
 func MeasureThroughput(
-	index Index,
+	idx index.Index,
 	queries []vector.Vector,
 	k int,
 	duration time.Duration,
@@ -96,7 +84,7 @@ func MeasureThroughput(
 
 	for time.Since(start) < duration {
 		query := queries[queryIdx%len(queries)]
-		_, err := index.Search(query, k)
+		_, err := idx.Search(query, k)
 		if err != nil {
 			return nil, fmt.Errorf("search failed: %w", err)
 		}
@@ -139,7 +127,9 @@ func MeasureMemoryUsage() *MemoryStats {
 }
 
 // MeasureIndexMemory estimates memory used by building an index
-// Takes a snapshot before and after building
+// Takes a snapshot before and after building. The result is noisy and
+// includes anything else the build allocated; indexes implementing
+// index.MemoryReporter give an exact per-category figure instead.
 func MeasureIndexMemory(buildFunc func() error) (*MemoryStats, error) {
 	// Force GC before measurement
 	runtime.GC()
@@ -0,0 +1,292 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+)
+
+// Recall only asks whether the right IDs came back. The metrics below also
+// look at where they came back (MRR, NDCG, MAP) and how close the returned
+// neighbours are when they are not the exact ones (distance ratio,
+// tie-aware recall). All of them take the same [][]int results as
+// CalculateRecall; the distance-based ones also take per-result distances,
+// closest first, as returned by Search and testdata.ComputeNeighbors.
+
+// MeanReciprocalRank measures how high the true nearest neighbour ranks
+// For each query the score is 1/rank of groundTruth[i][0] within the first k
+// approximate results (0 if it is missing); the result is the mean.
+func MeanReciprocalRank(approxResults, groundTruth [][]int, k int) (float64, error) {
+	if err := checkRankingInput(approxResults, groundTruth, k); err != nil {
+		return 0, err
+	}
+
+	var total float64
+	for i := range approxResults {
+		if len(groundTruth[i]) == 0 {
+			return 0, fmt.Errorf("query %d has no ground truth", i)
+		}
+		nearest := groundTruth[i][0]
+		for rank, idx := range truncate(approxResults[i], k) {
+			if idx == nearest {
+				total += 1 / float64(rank+1)
+				break
+			}
+		}
+	}
+	return total / float64(len(approxResults)), nil
+}
+
+// NDCG computes normalized discounted cumulative gain at k
+// Relevance is graded by true rank: the true nearest neighbour is worth k,
+// the second k-1, down to 1 for the k-th; anything else is worth 0. Gains
+// are discounted by log2(position+1), so finding the right neighbours in the
+// wrong order scores below 1 while plain recall would not notice.
+func NDCG(approxResults, groundTruth [][]int, k int) (float64, error) {
+	if err := checkRankingInput(approxResults, groundTruth, k); err != nil {
+		return 0, err
+	}
+
+	var total float64
+	for i := range approxResults {
+		truth := truncate(groundTruth[i], k)
+		relevance := make(map[int]float64, len(truth))
+		for rank, idx := range truth {
+			relevance[idx] = float64(k - rank)
+		}
+
+		var dcg, idcg float64
+		for pos, idx := range truncate(approxResults[i], k) {
+			dcg += relevance[idx] / math.Log2(float64(pos+2))
+			delete(relevance, idx) // A duplicate ID earns nothing twice
+		}
+		for pos := range truth {
+			idcg += float64(k-pos) / math.Log2(float64(pos+2))
+		}
+		if idcg > 0 {
+			total += dcg / idcg
+		}
+	}
+	return total / float64(len(approxResults)), nil
+}
+
+// MeanAveragePrecision computes MAP@k
+// Average precision sums precision@i over every position i holding a true
+// neighbour and divides by the number of true neighbours, so hits near the
+// top count for more than hits near the bottom.
+func MeanAveragePrecision(approxResults, groundTruth [][]int, k int) (float64, error) {
+	if err := checkRankingInput(approxResults, groundTruth, k); err != nil {
+		return 0, err
+	}
+
+	var total float64
+	for i := range approxResults {
+		truth := truncate(groundTruth[i], k)
+		if len(truth) == 0 {
+			continue
+		}
+		truthSet := make(map[int]bool, len(truth))
+		for _, idx := range truth {
+			truthSet[idx] = true
+		}
+
+		hits := 0
+		var sum float64
+		for pos, idx := range truncate(approxResults[i], k) {
+			if truthSet[idx] {
+				hits++
+				sum += float64(hits) / float64(pos+1)
+				delete(truthSet, idx)
+			}
+		}
+		total += sum / float64(len(truth))
+	}
+	return total / float64(len(approxResults)), nil
+}
+
+// DistanceRatio compares the k-th approximate distance with the k-th true
+// distance, averaged over queries
+// 1.0 means the approximate k-th neighbour is as close as the real one; the
+// relative distance error is DistanceRatio - 1. Distances must be positive
+// (L2, cosine); for dot-product scores the ratio has no meaning. Queries that
+// returned fewer than k results have no k-th distance and are left out (their
+// misses already show in recall); if none returned k, the ratio is 0.
+func DistanceRatio(approxDistances, trueDistances [][]float64, k int) (float64, error) {
+	if err := checkDistanceInput(approxDistances, trueDistances, k); err != nil {
+		return 0, err
+	}
+
+	var total float64
+	counted := 0
+	for i := range approxDistances {
+		if len(approxDistances[i]) < k {
+			continue
+		}
+		approx, truth := approxDistances[i][k-1], trueDistances[i][k-1]
+		switch {
+		case approx == truth:
+			total++
+		case truth <= 0:
+			return 0, fmt.Errorf("query %d: true distance %v is not positive", i, truth)
+		default:
+			total += approx / truth
+		}
+		counted++
+	}
+	if counted == 0 {
+		return 0, nil
+	}
+	return total / float64(counted), nil
+}
+
+// TieAwareRecall is recall@k that also accepts results tied with the true
+// k-th neighbour
+// When several vectors sit at exactly the k-th distance, ground truth can
+// only list some of them, and plain recall counts an equally good pick as a
+// miss. Here a result is correct if its ID is in the true top k or its
+// distance is at most the true k-th distance plus epsilon.
+func TieAwareRecall(
+	approxResults, groundTruth [][]int,
+	approxDistances, trueDistances [][]float64,
+	k int,
+	epsilon float64,
+) (float64, error) {
+	if err := checkRankingInput(approxResults, groundTruth, k); err != nil {
+		return 0, err
+	}
+	if len(approxDistances) != len(approxResults) || len(trueDistances) != len(groundTruth) {
+		return 0, fmt.Errorf("distances do not match results: %d/%d approximate, %d/%d true",
+			len(approxDistances), len(approxResults), len(trueDistances), len(groundTruth))
+	}
+
+	var total float64
+	for i := range approxResults {
+		approx := truncate(approxResults[i], k)
+		if len(approxDistances[i]) < len(approx) {
+			return 0, fmt.Errorf("query %d: %d distances for %d results", i, len(approxDistances[i]), len(approx))
+		}
+		if len(trueDistances[i]) < k {
+			return 0, fmt.Errorf("query %d: %d true distances, need %d", i, len(trueDistances[i]), k)
+		}
+		radius := trueDistances[i][k-1] + epsilon
+
+		truthSet := make(map[int]bool, k)
+		for _, idx := range truncate(groundTruth[i], k) {
+			truthSet[idx] = true
+		}
+
+		matches := 0
+		seen := make(map[int]bool, len(approx))
+		for pos, idx := range approx {
+			if seen[idx] {
+				continue
+			}
+			seen[idx] = true
+			if truthSet[idx] || approxDistances[i][pos] <= radius {
+				matches++
+			}
+		}
+		total += float64(matches) / float64(k)
+	}
+	return total / float64(len(approxResults)), nil
+}
+
+// RankingResult bundles every quality metric for one set of results
+type RankingResult struct {
+	K              int
+	Recall         float64 // Set-overlap recall@k, as CalculateRecall
+	MRR            float64 // Mean reciprocal rank of the true nearest neighbour
+	NDCG           float64 // Normalized discounted cumulative gain@k
+	MAP            float64 // Mean average precision@k
+	TieAwareRecall float64 // Recall counting ties at the k-th distance (0 without distances)
+	DistanceRatio  float64 // Mean k-th distance ratio (0 without positive distances)
+}
+
+// EvaluateRanking computes every ranking metric at once
+// The distances are optional: pass nil for either and the distance-based
+// fields stay 0. DistanceRatio is also left at 0 when some true k-th
+// distance is not positive, as with dot-product scores.
+func EvaluateRanking(
+	approxResults, groundTruth [][]int,
+	approxDistances, trueDistances [][]float64,
+	k int,
+	epsilon float64,
+) (*RankingResult, error) {
+	var err error
+	r := &RankingResult{K: k}
+
+	if r.Recall, err = CalculateRecall(approxResults, groundTruth, k); err != nil {
+		return nil, err
+	}
+	if r.MRR, err = MeanReciprocalRank(approxResults, groundTruth, k); err != nil {
+		return nil, fmt.Errorf("MRR: %w", err)
+	}
+	if r.NDCG, err = NDCG(approxResults, groundTruth, k); err != nil {
+		return nil, fmt.Errorf("NDCG: %w", err)
+	}
+	if r.MAP, err = MeanAveragePrecision(approxResults, groundTruth, k); err != nil {
+		return nil, fmt.Errorf("MAP: %w", err)
+	}
+
+	if approxDistances == nil || trueDistances == nil {
+		return r, nil
+	}
+	if r.TieAwareRecall, err = TieAwareRecall(approxResults, groundTruth, approxDistances, trueDistances, k, epsilon); err != nil {
+		return nil, fmt.Errorf("tie-aware recall: %w", err)
+	}
+	if allPositive(trueDistances, k) {
+		if r.DistanceRatio, err = DistanceRatio(approxDistances, trueDistances, k); err != nil {
+			return nil, fmt.Errorf("distance ratio: %w", err)
+		}
+	}
+	return r, nil
+}
+
+func checkRankingInput(approxResults, groundTruth [][]int, k int) error {
+	if k <= 0 {
+		return fmt.Errorf("k must be positive, got %d", k)
+	}
+	if len(approxResults) == 0 || len(groundTruth) == 0 {
+		return fmt.Errorf("empty results or ground truth")
+	}
+	if len(approxResults) != len(groundTruth) {
+		return fmt.Errorf("mismatch: %d approximate results vs %d ground truth",
+			len(approxResults), len(groundTruth))
+	}
+	return nil
+}
+
+func checkDistanceInput(approxDistances, trueDistances [][]float64, k int) error {
+	if k <= 0 {
+		return fmt.Errorf("k must be positive, got %d", k)
+	}
+	if len(approxDistances) == 0 || len(trueDistances) == 0 {
+		return fmt.Errorf("empty distances")
+	}
+	if len(approxDistances) != len(trueDistances) {
+		return fmt.Errorf("mismatch: %d approximate distance rows vs %d true",
+			len(approxDistances), len(trueDistances))
+	}
+	for i := range trueDistances {
+		if len(trueDistances[i]) < k {
+			return fmt.Errorf("query %d: need %d true distances, got %d", i, k, len(trueDistances[i]))
+		}
+	}
+	return nil
+}
+
+// allPositive reports whether every row has a positive k-th distance
+func allPositive(distances [][]float64, k int) bool {
+	for _, row := range distances {
+		if len(row) < k || row[k-1] <= 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func truncate(ids []int, k int) []int {
+	if len(ids) > k {
+		return ids[:k]
+	}
+	return ids
+}
@@ -0,0 +1,153 @@
+package metrics
+
+import (
+	"math"
+	"testing"
+)
+
+func assertClose(t *testing.T, name string, got, want float64) {
+	t.Helper()
+	if math.Abs(got-want) > 1e-9 {
+		t.Errorf("%s = %v, want %v", name, got, want)
+	}
+}
+
+func TestMeanReciprocalRank(t *testing.T) {
+	truth := [][]int{{1, 2, 3}, {4, 5, 6}, {7, 8, 9}}
+	approx := [][]int{
+		{1, 2, 3},  // Nearest at rank 1
+		{5, 4, 6},  // Nearest at rank 2
+		{8, 9, 10}, // Nearest missing
+	}
+
+	got, err := MeanReciprocalRank(approx, truth, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertClose(t, "MRR", got, (1+0.5+0)/3)
+
+	// Beyond k the nearest neighbour does not count
+	got, err = MeanReciprocalRank([][]int{{5, 4}}, [][]int{{4, 5}}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertClose(t, "MRR@1", got, 0)
+}
+
+func TestNDCG(t *testing.T) {
+	truth := [][]int{{1, 2, 3}}
+
+	perfect, err := NDCG([][]int{{1, 2, 3}}, truth, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertClose(t, "NDCG(perfect)", perfect, 1)
+
+	// Same set, reversed: recall is 1 but NDCG must drop
+	reversed, err := NDCG([][]int{{3, 2, 1}}, truth, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idcg := 3/math.Log2(2) + 2/math.Log2(3) + 1/math.Log2(4)
+	dcg := 1/math.Log2(2) + 2/math.Log2(3) + 3/math.Log2(4)
+	assertClose(t, "NDCG(reversed)", reversed, dcg/idcg)
+	if reversed >= perfect {
+		t.Errorf("reversed NDCG %v should be below perfect %v", reversed, perfect)
+	}
+
+	// Duplicates earn nothing twice
+	dup, err := NDCG([][]int{{1, 1, 1}}, truth, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertClose(t, "NDCG(duplicates)", dup, 3/idcg)
+}
+
+func TestMeanAveragePrecision(t *testing.T) {
+	truth := [][]int{{1, 2, 3, 4}}
+	// Hits at positions 1 and 3: AP = (1/1 + 2/3) / 4
+	got, err := MeanAveragePrecision([][]int{{1, 9, 2, 8}}, truth, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertClose(t, "MAP", got, (1+2.0/3)/4)
+
+	if _, err := MeanAveragePrecision([][]int{{1}}, [][]int{{1}, {2}}, 1); err == nil {
+		t.Error("expected error for mismatched query counts")
+	}
+}
+
+func TestDistanceRatio(t *testing.T) {
+	approx := [][]float64{{1, 2, 3}, {1, 2, 4}, {0, 0, 0}}
+	truth := [][]float64{{1, 2, 3}, {1, 2, 2}, {0, 0, 0}}
+
+	got, err := DistanceRatio(approx, truth, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertClose(t, "DistanceRatio", got, (1+2+1)/3.0)
+
+	if _, err := DistanceRatio([][]float64{{1}}, [][]float64{{0}}, 1); err == nil {
+		t.Error("expected error for zero true distance")
+	}
+	if _, err := DistanceRatio([][]float64{{1}}, [][]float64{{1}}, 2); err == nil {
+		t.Error("expected error when rows are shorter than k")
+	}
+
+	// A query that came back short is left out rather than failing the rest
+	got, err = DistanceRatio([][]float64{{1, 3}, {1}}, [][]float64{{1, 2}, {1, 2}}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertClose(t, "DistanceRatio with a short row", got, 1.5)
+}
+
+func TestTieAwareRecall(t *testing.T) {
+	// Vectors 3 and 4 are both at the k-th distance; ground truth lists 3
+	truth := [][]int{{1, 2, 3}}
+	trueDist := [][]float64{{1, 2, 3}}
+	approx := [][]int{{1, 2, 4}}
+	approxDist := [][]float64{{1, 2, 3}}
+
+	plain, err := CalculateRecall(approx, truth, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertClose(t, "plain recall", plain, 2.0/3)
+
+	tied, err := TieAwareRecall(approx, truth, approxDist, trueDist, 3, 1e-9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertClose(t, "tie-aware recall", tied, 1)
+
+	// A genuinely farther result is still a miss
+	far, err := TieAwareRecall(approx, truth, [][]float64{{1, 2, 3.5}}, trueDist, 3, 1e-9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertClose(t, "tie-aware recall (far)", far, 2.0/3)
+}
+
+func TestEvaluateRanking(t *testing.T) {
+	truth := [][]int{{1, 2}}
+	approx := [][]int{{2, 1}}
+
+	r, err := EvaluateRanking(approx, truth, nil, nil, 2, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertClose(t, "Recall", r.Recall, 1)
+	assertClose(t, "MRR", r.MRR, 0.5)
+	if r.TieAwareRecall != 0 || r.DistanceRatio != 0 {
+		t.Errorf("distance metrics without distances = %+v", r)
+	}
+
+	// Negative (dot product) distances: tie-aware recall works, ratio is skipped
+	r, err = EvaluateRanking(approx, truth, [][]float64{{-5, -4}}, [][]float64{{-5, -4}}, 2, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertClose(t, "TieAwareRecall", r.TieAwareRecall, 1)
+	assertClose(t, "DistanceRatio", r.DistanceRatio, 0)
+}
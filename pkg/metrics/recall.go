@@ -3,24 +3,10 @@ package metrics
 import (
 	"fmt"
 
+	"github.com/tmdgusya/database-class/pkg/index"
 	"github.com/tmdgusya/database-class/pkg/vector"
 )
 
-// SearchResult represents a single search result
-type SearchResult struct {
-	Vector   vector.Vector
-	Distance float64
-	Index    int // Position in the original index
-}
-
-// Index interface for any vector index
-// All indexes (Flat, IVF, HNSW) must implement this
-type Index interface {
-	Add(v vector.Vector) error
-	Search(query vector.Vector, k int) ([]SearchResult, error)
-	Size() int
-}
-
 // CalculateRecall measures recall@k by comparing approximate results against ground truth
 // Recall = (number of correct results) / k
 // Returns average recall across all queries
@@ -176,7 +162,7 @@ func CalculateDetailedRecall(
 
 // ExtractIndices extracts index values from SearchResults
 // Helper function to convert SearchResult slices to index slices
-func ExtractIndices(results []SearchResult) []int {
+func ExtractIndices(results []index.SearchResult) []int {
 	indices := make([]int, len(results))
 	for i, r := range results {
 		indices[i] = r.Index
@@ -185,10 +171,49 @@ func ExtractIndices(results []SearchResult) []int {
 }
 
 // ExtractIndicesFromBatch extracts indices from a batch of search results
-func ExtractIndicesFromBatch(batch [][]SearchResult) [][]int {
+func ExtractIndicesFromBatch(batch [][]index.SearchResult) [][]int {
 	indices := make([][]int, len(batch))
 	for i, results := range batch {
 		indices[i] = ExtractIndices(results)
 	}
 	return indices
 }
+
+// SearchBatch runs every query against idx and returns the result IDs
+func SearchBatch(idx index.Index, queries []vector.Vector, k int) ([][]int, error) {
+	batch, _, err := SearchBatchWithDistances(idx, queries, k)
+	return batch, err
+}
+
+// SearchBatchWithDistances is SearchBatch that also returns each result's
+// distance, for DistanceRatio and TieAwareRecall
+func SearchBatchWithDistances(idx index.Index, queries []vector.Vector, k int) ([][]int, [][]float64, error) {
+	batch := make([][]int, len(queries))
+	distances := make([][]float64, len(queries))
+	for i, query := range queries {
+		results, err := idx.Search(query, k)
+		if err != nil {
+			return nil, nil, fmt.Errorf("search failed at query %d: %w", i, err)
+		}
+		batch[i] = ExtractIndices(results)
+		distances[i] = make([]float64, len(results))
+		for j, r := range results {
+			distances[i][j] = r.Distance
+		}
+	}
+	return batch, distances, nil
+}
+
+// CompareRecall measures recall@k of approx using exact (typically a flat
+// index over the same vectors, added in the same order) as ground truth
+func CompareRecall(approx, exact index.Index, queries []vector.Vector, k int) (float64, error) {
+	approxResults, err := SearchBatch(approx, queries, k)
+	if err != nil {
+		return 0, fmt.Errorf("approximate index: %w", err)
+	}
+	groundTruth, err := SearchBatch(exact, queries, k)
+	if err != nil {
+		return 0, fmt.Errorf("exact index: %w", err)
+	}
+	return CalculateRecall(approxResults, groundTruth, k)
+}
@@ -0,0 +1,70 @@
+package metrics
+
+import (
+	"math"
+	"testing"
+
+	"github.com/tmdgusya/database-class/pkg/index"
+	"github.com/tmdgusya/database-class/pkg/vector"
+)
+
+// lineIndex is a 1-D index whose result IDs are the k stored values closest
+// to the query's first component, optionally shifted to simulate misses
+type lineIndex struct {
+	values []float64
+	shift  int
+}
+
+func (l *lineIndex) Add(v vector.Vector) error {
+	l.values = append(l.values, v[0])
+	return nil
+}
+
+func (l *lineIndex) Search(query vector.Vector, k int) ([]index.SearchResult, error) {
+	results := make([]index.SearchResult, 0, k)
+	for i := 0; i < k && i+l.shift < len(l.values); i++ {
+		id := int(query[0]) + i + l.shift
+		results = append(results, index.SearchResult{
+			Distance: math.Abs(l.values[id] - query[0]),
+			Index:    id,
+		})
+	}
+	return results, nil
+}
+
+func (l *lineIndex) Size() int { return len(l.values) }
+
+func TestCompareRecall(t *testing.T) {
+	exact := &lineIndex{values: []float64{0, 1, 2, 3, 4, 5, 6, 7}}
+	approx := &lineIndex{values: exact.values, shift: 1}
+	queries := []vector.Vector{{0}, {2}}
+
+	recall, err := CompareRecall(exact, exact, queries, 4)
+	if err != nil {
+		t.Fatalf("CompareRecall() failed: %v", err)
+	}
+	if recall != 1 {
+		t.Errorf("recall of exact vs itself = %v, want 1", recall)
+	}
+
+	// Shifting by one loses one of the four true neighbours per query
+	recall, err = CompareRecall(approx, exact, queries, 4)
+	if err != nil {
+		t.Fatalf("CompareRecall() failed: %v", err)
+	}
+	if recall != 0.75 {
+		t.Errorf("recall = %v, want 0.75", recall)
+	}
+}
+
+func TestMeasureSearchLatencyAcceptsIndex(t *testing.T) {
+	var idx index.Index = &lineIndex{values: []float64{0, 1, 2, 3}}
+
+	result, err := MeasureSearchLatency(idx, []vector.Vector{{0}, {1}}, 2)
+	if err != nil {
+		t.Fatalf("MeasureSearchLatency() failed: %v", err)
+	}
+	if result.Max < result.Min {
+		t.Errorf("Max (%v) < Min (%v)", result.Max, result.Min)
+	}
+}
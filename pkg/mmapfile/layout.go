@@ -0,0 +1,183 @@
+package mmapfile
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"unsafe"
+)
+
+// Writer writes a file as a sequence of words
+// Errors stick: after the first failure every call is a no-op and Flush
+// returns the error.
+type Writer struct {
+	w   *bufio.Writer
+	buf [wordSize]byte
+	err error
+}
+
+// NewWriter returns a Writer that writes to w
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: bufio.NewWriterSize(w, 1<<20)}
+}
+
+// Magic writes an 8-character file signature
+func (w *Writer) Magic(magic string) {
+	if len(magic) != wordSize {
+		panic(fmt.Sprintf("mmapfile: magic %q is not %d bytes", magic, wordSize))
+	}
+	w.write([]byte(magic))
+}
+
+// Uint64 writes one word
+func (w *Writer) Uint64(v uint64) {
+	binary.LittleEndian.PutUint64(w.buf[:], v)
+	w.write(w.buf[:])
+}
+
+// Int writes v as an int64
+func (w *Writer) Int(v int) {
+	w.Uint64(uint64(int64(v)))
+}
+
+// Float64 writes one float64
+func (w *Writer) Float64(v float64) {
+	w.Uint64(math.Float64bits(v))
+}
+
+// Float64s writes each value in order, without a length
+func (w *Writer) Float64s(values []float64) {
+	for _, v := range values {
+		w.Float64(v)
+	}
+}
+
+// Ints writes each value as an int64, without a length
+func (w *Writer) Ints(values []int) {
+	for _, v := range values {
+		w.Int(v)
+	}
+}
+
+func (w *Writer) write(p []byte) {
+	if w.err == nil {
+		_, w.err = w.w.Write(p)
+	}
+}
+
+// Flush writes any buffered data and reports the first error
+func (w *Writer) Flush() error {
+	if w.err != nil {
+		return w.err
+	}
+	return w.w.Flush()
+}
+
+// Reader reads words from a mapped file in the order a Writer wrote them
+// Float64s and Ints return views into the mapping, not copies; they are
+// read-only. Errors stick like the Writer's: reads past the end return zero
+// values and Err reports what went wrong.
+type Reader struct {
+	data []byte
+	off  int
+	err  error
+}
+
+// NewReader returns a Reader over data, which must be word-aligned, as
+// File.Bytes is
+func NewReader(data []byte) *Reader {
+	r := &Reader{data: data}
+	if len(data) > 0 && uintptr(unsafe.Pointer(&data[0]))%wordSize != 0 {
+		r.err = fmt.Errorf("mmapfile: data is not %d-byte aligned", wordSize)
+	}
+	return r
+}
+
+// take returns the next n words as bytes
+func (r *Reader) take(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || n > (len(r.data)-r.off)/wordSize {
+		r.err = fmt.Errorf("truncated file: need %d words at offset %d of %d bytes", n, r.off, len(r.data))
+		return nil
+	}
+	b := r.data[r.off : r.off+n*wordSize]
+	r.off += n * wordSize
+	return b
+}
+
+// Magic checks the file signature
+func (r *Reader) Magic(magic string) {
+	b := r.take(1)
+	if r.err == nil && string(b) != magic {
+		r.err = fmt.Errorf("bad signature %q, want %q", b, magic)
+	}
+}
+
+// Uint64 reads one word
+func (r *Reader) Uint64() uint64 {
+	b := r.take(1)
+	if b == nil {
+		return 0
+	}
+	return binary.LittleEndian.Uint64(b)
+}
+
+// Int reads an int64 and checks that it lies in [min, max]
+func (r *Reader) Int(name string, min, max int) int {
+	v := int(int64(r.Uint64()))
+	if r.err == nil && (v < min || v > max) {
+		r.err = fmt.Errorf("%s = %d outside [%d, %d]", name, v, min, max)
+	}
+	return v
+}
+
+// Float64 reads one float64
+func (r *Reader) Float64() float64 {
+	return math.Float64frombits(r.Uint64())
+}
+
+// Float64s returns the next n float64s as a view into the data
+func (r *Reader) Float64s(n int) []float64 {
+	b := r.take(n)
+	if b == nil {
+		return nil
+	}
+	if n == 0 {
+		return []float64{}
+	}
+	return unsafe.Slice((*float64)(unsafe.Pointer(&b[0])), n)
+}
+
+// Ints returns the next n int64s as a view into the data
+func (r *Reader) Ints(n int) []int {
+	b := r.take(n)
+	if b == nil {
+		return nil
+	}
+	if n == 0 {
+		return []int{}
+	}
+	return unsafe.Slice((*int)(unsafe.Pointer(&b[0])), n)
+}
+
+// Remaining returns the number of unread words
+func (r *Reader) Remaining() int {
+	return (len(r.data) - r.off) / wordSize
+}
+
+// Err returns the first error
+func (r *Reader) Err() error {
+	return r.err
+}
+
+// Finish returns the first error, or one if any data was left unread
+func (r *Reader) Finish() error {
+	if r.err == nil && r.off != len(r.data) {
+		return fmt.Errorf("%d bytes of trailing data", len(r.data)-r.off)
+	}
+	return r.err
+}
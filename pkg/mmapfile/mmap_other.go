@@ -0,0 +1,19 @@
+//go:build !unix
+
+package mmapfile
+
+import (
+	"io"
+	"os"
+	"unsafe"
+)
+
+// mapFile reads f into word-aligned memory, standing in for a mapping
+func mapFile(f *os.File, size int) ([]byte, func([]byte) error, error) {
+	words := make([]uint64, (size+wordSize-1)/wordSize)
+	data := unsafe.Slice((*byte)(unsafe.Pointer(&words[0])), size)
+	if _, err := io.ReadFull(f, data); err != nil {
+		return nil, nil, err
+	}
+	return data, nil, nil
+}
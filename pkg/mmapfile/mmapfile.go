@@ -0,0 +1,96 @@
+// Package mmapfile maps index files into memory read-only and lays out the
+// data inside them so that it can be used in place
+//
+// A file is a sequence of 8-byte words: little-endian uint64s, int64s and
+// float64s. Because the mapping starts on a page boundary and every value is
+// one word, a run of float64s in the file can be handed out as a
+// vector.Vector that points into the mapping, with no decoding or copying.
+// Pages are read on first touch and shared with every other process mapping
+// the same file, so opening a large index is nearly free.
+//
+// On unix the file is mapped with syscall.Mmap; elsewhere it is read into
+// memory, which is correct but loses the fast startup.
+package mmapfile
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"unsafe"
+)
+
+// wordSize is the size and alignment of every value in a file
+const wordSize = 8
+
+// File is a read-only view of a file's bytes
+// Slices obtained from it, and from Readers over it, must not be used after
+// Close: on unix, touching them then crashes the process.
+type File struct {
+	data   []byte
+	unmap  func([]byte) error
+	closed bool
+}
+
+// Open maps the file at path
+func Open(path string) (*File, error) {
+	if err := checkHost(); err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close() // The mapping outlives the descriptor
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := info.Size()
+	if size == 0 {
+		return &File{}, nil
+	}
+	if size != int64(int(size)) {
+		return nil, fmt.Errorf("%s: %d bytes do not fit in the address space", path, size)
+	}
+
+	data, unmap, err := mapFile(f, int(size))
+	if err != nil {
+		return nil, fmt.Errorf("map %s: %w", path, err)
+	}
+	return &File{data: data, unmap: unmap}, nil
+}
+
+// Bytes returns the mapped contents
+func (f *File) Bytes() []byte {
+	return f.data
+}
+
+// Len returns the file's size in bytes
+func (f *File) Len() int {
+	return len(f.data)
+}
+
+// Close releases the mapping; calling it again does nothing
+func (f *File) Close() error {
+	if f.closed {
+		return nil
+	}
+	f.closed = true
+	data := f.data
+	f.data = nil
+	if f.unmap == nil || len(data) == 0 {
+		return nil
+	}
+	return f.unmap(data)
+}
+
+// checkHost rejects machines whose native layout differs from the file's
+func checkHost() error {
+	one := uint64(1)
+	littleEndian := *(*byte)(unsafe.Pointer(&one)) == 1
+	if !littleEndian || unsafe.Sizeof(int(0)) != wordSize {
+		return errors.New("mapped index files need a 64-bit little-endian machine")
+	}
+	return nil
+}
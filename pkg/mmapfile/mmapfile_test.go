@@ -0,0 +1,113 @@
+package mmapfile
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFile(t *testing.T, write func(w *Writer)) string {
+	t.Helper()
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	write(w)
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "data")
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRoundTrip(t *testing.T) {
+	path := writeFile(t, func(w *Writer) {
+		w.Magic("TESTFILE")
+		w.Int(-3)
+		w.Float64(0.5)
+		w.Float64s([]float64{1, 2, 3})
+		w.Ints([]int{7, -8})
+	})
+
+	f, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	r := NewReader(f.Bytes())
+	r.Magic("TESTFILE")
+	if got := r.Int("n", -5, 5); got != -3 {
+		t.Errorf("Int = %d, want -3", got)
+	}
+	if got := r.Float64(); got != 0.5 {
+		t.Errorf("Float64 = %v, want 0.5", got)
+	}
+	floats := r.Float64s(3)
+	ints := r.Ints(2)
+	if err := r.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	if len(floats) != 3 || floats[2] != 3 || len(ints) != 2 || ints[1] != -8 {
+		t.Errorf("views = %v, %v", floats, ints)
+	}
+	if empty := r.Float64s(0); empty == nil || len(empty) != 0 {
+		t.Errorf("zero-length view = %#v, want empty and non-nil", empty)
+	}
+}
+
+func TestReaderErrors(t *testing.T) {
+	path := writeFile(t, func(w *Writer) {
+		w.Magic("TESTFILE")
+		w.Int(10)
+		w.Float64s([]float64{1, 2})
+	})
+	f, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	tests := []struct {
+		name string
+		read func(r *Reader)
+	}{
+		{"wrong magic", func(r *Reader) { r.Magic("OTHERSIG") }},
+		{"out of range", func(r *Reader) { r.Magic("TESTFILE"); r.Int("n", 0, 5) }},
+		{"past the end", func(r *Reader) { r.Magic("TESTFILE"); r.Int("n", 0, 10); r.Float64s(3) }},
+		{"trailing data", func(r *Reader) { r.Magic("TESTFILE"); r.Int("n", 0, 10) }},
+	}
+	for _, tt := range tests {
+		r := NewReader(f.Bytes())
+		tt.read(r)
+		if r.Finish() == nil {
+			t.Errorf("%s: no error", tt.name)
+		}
+	}
+
+	if r := NewReader(f.Bytes()[1:]); r.Err() == nil {
+		t.Error("unaligned data accepted")
+	}
+}
+
+func TestCloseTwice(t *testing.T) {
+	path := writeFile(t, func(w *Writer) { w.Int(1) })
+	f, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Len() != 8 {
+		t.Errorf("Len = %d, want 8", f.Len())
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Errorf("second Close: %v", err)
+	}
+	if f.Bytes() != nil {
+		t.Error("Bytes still returns the unmapped data")
+	}
+}
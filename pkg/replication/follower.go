@@ -0,0 +1,320 @@
+package replication
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/tmdgusya/database-class/pkg/wal"
+)
+
+// Follower keeps a replica in sync with a leader
+// It connects in the background and reconnects after any failure, resuming
+// from the last applied LSN when the leader still has the records and
+// bootstrapping from a full copy otherwise.
+type Follower struct {
+	addr    string
+	opts    FollowerOptions
+	restore func(io.Reader) error
+	apply   func(wal.Record) error
+
+	// state is held for writing while restore or apply changes the replica
+	// and for reading by Read, so reads see whole records only
+	state sync.RWMutex
+
+	mu        sync.Mutex
+	leaderID  uint64        // Leader the replica descends from; 0 asks for a copy
+	applied   uint64        // LSN of the last applied record
+	leaderLSN uint64        // Leader's LSN at the last heartbeat
+	syncedAt  time.Time     // Last heartbeat received with everything applied
+	conn      net.Conn      // Current connection, if any
+	err       error         // Why the last connection ended
+	changed   chan struct{} // Closed and replaced whenever applied advances
+	closed    bool
+	done      chan struct{} // Closed by Close
+	stopped   chan struct{} // Closed when the connection loop exits
+}
+
+// Status describes a follower's progress
+type Status struct {
+	Applied   uint64    // LSN of the last applied record
+	LeaderLSN uint64    // Leader's LSN at the last heartbeat
+	SyncedAt  time.Time // When the replica was last known to be in sync; zero if never
+	Connected bool
+	Err       error // Why the last connection ended, if it did
+}
+
+// Follow starts replicating from the leader at addr
+// restore replaces the replica with a copy written by the leader's save;
+// apply applies one record. Both run on the follower's goroutine, never
+// concurrently with each other or with Read.
+func Follow(addr string, opts FollowerOptions, restore func(io.Reader) error, apply func(wal.Record) error) *Follower {
+	f := &Follower{
+		addr:    addr,
+		opts:    opts.withDefaults(),
+		restore: restore,
+		apply:   apply,
+		changed: make(chan struct{}),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	go f.run()
+	return f
+}
+
+// Read runs fn if the replica was in sync with the leader within
+// MaxStaleness, passing it the LSN the replica reflects
+// No record is applied while fn runs.
+func (f *Follower) Read(fn func(lsn uint64) error) error {
+	f.state.RLock()
+	defer f.state.RUnlock()
+
+	f.mu.Lock()
+	closed, applied, syncedAt := f.closed, f.applied, f.syncedAt
+	f.mu.Unlock()
+
+	if closed {
+		return ErrClosed
+	}
+	if syncedAt.IsZero() {
+		return fmt.Errorf("%w: never in sync with the leader", ErrStale)
+	}
+	if age := time.Since(syncedAt); age > f.opts.MaxStaleness {
+		return fmt.Errorf("%w: last in sync %v ago", ErrStale, age.Round(time.Millisecond))
+	}
+	return fn(applied)
+}
+
+// Wait blocks until the replica has applied LSN lsn, such as one returned
+// by the leader's Append, so a client can read its own writes
+func (f *Follower) Wait(ctx context.Context, lsn uint64) error {
+	for {
+		f.mu.Lock()
+		applied, closed, changed := f.applied, f.closed, f.changed
+		f.mu.Unlock()
+
+		if applied >= lsn {
+			return nil
+		}
+		if closed {
+			return ErrClosed
+		}
+		select {
+		case <-changed:
+		case <-f.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Status returns the follower's progress
+func (f *Follower) Status() Status {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return Status{
+		Applied:   f.applied,
+		LeaderLSN: f.leaderLSN,
+		SyncedAt:  f.syncedAt,
+		Connected: f.conn != nil,
+		Err:       f.err,
+	}
+}
+
+// Close disconnects from the leader and stops replicating
+func (f *Follower) Close() error {
+	f.mu.Lock()
+	if f.closed {
+		f.mu.Unlock()
+		return ErrClosed
+	}
+	f.closed = true
+	close(f.done)
+	if f.conn != nil {
+		f.conn.Close()
+	}
+	f.mu.Unlock()
+
+	<-f.stopped
+	return nil
+}
+
+// run connects to the leader until Close, waiting Retry between attempts
+func (f *Follower) run() {
+	defer close(f.stopped)
+	for {
+		err := f.session()
+
+		f.mu.Lock()
+		f.conn = nil
+		if f.closed {
+			f.mu.Unlock()
+			return
+		}
+		f.err = err
+		f.mu.Unlock()
+
+		select {
+		case <-f.done:
+			return
+		case <-time.After(f.opts.Retry):
+		}
+	}
+}
+
+// session handles one connection to the leader; it always returns an error
+func (f *Follower) session() error {
+	conn, err := net.DialTimeout("tcp", f.addr, f.opts.Timeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	f.mu.Lock()
+	if f.closed {
+		f.mu.Unlock()
+		return ErrClosed
+	}
+	f.conn = conn
+	leaderID, applied := f.leaderID, f.applied
+	f.mu.Unlock()
+
+	w := bufio.NewWriter(conn)
+	w.WriteString(handshakeMagic)
+	writeUint64(w, leaderID)
+	writeUint64(w, applied)
+	conn.SetWriteDeadline(time.Now().Add(f.opts.Timeout))
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	r := bufio.NewReaderSize(conn, 64<<10)
+	for {
+		// Heartbeats arrive well within Timeout from a live leader
+		conn.SetReadDeadline(time.Now().Add(f.opts.Timeout))
+		typ, err := r.ReadByte()
+		if err != nil {
+			return err
+		}
+
+		switch typ {
+		case msgSnapshot:
+			// A large copy may take longer than Timeout to read and restore
+			conn.SetReadDeadline(time.Time{})
+			err = f.readSnapshot(r)
+		case msgRecord:
+			var rec wal.Record
+			if rec, err = wal.ReadRecord(r); err == nil {
+				err = f.applyRecord(rec)
+			}
+		case msgHeartbeat:
+			var lsn uint64
+			if lsn, err = readUint64(r); err == nil {
+				conn.SetWriteDeadline(time.Now().Add(f.opts.Timeout))
+				err = f.heartbeat(w, lsn)
+			}
+		default:
+			err = fmt.Errorf("unknown message type %q", typ)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// readSnapshot replaces the replica with the leader's copy
+func (f *Follower) readSnapshot(r io.Reader) error {
+	var header [3]uint64 // leader ID, covered LSN, length
+	for i := range header {
+		v, err := readUint64(r)
+		if err != nil {
+			return err
+		}
+		header[i] = v
+	}
+	leaderID, covered, size := header[0], header[1], header[2]
+
+	body := io.LimitReader(r, int64(size))
+	f.state.Lock()
+	defer f.state.Unlock()
+
+	if err := f.restore(body); err != nil {
+		f.forget()
+		return fmt.Errorf("restore copy of LSN %d: %w", covered, err)
+	}
+	// Skip whatever restore left unread
+	if _, err := io.Copy(io.Discard, body); err != nil {
+		f.forget()
+		return err
+	}
+
+	f.mu.Lock()
+	f.leaderID, f.applied = leaderID, covered
+	f.advanced()
+	f.mu.Unlock()
+	return nil
+}
+
+// applyRecord applies rec if it is the next record
+func (f *Follower) applyRecord(rec wal.Record) error {
+	f.mu.Lock()
+	applied := f.applied
+	f.mu.Unlock()
+
+	if rec.LSN <= applied {
+		return nil
+	}
+	if rec.LSN != applied+1 {
+		f.forget()
+		return fmt.Errorf("expected LSN %d, got %d", applied+1, rec.LSN)
+	}
+
+	// Hold the replica until applied advances, so Read never sees the
+	// record without its LSN
+	f.state.Lock()
+	defer f.state.Unlock()
+
+	if err := f.apply(rec); err != nil {
+		// The replica may now differ from the leader; start over from a copy
+		f.forget()
+		return fmt.Errorf("apply LSN %d: %w", rec.LSN, err)
+	}
+
+	f.mu.Lock()
+	f.applied = rec.LSN
+	f.advanced()
+	f.mu.Unlock()
+	return nil
+}
+
+// heartbeat records the leader's LSN and acknowledges it
+func (f *Follower) heartbeat(w *bufio.Writer, lsn uint64) error {
+	f.mu.Lock()
+	f.leaderLSN = lsn
+	if f.applied >= lsn {
+		f.syncedAt = time.Now()
+	}
+	applied := f.applied
+	f.mu.Unlock()
+
+	writeUint64(w, applied)
+	return w.Flush()
+}
+
+// forget makes the next connection ask for a full copy
+func (f *Follower) forget() {
+	f.mu.Lock()
+	f.leaderID = 0
+	f.mu.Unlock()
+}
+
+// advanced wakes Wait callers; f.mu must be held
+func (f *Follower) advanced() {
+	close(f.changed)
+	f.changed = make(chan struct{})
+}
@@ -0,0 +1,310 @@
+package replication
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/tmdgusya/database-class/pkg/wal"
+)
+
+// Leader applies changes and streams them to every connected follower
+type Leader struct {
+	opts LeaderOptions
+	save func(io.Writer) error
+	ln   net.Listener
+	id   uint64 // Random per leader, so followers notice a new history
+
+	mu      sync.Mutex
+	lsn     uint64        // LSN of the newest change
+	backlog []wal.Record  // The newest records, ending at lsn
+	notify  chan struct{} // Closed and replaced by every Append
+	peers   map[net.Conn]*peer
+	closed  bool
+	done    chan struct{} // Closed by Close
+	wg      sync.WaitGroup
+}
+
+// peer is a connected follower
+type peer struct {
+	addr  string
+	acked atomic.Uint64 // LSN the follower last reported applied
+}
+
+// PeerStatus describes a connected follower
+type PeerStatus struct {
+	Addr    string // Follower's remote address
+	Applied uint64 // LSN it last reported applied
+}
+
+// NewLeader serves followers connecting to ln
+// save writes a full copy of the state for bootstrapping followers; it runs
+// with Append blocked, so the copy matches an exact LSN.
+func NewLeader(ln net.Listener, opts LeaderOptions, save func(io.Writer) error) (*Leader, error) {
+	opts, err := opts.withDefaults()
+	if err != nil {
+		return nil, err
+	}
+	l := &Leader{
+		opts:   opts,
+		save:   save,
+		ln:     ln,
+		id:     rand.Uint64() | 1, // 0 means "no leader" in the handshake
+		notify: make(chan struct{}),
+		peers:  make(map[net.Conn]*peer),
+		done:   make(chan struct{}),
+	}
+	l.wg.Add(1)
+	go l.serve()
+	return l, nil
+}
+
+// Addr returns the address followers connect to
+func (l *Leader) Addr() net.Addr {
+	return l.ln.Addr()
+}
+
+// Append runs apply and, if it succeeds, assigns records the next LSNs
+// and queues them for every follower
+// Callers apply the change to their state inside apply, so that a
+// concurrent bootstrap copy sees either the state before the records or the
+// state after them. Records whose apply failed are not replicated. Append
+// returns the LSN of the last record without waiting for followers.
+func (l *Leader) Append(apply func() error, records ...wal.Record) (uint64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.closed {
+		return 0, ErrClosed
+	}
+	if apply != nil {
+		if err := apply(); err != nil {
+			return l.lsn, err
+		}
+	}
+	if len(records) == 0 {
+		return l.lsn, nil
+	}
+
+	for _, r := range records {
+		l.lsn++
+		r.LSN = l.lsn
+		// Records are encoded later, after the caller may have reused them
+		if r.Vector != nil {
+			r.Vector = r.Vector.Clone()
+		}
+		r.Data = bytes.Clone(r.Data)
+		l.backlog = append(l.backlog, r)
+	}
+	// Trim in bulk so each record is copied at most once
+	if len(l.backlog) > 2*l.opts.Backlog {
+		l.backlog = append([]wal.Record(nil), l.backlog[len(l.backlog)-l.opts.Backlog:]...)
+	}
+	close(l.notify)
+	l.notify = make(chan struct{})
+	return l.lsn, nil
+}
+
+// LSN returns the LSN of the newest change
+func (l *Leader) LSN() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.lsn
+}
+
+// Peers returns the connected followers, sorted by address
+func (l *Leader) Peers() []PeerStatus {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	out := make([]PeerStatus, 0, len(l.peers))
+	for _, p := range l.peers {
+		out = append(out, PeerStatus{Addr: p.addr, Applied: p.acked.Load()})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Addr < out[j].Addr })
+	return out
+}
+
+// Close disconnects every follower and stops accepting new ones
+func (l *Leader) Close() error {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return ErrClosed
+	}
+	l.closed = true
+	close(l.done)
+	err := l.ln.Close()
+	for conn := range l.peers {
+		conn.Close()
+	}
+	l.mu.Unlock()
+
+	l.wg.Wait()
+	return err
+}
+
+// serve accepts followers until the listener is closed
+func (l *Leader) serve() {
+	defer l.wg.Done()
+	for {
+		conn, err := l.ln.Accept()
+		if err != nil {
+			return
+		}
+
+		l.mu.Lock()
+		if l.closed {
+			l.mu.Unlock()
+			conn.Close()
+			return
+		}
+		p := &peer{addr: conn.RemoteAddr().String()}
+		l.peers[conn] = p
+		l.wg.Add(1)
+		l.mu.Unlock()
+
+		go l.stream(conn, p)
+	}
+}
+
+// stream serves one follower until it disconnects, falls out of the
+// backlog or the leader closes
+// Errors only end the connection; the follower reconnects and resumes.
+func (l *Leader) stream(conn net.Conn, p *peer) {
+	defer l.wg.Done()
+	defer func() {
+		conn.Close()
+		l.mu.Lock()
+		delete(l.peers, conn)
+		l.mu.Unlock()
+	}()
+
+	r := bufio.NewReader(conn)
+	conn.SetReadDeadline(time.Now().Add(l.opts.Timeout))
+	leaderID, from, err := readHandshake(r)
+	if err != nil {
+		return
+	}
+	conn.SetReadDeadline(time.Time{})
+	p.acked.Store(from)
+
+	// Acks arrive after heartbeats; closing the connection ends both loops
+	go func() {
+		defer conn.Close()
+		for {
+			lsn, err := readUint64(r)
+			if err != nil {
+				return
+			}
+			p.acked.Store(lsn)
+		}
+	}()
+
+	w := bufio.NewWriterSize(conn, 64<<10)
+	sent, err := l.bootstrap(conn, w, leaderID, from)
+	if err != nil {
+		return
+	}
+
+	ticker := time.NewTicker(l.opts.Heartbeat)
+	defer ticker.Stop()
+	heartbeat := true
+	for {
+		l.mu.Lock()
+		pending, ok := l.since(sent)
+		lsn, notify := l.lsn, l.notify
+		l.mu.Unlock()
+		if !ok {
+			return
+		}
+
+		// Writes past the buffer reach the connection, so set the deadline first
+		conn.SetWriteDeadline(time.Now().Add(l.opts.Timeout))
+		for _, rec := range pending {
+			w.WriteByte(msgRecord)
+			wal.WriteRecord(w, rec)
+		}
+		if heartbeat {
+			w.WriteByte(msgHeartbeat)
+			writeUint64(w, lsn)
+			heartbeat = false
+		}
+		if err := w.Flush(); err != nil {
+			return
+		}
+		sent = lsn
+
+		select {
+		case <-notify:
+		case <-ticker.C:
+			heartbeat = true
+		case <-l.done:
+			return
+		}
+	}
+}
+
+// bootstrap decides where the follower resumes and returns the LSN it has
+// after this call
+// A follower of this leader still covered by the backlog resumes where it
+// stopped; any other follower first receives a full copy.
+func (l *Leader) bootstrap(conn net.Conn, w *bufio.Writer, leaderID, from uint64) (uint64, error) {
+	l.mu.Lock()
+	if leaderID == l.id {
+		if _, ok := l.since(from); ok {
+			l.mu.Unlock()
+			return from, nil
+		}
+	}
+	var state bytes.Buffer
+	err := l.save(&state)
+	covered := l.lsn
+	l.mu.Unlock()
+	if err != nil {
+		return 0, fmt.Errorf("save: %w", err)
+	}
+
+	conn.SetWriteDeadline(time.Now().Add(l.opts.Timeout))
+	w.WriteByte(msgSnapshot)
+	writeUint64(w, l.id)
+	writeUint64(w, covered)
+	writeUint64(w, uint64(state.Len()))
+	w.Write(state.Bytes())
+	return covered, w.Flush()
+}
+
+// since returns the records after LSN from; l.mu must be held
+// It reports false if they are no longer all in the backlog, or if from is
+// beyond the leader's LSN.
+func (l *Leader) since(from uint64) ([]wal.Record, bool) {
+	base := l.lsn - uint64(len(l.backlog)) // LSN before the first backlog record
+	if from < base || from > l.lsn {
+		return nil, false
+	}
+	return l.backlog[from-base:], true
+}
+
+// readHandshake reads the follower's opening message
+func readHandshake(r io.Reader) (leaderID, from uint64, err error) {
+	magic := make([]byte, len(handshakeMagic))
+	if _, err := io.ReadFull(r, magic); err != nil {
+		return 0, 0, err
+	}
+	if string(magic) != handshakeMagic {
+		return 0, 0, fmt.Errorf("bad handshake %q", magic)
+	}
+	if leaderID, err = readUint64(r); err != nil {
+		return 0, 0, err
+	}
+	from, err = readUint64(r)
+	return leaderID, from, err
+}
@@ -0,0 +1,119 @@
+// Package replication keeps follower replicas of an index in sync with a
+// leader over TCP
+//
+// The leader applies every change locally and streams it to its followers
+// as a wal.Record, numbered by a log sequence number (LSN). Followers apply
+// the records to their own copy in the same order. A new follower, or one
+// that fell behind the leader's in-memory backlog, bootstraps from a full
+// copy of the leader's state written by save, followed by the records
+// applied after it.
+//
+// Replication is asynchronous: Append returns once the leader applied the
+// change. The leader sends a heartbeat with its latest LSN every
+// Heartbeat; a follower that has applied everything up to a heartbeat was
+// in sync at that moment, and Read refuses to serve once that moment is
+// older than MaxStaleness.
+//
+//	leader, err := replication.NewLeader(ln, replication.LeaderOptions{}, idx.Save)
+//	lsn, err := leader.Append(func() error { return idx.Add(v) }, wal.Record{Op: wal.OpAdd, Vector: v})
+//
+//	follower := replication.Follow(addr, replication.FollowerOptions{}, restore, apply)
+//	err = follower.Read(func(lsn uint64) error { results, err = replica.Search(q, 10); return err })
+package replication
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+var (
+	// ErrClosed is returned after Close
+	ErrClosed = errors.New("replication closed")
+
+	// ErrStale is returned by Follower.Read when the replica lags the leader
+	// by more than MaxStaleness
+	ErrStale = errors.New("replica too stale")
+)
+
+// Stream protocol, all integers little-endian
+//
+// The follower opens with the handshake:
+//
+//	magic "VECREPL1", leader ID uint64 (0 = send a copy), applied LSN uint64
+//
+// and then sends its applied LSN (uint64) after every heartbeat. The leader
+// sends messages starting with a type byte:
+//
+//	'S' snapshot   leader ID uint64, covered LSN uint64, length uint64, bytes written by save
+//	'R' record     one record framed by wal.WriteRecord
+//	'H' heartbeat  leader LSN uint64
+const handshakeMagic = "VECREPL1"
+
+const (
+	msgSnapshot  = 'S'
+	msgRecord    = 'R'
+	msgHeartbeat = 'H'
+)
+
+// LeaderOptions configures a Leader
+type LeaderOptions struct {
+	Backlog   int           // Records kept for followers to catch up from; default 10000
+	Heartbeat time.Duration // Interval between heartbeats; default 100ms
+	Timeout   time.Duration // Limit on each batch of writes to a follower, bootstrap copy included; default 5s
+}
+
+func (o LeaderOptions) withDefaults() (LeaderOptions, error) {
+	if o.Backlog == 0 {
+		o.Backlog = 10000
+	}
+	if o.Heartbeat == 0 {
+		o.Heartbeat = 100 * time.Millisecond
+	}
+	if o.Timeout == 0 {
+		o.Timeout = 5 * time.Second
+	}
+	if o.Backlog < 0 || o.Heartbeat < 0 || o.Timeout < 0 {
+		return o, fmt.Errorf("options must be non-negative, got %+v", o)
+	}
+	return o, nil
+}
+
+// FollowerOptions configures a Follower
+type FollowerOptions struct {
+	MaxStaleness time.Duration // Oldest sync Read accepts; default 1s
+	Timeout      time.Duration // Silence after which the leader is presumed gone; default 5s
+	Retry        time.Duration // Wait between connection attempts; default 100ms
+}
+
+func (o FollowerOptions) withDefaults() FollowerOptions {
+	if o.MaxStaleness <= 0 {
+		o.MaxStaleness = time.Second
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = 5 * time.Second
+	}
+	if o.Retry <= 0 {
+		o.Retry = 100 * time.Millisecond
+	}
+	return o
+}
+
+// writeUint64 writes v as 8 little-endian bytes
+func writeUint64(w io.Writer, v uint64) error {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], v)
+	_, err := w.Write(b[:])
+	return err
+}
+
+// readUint64 reads 8 little-endian bytes
+func readUint64(r io.Reader) (uint64, error) {
+	var b [8]byte
+	if _, err := io.ReadFull(r, b[:]); err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint64(b[:]), nil
+}
@@ -0,0 +1,374 @@
+package replication
+
+import (
+	"bufio"
+	"context"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	flat "github.com/tmdgusya/database-class/01-flat/solution"
+	"github.com/tmdgusya/database-class/pkg/distance"
+	"github.com/tmdgusya/database-class/pkg/index"
+	"github.com/tmdgusya/database-class/pkg/testdata"
+	"github.com/tmdgusya/database-class/pkg/vector"
+	"github.com/tmdgusya/database-class/pkg/wal"
+)
+
+// replica is a flat index keyed by string IDs with tombstones for deletes
+type replica struct {
+	mu       sync.RWMutex
+	idx      *flat.FlatIndex
+	ids      []string        // Local ID -> key
+	deleted  map[string]bool // Keys removed
+	restores atomic.Int32
+}
+
+func newReplica() *replica {
+	idx, _ := flat.NewFlatIndex(flat.Config{Metric: distance.L2Distance})
+	return &replica{idx: idx, deleted: map[string]bool{}}
+}
+
+func (r *replica) apply(rec wal.Record) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch rec.Op {
+	case wal.OpAdd:
+		if err := r.idx.Add(rec.Vector); err != nil {
+			return err
+		}
+		r.ids = append(r.ids, rec.ID)
+	case wal.OpDelete:
+		r.deleted[rec.ID] = true
+	default:
+		return fmt.Errorf("unsupported op %v", rec.Op)
+	}
+	return nil
+}
+
+// header precedes the index in a saved replica
+type header struct {
+	IDs     []string
+	Deleted map[string]bool
+}
+
+func (r *replica) save(w io.Writer) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if err := gob.NewEncoder(w).Encode(header{r.ids, r.deleted}); err != nil {
+		return err
+	}
+	return r.idx.Save(w)
+}
+
+func (r *replica) restore(rd io.Reader) error {
+	br := bufio.NewReader(rd) // Shared by both decoders
+	var h header
+	if err := gob.NewDecoder(br).Decode(&h); err != nil {
+		return err
+	}
+	idx, err := flat.LoadFlatIndex(br, distance.L2Distance)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.idx, r.ids, r.deleted = idx, h.IDs, h.Deleted
+	if r.deleted == nil {
+		r.deleted = map[string]bool{}
+	}
+	r.restores.Add(1)
+	return nil
+}
+
+// search returns the keys of the k nearest live vectors
+func (r *replica) search(q vector.Vector, k int) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	results, err := r.idx.SearchWithOptions(q, k, index.SearchOptions{
+		Filter: func(id int) bool { return !r.deleted[r.ids[id]] },
+	})
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, len(results))
+	for i, res := range results {
+		keys[i] = r.ids[res.Index]
+	}
+	return keys, nil
+}
+
+// cluster is a leader with its replica
+type cluster struct {
+	leader *Leader
+	state  *replica
+}
+
+func newCluster(t *testing.T, opts LeaderOptions) *cluster {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &cluster{state: newReplica()}
+	c.leader, err = NewLeader(ln, opts, c.state.save)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.leader.Close() })
+	return c
+}
+
+func (c *cluster) add(t *testing.T, key string, v vector.Vector) uint64 {
+	t.Helper()
+	rec := wal.Record{Op: wal.OpAdd, ID: key, Vector: v}
+	lsn, err := c.leader.Append(func() error { return c.state.apply(rec) }, rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return lsn
+}
+
+func (c *cluster) follow(t *testing.T, opts FollowerOptions) (*Follower, *replica) {
+	t.Helper()
+	r := newReplica()
+	f := Follow(c.leader.Addr().String(), opts, r.restore, r.apply)
+	t.Cleanup(func() { f.Close() })
+	return f, r
+}
+
+func wait(t *testing.T, f *Follower, lsn uint64) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := f.Wait(ctx, lsn); err != nil {
+		t.Fatalf("Wait(%d) failed: %v (status %+v)", lsn, err, f.Status())
+	}
+}
+
+// waitFresh waits for a heartbeat that finds the follower in sync
+func waitFresh(t *testing.T, f *Follower) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		err := f.Read(func(uint64) error { return nil })
+		if err == nil {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Read() never succeeded: %v", err)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestBootstrapAndStream(t *testing.T) {
+	c := newCluster(t, LeaderOptions{Heartbeat: 10 * time.Millisecond})
+	vectors := testdata.GenerateRandomVectors(300, 8, 1)
+
+	// Half the data exists before the follower joins and arrives as a copy
+	for i, v := range vectors[:150] {
+		c.add(t, fmt.Sprint(i), v)
+	}
+	f, r := c.follow(t, FollowerOptions{})
+	wait(t, f, 150)
+
+	var lsn uint64
+	for i, v := range vectors[150:] {
+		lsn = c.add(t, fmt.Sprint(150+i), v)
+	}
+	del := wal.Record{Op: wal.OpDelete, ID: "7"}
+	lsn, err := c.leader.Append(func() error { return c.state.apply(del) }, del)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wait(t, f, lsn)
+	waitFresh(t, f)
+
+	if r.restores.Load() != 1 {
+		t.Errorf("restored %d copies, want 1", r.restores.Load())
+	}
+	for i, q := range testdata.GenerateRandomVectors(10, 8, 2) {
+		want, _ := c.state.search(q, 5)
+		var got []string
+		err := f.Read(func(readLSN uint64) error {
+			if readLSN < lsn {
+				return fmt.Errorf("read at LSN %d, want %d", readLSN, lsn)
+			}
+			var err error
+			got, err = r.search(q, 5)
+			return err
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("query %d: follower %v, leader %v", i, got, want)
+		}
+	}
+	got, _ := r.search(vectors[7], 1)
+	if got[0] == "7" {
+		t.Error("delete not replicated")
+	}
+
+	// The leader hears the follower's acknowledgements
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		peers := c.leader.Peers()
+		if len(peers) == 1 && peers[0].Applied == lsn {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Peers() = %+v, want one follower at LSN %d", peers, lsn)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestResumeFromBacklog(t *testing.T) {
+	c := newCluster(t, LeaderOptions{Backlog: 20, Heartbeat: 10 * time.Millisecond})
+	vectors := testdata.GenerateRandomVectors(100, 4, 3)
+	f, r := c.follow(t, FollowerOptions{Retry: 200 * time.Millisecond})
+	wait(t, f, 0)
+	for !f.Status().Connected {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// A short outage: the missed records are still in the backlog
+	for i, v := range vectors[:10] {
+		c.add(t, fmt.Sprint(i), v)
+	}
+	wait(t, f, 10)
+	dropConnection(f)
+	for i, v := range vectors[10:20] {
+		c.add(t, fmt.Sprint(i+10), v)
+	}
+	wait(t, f, 20)
+	if n := r.restores.Load(); n != 1 {
+		t.Errorf("restored %d copies after a short outage, want 1", n)
+	}
+
+	// A long outage: the leader no longer has the records, so it sends a copy
+	dropConnection(f)
+	for i, v := range vectors[20:] {
+		c.add(t, fmt.Sprint(i+20), v)
+	}
+	wait(t, f, 100)
+	if n := r.restores.Load(); n != 2 {
+		t.Errorf("restored %d copies after a long outage, want 2", n)
+	}
+	if r.idx.Size() != 100 {
+		t.Errorf("follower holds %d vectors, want 100", r.idx.Size())
+	}
+}
+
+// dropConnection breaks the follower's connection as a network failure would
+func dropConnection(f *Follower) {
+	f.mu.Lock()
+	if f.conn != nil {
+		f.conn.Close()
+	}
+	f.mu.Unlock()
+}
+
+func TestStalenessBound(t *testing.T) {
+	c := newCluster(t, LeaderOptions{Heartbeat: 10 * time.Millisecond})
+	lsn := c.add(t, "a", vector.Vector{1, 2})
+	f, _ := c.follow(t, FollowerOptions{MaxStaleness: 100 * time.Millisecond, Timeout: time.Second})
+	wait(t, f, lsn)
+
+	waitFresh(t, f)
+
+	// Without heartbeats the replica ages out
+	c.leader.Close()
+	time.Sleep(150 * time.Millisecond)
+	read := func() error { return f.Read(func(uint64) error { return nil }) }
+	if err := read(); !errors.Is(err, ErrStale) {
+		t.Errorf("Read() after the leader stopped: %v, want ErrStale", err)
+	}
+	if st := f.Status(); st.Applied != lsn || st.LeaderLSN != lsn {
+		t.Errorf("Status() = %+v", st)
+	}
+
+	f.Close()
+	if err := read(); !errors.Is(err, ErrClosed) {
+		t.Errorf("Read() after Close: %v", err)
+	}
+	if _, err := c.leader.Append(nil); !errors.Is(err, ErrClosed) {
+		t.Errorf("Append() after Close: %v", err)
+	}
+}
+
+func TestFailedApplyIsNotReplicated(t *testing.T) {
+	c := newCluster(t, LeaderOptions{Heartbeat: 10 * time.Millisecond})
+	f, r := c.follow(t, FollowerOptions{})
+
+	c.add(t, "a", vector.Vector{1, 2})
+	bad := wal.Record{Op: wal.OpAdd, ID: "b", Vector: vector.Vector{1, 2, 3}}
+	if _, err := c.leader.Append(func() error { return c.state.apply(bad) }, bad); err == nil {
+		t.Fatal("Append() of a wrong-dimension vector succeeded")
+	}
+	lsn := c.add(t, "c", vector.Vector{3, 4})
+	if lsn != 2 {
+		t.Errorf("LSN = %d, want 2: the failed change consumed an LSN", lsn)
+	}
+	wait(t, f, lsn)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if fmt.Sprint(r.ids) != "[a c]" {
+		t.Errorf("follower IDs = %v, want [a c]", r.ids)
+	}
+}
+
+func TestManyFollowers(t *testing.T) {
+	c := newCluster(t, LeaderOptions{Heartbeat: 10 * time.Millisecond})
+	vectors := testdata.GenerateRandomVectors(200, 4, 4)
+
+	followers := make([]*Follower, 3)
+	replicas := make([]*replica, 3)
+	for i := range followers {
+		followers[i], replicas[i] = c.follow(t, FollowerOptions{})
+	}
+
+	var wg sync.WaitGroup
+	var last atomic.Uint64
+	for w := range 4 {
+		wg.Go(func() {
+			for i := w; i < len(vectors); i += 4 {
+				rec := wal.Record{Op: wal.OpAdd, ID: fmt.Sprint(i), Vector: vectors[i]}
+				lsn, err := c.leader.Append(func() error { return c.state.apply(rec) }, rec)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				for {
+					old := last.Load()
+					if lsn <= old || last.CompareAndSwap(old, lsn) {
+						break
+					}
+				}
+			}
+		})
+	}
+	wg.Wait()
+
+	for i, f := range followers {
+		wait(t, f, last.Load())
+		replicas[i].mu.RLock()
+		got := fmt.Sprint(replicas[i].ids)
+		replicas[i].mu.RUnlock()
+		if want := fmt.Sprint(c.state.ids); got != want {
+			t.Errorf("follower %d applied records in a different order", i)
+		}
+	}
+}
@@ -0,0 +1,343 @@
+// Package shard hash-partitions one logical index across several inner
+// indexes
+//
+// A ShardedIndex assigns every vector a global ID and stores it in the shard
+// ShardOf picks for that ID. Each shard has its own lock, so writers to
+// different shards run in parallel instead of queueing behind one
+// sync.RWMutex. Searches scatter to every shard concurrently and gather the
+// per-shard top-k into one result with a heap. Because the partition
+// depends only on the ID and the shard count, the same function can route
+// requests to shards living on other nodes.
+//
+//	idx, err := shard.New(8, func(int) (index.Index, error) {
+//		return flat.NewFlatIndex(flat.Config{Metric: distance.L2Distance})
+//	})
+package shard
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+
+	"github.com/tmdgusya/database-class/pkg/index"
+	"github.com/tmdgusya/database-class/pkg/topk"
+	"github.com/tmdgusya/database-class/pkg/vector"
+)
+
+// Factory creates the inner index for shard i
+type Factory func(i int) (index.Index, error)
+
+// ShardedIndex spreads vectors over independently locked inner indexes
+// It satisfies index.OptionSearcher, index.Trainable and
+// index.MemoryReporter; SearchWithOptions fails if a shard does not accept
+// search options.
+type ShardedIndex struct {
+	shards []*shard
+	nextID atomic.Int64
+}
+
+// shard is one partition: an inner index and the global IDs of its vectors
+type shard struct {
+	mu  sync.RWMutex // Held for writing across inner Add and the ids append
+	idx index.Index
+	ids []int // Global ID of each local ID, in insertion order
+}
+
+// Compile-time checks that the wrapper satisfies the shared interfaces
+var (
+	_ index.OptionSearcher = (*ShardedIndex)(nil)
+	_ index.Trainable      = (*ShardedIndex)(nil)
+	_ index.MemoryReporter = (*ShardedIndex)(nil)
+)
+
+// New creates n shards with factory
+// The inner indexes must number their vectors 0, 1, 2, ... in insertion
+// order, as the flat, IVF and HNSW indexes do.
+func New(n int, factory Factory) (*ShardedIndex, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("shard count must be positive, got %d", n)
+	}
+	s := &ShardedIndex{shards: make([]*shard, n)}
+	for i := range s.shards {
+		idx, err := factory(i)
+		if err != nil {
+			return nil, fmt.Errorf("shard %d: %w", i, err)
+		}
+		s.shards[i] = &shard{idx: idx}
+	}
+	return s, nil
+}
+
+// ShardOf returns the shard that stores global ID id among n shards
+// IDs are mixed with the SplitMix64 finalizer first, so consecutive IDs
+// spread evenly instead of striding across the shards.
+func ShardOf(id, n int) int {
+	z := uint64(id) + 0x9e3779b97f4a7c15
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	z ^= z >> 31
+	return int(z % uint64(n))
+}
+
+// Shards returns the number of shards
+func (s *ShardedIndex) Shards() int {
+	return len(s.shards)
+}
+
+// Shard returns the inner index of shard i, for inspection
+// Adding to it directly desynchronizes the shard's ID map.
+func (s *ShardedIndex) Shard(i int) index.Index {
+	return s.shards[i].idx
+}
+
+// ShardSizes returns the number of vectors in each shard
+func (s *ShardedIndex) ShardSizes() []int {
+	sizes := make([]int, len(s.shards))
+	for i, sh := range s.shards {
+		sh.mu.RLock()
+		sizes[i] = len(sh.ids)
+		sh.mu.RUnlock()
+	}
+	return sizes
+}
+
+// Insert adds v to its shard and returns its global ID
+// A failed insert still consumes an ID, so IDs may have gaps.
+func (s *ShardedIndex) Insert(v vector.Vector) (int, error) {
+	id := int(s.nextID.Add(1) - 1)
+	i := ShardOf(id, len(s.shards))
+	if err := s.shards[i].add(id, v); err != nil {
+		return 0, fmt.Errorf("shard %d: %w", i, err)
+	}
+	return id, nil
+}
+
+// Add adds v to its shard; use Insert to learn its ID
+func (s *ShardedIndex) Add(v vector.Vector) error {
+	_, err := s.Insert(v)
+	return err
+}
+
+// AddBatch adds vectors with consecutive IDs, filling every shard in
+// parallel, and returns the IDs
+// On error some vectors may already have been added; the first error is
+// returned.
+func (s *ShardedIndex) AddBatch(vectors []vector.Vector) ([]int, error) {
+	first := int(s.nextID.Add(int64(len(vectors)))) - len(vectors)
+	ids := make([]int, len(vectors))
+	parts := make([][]int, len(s.shards)) // Positions in vectors per shard
+	for j := range vectors {
+		ids[j] = first + j
+		i := ShardOf(ids[j], len(s.shards))
+		parts[i] = append(parts[i], j)
+	}
+
+	errs := make([]error, len(s.shards))
+	var wg sync.WaitGroup
+	for i, part := range parts {
+		if len(part) == 0 {
+			continue
+		}
+		wg.Go(func() {
+			for _, j := range part {
+				if err := s.shards[i].add(ids[j], vectors[j]); err != nil {
+					errs[i] = fmt.Errorf("shard %d: vector %d: %w", i, j, err)
+					return
+				}
+			}
+		})
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ids, nil
+}
+
+func (sh *shard) add(id int, v vector.Vector) error {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	if err := sh.idx.Add(v); err != nil {
+		return err
+	}
+	sh.ids = append(sh.ids, id)
+	return nil
+}
+
+// Train trains every trainable shard concurrently on its own part of
+// vectors: shard i gets vectors i, i+n, i+2n, ...
+// Shards that need no training are skipped. Each shard learns its own
+// structure (IVF centroids), which matches its data because the partition
+// is random; use TrainShard to supply per-shard samples instead.
+func (s *ShardedIndex) Train(vectors []vector.Vector) error {
+	n := len(s.shards)
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := range s.shards {
+		if _, ok := s.shards[i].idx.(index.Trainable); !ok {
+			continue
+		}
+		part := make([]vector.Vector, 0, (len(vectors)+n-1)/n)
+		for j := i; j < len(vectors); j += n {
+			part = append(part, vectors[j])
+		}
+		wg.Go(func() { errs[i] = s.TrainShard(i, part) })
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TrainShard trains shard i on vectors
+func (s *ShardedIndex) TrainShard(i int, vectors []vector.Vector) error {
+	if i < 0 || i >= len(s.shards) {
+		return fmt.Errorf("shard %d out of range [0, %d)", i, len(s.shards))
+	}
+	trainable, ok := s.shards[i].idx.(index.Trainable)
+	if !ok {
+		return fmt.Errorf("shard %d does not need training", i)
+	}
+	if err := trainable.Train(vectors); err != nil {
+		return fmt.Errorf("shard %d: %w", i, err)
+	}
+	return nil
+}
+
+// Search returns the k nearest vectors across all shards
+// Unlike SearchWithOptions it also works with shards that only implement
+// index.Index.
+func (s *ShardedIndex) Search(query vector.Vector, k int) ([]index.SearchResult, error) {
+	return s.scatter(query, k, nil)
+}
+
+// SearchWithOptions searches every shard concurrently with opts and merges
+// their results
+// Filter sees global IDs. Stats sums the work of every shard; the scan
+// phase covers the scatter and the select phase the merge.
+func (s *ShardedIndex) SearchWithOptions(query vector.Vector, k int, opts index.SearchOptions) ([]index.SearchResult, error) {
+	return s.scatter(query, k, &opts)
+}
+
+// scatter searches every shard, with opts if non-nil, and gathers the
+// results
+func (s *ShardedIndex) scatter(query vector.Vector, k int, opts *index.SearchOptions) ([]index.SearchResult, error) {
+	if err := query.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid query: %w", err)
+	}
+	if k <= 0 {
+		return nil, fmt.Errorf("k must be positive, got %d", k)
+	}
+	var tr index.Trace
+	if opts != nil {
+		if err := opts.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid search options: %w", err)
+		}
+		tr = opts.StartTrace("shard.Search")
+	}
+	results, err := s.gather(query, k, opts, &tr)
+	tr.End(err)
+	return results, err
+}
+
+func (s *ShardedIndex) gather(query vector.Vector, k int, opts *index.SearchOptions, tr *index.Trace) ([]index.SearchResult, error) {
+	type part struct {
+		ids     []int // The shard's IDs when its search started
+		results []index.SearchResult
+		stats   index.SearchStats
+		err     error
+	}
+	parts := make([]part, len(s.shards))
+
+	tr.Phase(index.PhaseScan)
+	var wg sync.WaitGroup
+	for i, sh := range s.shards {
+		sh.mu.RLock()
+		ids := sh.ids[:len(sh.ids):len(sh.ids)]
+		sh.mu.RUnlock()
+		if len(ids) == 0 {
+			continue
+		}
+		parts[i].ids = ids
+
+		wg.Go(func() {
+			p := &parts[i]
+			if opts == nil {
+				p.results, p.err = sh.idx.Search(query, k)
+				return
+			}
+			searcher, ok := sh.idx.(index.OptionSearcher)
+			if !ok {
+				p.err = fmt.Errorf("does not support search options")
+				return
+			}
+			sub := *opts
+			sub.Tracer, sub.Stats = nil, &p.stats
+			sub.Filter = func(local int) bool {
+				return local < len(ids) && opts.Accept(ids[local])
+			}
+			p.results, p.err = searcher.SearchWithOptions(query, k, sub)
+		})
+	}
+	wg.Wait()
+
+	tr.Phase(index.PhaseSelect)
+	stats := tr.Stats()
+	best := topk.New[index.SearchResult](k)
+	for i, p := range parts {
+		if p.err != nil {
+			return nil, fmt.Errorf("shard %d: %w", i, p.err)
+		}
+		stats.DistanceComputations += p.stats.DistanceComputations
+		stats.NodesVisited += p.stats.NodesVisited
+		stats.ClustersProbed += p.stats.ClustersProbed
+		stats.CandidatesScanned += p.stats.CandidatesScanned
+		for _, r := range p.results {
+			// Vectors added after the shard's IDs were read are not mapped yet
+			if r.Index >= len(p.ids) {
+				continue
+			}
+			r.Index = p.ids[r.Index]
+			best.Push(r, r.Distance)
+		}
+	}
+
+	items := best.Sorted()
+	results := make([]index.SearchResult, len(items))
+	for i, item := range items {
+		results[i] = item.Value
+	}
+	return results, nil
+}
+
+// Size returns the number of vectors across all shards
+func (s *ShardedIndex) Size() int {
+	total := 0
+	for _, size := range s.ShardSizes() {
+		total += size
+	}
+	return total
+}
+
+// MemoryUsage sums the shards that report their memory, plus the ID maps
+func (s *ShardedIndex) MemoryUsage() index.MemoryBreakdown {
+	m := index.MemoryBreakdown{
+		Metadata: uint64(unsafe.Sizeof(*s)) + uint64(len(s.shards))*uint64(unsafe.Sizeof(shard{})),
+	}
+	for _, sh := range s.shards {
+		if reporter, ok := sh.idx.(index.MemoryReporter); ok {
+			m = m.Add(reporter.MemoryUsage())
+		}
+		sh.mu.RLock()
+		m.IDs += index.IntsBytes(sh.ids)
+		sh.mu.RUnlock()
+	}
+	return m
+}
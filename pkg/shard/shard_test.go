@@ -0,0 +1,254 @@
+package shard
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+
+	flat "github.com/tmdgusya/database-class/01-flat/solution"
+	ivf "github.com/tmdgusya/database-class/02-ivf/solution"
+	hnsw "github.com/tmdgusya/database-class/03-hnsw/solution"
+	"github.com/tmdgusya/database-class/pkg/distance"
+	"github.com/tmdgusya/database-class/pkg/index"
+	"github.com/tmdgusya/database-class/pkg/metrics"
+	"github.com/tmdgusya/database-class/pkg/testdata"
+	"github.com/tmdgusya/database-class/pkg/vector"
+)
+
+func flatFactory(int) (index.Index, error) {
+	return flat.NewFlatIndex(flat.Config{Metric: distance.L2Distance})
+}
+
+func newFlatShards(t *testing.T, n int) *ShardedIndex {
+	t.Helper()
+	s, err := New(n, flatFactory)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	return s
+}
+
+func TestShardedMatchesSingleIndex(t *testing.T) {
+	vectors := testdata.GenerateRandomVectors(500, 8, 1)
+	s := newFlatShards(t, 4)
+	single, _ := flat.NewFlatIndex(flat.Config{Metric: distance.L2Distance})
+	for i, v := range vectors {
+		id, err := s.Insert(v)
+		if err != nil || id != i {
+			t.Fatalf("Insert(%d) = %d, %v", i, id, err)
+		}
+		single.Add(v)
+	}
+
+	if s.Size() != 500 {
+		t.Errorf("Size() = %d, want 500", s.Size())
+	}
+	for i, size := range s.ShardSizes() {
+		if size < 90 || size > 160 {
+			t.Errorf("shard %d holds %d of 500 vectors", i, size)
+		}
+	}
+
+	for _, q := range testdata.GenerateRandomVectors(20, 8, 2) {
+		got, err := s.Search(q, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, _ := single.Search(q, 10)
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("Search() = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestShardOf(t *testing.T) {
+	counts := make([]int, 8)
+	for id := range 8000 {
+		i := ShardOf(id, 8)
+		if i != ShardOf(id, 8) {
+			t.Fatal("ShardOf is not deterministic")
+		}
+		counts[i]++
+	}
+	for i, c := range counts {
+		if c < 850 || c > 1150 {
+			t.Errorf("shard %d got %d of 8000 IDs", i, c)
+		}
+	}
+}
+
+func TestSearchOptions(t *testing.T) {
+	vectors := testdata.GenerateRandomVectors(200, 4, 3)
+	s := newFlatShards(t, 3)
+	if _, err := s.AddBatch(vectors); err != nil {
+		t.Fatal(err)
+	}
+
+	var stats index.SearchStats
+	results, err := s.SearchWithOptions(vectors[10], 5, index.SearchOptions{
+		Filter:         func(id int) bool { return id >= 100 },
+		IncludeVectors: true,
+		Stats:          &stats,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range results {
+		if r.Index < 100 {
+			t.Errorf("filtered ID %d returned", r.Index)
+		}
+		if !r.Vector.Equal(vectors[r.Index], 0) {
+			t.Errorf("result %d carries the wrong vector", r.Index)
+		}
+	}
+	if stats.CandidatesScanned != 100 || stats.PhaseDuration(index.PhaseSelect) == 0 {
+		t.Errorf("stats = %+v, want 100 candidates scanned", stats)
+	}
+
+	if _, err := s.Search(vectors[0], 0); err == nil {
+		t.Error("k = 0 not rejected")
+	}
+	if _, err := s.Search(vector.Vector{1}, 1); err == nil {
+		t.Error("dimension mismatch not rejected")
+	}
+	if _, err := s.Insert(vector.Vector{1}); err == nil {
+		t.Error("Insert with the wrong dimension succeeded")
+	}
+}
+
+func TestPerShardTraining(t *testing.T) {
+	vectors := testdata.GenerateClusteredVectors(4000, 16, 20, 4)
+	queries := testdata.GenerateClusteredVectors(50, 16, 20, 5)
+
+	s, err := New(4, func(int) (index.Index, error) {
+		return ivf.NewIVFIndex(ivf.Config{Metric: distance.L2Distance, NumClusters: 16, NumProbes: 4})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Train(vectors[:40]); err == nil {
+		t.Error("Train with 10 vectors per shard and 16 lists succeeded")
+	}
+	if err := index.Train(s, vectors); err != nil {
+		t.Fatalf("Train() failed: %v", err)
+	}
+	if _, err := s.AddBatch(vectors); err != nil {
+		t.Fatal(err)
+	}
+
+	// Every shard learned its own centroids
+	for i := range s.Shards() {
+		if lists := s.Shard(i).(*ivf.IVFIndex).ListSizes(); len(lists) != 16 {
+			t.Errorf("shard %d has %d lists", i, len(lists))
+		}
+	}
+
+	groundTruth := make([][]int, len(queries))
+	for i, q := range queries {
+		groundTruth[i] = bruteForce(vectors, q, 10)
+	}
+	got, err := metrics.SearchBatch(s, queries, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recall, _ := metrics.CalculateRecall(got, groundTruth, 10); recall < 0.9 {
+		t.Errorf("recall@10 = %.2f, want >= 0.9", recall)
+	}
+
+	flatShards := newFlatShards(t, 2)
+	if err := flatShards.Train(vectors); err != nil {
+		t.Errorf("Train() on shards that need no training: %v", err)
+	}
+	if err := flatShards.TrainShard(0, vectors); err == nil {
+		t.Error("TrainShard() on a flat shard succeeded")
+	}
+}
+
+func bruteForce(vectors []vector.Vector, q vector.Vector, k int) []int {
+	idx, _ := flat.NewFlatIndex(flat.Config{Metric: distance.L2Distance})
+	for _, v := range vectors {
+		idx.Add(v)
+	}
+	results, _ := idx.Search(q, k)
+	ids := make([]int, len(results))
+	for i, r := range results {
+		ids[i] = r.Index
+	}
+	return ids
+}
+
+func TestConcurrentWriters(t *testing.T) {
+	s, err := New(4, func(int) (index.Index, error) {
+		return hnsw.NewHNSWIndex(hnsw.Config{Metric: distance.L2Distance, M: 8, EfConstruction: 32, EfSearch: 32})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	vectors := testdata.GenerateRandomVectors(800, 8, 6)
+
+	seen := make([]bool, len(vectors))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for w := range 8 {
+		wg.Go(func() {
+			for i := w; i < len(vectors); i += 8 {
+				id, err := s.Insert(vectors[i])
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				mu.Lock()
+				seen[id] = true
+				mu.Unlock()
+				if _, err := s.Search(vectors[i], 3); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		})
+	}
+	wg.Wait()
+
+	for id, ok := range seen {
+		if !ok {
+			t.Fatalf("ID %d never assigned", id)
+		}
+	}
+	if s.Size() != len(vectors) {
+		t.Errorf("Size() = %d, want %d", s.Size(), len(vectors))
+	}
+	if m := s.MemoryUsage(); m.Graph == 0 || m.IDs == 0 {
+		t.Errorf("MemoryUsage() = %+v", m)
+	}
+}
+
+// plainIndex hides every method but those of index.Index
+type plainIndex struct{ index.Index }
+
+func TestPlainShards(t *testing.T) {
+	s, err := New(2, func(i int) (index.Index, error) {
+		idx, err := flatFactory(i)
+		return plainIndex{idx}, err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	vectors := testdata.GenerateRandomVectors(50, 4, 7)
+	s.AddBatch(vectors)
+
+	if results, err := s.Search(vectors[7], 1); err != nil || results[0].Index != 7 {
+		t.Errorf("Search() = %v, %v", results, err)
+	}
+	if _, err := s.SearchWithOptions(vectors[7], 1, index.SearchOptions{}); err == nil {
+		t.Error("SearchWithOptions() on shards without options succeeded")
+	}
+
+	boom := errors.New("boom")
+	if _, err := New(3, func(i int) (index.Index, error) { return nil, boom }); !errors.Is(err, boom) {
+		t.Errorf("factory error not returned: %v", err)
+	}
+	if _, err := New(0, flatFactory); err == nil {
+		t.Error("New(0) succeeded")
+	}
+}
@@ -0,0 +1,135 @@
+package testdata
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/tmdgusya/database-class/pkg/vector"
+)
+
+// CSVReader reads one vector per line of text
+// Values are separated by commas and/or whitespace, so both CSV exports and
+// hand-typed "0.1 0.2 0.3" lines work. Blank lines and lines starting with
+// '#' are skipped.
+type CSVReader struct {
+	sc   *bufio.Scanner
+	line int
+}
+
+// NewCSVReader reads vectors from r
+func NewCSVReader(r io.Reader) *CSVReader {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 1<<16), 1<<26) // Wide vectors make long lines
+	return &CSVReader{sc: sc}
+}
+
+// Read returns the next vector, or io.EOF when the input is exhausted
+func (cr *CSVReader) Read() (vector.Vector, error) {
+	for cr.sc.Scan() {
+		cr.line++
+		text := strings.TrimSpace(cr.sc.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.FieldsFunc(text, func(r rune) bool {
+			return r == ',' || r == ' ' || r == '\t'
+		})
+		v := make(vector.Vector, len(fields))
+		for i, field := range fields {
+			x, err := strconv.ParseFloat(field, 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %d, column %d: %w", cr.line, i+1, err)
+			}
+			v[i] = x
+		}
+		return v, nil
+	}
+	if err := cr.sc.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+// CSVWriter writes one comma-separated vector per line
+type CSVWriter struct {
+	w   *bufio.Writer
+	buf []byte
+}
+
+// NewCSVWriter writes vectors to w; call Flush when done
+func NewCSVWriter(w io.Writer) *CSVWriter {
+	return &CSVWriter{w: bufio.NewWriterSize(w, 1<<16)}
+}
+
+// Write appends one vector; values round-trip exactly
+func (cw *CSVWriter) Write(v vector.Vector) error {
+	cw.buf = cw.buf[:0]
+	for i, x := range v {
+		if i > 0 {
+			cw.buf = append(cw.buf, ',')
+		}
+		cw.buf = strconv.AppendFloat(cw.buf, x, 'g', -1, 64)
+	}
+	cw.buf = append(cw.buf, '\n')
+	_, err := cw.w.Write(cw.buf)
+	return err
+}
+
+// Flush writes any buffered lines to the underlying writer
+func (cw *CSVWriter) Flush() error {
+	return cw.w.Flush()
+}
+
+// LoadCSV reads up to limit vectors from a text file (limit <= 0 reads all)
+func LoadCSV(path string, limit int) ([]vector.Vector, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return readAll(NewCSVReader(f).Read, limit, path)
+}
+
+// SaveCSV writes vectors to path, one comma-separated line each
+func SaveCSV(path string, vectors []vector.Vector) error {
+	return saveAll(path, func(w io.Writer) error {
+		cw := NewCSVWriter(w)
+		for i, v := range vectors {
+			if err := cw.Write(v); err != nil {
+				return fmt.Errorf("vector %d: %w", i, err)
+			}
+		}
+		return cw.Flush()
+	})
+}
+
+// SaveVectors writes vectors to path in the format its extension names:
+// .fvecs, .bvecs, .npy (float32) or .csv
+func SaveVectors(path string, vectors []vector.Vector) error {
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".fvecs":
+		return SaveFvecs(path, vectors)
+	case ".bvecs":
+		return saveAll(path, func(w io.Writer) error {
+			bw := NewBvecsWriter(w)
+			for i, v := range vectors {
+				if err := bw.Write(v); err != nil {
+					return fmt.Errorf("vector %d: %w", i, err)
+				}
+			}
+			return bw.Flush()
+		})
+	case ".npy":
+		return SaveNpy(path, vectors, NpyFloat32)
+	case ".csv":
+		return SaveCSV(path, vectors)
+	default:
+		return fmt.Errorf("%s: unknown vector file extension %q (want .fvecs, .bvecs, .npy or .csv)", path, ext)
+	}
+}
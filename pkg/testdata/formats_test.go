@@ -0,0 +1,367 @@
+package testdata
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tmdgusya/database-class/pkg/vector"
+)
+
+// narrow rounds every value to float32, as .fvecs and '<f4' store them
+func narrow(vectors []vector.Vector) []vector.Vector {
+	out := make([]vector.Vector, len(vectors))
+	for i, v := range vectors {
+		out[i] = make(vector.Vector, len(v))
+		for j, x := range v {
+			out[i][j] = float64(float32(x))
+		}
+	}
+	return out
+}
+
+func assertVectorsEqual(t *testing.T, got, want []vector.Vector) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d vectors, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if !got[i].Equal(want[i], 0) {
+			t.Fatalf("vector %d = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestFvecsRoundTrip(t *testing.T) {
+	vectors := GenerateRandomVectors(50, 7, 42)
+	path := filepath.Join(t.TempDir(), "base.fvecs")
+
+	if err := SaveFvecs(path, vectors); err != nil {
+		t.Fatalf("SaveFvecs() failed: %v", err)
+	}
+	info, _ := os.Stat(path)
+	if want := int64(50 * (4 + 7*4)); info.Size() != want {
+		t.Errorf("file size = %d, want %d", info.Size(), want)
+	}
+
+	got, err := LoadFvecs(path, 0)
+	if err != nil {
+		t.Fatalf("LoadFvecs() failed: %v", err)
+	}
+	assertVectorsEqual(t, got, narrow(vectors))
+
+	limited, err := LoadVectors(path, 10)
+	if err != nil {
+		t.Fatalf("LoadVectors() failed: %v", err)
+	}
+	if len(limited) != 10 {
+		t.Errorf("limit 10 returned %d vectors", len(limited))
+	}
+}
+
+func TestFvecsReaderStreams(t *testing.T) {
+	var buf bytes.Buffer
+	fw := NewFvecsWriter(&buf)
+	for _, v := range []vector.Vector{{1, 2}, {3, 4}, {5, 6}} {
+		if err := fw.Write(v); err != nil {
+			t.Fatalf("Write() failed: %v", err)
+		}
+	}
+	if err := fw.Write(vector.Vector{1, 2, 3}); err == nil {
+		t.Error("Write() should reject a second dimension")
+	}
+	fw.Flush()
+
+	fr := NewFvecsReader(&buf)
+	count := 0
+	for {
+		v, err := fr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Read() failed: %v", err)
+		}
+		if v[0] != float64(2*count+1) {
+			t.Errorf("row %d = %v", count, v)
+		}
+		count++
+	}
+	if count != 3 || fr.Dimension() != 2 {
+		t.Errorf("read %d rows of dim %d, want 3 of dim 2", count, fr.Dimension())
+	}
+}
+
+func TestVecsCorruptInput(t *testing.T) {
+	record := func(dim int, payload int) []byte {
+		b := binary.LittleEndian.AppendUint32(nil, uint32(dim))
+		return append(b, make([]byte, payload)...)
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"truncated header", []byte{1, 0}},
+		{"truncated payload", record(4, 8)},
+		{"zero dimension", record(0, 0)},
+		{"mixed dimensions", append(record(1, 4), record(2, 8)...)},
+		{"oversized dimension", record(1<<30, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fr := NewFvecsReader(bytes.NewReader(tt.data))
+			var err error
+			for err == nil {
+				_, err = fr.Read()
+			}
+			if err == io.EOF {
+				t.Error("corrupt input read cleanly to EOF")
+			}
+		})
+	}
+}
+
+func TestVecsHeaderBeyondFile(t *testing.T) {
+	// A 4 MiB record is under the size cap, but the file cannot hold it
+	path := filepath.Join(t.TempDir(), "short.fvecs")
+	data := binary.LittleEndian.AppendUint32(nil, 1<<20)
+	if err := os.WriteFile(path, append(data, make([]byte, 16)...), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := LoadFvecs(path, 0)
+	if err == nil || !strings.Contains(err.Error(), "bytes, 16 left") {
+		t.Errorf("LoadFvecs() error = %v, want a check against the file size", err)
+	}
+}
+
+func TestBvecsRoundTrip(t *testing.T) {
+	vectors := []vector.Vector{{0, 1, 255}, {10, 20, 30}}
+	path := filepath.Join(t.TempDir(), "base.bvecs")
+
+	f, _ := os.Create(path)
+	bw := NewBvecsWriter(f)
+	for _, v := range vectors {
+		if err := bw.Write(v); err != nil {
+			t.Fatalf("Write() failed: %v", err)
+		}
+	}
+	if err := bw.Write(vector.Vector{1, 2, 256}); err == nil {
+		t.Error("Write() should reject 256")
+	}
+	if err := bw.Write(vector.Vector{1, 2, 0.5}); err == nil {
+		t.Error("Write() should reject 0.5")
+	}
+	bw.Flush()
+	f.Close()
+
+	got, err := LoadVectors(path, 0)
+	if err != nil {
+		t.Fatalf("LoadVectors() failed: %v", err)
+	}
+	assertVectorsEqual(t, got, vectors)
+}
+
+func TestIvecsGroundTruth(t *testing.T) {
+	rows := [][]int{{5, 3, 9, 1}, {0, 2, 4, 6}}
+	path := filepath.Join(t.TempDir(), "gt.ivecs")
+
+	if err := SaveIvecs(path, rows); err != nil {
+		t.Fatalf("SaveIvecs() failed: %v", err)
+	}
+
+	truth, err := LoadGroundTruth(path, 2)
+	if err != nil {
+		t.Fatalf("LoadGroundTruth() failed: %v", err)
+	}
+	if len(truth) != 2 || len(truth[0]) != 2 || truth[0][0] != 5 || truth[1][1] != 2 {
+		t.Errorf("LoadGroundTruth(k=2) = %v", truth)
+	}
+
+	if _, err := LoadGroundTruth(path, 5); err == nil {
+		t.Error("LoadGroundTruth() should fail when k exceeds the stored neighbours")
+	}
+}
+
+func TestNpyRoundTrip(t *testing.T) {
+	vectors := GenerateRandomVectors(20, 5, 7)
+
+	for _, dtype := range []string{NpyFloat32, NpyFloat64} {
+		t.Run(dtype, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "base.npy")
+			if err := SaveNpy(path, vectors, dtype); err != nil {
+				t.Fatalf("SaveNpy() failed: %v", err)
+			}
+
+			// The data must start on a 64-byte boundary
+			raw, _ := os.ReadFile(path)
+			elem := 8
+			if dtype == NpyFloat32 {
+				elem = 4
+			}
+			if offset := len(raw) - 20*5*elem; offset%64 != 0 {
+				t.Errorf("data offset %d is not 64-byte aligned", offset)
+			}
+
+			got, err := LoadVectors(path, 0)
+			if err != nil {
+				t.Fatalf("LoadVectors() failed: %v", err)
+			}
+			want := vectors
+			if dtype == NpyFloat32 {
+				want = narrow(vectors)
+			}
+			assertVectorsEqual(t, got, want)
+		})
+	}
+}
+
+// npyFile builds an .npy file by hand, the way numpy would
+func npyFile(major byte, header string, data []byte) []byte {
+	var b bytes.Buffer
+	b.WriteString(npyMagic)
+	b.Write([]byte{major, 0})
+	if major == 1 {
+		binary.Write(&b, binary.LittleEndian, uint16(len(header)))
+	} else {
+		binary.Write(&b, binary.LittleEndian, uint32(len(header)))
+	}
+	b.WriteString(header)
+	b.Write(data)
+	return b.Bytes()
+}
+
+func TestNpyReaderHeaders(t *testing.T) {
+	data := binary.LittleEndian.AppendUint64(nil, math.Float64bits(1.5))
+	data = binary.LittleEndian.AppendUint64(data, math.Float64bits(-2))
+
+	t.Run("version 2", func(t *testing.T) {
+		header := "{'descr': '<f8', 'fortran_order': False, 'shape': (1, 2), }\n"
+		nr, err := NewNpyReader(bytes.NewReader(npyFile(2, header, data)))
+		if err != nil {
+			t.Fatalf("NewNpyReader() failed: %v", err)
+		}
+		if rows, dim := nr.Shape(); rows != 1 || dim != 2 {
+			t.Errorf("Shape() = (%d, %d), want (1, 2)", rows, dim)
+		}
+		v, err := nr.Read()
+		if err != nil || v[0] != 1.5 || v[1] != -2 {
+			t.Errorf("Read() = %v, %v", v, err)
+		}
+		if _, err := nr.Read(); err != io.EOF {
+			t.Errorf("Read() past the end = %v, want io.EOF", err)
+		}
+	})
+
+	invalid := []struct {
+		name   string
+		header string
+	}{
+		{"int dtype", "{'descr': '<i4', 'fortran_order': False, 'shape': (1, 2), }"},
+		{"big endian", "{'descr': '>f8', 'fortran_order': False, 'shape': (1, 2), }"},
+		{"fortran order", "{'descr': '<f8', 'fortran_order': True, 'shape': (1, 2), }"},
+		{"1-D", "{'descr': '<f8', 'fortran_order': False, 'shape': (2,), }"},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewNpyReader(bytes.NewReader(npyFile(1, tt.header, data))); err == nil {
+				t.Error("NewNpyReader() should fail")
+			}
+		})
+	}
+
+	t.Run("oversized header length", func(t *testing.T) {
+		file := npyFile(2, "", nil)
+		binary.LittleEndian.PutUint32(file[8:], math.MaxUint32)
+		if _, err := NewNpyReader(bytes.NewReader(file)); err == nil {
+			t.Error("NewNpyReader() should reject a 4 GiB header")
+		}
+	})
+
+	t.Run("oversized row", func(t *testing.T) {
+		header := "{'descr': '<f8', 'fortran_order': False, 'shape': (1, 100000000), }"
+		if _, err := NewNpyReader(bytes.NewReader(npyFile(1, header, data))); err == nil {
+			t.Error("NewNpyReader() should reject an 800 MB row")
+		}
+	})
+
+	t.Run("row beyond file", func(t *testing.T) {
+		header := "{'descr': '<f8', 'fortran_order': False, 'shape': (1, 1048576), }"
+		path := filepath.Join(t.TempDir(), "short.npy")
+		if err := os.WriteFile(path, npyFile(1, header, data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := LoadNpy(path, 0)
+		if err == nil || !strings.Contains(err.Error(), "bytes, 16 left") {
+			t.Errorf("LoadNpy() error = %v, want a check against the file size", err)
+		}
+	})
+
+	t.Run("truncated data", func(t *testing.T) {
+		header := "{'descr': '<f8', 'fortran_order': False, 'shape': (2, 2), }"
+		nr, err := NewNpyReader(bytes.NewReader(npyFile(1, header, data)))
+		if err != nil {
+			t.Fatalf("NewNpyReader() failed: %v", err)
+		}
+		nr.Read()
+		if _, err := nr.Read(); err == nil || err == io.EOF {
+			t.Errorf("Read() of a missing row = %v, want an error", err)
+		}
+	})
+}
+
+func TestNpyWriterRowCount(t *testing.T) {
+	var buf bytes.Buffer
+	nw, err := NewNpyWriter(&buf, 2, 3, NpyFloat64)
+	if err != nil {
+		t.Fatalf("NewNpyWriter() failed: %v", err)
+	}
+	if err := nw.Write(vector.Vector{1, 2}); err == nil {
+		t.Error("Write() should reject the wrong dimension")
+	}
+	nw.Write(vector.Vector{1, 2, 3})
+	if err := nw.Close(); err == nil {
+		t.Error("Close() should fail after 1 of 2 rows")
+	}
+}
+
+func TestLoadVectorsUnknownExtension(t *testing.T) {
+	_, err := LoadVectors("data.hdf5", 0)
+	if err == nil || !strings.Contains(err.Error(), "extension") {
+		t.Errorf("LoadVectors(.hdf5) = %v, want an extension error", err)
+	}
+}
+
+func TestCSVRoundTrip(t *testing.T) {
+	vectors := GenerateRandomVectors(20, 5, 42)
+	path := filepath.Join(t.TempDir(), "base.csv")
+
+	if err := SaveVectors(path, vectors); err != nil {
+		t.Fatalf("SaveVectors() failed: %v", err)
+	}
+	got, err := LoadVectors(path, 0)
+	if err != nil {
+		t.Fatalf("LoadVectors() failed: %v", err)
+	}
+	assertVectorsEqual(t, got, vectors) // Shortest exact formatting loses nothing
+}
+
+func TestCSVReaderLenient(t *testing.T) {
+	input := "# query vectors\n1,2,3\n\n  4 5\t6 \n7, 8, 9\n"
+	got, err := readAll(NewCSVReader(strings.NewReader(input)).Read, 0, "stdin")
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	assertVectorsEqual(t, got, []vector.Vector{{1, 2, 3}, {4, 5, 6}, {7, 8, 9}})
+
+	_, err = readAll(NewCSVReader(strings.NewReader("1,2\n3,x\n")).Read, 0, "stdin")
+	if err == nil || !strings.Contains(err.Error(), "line 2, column 2") {
+		t.Errorf("bad value: err = %v, want its position", err)
+	}
+}
@@ -1,7 +1,6 @@
 package testdata
 
 import (
-	"fmt"
 	"math"
 	"math/rand"
 
@@ -223,63 +222,3 @@ func normalize(v vector.Vector) vector.Vector {
 
 	return normalized
 }
-
-// ComputeGroundTruth computes exact k-NN for queries against a database
-// This is the "ground truth" for evaluating approximate algorithms
-// Uses brute force - slow but exact
-func ComputeGroundTruth(
-	queries []vector.Vector,
-	database []vector.Vector,
-	k int,
-	metric func(a, b vector.Vector) (float64, error),
-) ([][]int, error) {
-	if len(queries) == 0 || len(database) == 0 || k <= 0 {
-		return nil, fmt.Errorf("invalid parameters: queries=%d, database=%d, k=%d",
-			len(queries), len(database), k)
-	}
-
-	if k > len(database) {
-		k = len(database)
-	}
-
-	results := make([][]int, len(queries))
-
-	for i, query := range queries {
-		// Calculate distances to all database vectors
-		type distIdx struct {
-			dist  float64
-			index int
-		}
-
-		distances := make([]distIdx, len(database))
-		for j, dbVec := range database {
-			dist, err := metric(query, dbVec)
-			if err != nil {
-				return nil, fmt.Errorf("metric error at query %d, db %d: %w", i, j, err)
-			}
-			distances[j] = distIdx{dist: dist, index: j}
-		}
-
-		// Sort by distance
-		// Simple selection sort for k smallest
-		for j := 0; j < k; j++ {
-			minIdx := j
-			for l := j + 1; l < len(distances); l++ {
-				if distances[l].dist < distances[minIdx].dist {
-					minIdx = l
-				}
-			}
-			distances[j], distances[minIdx] = distances[minIdx], distances[j]
-		}
-
-		// Extract indices
-		topK := make([]int, k)
-		for j := 0; j < k; j++ {
-			topK[j] = distances[j].index
-		}
-
-		results[i] = topK
-	}
-
-	return results, nil
-}
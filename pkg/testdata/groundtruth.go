@@ -0,0 +1,174 @@
+package testdata
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"github.com/tmdgusya/database-class/pkg/distance"
+	"github.com/tmdgusya/database-class/pkg/topk"
+	"github.com/tmdgusya/database-class/pkg/vector"
+)
+
+// Neighbor is one exact nearest neighbour of a query
+type Neighbor struct {
+	Index    int     // Position in the database
+	Distance float64 // Distance to the query
+}
+
+// GroundTruth holds the exact k nearest neighbours of every query, closest
+// first. Distances are kept for metrics such as the distance ratio that
+// compare how far approximate results are, not just which ones they are.
+type GroundTruth struct {
+	K         int
+	Neighbors [][]Neighbor // One row per query
+}
+
+// Indices returns the neighbour positions in the [][]int shape
+// metrics.CalculateRecall expects
+func (gt *GroundTruth) Indices() [][]int {
+	indices := make([][]int, len(gt.Neighbors))
+	for i, row := range gt.Neighbors {
+		indices[i] = make([]int, len(row))
+		for j, n := range row {
+			indices[i][j] = n.Index
+		}
+	}
+	return indices
+}
+
+// Distances returns the neighbour distances, row by row
+func (gt *GroundTruth) Distances() [][]float64 {
+	distances := make([][]float64, len(gt.Neighbors))
+	for i, row := range gt.Neighbors {
+		distances[i] = make([]float64, len(row))
+		for j, n := range row {
+			distances[i][j] = n.Distance
+		}
+	}
+	return distances
+}
+
+// ComputeGroundTruth computes exact k-NN for queries against a database
+// This is the "ground truth" for evaluating approximate algorithms
+// Uses brute force - slow but exact. See ComputeNeighbors for distances and
+// control over parallelism.
+func ComputeGroundTruth(
+	queries []vector.Vector,
+	database []vector.Vector,
+	k int,
+	metric func(a, b vector.Vector) (float64, error),
+) ([][]int, error) {
+	gt, err := ComputeNeighbors(queries, database, k, metric, 0)
+	if err != nil {
+		return nil, err
+	}
+	return gt.Indices(), nil
+}
+
+// ComputeNeighbors computes exact k-NN with distances, splitting the queries
+// across workers goroutines (workers <= 0 uses GOMAXPROCS)
+// Each query keeps a bounded heap of k entries, so memory is O(queries * k)
+// however large the database is. Results do not depend on the worker count:
+// ties are broken by database position.
+func ComputeNeighbors(
+	queries []vector.Vector,
+	database []vector.Vector,
+	k int,
+	metric func(a, b vector.Vector) (float64, error),
+	workers int,
+) (*GroundTruth, error) {
+	if len(queries) == 0 || len(database) == 0 || k <= 0 {
+		return nil, fmt.Errorf("invalid parameters: queries=%d, database=%d, k=%d",
+			len(queries), len(database), k)
+	}
+	if metric == nil {
+		return nil, fmt.Errorf("metric cannot be nil")
+	}
+
+	if k > len(database) {
+		k = len(database)
+	}
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > len(queries) {
+		workers = len(queries)
+	}
+
+	measure := metric
+	if sameDimension(queries, database) {
+		// Every pair is valid, so built-in metrics can skip their checks
+		if fast, ok := distance.Unchecked(metric); ok {
+			measure = func(a, b vector.Vector) (float64, error) { return fast(a, b), nil }
+		}
+	}
+
+	gt := &GroundTruth{K: k, Neighbors: make([][]Neighbor, len(queries))}
+
+	var (
+		next     atomic.Int64 // Next query to claim
+		failed   atomic.Bool
+		errOnce  sync.Once
+		firstErr error
+		wg       sync.WaitGroup
+	)
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			best := topk.New[int](k)
+
+			for !failed.Load() {
+				i := int(next.Add(1) - 1)
+				if i >= len(queries) {
+					return
+				}
+
+				best.Reset()
+				for j, dbVec := range database {
+					dist, err := measure(queries[i], dbVec)
+					if err != nil {
+						errOnce.Do(func() {
+							firstErr = fmt.Errorf("metric error at query %d, db %d: %w", i, j, err)
+						})
+						failed.Store(true)
+						return
+					}
+					best.Push(j, dist)
+				}
+
+				items := best.Sorted()
+				row := make([]Neighbor, len(items))
+				for j, item := range items {
+					row[j] = Neighbor{Index: item.Value, Distance: item.Distance}
+				}
+				gt.Neighbors[i] = row
+			}
+		}()
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return gt, nil
+}
+
+// sameDimension reports whether every vector has the same, non-zero length
+func sameDimension(queries, database []vector.Vector) bool {
+	dim := len(queries[0])
+	if dim == 0 {
+		return false
+	}
+	for _, set := range [][]vector.Vector{queries, database} {
+		for _, v := range set {
+			if len(v) != dim {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,187 @@
+package testdata
+
+import (
+	"errors"
+	"os"
+	"sort"
+	"sync/atomic"
+	"testing"
+
+	"github.com/tmdgusya/database-class/pkg/distance"
+	"github.com/tmdgusya/database-class/pkg/vector"
+)
+
+// sortedNeighbors is the reference: score everything, stable-sort, keep k
+func sortedNeighbors(t *testing.T, query vector.Vector, database []vector.Vector, k int) []Neighbor {
+	t.Helper()
+	all := make([]Neighbor, len(database))
+	for i, v := range database {
+		d, err := distance.L2Distance(query, v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		all[i] = Neighbor{Index: i, Distance: d}
+	}
+	sort.SliceStable(all, func(i, j int) bool { return all[i].Distance < all[j].Distance })
+	return all[:k]
+}
+
+func TestComputeNeighborsMatchesSort(t *testing.T) {
+	database := GenerateRandomVectors(500, 16, 1)
+	queries := GenerateRandomVectors(40, 16, 2)
+	k := 10
+
+	gt, err := ComputeNeighbors(queries, database, k, distance.L2Distance, 4)
+	if err != nil {
+		t.Fatalf("ComputeNeighbors: %v", err)
+	}
+	if gt.K != k || len(gt.Neighbors) != len(queries) {
+		t.Fatalf("got K=%d with %d rows, want K=%d with %d rows", gt.K, len(gt.Neighbors), k, len(queries))
+	}
+
+	for i, q := range queries {
+		want := sortedNeighbors(t, q, database, k)
+		for j := range want {
+			got := gt.Neighbors[i][j]
+			if got.Index != want[j].Index || abs(got.Distance-want[j].Distance) > 1e-9 {
+				t.Fatalf("query %d rank %d = %+v, want %+v", i, j, got, want[j])
+			}
+		}
+	}
+}
+
+func TestComputeNeighborsDeterministic(t *testing.T) {
+	// A grid has many equal distances, so this also checks tie-breaking
+	database := GenerateGridVectors(8, 2)
+	queries := GenerateRandomVectors(30, 2, 3)
+
+	serial, err := ComputeNeighbors(queries, database, 5, distance.L2DistanceSquared, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parallel, err := ComputeNeighbors(queries, database, 5, distance.L2DistanceSquared, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range serial.Neighbors {
+		for j := range serial.Neighbors[i] {
+			if serial.Neighbors[i][j] != parallel.Neighbors[i][j] {
+				t.Fatalf("query %d rank %d: 1 worker %+v, 8 workers %+v",
+					i, j, serial.Neighbors[i][j], parallel.Neighbors[i][j])
+			}
+		}
+	}
+
+	indices, err := ComputeGroundTruth(queries, database, 5, distance.L2DistanceSquared)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, row := range serial.Indices() {
+		for j := range row {
+			if indices[i][j] != row[j] {
+				t.Fatalf("ComputeGroundTruth query %d rank %d = %d, want %d", i, j, indices[i][j], row[j])
+			}
+		}
+	}
+}
+
+func TestComputeNeighborsMetricError(t *testing.T) {
+	database := GenerateRandomVectors(50, 4, 1)
+	queries := GenerateRandomVectors(20, 4, 2)
+	boom := errors.New("boom")
+
+	var calls atomic.Int64
+	failing := func(a, b vector.Vector) (float64, error) {
+		if calls.Add(1) == 100 {
+			return 0, boom
+		}
+		return distance.L2Distance(a, b)
+	}
+
+	if _, err := ComputeNeighbors(queries, database, 5, failing, 4); !errors.Is(err, boom) {
+		t.Fatalf("error = %v, want %v", err, boom)
+	}
+	if _, err := ComputeNeighbors(queries, database, 0, distance.L2Distance, 1); err == nil {
+		t.Fatal("expected error for k=0")
+	}
+}
+
+func TestGroundTruthCache(t *testing.T) {
+	database := GenerateRandomVectors(200, 8, 1)
+	queries := GenerateRandomVectors(10, 8, 2)
+	cache := &GroundTruthCache{Dir: t.TempDir()}
+
+	var calls atomic.Int64
+	counting := func(a, b vector.Vector) (float64, error) {
+		calls.Add(1)
+		return distance.L2Distance(a, b)
+	}
+
+	first, err := cache.GetOrCompute(queries, database, 5, "l2", counting, 2)
+	if err != nil {
+		t.Fatalf("first GetOrCompute: %v", err)
+	}
+	computed := calls.Load()
+	if computed == 0 {
+		t.Fatal("metric was never called on a cache miss")
+	}
+
+	second, err := cache.GetOrCompute(queries, database, 5, "l2", counting, 2)
+	if err != nil {
+		t.Fatalf("second GetOrCompute: %v", err)
+	}
+	if calls.Load() != computed {
+		t.Fatalf("metric called %d more times on a cache hit", calls.Load()-computed)
+	}
+	for i := range first.Neighbors {
+		for j := range first.Neighbors[i] {
+			if first.Neighbors[i][j] != second.Neighbors[i][j] {
+				t.Fatalf("cached query %d rank %d = %+v, want %+v", i, j, second.Neighbors[i][j], first.Neighbors[i][j])
+			}
+		}
+	}
+
+	// A different k is a different entry
+	if _, ok, err := cache.Load(GroundTruthKey{DatasetHash(queries, database), "l2", 3}); err != nil || ok {
+		t.Fatalf("Load(k=3) = ok %v, err %v; want a miss", ok, err)
+	}
+
+	entries, err := os.ReadDir(cache.Dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("cache dir has %d entries, want 1 (no leftover temp files)", len(entries))
+	}
+}
+
+func TestDatasetHash(t *testing.T) {
+	database := GenerateRandomVectors(20, 4, 1)
+	queries := GenerateRandomVectors(5, 4, 2)
+	h := DatasetHash(queries, database)
+
+	if DatasetHash(queries, database) != h {
+		t.Fatal("hash is not stable")
+	}
+
+	changed := make([]vector.Vector, len(database))
+	copy(changed, database)
+	changed[7] = changed[7].Clone()
+	changed[7][0] += 1e-12
+	if DatasetHash(queries, changed) == h {
+		t.Fatal("hash did not change when a value changed")
+	}
+
+	// Moving a vector from database to queries must not collide
+	if DatasetHash(append(queries, database[0]), database[1:]) == h {
+		t.Fatal("hash did not change when the split changed")
+	}
+}
+
+func abs(x float64) float64 {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,134 @@
+package testdata
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+
+	"github.com/tmdgusya/database-class/pkg/vector"
+)
+
+// DatasetHash fingerprints a query set and database
+// Any change to a value, a dimension or the order of vectors changes the
+// hash, so it is safe to key cached ground truth on it.
+func DatasetHash(queries, database []vector.Vector) string {
+	h := sha256.New()
+	var buf [8]byte
+	for _, set := range [][]vector.Vector{queries, database} {
+		binary.LittleEndian.PutUint64(buf[:], uint64(len(set)))
+		h.Write(buf[:])
+		for _, v := range set {
+			binary.LittleEndian.PutUint64(buf[:], uint64(len(v)))
+			h.Write(buf[:])
+			for _, x := range v {
+				binary.LittleEndian.PutUint64(buf[:], math.Float64bits(x))
+				h.Write(buf[:])
+			}
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// GroundTruthKey identifies one cached ground truth
+type GroundTruthKey struct {
+	DatasetHash string // From DatasetHash
+	Metric      string // Metric name, e.g. "l2"
+	K           int
+}
+
+// Filename returns the cache file name for the key
+func (k GroundTruthKey) Filename() string {
+	return fmt.Sprintf("gt-%s-%s-k%d.gob", k.DatasetHash, k.Metric, k.K)
+}
+
+// GroundTruthCache stores computed ground truth as files in Dir
+type GroundTruthCache struct {
+	Dir string
+}
+
+// Load returns the cached ground truth for key
+// ok is false, with a nil error, when nothing is cached yet.
+func (c *GroundTruthCache) Load(key GroundTruthKey) (gt *GroundTruth, ok bool, err error) {
+	f, err := os.Open(filepath.Join(c.Dir, key.Filename()))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	defer f.Close()
+
+	gt = &GroundTruth{}
+	if err := gob.NewDecoder(f).Decode(gt); err != nil {
+		return nil, false, fmt.Errorf("decode %s: %w", f.Name(), err)
+	}
+	if gt.K != key.K {
+		return nil, false, fmt.Errorf("%s: cached k=%d, want %d", f.Name(), gt.K, key.K)
+	}
+	return gt, true, nil
+}
+
+// Store writes gt under key
+// The file is written to a temporary name and renamed into place, so a
+// crashed or concurrent run never leaves a half-written entry behind.
+func (c *GroundTruthCache) Store(key GroundTruthKey, gt *GroundTruth) error {
+	if err := os.MkdirAll(c.Dir, 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(c.Dir, key.Filename()+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // No-op once renamed
+
+	if err := gob.NewEncoder(tmp).Encode(gt); err != nil {
+		tmp.Close()
+		return fmt.Errorf("encode ground truth: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(c.Dir, key.Filename()))
+}
+
+// GetOrCompute returns the cached ground truth for the dataset, computing
+// and storing it with ComputeNeighbors on a miss
+// metricName is part of the key, so it must uniquely name metric.
+func (c *GroundTruthCache) GetOrCompute(
+	queries []vector.Vector,
+	database []vector.Vector,
+	k int,
+	metricName string,
+	metric func(a, b vector.Vector) (float64, error),
+	workers int,
+) (*GroundTruth, error) {
+	if k > len(database) {
+		k = len(database) // Same clamp ComputeNeighbors applies
+	}
+	key := GroundTruthKey{DatasetHash: DatasetHash(queries, database), Metric: metricName, K: k}
+
+	gt, ok, err := c.Load(key)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		return gt, nil
+	}
+
+	gt, err = ComputeNeighbors(queries, database, k, metric, workers)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.Store(key, gt); err != nil {
+		return nil, fmt.Errorf("store ground truth: %w", err)
+	}
+	return gt, nil
+}
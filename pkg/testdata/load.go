@@ -0,0 +1,26 @@
+package testdata
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/tmdgusya/database-class/pkg/vector"
+)
+
+// LoadVectors reads up to limit vectors (limit <= 0 reads all) from a file,
+// choosing the format by extension: .fvecs, .bvecs, .npy or .csv
+func LoadVectors(path string, limit int) ([]vector.Vector, error) {
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".fvecs":
+		return LoadFvecs(path, limit)
+	case ".bvecs":
+		return LoadBvecs(path, limit)
+	case ".npy":
+		return LoadNpy(path, limit)
+	case ".csv":
+		return LoadCSV(path, limit)
+	default:
+		return nil, fmt.Errorf("%s: unknown vector file extension %q (want .fvecs, .bvecs, .npy or .csv)", path, ext)
+	}
+}
@@ -0,0 +1,321 @@
+package testdata
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+
+	"github.com/tmdgusya/database-class/pkg/vector"
+)
+
+// NumPy .npy format (https://numpy.org/doc/stable/reference/generated/numpy.lib.format.html):
+//
+//	"\x93NUMPY" | major | minor | header length | header | data
+//
+// The header is a Python dict literal such as
+// {'descr': '<f4', 'fortran_order': False, 'shape': (1000, 128), }
+// padded with spaces and a newline so the data starts 64-byte aligned. Only
+// 2-D, C-ordered, little-endian float32 ('<f4') and float64 ('<f8') arrays
+// are supported: one row per vector.
+
+const npyMagic = "\x93NUMPY"
+
+// maxNpyHeaderLen bounds the header length read from a version 2 or 3 file
+// Real headers are a few hundred bytes; the 4-byte field exists for
+// structured dtypes with many fields, which are not supported anyway.
+const maxNpyHeaderLen = 1 << 20
+
+// Supported element types
+const (
+	NpyFloat32 = "<f4"
+	NpyFloat64 = "<f8"
+)
+
+var (
+	npyDescrPattern   = regexp.MustCompile(`'descr':\s*'([^']*)'`)
+	npyFortranPattern = regexp.MustCompile(`'fortran_order':\s*(True|False)`)
+	npyShapePattern   = regexp.MustCompile(`'shape':\s*\(([^)]*)\)`)
+)
+
+// NpyReader streams the rows of a 2-D .npy array as vectors
+type NpyReader struct {
+	r     *bufio.Reader
+	descr string
+	rows  int
+	dim   int
+	read  int // Rows returned so far
+	buf   []byte
+}
+
+// NewNpyReader parses the .npy header from r
+func NewNpyReader(r io.Reader) (*NpyReader, error) {
+	remaining := remainingSize(r)
+	br := bufio.NewReaderSize(r, 1<<16)
+
+	var prefix [8]byte
+	if _, err := io.ReadFull(br, prefix[:]); err != nil {
+		return nil, fmt.Errorf("read npy magic: %w", err)
+	}
+	if string(prefix[:6]) != npyMagic {
+		return nil, fmt.Errorf("not an npy file (bad magic)")
+	}
+
+	// Version 1.x uses a 2-byte header length, 2.x and 3.x use 4 bytes
+	var headerLen, lenSize int
+	switch major := prefix[6]; major {
+	case 1:
+		var n [2]byte
+		if _, err := io.ReadFull(br, n[:]); err != nil {
+			return nil, fmt.Errorf("read npy header length: %w", err)
+		}
+		headerLen, lenSize = int(binary.LittleEndian.Uint16(n[:])), len(n)
+	case 2, 3:
+		var n [4]byte
+		if _, err := io.ReadFull(br, n[:]); err != nil {
+			return nil, fmt.Errorf("read npy header length: %w", err)
+		}
+		headerLen, lenSize = int(binary.LittleEndian.Uint32(n[:])), len(n)
+	default:
+		return nil, fmt.Errorf("unsupported npy version %d.%d", major, prefix[7])
+	}
+
+	if headerLen > maxNpyHeaderLen {
+		return nil, fmt.Errorf("npy header length %d exceeds %d", headerLen, maxNpyHeaderLen)
+	}
+	if remaining >= 0 {
+		remaining -= int64(len(prefix) + lenSize)
+		if int64(headerLen) > remaining {
+			return nil, fmt.Errorf("npy header length %d exceeds the %d bytes left in the file", headerLen, remaining)
+		}
+		remaining -= int64(headerLen)
+	}
+
+	header := make([]byte, headerLen)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return nil, fmt.Errorf("read npy header: %w", err)
+	}
+
+	nr := &NpyReader{r: br}
+	if err := nr.parseHeader(header); err != nil {
+		return nil, err
+	}
+	// Read allocates a row at a time; a file too short for one row is corrupt
+	rowSize := nr.dim * npyElemSize(nr.descr)
+	if nr.rows > 0 && remaining >= 0 && int64(rowSize) > remaining {
+		return nil, fmt.Errorf("npy data truncated: a row needs %d bytes, %d left", rowSize, remaining)
+	}
+	return nr, nil
+}
+
+func (nr *NpyReader) parseHeader(header []byte) error {
+	m := npyDescrPattern.FindSubmatch(header)
+	if m == nil {
+		return fmt.Errorf("npy header has no descr")
+	}
+	nr.descr = string(m[1])
+	if nr.descr != NpyFloat32 && nr.descr != NpyFloat64 {
+		return fmt.Errorf("unsupported npy dtype %q (want %q or %q)", nr.descr, NpyFloat32, NpyFloat64)
+	}
+
+	m = npyFortranPattern.FindSubmatch(header)
+	if m == nil {
+		return fmt.Errorf("npy header has no fortran_order")
+	}
+	if string(m[1]) == "True" {
+		return fmt.Errorf("fortran-ordered npy arrays are not supported")
+	}
+
+	m = npyShapePattern.FindSubmatch(header)
+	if m == nil {
+		return fmt.Errorf("npy header has no shape")
+	}
+	var shape []int
+	for _, field := range bytes.Split(m[1], []byte(",")) {
+		field = bytes.TrimSpace(field)
+		if len(field) == 0 {
+			continue
+		}
+		n, err := strconv.Atoi(string(field))
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid npy shape %q", m[1])
+		}
+		shape = append(shape, n)
+	}
+	if len(shape) != 2 || shape[1] == 0 {
+		return fmt.Errorf("npy array must be 2-D with non-zero rows length, got shape %v", shape)
+	}
+	if shape[1] > maxRecordSize/npyElemSize(nr.descr) {
+		return fmt.Errorf("npy row of %d elements exceeds the %d-byte record limit", shape[1], maxRecordSize)
+	}
+
+	nr.rows, nr.dim = shape[0], shape[1]
+	return nil
+}
+
+// Shape returns the number of rows and the dimension
+func (nr *NpyReader) Shape() (rows, dim int) {
+	return nr.rows, nr.dim
+}
+
+// Dtype returns the element type, NpyFloat32 or NpyFloat64
+func (nr *NpyReader) Dtype() string {
+	return nr.descr
+}
+
+// Read returns the next row, or io.EOF after the last one
+func (nr *NpyReader) Read() (vector.Vector, error) {
+	if nr.read == nr.rows {
+		return nil, io.EOF
+	}
+
+	elemSize := npyElemSize(nr.descr)
+	size := nr.dim * elemSize
+	if cap(nr.buf) < size {
+		nr.buf = make([]byte, size)
+	}
+	nr.buf = nr.buf[:size]
+	if _, err := io.ReadFull(nr.r, nr.buf); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, fmt.Errorf("row %d of %d: %w", nr.read, nr.rows, err)
+	}
+
+	v := make(vector.Vector, nr.dim)
+	for i := range v {
+		if elemSize == 4 {
+			v[i] = float64(math.Float32frombits(binary.LittleEndian.Uint32(nr.buf[i*4:])))
+		} else {
+			v[i] = math.Float64frombits(binary.LittleEndian.Uint64(nr.buf[i*8:]))
+		}
+	}
+	nr.read++
+	return v, nil
+}
+
+// NpyWriter writes a 2-D .npy array row by row
+// The shape goes in the header, so the row count is fixed up front and
+// Close fails if a different number of rows was written.
+type NpyWriter struct {
+	w       *bufio.Writer
+	descr   string
+	rows    int
+	dim     int
+	written int
+	buf     []byte
+}
+
+// NewNpyWriter writes the header for a rows x dim array of dtype to w
+func NewNpyWriter(w io.Writer, rows, dim int, dtype string) (*NpyWriter, error) {
+	if dtype != NpyFloat32 && dtype != NpyFloat64 {
+		return nil, fmt.Errorf("unsupported npy dtype %q (want %q or %q)", dtype, NpyFloat32, NpyFloat64)
+	}
+	if rows < 0 || dim <= 0 {
+		return nil, fmt.Errorf("invalid npy shape (%d, %d)", rows, dim)
+	}
+
+	header := fmt.Sprintf("{'descr': '%s', 'fortran_order': False, 'shape': (%d, %d), }", dtype, rows, dim)
+	// magic(6) + version(2) + length(2) + header + '\n' must be a multiple of 64
+	total := len(npyMagic) + 2 + 2 + len(header) + 1
+	if pad := total % 64; pad != 0 {
+		header += string(bytes.Repeat([]byte{' '}, 64-pad))
+	}
+	header += "\n"
+	if len(header) > math.MaxUint16 {
+		return nil, fmt.Errorf("npy header too long")
+	}
+
+	bw := bufio.NewWriterSize(w, 1<<16)
+	bw.WriteString(npyMagic)
+	bw.Write([]byte{1, 0})
+	binary.Write(bw, binary.LittleEndian, uint16(len(header)))
+	if _, err := bw.WriteString(header); err != nil {
+		return nil, err
+	}
+
+	return &NpyWriter{w: bw, descr: dtype, rows: rows, dim: dim}, nil
+}
+
+// Write appends one row
+func (nw *NpyWriter) Write(v vector.Vector) error {
+	if len(v) != nw.dim {
+		return fmt.Errorf("dimension mismatch: expected %d, got %d", nw.dim, len(v))
+	}
+	if nw.written == nw.rows {
+		return fmt.Errorf("npy array already has all %d rows", nw.rows)
+	}
+
+	nw.buf = nw.buf[:0]
+	for _, x := range v {
+		if nw.descr == NpyFloat32 {
+			nw.buf = binary.LittleEndian.AppendUint32(nw.buf, math.Float32bits(float32(x)))
+		} else {
+			nw.buf = binary.LittleEndian.AppendUint64(nw.buf, math.Float64bits(x))
+		}
+	}
+	if _, err := nw.w.Write(nw.buf); err != nil {
+		return err
+	}
+	nw.written++
+	return nil
+}
+
+// Close flushes buffered rows and checks that the promised shape was written
+// It does not close the underlying writer.
+func (nw *NpyWriter) Close() error {
+	if err := nw.w.Flush(); err != nil {
+		return err
+	}
+	if nw.written != nw.rows {
+		return fmt.Errorf("npy header promised %d rows, wrote %d", nw.rows, nw.written)
+	}
+	return nil
+}
+
+func npyElemSize(descr string) int {
+	if descr == NpyFloat32 {
+		return 4
+	}
+	return 8
+}
+
+// LoadNpy reads up to limit rows from a .npy file (limit <= 0 reads all)
+func LoadNpy(path string, limit int) ([]vector.Vector, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	nr, err := NewNpyReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return readAll(nr.Read, limit, path)
+}
+
+// SaveNpy writes vectors to path as a 2-D .npy array of dtype
+func SaveNpy(path string, vectors []vector.Vector, dtype string) error {
+	if len(vectors) == 0 {
+		return fmt.Errorf("no vectors to save")
+	}
+	return saveAll(path, func(w io.Writer) error {
+		nw, err := NewNpyWriter(w, len(vectors), len(vectors[0]), dtype)
+		if err != nil {
+			return err
+		}
+		for i, v := range vectors {
+			if err := nw.Write(v); err != nil {
+				return fmt.Errorf("vector %d: %w", i, err)
+			}
+		}
+		return nw.Close()
+	})
+}
@@ -0,0 +1,389 @@
+package testdata
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+
+	"github.com/tmdgusya/database-class/pkg/vector"
+)
+
+// TEXMEX formats (http://corpus-texmex.irisa.fr/), used by SIFT1M, GIST1M
+// and most ANN benchmarks. A file is a sequence of records:
+//
+//	int32 d | d elements
+//
+// all little-endian. The element type is float32 for .fvecs, int32 for
+// .ivecs (ground truth neighbour IDs) and uint8 for .bvecs.
+
+// maxRecordSize bounds the payload size taken from a header, so a corrupt
+// dimension cannot make a reader allocate gigabytes before reading anything
+const maxRecordSize = 64 << 20
+
+// vecsReader reads the records shared by all three formats
+type vecsReader struct {
+	r         *bufio.Reader
+	elemSize  int
+	dim       int   // -1 until the first record
+	record    int   // Records read so far
+	remaining int64 // Bytes left in the file, -1 if r is not a file
+	buf       []byte
+}
+
+func newVecsReader(r io.Reader, elemSize int) vecsReader {
+	return vecsReader{
+		r:         bufio.NewReaderSize(r, 1<<16),
+		elemSize:  elemSize,
+		dim:       -1,
+		remaining: remainingSize(r),
+	}
+}
+
+// next returns the raw payload of the next record, or io.EOF at a clean end
+// The returned slice is reused by the following call.
+func (vr *vecsReader) next() ([]byte, error) {
+	var header [4]byte
+	if _, err := io.ReadFull(vr.r, header[:]); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, fmt.Errorf("record %d: truncated dimension header", vr.record)
+		}
+		return nil, err // io.EOF: no more records
+	}
+	if vr.remaining >= 0 {
+		vr.remaining -= int64(len(header))
+	}
+
+	dim := int(int32(binary.LittleEndian.Uint32(header[:])))
+	if dim <= 0 {
+		return nil, fmt.Errorf("record %d: invalid dimension %d", vr.record, dim)
+	}
+	if vr.dim == -1 {
+		vr.dim = dim
+	} else if dim != vr.dim {
+		return nil, fmt.Errorf("record %d: dimension mismatch: expected %d, got %d", vr.record, vr.dim, dim)
+	}
+
+	size := dim * vr.elemSize
+	if size > maxRecordSize {
+		return nil, fmt.Errorf("record %d: dimension %d exceeds the %d-byte record limit", vr.record, dim, maxRecordSize)
+	}
+	if vr.remaining >= 0 && int64(size) > vr.remaining {
+		return nil, fmt.Errorf("record %d: truncated payload: need %d bytes, %d left", vr.record, size, vr.remaining)
+	}
+	if cap(vr.buf) < size {
+		vr.buf = make([]byte, size)
+	}
+	vr.buf = vr.buf[:size]
+	if _, err := io.ReadFull(vr.r, vr.buf); err != nil {
+		return nil, fmt.Errorf("record %d: truncated payload: %w", vr.record, err)
+	}
+	if vr.remaining >= 0 {
+		vr.remaining -= int64(size)
+	}
+
+	vr.record++
+	return vr.buf, nil
+}
+
+// Dimension returns the dimension seen so far (-1 before the first record)
+func (vr *vecsReader) Dimension() int {
+	return vr.dim
+}
+
+// FvecsReader streams float32 vectors from an .fvecs file
+type FvecsReader struct{ vecsReader }
+
+// NewFvecsReader reads .fvecs records from r
+func NewFvecsReader(r io.Reader) *FvecsReader {
+	return &FvecsReader{newVecsReader(r, 4)}
+}
+
+// Read returns the next vector, or io.EOF after the last one
+func (fr *FvecsReader) Read() (vector.Vector, error) {
+	payload, err := fr.next()
+	if err != nil {
+		return nil, err
+	}
+	v := make(vector.Vector, len(payload)/4)
+	for i := range v {
+		v[i] = float64(math.Float32frombits(binary.LittleEndian.Uint32(payload[i*4:])))
+	}
+	return v, nil
+}
+
+// BvecsReader streams uint8 vectors from a .bvecs file (e.g. SIFT1B)
+type BvecsReader struct{ vecsReader }
+
+// NewBvecsReader reads .bvecs records from r
+func NewBvecsReader(r io.Reader) *BvecsReader {
+	return &BvecsReader{newVecsReader(r, 1)}
+}
+
+// Read returns the next vector, or io.EOF after the last one
+func (br *BvecsReader) Read() (vector.Vector, error) {
+	payload, err := br.next()
+	if err != nil {
+		return nil, err
+	}
+	v := make(vector.Vector, len(payload))
+	for i, b := range payload {
+		v[i] = float64(b)
+	}
+	return v, nil
+}
+
+// IvecsReader streams int32 rows from an .ivecs file
+type IvecsReader struct{ vecsReader }
+
+// NewIvecsReader reads .ivecs records from r
+func NewIvecsReader(r io.Reader) *IvecsReader {
+	return &IvecsReader{newVecsReader(r, 4)}
+}
+
+// Read returns the next row, or io.EOF after the last one
+func (ir *IvecsReader) Read() ([]int, error) {
+	payload, err := ir.next()
+	if err != nil {
+		return nil, err
+	}
+	row := make([]int, len(payload)/4)
+	for i := range row {
+		row[i] = int(int32(binary.LittleEndian.Uint32(payload[i*4:])))
+	}
+	return row, nil
+}
+
+// vecsWriter writes records in the shared layout
+type vecsWriter struct {
+	w   *bufio.Writer
+	dim int // -1 until the first record
+	buf []byte
+}
+
+func newVecsWriter(w io.Writer) vecsWriter {
+	return vecsWriter{w: bufio.NewWriterSize(w, 1<<16), dim: -1}
+}
+
+// begin checks the dimension and writes the record header
+func (vw *vecsWriter) begin(dim int) error {
+	if dim <= 0 {
+		return fmt.Errorf("cannot write empty record")
+	}
+	if vw.dim == -1 {
+		vw.dim = dim
+	} else if dim != vw.dim {
+		return fmt.Errorf("dimension mismatch: expected %d, got %d", vw.dim, dim)
+	}
+
+	var header [4]byte
+	binary.LittleEndian.PutUint32(header[:], uint32(dim))
+	_, err := vw.w.Write(header[:])
+	return err
+}
+
+// Flush writes any buffered records to the underlying writer
+func (vw *vecsWriter) Flush() error {
+	return vw.w.Flush()
+}
+
+// FvecsWriter writes vectors as .fvecs (values are narrowed to float32)
+type FvecsWriter struct{ vecsWriter }
+
+// NewFvecsWriter writes .fvecs records to w; call Flush when done
+func NewFvecsWriter(w io.Writer) *FvecsWriter {
+	return &FvecsWriter{newVecsWriter(w)}
+}
+
+// Write appends one vector
+func (fw *FvecsWriter) Write(v vector.Vector) error {
+	if err := fw.begin(len(v)); err != nil {
+		return err
+	}
+	fw.buf = fw.buf[:0]
+	for _, x := range v {
+		fw.buf = binary.LittleEndian.AppendUint32(fw.buf, math.Float32bits(float32(x)))
+	}
+	_, err := fw.w.Write(fw.buf)
+	return err
+}
+
+// BvecsWriter writes vectors as .bvecs
+type BvecsWriter struct{ vecsWriter }
+
+// NewBvecsWriter writes .bvecs records to w; call Flush when done
+func NewBvecsWriter(w io.Writer) *BvecsWriter {
+	return &BvecsWriter{newVecsWriter(w)}
+}
+
+// Write appends one vector; every value must be an integer in [0, 255]
+func (bw *BvecsWriter) Write(v vector.Vector) error {
+	for i, x := range v {
+		if x < 0 || x > 255 || x != math.Trunc(x) {
+			return fmt.Errorf("value at index %d (%v) does not fit in uint8", i, x)
+		}
+	}
+	if err := bw.begin(len(v)); err != nil {
+		return err
+	}
+	bw.buf = bw.buf[:0]
+	for _, x := range v {
+		bw.buf = append(bw.buf, byte(x))
+	}
+	_, err := bw.w.Write(bw.buf)
+	return err
+}
+
+// IvecsWriter writes integer rows as .ivecs
+type IvecsWriter struct{ vecsWriter }
+
+// NewIvecsWriter writes .ivecs records to w; call Flush when done
+func NewIvecsWriter(w io.Writer) *IvecsWriter {
+	return &IvecsWriter{newVecsWriter(w)}
+}
+
+// Write appends one row; every value must fit in int32
+func (iw *IvecsWriter) Write(row []int) error {
+	for i, x := range row {
+		if x < math.MinInt32 || x > math.MaxInt32 {
+			return fmt.Errorf("value at index %d (%d) does not fit in int32", i, x)
+		}
+	}
+	if err := iw.begin(len(row)); err != nil {
+		return err
+	}
+	iw.buf = iw.buf[:0]
+	for _, x := range row {
+		iw.buf = binary.LittleEndian.AppendUint32(iw.buf, uint32(int32(x)))
+	}
+	_, err := iw.w.Write(iw.buf)
+	return err
+}
+
+// LoadFvecs reads up to limit vectors from an .fvecs file (limit <= 0 reads all)
+func LoadFvecs(path string, limit int) ([]vector.Vector, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return readAll(NewFvecsReader(f).Read, limit, path)
+}
+
+// LoadBvecs reads up to limit vectors from a .bvecs file (limit <= 0 reads all)
+func LoadBvecs(path string, limit int) ([]vector.Vector, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return readAll(NewBvecsReader(f).Read, limit, path)
+}
+
+// LoadIvecs reads up to limit rows from an .ivecs file (limit <= 0 reads all)
+func LoadIvecs(path string, limit int) ([][]int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return readAll(NewIvecsReader(f).Read, limit, path)
+}
+
+// LoadGroundTruth reads a TEXMEX ground-truth .ivecs file and keeps the
+// first k neighbours of each query, in the [][]int shape CalculateRecall
+// expects. Files usually hold 100 neighbours per query; asking for more than
+// a row holds is an error.
+func LoadGroundTruth(path string, k int) ([][]int, error) {
+	if k <= 0 {
+		return nil, fmt.Errorf("k must be positive, got %d", k)
+	}
+	rows, err := LoadIvecs(path, 0)
+	if err != nil {
+		return nil, err
+	}
+	for i, row := range rows {
+		if len(row) < k {
+			return nil, fmt.Errorf("%s: query %d has %d neighbours, need %d", path, i, len(row), k)
+		}
+		rows[i] = row[:k:k]
+	}
+	return rows, nil
+}
+
+// SaveFvecs writes vectors to path as .fvecs
+func SaveFvecs(path string, vectors []vector.Vector) error {
+	return saveAll(path, func(w io.Writer) error {
+		fw := NewFvecsWriter(w)
+		for i, v := range vectors {
+			if err := fw.Write(v); err != nil {
+				return fmt.Errorf("vector %d: %w", i, err)
+			}
+		}
+		return fw.Flush()
+	})
+}
+
+// SaveIvecs writes rows to path as .ivecs
+func SaveIvecs(path string, rows [][]int) error {
+	return saveAll(path, func(w io.Writer) error {
+		iw := NewIvecsWriter(w)
+		for i, row := range rows {
+			if err := iw.Write(row); err != nil {
+				return fmt.Errorf("row %d: %w", i, err)
+			}
+		}
+		return iw.Flush()
+	})
+}
+
+// readAll drains a streaming reader, stopping after limit records if positive
+func readAll[T any](read func() (T, error), limit int, path string) ([]T, error) {
+	var out []T
+	for limit <= 0 || len(out) < limit {
+		item, err := read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		out = append(out, item)
+	}
+	return out, nil
+}
+
+// remainingSize returns the bytes left to read when r is a regular file,
+// or -1 when the size is unknown
+func remainingSize(r io.Reader) int64 {
+	f, ok := r.(*os.File)
+	if !ok {
+		return -1
+	}
+	info, err := f.Stat()
+	if err != nil || !info.Mode().IsRegular() {
+		return -1
+	}
+	pos, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return -1
+	}
+	return info.Size() - pos
+}
+
+// saveAll creates path, runs write and reports the first error, including
+// the one from closing the file
+func saveAll(path string, write func(w io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return f.Close()
+}
@@ -0,0 +1,212 @@
+package topk
+
+import (
+	"math"
+	"sort"
+)
+
+// Item is a value ranked by its distance
+type Item[T any] struct {
+	Value    T
+	Distance float64
+}
+
+// Heap keeps the k items with the smallest distances seen so far
+// It is a bounded max-heap: the root is the worst item kept, so each Push is
+// O(log k) and memory stays O(k) no matter how many items are offered.
+type Heap[T any] struct {
+	k     int
+	items []Item[T]
+}
+
+// maxPrealloc caps the capacity New reserves up front
+// k often comes straight from a request, and a huge k with few items would
+// otherwise allocate (or panic on) a slice sized for k.
+const maxPrealloc = 1024
+
+// New creates a heap that keeps at most k items
+// Room for up to maxPrealloc items is reserved; beyond that the heap grows
+// as items arrive.
+func New[T any](k int) *Heap[T] {
+	if k < 0 {
+		k = 0
+	}
+	return &Heap[T]{
+		k:     k,
+		items: make([]Item[T], 0, min(k, maxPrealloc)),
+	}
+}
+
+// Len returns the number of items kept
+func (h *Heap[T]) Len() int {
+	return len(h.items)
+}
+
+// Full reports whether the heap holds k items
+func (h *Heap[T]) Full() bool {
+	return len(h.items) >= h.k
+}
+
+// Threshold returns the distance an item must beat to be kept
+// It is +Inf until the heap is full.
+func (h *Heap[T]) Threshold() float64 {
+	if !h.Full() {
+		return math.Inf(1)
+	}
+	if h.k == 0 {
+		return math.Inf(-1)
+	}
+	return h.items[0].Distance
+}
+
+// Accepts reports whether an item at dist would be kept by Push
+// Use it to skip building values that would be thrown away.
+func (h *Heap[T]) Accepts(dist float64) bool {
+	return dist < h.Threshold()
+}
+
+// Push offers an item and reports whether it was kept
+// When full, the item replaces the current worst only if it is strictly
+// closer, so among equal distances the earliest pushed items win.
+func (h *Heap[T]) Push(value T, dist float64) bool {
+	if h.k == 0 {
+		return false
+	}
+
+	if len(h.items) < h.k {
+		h.items = append(h.items, Item[T]{Value: value, Distance: dist})
+		h.up(len(h.items) - 1)
+		return true
+	}
+
+	if dist >= h.items[0].Distance {
+		return false
+	}
+
+	h.items[0] = Item[T]{Value: value, Distance: dist}
+	h.down(0)
+	return true
+}
+
+// Pop removes and returns the worst item
+func (h *Heap[T]) Pop() (Item[T], bool) {
+	if len(h.items) == 0 {
+		return Item[T]{}, false
+	}
+
+	worst := h.items[0]
+	last := len(h.items) - 1
+	h.items[0] = h.items[last]
+	h.items = h.items[:last]
+	if last > 0 {
+		h.down(0)
+	}
+	return worst, true
+}
+
+// Sorted empties the heap and returns its items, closest first
+func (h *Heap[T]) Sorted() []Item[T] {
+	result := make([]Item[T], len(h.items))
+	for i := len(result) - 1; i >= 0; i-- {
+		result[i], _ = h.Pop()
+	}
+	return result
+}
+
+// Reset empties the heap so it can be reused
+func (h *Heap[T]) Reset() {
+	h.items = h.items[:0]
+}
+
+func (h *Heap[T]) up(i int) {
+	for i > 0 {
+		parent := (i - 1) / 2
+		if h.items[parent].Distance >= h.items[i].Distance {
+			break
+		}
+		h.items[parent], h.items[i] = h.items[i], h.items[parent]
+		i = parent
+	}
+}
+
+func (h *Heap[T]) down(i int) {
+	n := len(h.items)
+	for {
+		largest := i
+		left, right := 2*i+1, 2*i+2
+		if left < n && h.items[left].Distance > h.items[largest].Distance {
+			largest = left
+		}
+		if right < n && h.items[right].Distance > h.items[largest].Distance {
+			largest = right
+		}
+		if largest == i {
+			return
+		}
+		h.items[i], h.items[largest] = h.items[largest], h.items[i]
+		i = largest
+	}
+}
+
+// Select returns the k items with the smallest distances, closest first
+// It reorders items in place using quickselect (O(n) on average) and then
+// sorts only the k selected items. Prefer it over Heap when every distance
+// is already materialized.
+func Select[T any](items []Item[T], k int) []Item[T] {
+	if k <= 0 {
+		return items[:0]
+	}
+	if k < len(items) {
+		quickselect(items, k)
+		items = items[:k]
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Distance < items[j].Distance
+	})
+	return items
+}
+
+// quickselect partitions items so the k smallest occupy items[:k]
+func quickselect[T any](items []Item[T], k int) {
+	lo, hi := 0, len(items)-1
+	for lo < hi {
+		// Median of three keeps sorted input from degrading to O(n^2)
+		mid := lo + (hi-lo)/2
+		if items[mid].Distance < items[lo].Distance {
+			items[mid], items[lo] = items[lo], items[mid]
+		}
+		if items[hi].Distance < items[lo].Distance {
+			items[hi], items[lo] = items[lo], items[hi]
+		}
+		if items[hi].Distance < items[mid].Distance {
+			items[hi], items[mid] = items[mid], items[hi]
+		}
+		pivot := items[mid].Distance
+
+		i, j := lo, hi
+		for i <= j {
+			for items[i].Distance < pivot {
+				i++
+			}
+			for items[j].Distance > pivot {
+				j--
+			}
+			if i <= j {
+				items[i], items[j] = items[j], items[i]
+				i++
+				j--
+			}
+		}
+
+		// items[lo:j+1] <= pivot <= items[i:hi+1]
+		switch {
+		case k-1 <= j:
+			hi = j
+		case k-1 >= i:
+			lo = i
+		default:
+			return
+		}
+	}
+}
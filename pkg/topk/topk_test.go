@@ -0,0 +1,161 @@
+package topk
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func randomDistances(n int, seed int64) []float64 {
+	rng := rand.New(rand.NewSource(seed))
+	dists := make([]float64, n)
+	for i := range dists {
+		// Few distinct values so ties are common
+		dists[i] = float64(rng.Intn(n / 4))
+	}
+	return dists
+}
+
+func sortedTopK(dists []float64, k int) []float64 {
+	sorted := append([]float64(nil), dists...)
+	sort.Float64s(sorted)
+	if k > len(sorted) {
+		k = len(sorted)
+	}
+	return sorted[:k]
+}
+
+func TestHeap(t *testing.T) {
+	for _, k := range []int{1, 5, 10, 100, 2000} {
+		dists := randomDistances(1000, int64(k))
+
+		h := New[int](k)
+		for i, d := range dists {
+			h.Push(i, d)
+		}
+
+		got := h.Sorted()
+		want := sortedTopK(dists, k)
+		if len(got) != len(want) {
+			t.Fatalf("k=%d: got %d items, want %d", k, len(got), len(want))
+		}
+		for i := range want {
+			if got[i].Distance != want[i] {
+				t.Errorf("k=%d rank %d: distance %f, want %f", k, i, got[i].Distance, want[i])
+			}
+			if dists[got[i].Value] != got[i].Distance {
+				t.Errorf("k=%d rank %d: value %d does not match its distance", k, i, got[i].Value)
+			}
+		}
+		if h.Len() != 0 {
+			t.Errorf("Sorted() should empty the heap, %d left", h.Len())
+		}
+	}
+}
+
+func TestHeapThreshold(t *testing.T) {
+	h := New[int](2)
+
+	if !h.Accepts(1e300) {
+		t.Error("a heap that is not full should accept anything")
+	}
+
+	h.Push(0, 5)
+	h.Push(1, 3)
+	if h.Threshold() != 5 {
+		t.Errorf("Threshold() = %f, want 5", h.Threshold())
+	}
+	if h.Accepts(5) {
+		t.Error("ties with the worst item should be rejected")
+	}
+	if !h.Push(2, 1) {
+		t.Error("Push(1) should replace the worst item")
+	}
+	if h.Threshold() != 3 {
+		t.Errorf("Threshold() = %f, want 3", h.Threshold())
+	}
+}
+
+func TestHeapZeroK(t *testing.T) {
+	h := New[int](0)
+	if h.Push(0, 1) {
+		t.Error("a heap with k=0 should keep nothing")
+	}
+	if len(h.Sorted()) != 0 {
+		t.Error("Sorted() should be empty")
+	}
+}
+
+func TestHeapHugeK(t *testing.T) {
+	// k is often caller-controlled; it must not size the allocation
+	h := New[int](1 << 60)
+	for i := range 3 {
+		h.Push(i, float64(3-i))
+	}
+	if got := h.Sorted(); len(got) != 3 || got[0].Value != 2 {
+		t.Errorf("Sorted() = %v", got)
+	}
+}
+
+func TestSelect(t *testing.T) {
+	for _, k := range []int{0, 1, 7, 250, 999, 1000, 5000} {
+		dists := randomDistances(1000, int64(k)+1)
+
+		items := make([]Item[int], len(dists))
+		for i, d := range dists {
+			items[i] = Item[int]{Value: i, Distance: d}
+		}
+
+		got := Select(items, k)
+		want := sortedTopK(dists, k)
+		if k == 0 {
+			want = nil
+		}
+		if len(got) != len(want) {
+			t.Fatalf("k=%d: got %d items, want %d", k, len(got), len(want))
+		}
+		for i := range want {
+			if got[i].Distance != want[i] {
+				t.Errorf("k=%d rank %d: distance %f, want %f", k, i, got[i].Distance, want[i])
+			}
+		}
+	}
+}
+
+func TestSelectSortedInput(t *testing.T) {
+	items := make([]Item[int], 10000)
+	for i := range items {
+		items[i] = Item[int]{Value: i, Distance: float64(i)}
+	}
+
+	got := Select(items, 3)
+	for i, item := range got {
+		if item.Value != i {
+			t.Errorf("rank %d: got %d, want %d", i, item.Value, i)
+		}
+	}
+}
+
+func BenchmarkHeap(b *testing.B) {
+	dists := randomDistances(100000, 1)
+	h := New[int](10)
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		h.Reset()
+		for i, d := range dists {
+			h.Push(i, d)
+		}
+	}
+}
+
+func BenchmarkSelect(b *testing.B) {
+	dists := randomDistances(100000, 1)
+	items := make([]Item[int], len(dists))
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		for i, d := range dists {
+			items[i] = Item[int]{Value: i, Distance: d}
+		}
+		Select(items, 10)
+	}
+}
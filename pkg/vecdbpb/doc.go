@@ -0,0 +1,11 @@
+// Package vecdbpb is the generated gRPC client and message types for
+// cmd/vecdbd (see vecdb.proto)
+//
+// Dial the server and wrap the connection:
+//
+//	conn, err := grpc.NewClient("localhost:9090",
+//	    grpc.WithTransportCredentials(insecure.NewCredentials()))
+//	client := vecdbpb.NewVectorDBClient(conn)
+package vecdbpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative vecdb.proto
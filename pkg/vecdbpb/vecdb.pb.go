@@ -0,0 +1,1179 @@
+// gRPC API of cmd/vecdbd
+//
+// It mirrors the HTTP/JSON API: named collections of points with string IDs
+// and JSON-like payloads. Vectors travel as packed float32, 4 bytes per
+// component instead of ~20 characters of JSON; the server stores float64.
+//
+// Regenerate vecdb.pb.go and vecdb_grpc.pb.go after editing, with
+// protoc-gen-go and protoc-gen-go-grpc:
+//
+//	protoc --go_out=. --go_opt=paths=source_relative \
+//	    --go-grpc_out=. --go-grpc_opt=paths=source_relative vecdb.proto
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: vecdb.proto
+
+package vecdbpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// CollectionConfig matches the HTTP create body; zero values take defaults
+type CollectionConfig struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Name      string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Dimension int32                  `protobuf:"varint,2,opt,name=dimension,proto3" json:"dimension,omitempty"`
+	Metric    string                 `protobuf:"bytes,3,opt,name=metric,proto3" json:"metric,omitempty"` // l2 | l2sq | cosine | dot; default l2
+	Index     string                 `protobuf:"bytes,4,opt,name=index,proto3" json:"index,omitempty"`   // flat | ivf | hnsw; default flat
+	// IVF
+	Nlist     int32 `protobuf:"varint,5,opt,name=nlist,proto3" json:"nlist,omitempty"`
+	Nprobe    int32 `protobuf:"varint,6,opt,name=nprobe,proto3" json:"nprobe,omitempty"`
+	TrainSize int32 `protobuf:"varint,7,opt,name=train_size,json=trainSize,proto3" json:"train_size,omitempty"`
+	// HNSW
+	M              int32 `protobuf:"varint,8,opt,name=m,proto3" json:"m,omitempty"`
+	EfConstruction int32 `protobuf:"varint,9,opt,name=ef_construction,json=efConstruction,proto3" json:"ef_construction,omitempty"`
+	EfSearch       int32 `protobuf:"varint,10,opt,name=ef_search,json=efSearch,proto3" json:"ef_search,omitempty"`
+	// Payload schema; empty accepts any payload
+	Schema        map[string]*FieldSchema `protobuf:"bytes,11,rep,name=schema,proto3" json:"schema,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CollectionConfig) Reset() {
+	*x = CollectionConfig{}
+	mi := &file_vecdb_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CollectionConfig) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CollectionConfig) ProtoMessage() {}
+
+func (x *CollectionConfig) ProtoReflect() protoreflect.Message {
+	mi := &file_vecdb_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CollectionConfig.ProtoReflect.Descriptor instead.
+func (*CollectionConfig) Descriptor() ([]byte, []int) {
+	return file_vecdb_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *CollectionConfig) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *CollectionConfig) GetDimension() int32 {
+	if x != nil {
+		return x.Dimension
+	}
+	return 0
+}
+
+func (x *CollectionConfig) GetMetric() string {
+	if x != nil {
+		return x.Metric
+	}
+	return ""
+}
+
+func (x *CollectionConfig) GetIndex() string {
+	if x != nil {
+		return x.Index
+	}
+	return ""
+}
+
+func (x *CollectionConfig) GetNlist() int32 {
+	if x != nil {
+		return x.Nlist
+	}
+	return 0
+}
+
+func (x *CollectionConfig) GetNprobe() int32 {
+	if x != nil {
+		return x.Nprobe
+	}
+	return 0
+}
+
+func (x *CollectionConfig) GetTrainSize() int32 {
+	if x != nil {
+		return x.TrainSize
+	}
+	return 0
+}
+
+func (x *CollectionConfig) GetM() int32 {
+	if x != nil {
+		return x.M
+	}
+	return 0
+}
+
+func (x *CollectionConfig) GetEfConstruction() int32 {
+	if x != nil {
+		return x.EfConstruction
+	}
+	return 0
+}
+
+func (x *CollectionConfig) GetEfSearch() int32 {
+	if x != nil {
+		return x.EfSearch
+	}
+	return 0
+}
+
+func (x *CollectionConfig) GetSchema() map[string]*FieldSchema {
+	if x != nil {
+		return x.Schema
+	}
+	return nil
+}
+
+// FieldSchema declares one payload field
+type FieldSchema struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"` // string | number | integer | bool
+	Required      bool                   `protobuf:"varint,2,opt,name=required,proto3" json:"required,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FieldSchema) Reset() {
+	*x = FieldSchema{}
+	mi := &file_vecdb_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FieldSchema) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FieldSchema) ProtoMessage() {}
+
+func (x *FieldSchema) ProtoReflect() protoreflect.Message {
+	mi := &file_vecdb_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FieldSchema.ProtoReflect.Descriptor instead.
+func (*FieldSchema) Descriptor() ([]byte, []int) {
+	return file_vecdb_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *FieldSchema) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *FieldSchema) GetRequired() bool {
+	if x != nil {
+		return x.Required
+	}
+	return false
+}
+
+type CollectionInfo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Config        *CollectionConfig      `protobuf:"bytes,1,opt,name=config,proto3" json:"config,omitempty"`    // With defaults filled in
+	Points        int64                  `protobuf:"varint,2,opt,name=points,proto3" json:"points,omitempty"`   // Live points
+	Deleted       int64                  `protobuf:"varint,3,opt,name=deleted,proto3" json:"deleted,omitempty"` // Tombstones still in the index
+	Trained       bool                   `protobuf:"varint,4,opt,name=trained,proto3" json:"trained,omitempty"`
+	MemoryBytes   uint64                 `protobuf:"varint,5,opt,name=memory_bytes,json=memoryBytes,proto3" json:"memory_bytes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CollectionInfo) Reset() {
+	*x = CollectionInfo{}
+	mi := &file_vecdb_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CollectionInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CollectionInfo) ProtoMessage() {}
+
+func (x *CollectionInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_vecdb_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CollectionInfo.ProtoReflect.Descriptor instead.
+func (*CollectionInfo) Descriptor() ([]byte, []int) {
+	return file_vecdb_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *CollectionInfo) GetConfig() *CollectionConfig {
+	if x != nil {
+		return x.Config
+	}
+	return nil
+}
+
+func (x *CollectionInfo) GetPoints() int64 {
+	if x != nil {
+		return x.Points
+	}
+	return 0
+}
+
+func (x *CollectionInfo) GetDeleted() int64 {
+	if x != nil {
+		return x.Deleted
+	}
+	return 0
+}
+
+func (x *CollectionInfo) GetTrained() bool {
+	if x != nil {
+		return x.Trained
+	}
+	return false
+}
+
+func (x *CollectionInfo) GetMemoryBytes() uint64 {
+	if x != nil {
+		return x.MemoryBytes
+	}
+	return 0
+}
+
+type Point struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Vector        []float32              `protobuf:"fixed32,2,rep,packed,name=vector,proto3" json:"vector,omitempty"`
+	Payload       *structpb.Struct       `protobuf:"bytes,3,opt,name=payload,proto3" json:"payload,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Point) Reset() {
+	*x = Point{}
+	mi := &file_vecdb_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Point) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Point) ProtoMessage() {}
+
+func (x *Point) ProtoReflect() protoreflect.Message {
+	mi := &file_vecdb_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Point.ProtoReflect.Descriptor instead.
+func (*Point) Descriptor() ([]byte, []int) {
+	return file_vecdb_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Point) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Point) GetVector() []float32 {
+	if x != nil {
+		return x.Vector
+	}
+	return nil
+}
+
+func (x *Point) GetPayload() *structpb.Struct {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+type CreateCollectionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Config        *CollectionConfig      `protobuf:"bytes,1,opt,name=config,proto3" json:"config,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateCollectionRequest) Reset() {
+	*x = CreateCollectionRequest{}
+	mi := &file_vecdb_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateCollectionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateCollectionRequest) ProtoMessage() {}
+
+func (x *CreateCollectionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_vecdb_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateCollectionRequest.ProtoReflect.Descriptor instead.
+func (*CreateCollectionRequest) Descriptor() ([]byte, []int) {
+	return file_vecdb_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *CreateCollectionRequest) GetConfig() *CollectionConfig {
+	if x != nil {
+		return x.Config
+	}
+	return nil
+}
+
+type GetCollectionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetCollectionRequest) Reset() {
+	*x = GetCollectionRequest{}
+	mi := &file_vecdb_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCollectionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCollectionRequest) ProtoMessage() {}
+
+func (x *GetCollectionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_vecdb_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCollectionRequest.ProtoReflect.Descriptor instead.
+func (*GetCollectionRequest) Descriptor() ([]byte, []int) {
+	return file_vecdb_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *GetCollectionRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type ListCollectionsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListCollectionsRequest) Reset() {
+	*x = ListCollectionsRequest{}
+	mi := &file_vecdb_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListCollectionsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListCollectionsRequest) ProtoMessage() {}
+
+func (x *ListCollectionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_vecdb_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListCollectionsRequest.ProtoReflect.Descriptor instead.
+func (*ListCollectionsRequest) Descriptor() ([]byte, []int) {
+	return file_vecdb_proto_rawDescGZIP(), []int{6}
+}
+
+type ListCollectionsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Names         []string               `protobuf:"bytes,1,rep,name=names,proto3" json:"names,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListCollectionsResponse) Reset() {
+	*x = ListCollectionsResponse{}
+	mi := &file_vecdb_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListCollectionsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListCollectionsResponse) ProtoMessage() {}
+
+func (x *ListCollectionsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_vecdb_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListCollectionsResponse.ProtoReflect.Descriptor instead.
+func (*ListCollectionsResponse) Descriptor() ([]byte, []int) {
+	return file_vecdb_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ListCollectionsResponse) GetNames() []string {
+	if x != nil {
+		return x.Names
+	}
+	return nil
+}
+
+type DropCollectionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DropCollectionRequest) Reset() {
+	*x = DropCollectionRequest{}
+	mi := &file_vecdb_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DropCollectionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DropCollectionRequest) ProtoMessage() {}
+
+func (x *DropCollectionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_vecdb_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DropCollectionRequest.ProtoReflect.Descriptor instead.
+func (*DropCollectionRequest) Descriptor() ([]byte, []int) {
+	return file_vecdb_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *DropCollectionRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type DropCollectionResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DropCollectionResponse) Reset() {
+	*x = DropCollectionResponse{}
+	mi := &file_vecdb_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DropCollectionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DropCollectionResponse) ProtoMessage() {}
+
+func (x *DropCollectionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_vecdb_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DropCollectionResponse.ProtoReflect.Descriptor instead.
+func (*DropCollectionResponse) Descriptor() ([]byte, []int) {
+	return file_vecdb_proto_rawDescGZIP(), []int{9}
+}
+
+type UpsertRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Collection    string                 `protobuf:"bytes,1,opt,name=collection,proto3" json:"collection,omitempty"`
+	Points        []*Point               `protobuf:"bytes,2,rep,name=points,proto3" json:"points,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpsertRequest) Reset() {
+	*x = UpsertRequest{}
+	mi := &file_vecdb_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpsertRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpsertRequest) ProtoMessage() {}
+
+func (x *UpsertRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_vecdb_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpsertRequest.ProtoReflect.Descriptor instead.
+func (*UpsertRequest) Descriptor() ([]byte, []int) {
+	return file_vecdb_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *UpsertRequest) GetCollection() string {
+	if x != nil {
+		return x.Collection
+	}
+	return ""
+}
+
+func (x *UpsertRequest) GetPoints() []*Point {
+	if x != nil {
+		return x.Points
+	}
+	return nil
+}
+
+type UpsertResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Upserted      int64                  `protobuf:"varint,1,opt,name=upserted,proto3" json:"upserted,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpsertResponse) Reset() {
+	*x = UpsertResponse{}
+	mi := &file_vecdb_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpsertResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpsertResponse) ProtoMessage() {}
+
+func (x *UpsertResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_vecdb_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpsertResponse.ProtoReflect.Descriptor instead.
+func (*UpsertResponse) Descriptor() ([]byte, []int) {
+	return file_vecdb_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *UpsertResponse) GetUpserted() int64 {
+	if x != nil {
+		return x.Upserted
+	}
+	return 0
+}
+
+type DeleteRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Collection    string                 `protobuf:"bytes,1,opt,name=collection,proto3" json:"collection,omitempty"`
+	Ids           []string               `protobuf:"bytes,2,rep,name=ids,proto3" json:"ids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteRequest) Reset() {
+	*x = DeleteRequest{}
+	mi := &file_vecdb_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteRequest) ProtoMessage() {}
+
+func (x *DeleteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_vecdb_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteRequest.ProtoReflect.Descriptor instead.
+func (*DeleteRequest) Descriptor() ([]byte, []int) {
+	return file_vecdb_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *DeleteRequest) GetCollection() string {
+	if x != nil {
+		return x.Collection
+	}
+	return ""
+}
+
+func (x *DeleteRequest) GetIds() []string {
+	if x != nil {
+		return x.Ids
+	}
+	return nil
+}
+
+type DeleteResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Deleted       int64                  `protobuf:"varint,1,opt,name=deleted,proto3" json:"deleted,omitempty"` // IDs that were present
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteResponse) Reset() {
+	*x = DeleteResponse{}
+	mi := &file_vecdb_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteResponse) ProtoMessage() {}
+
+func (x *DeleteResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_vecdb_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteResponse.ProtoReflect.Descriptor instead.
+func (*DeleteResponse) Descriptor() ([]byte, []int) {
+	return file_vecdb_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *DeleteResponse) GetDeleted() int64 {
+	if x != nil {
+		return x.Deleted
+	}
+	return 0
+}
+
+type SearchRequest struct {
+	state          protoimpl.MessageState     `protogen:"open.v1"`
+	Collection     string                     `protobuf:"bytes,1,opt,name=collection,proto3" json:"collection,omitempty"`
+	Vector         []float32                  `protobuf:"fixed32,2,rep,packed,name=vector,proto3" json:"vector,omitempty"`
+	K              int32                      `protobuf:"varint,3,opt,name=k,proto3" json:"k,omitempty"`
+	Filter         map[string]*structpb.Value `protobuf:"bytes,4,rep,name=filter,proto3" json:"filter,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // Payload fields that must match exactly
+	Nprobe         int32                      `protobuf:"varint,5,opt,name=nprobe,proto3" json:"nprobe,omitempty"`
+	EfSearch       int32                      `protobuf:"varint,6,opt,name=ef_search,json=efSearch,proto3" json:"ef_search,omitempty"`
+	IncludeVectors bool                       `protobuf:"varint,7,opt,name=include_vectors,json=includeVectors,proto3" json:"include_vectors,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *SearchRequest) Reset() {
+	*x = SearchRequest{}
+	mi := &file_vecdb_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchRequest) ProtoMessage() {}
+
+func (x *SearchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_vecdb_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchRequest.ProtoReflect.Descriptor instead.
+func (*SearchRequest) Descriptor() ([]byte, []int) {
+	return file_vecdb_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *SearchRequest) GetCollection() string {
+	if x != nil {
+		return x.Collection
+	}
+	return ""
+}
+
+func (x *SearchRequest) GetVector() []float32 {
+	if x != nil {
+		return x.Vector
+	}
+	return nil
+}
+
+func (x *SearchRequest) GetK() int32 {
+	if x != nil {
+		return x.K
+	}
+	return 0
+}
+
+func (x *SearchRequest) GetFilter() map[string]*structpb.Value {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+func (x *SearchRequest) GetNprobe() int32 {
+	if x != nil {
+		return x.Nprobe
+	}
+	return 0
+}
+
+func (x *SearchRequest) GetEfSearch() int32 {
+	if x != nil {
+		return x.EfSearch
+	}
+	return 0
+}
+
+func (x *SearchRequest) GetIncludeVectors() bool {
+	if x != nil {
+		return x.IncludeVectors
+	}
+	return false
+}
+
+type Hit struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Distance      float64                `protobuf:"fixed64,2,opt,name=distance,proto3" json:"distance,omitempty"`
+	Payload       *structpb.Struct       `protobuf:"bytes,3,opt,name=payload,proto3" json:"payload,omitempty"`
+	Vector        []float32              `protobuf:"fixed32,4,rep,packed,name=vector,proto3" json:"vector,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Hit) Reset() {
+	*x = Hit{}
+	mi := &file_vecdb_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Hit) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Hit) ProtoMessage() {}
+
+func (x *Hit) ProtoReflect() protoreflect.Message {
+	mi := &file_vecdb_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Hit.ProtoReflect.Descriptor instead.
+func (*Hit) Descriptor() ([]byte, []int) {
+	return file_vecdb_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *Hit) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Hit) GetDistance() float64 {
+	if x != nil {
+		return x.Distance
+	}
+	return 0
+}
+
+func (x *Hit) GetPayload() *structpb.Struct {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *Hit) GetVector() []float32 {
+	if x != nil {
+		return x.Vector
+	}
+	return nil
+}
+
+type SearchResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Hits          []*Hit                 `protobuf:"bytes,1,rep,name=hits,proto3" json:"hits,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchResponse) Reset() {
+	*x = SearchResponse{}
+	mi := &file_vecdb_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchResponse) ProtoMessage() {}
+
+func (x *SearchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_vecdb_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchResponse.ProtoReflect.Descriptor instead.
+func (*SearchResponse) Descriptor() ([]byte, []int) {
+	return file_vecdb_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *SearchResponse) GetHits() []*Hit {
+	if x != nil {
+		return x.Hits
+	}
+	return nil
+}
+
+var File_vecdb_proto protoreflect.FileDescriptor
+
+const file_vecdb_proto_rawDesc = "" +
+	"\n" +
+	"\vvecdb.proto\x12\bvecdb.v1\x1a\x1cgoogle/protobuf/struct.proto\"\xa5\x03\n" +
+	"\x10CollectionConfig\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1c\n" +
+	"\tdimension\x18\x02 \x01(\x05R\tdimension\x12\x16\n" +
+	"\x06metric\x18\x03 \x01(\tR\x06metric\x12\x14\n" +
+	"\x05index\x18\x04 \x01(\tR\x05index\x12\x14\n" +
+	"\x05nlist\x18\x05 \x01(\x05R\x05nlist\x12\x16\n" +
+	"\x06nprobe\x18\x06 \x01(\x05R\x06nprobe\x12\x1d\n" +
+	"\n" +
+	"train_size\x18\a \x01(\x05R\ttrainSize\x12\f\n" +
+	"\x01m\x18\b \x01(\x05R\x01m\x12'\n" +
+	"\x0fef_construction\x18\t \x01(\x05R\x0eefConstruction\x12\x1b\n" +
+	"\tef_search\x18\n" +
+	" \x01(\x05R\befSearch\x12>\n" +
+	"\x06schema\x18\v \x03(\v2&.vecdb.v1.CollectionConfig.SchemaEntryR\x06schema\x1aP\n" +
+	"\vSchemaEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12+\n" +
+	"\x05value\x18\x02 \x01(\v2\x15.vecdb.v1.FieldSchemaR\x05value:\x028\x01\"=\n" +
+	"\vFieldSchema\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12\x1a\n" +
+	"\brequired\x18\x02 \x01(\bR\brequired\"\xb3\x01\n" +
+	"\x0eCollectionInfo\x122\n" +
+	"\x06config\x18\x01 \x01(\v2\x1a.vecdb.v1.CollectionConfigR\x06config\x12\x16\n" +
+	"\x06points\x18\x02 \x01(\x03R\x06points\x12\x18\n" +
+	"\adeleted\x18\x03 \x01(\x03R\adeleted\x12\x18\n" +
+	"\atrained\x18\x04 \x01(\bR\atrained\x12!\n" +
+	"\fmemory_bytes\x18\x05 \x01(\x04R\vmemoryBytes\"b\n" +
+	"\x05Point\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x16\n" +
+	"\x06vector\x18\x02 \x03(\x02R\x06vector\x121\n" +
+	"\apayload\x18\x03 \x01(\v2\x17.google.protobuf.StructR\apayload\"M\n" +
+	"\x17CreateCollectionRequest\x122\n" +
+	"\x06config\x18\x01 \x01(\v2\x1a.vecdb.v1.CollectionConfigR\x06config\"*\n" +
+	"\x14GetCollectionRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\"\x18\n" +
+	"\x16ListCollectionsRequest\"/\n" +
+	"\x17ListCollectionsResponse\x12\x14\n" +
+	"\x05names\x18\x01 \x03(\tR\x05names\"+\n" +
+	"\x15DropCollectionRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\"\x18\n" +
+	"\x16DropCollectionResponse\"X\n" +
+	"\rUpsertRequest\x12\x1e\n" +
+	"\n" +
+	"collection\x18\x01 \x01(\tR\n" +
+	"collection\x12'\n" +
+	"\x06points\x18\x02 \x03(\v2\x0f.vecdb.v1.PointR\x06points\",\n" +
+	"\x0eUpsertResponse\x12\x1a\n" +
+	"\bupserted\x18\x01 \x01(\x03R\bupserted\"A\n" +
+	"\rDeleteRequest\x12\x1e\n" +
+	"\n" +
+	"collection\x18\x01 \x01(\tR\n" +
+	"collection\x12\x10\n" +
+	"\x03ids\x18\x02 \x03(\tR\x03ids\"*\n" +
+	"\x0eDeleteResponse\x12\x18\n" +
+	"\adeleted\x18\x01 \x01(\x03R\adeleted\"\xc3\x02\n" +
+	"\rSearchRequest\x12\x1e\n" +
+	"\n" +
+	"collection\x18\x01 \x01(\tR\n" +
+	"collection\x12\x16\n" +
+	"\x06vector\x18\x02 \x03(\x02R\x06vector\x12\f\n" +
+	"\x01k\x18\x03 \x01(\x05R\x01k\x12;\n" +
+	"\x06filter\x18\x04 \x03(\v2#.vecdb.v1.SearchRequest.FilterEntryR\x06filter\x12\x16\n" +
+	"\x06nprobe\x18\x05 \x01(\x05R\x06nprobe\x12\x1b\n" +
+	"\tef_search\x18\x06 \x01(\x05R\befSearch\x12'\n" +
+	"\x0finclude_vectors\x18\a \x01(\bR\x0eincludeVectors\x1aQ\n" +
+	"\vFilterEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12,\n" +
+	"\x05value\x18\x02 \x01(\v2\x16.google.protobuf.ValueR\x05value:\x028\x01\"|\n" +
+	"\x03Hit\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1a\n" +
+	"\bdistance\x18\x02 \x01(\x01R\bdistance\x121\n" +
+	"\apayload\x18\x03 \x01(\v2\x17.google.protobuf.StructR\apayload\x12\x16\n" +
+	"\x06vector\x18\x04 \x03(\x02R\x06vector\"3\n" +
+	"\x0eSearchResponse\x12!\n" +
+	"\x04hits\x18\x01 \x03(\v2\r.vecdb.v1.HitR\x04hits2\xd3\x04\n" +
+	"\bVectorDB\x12O\n" +
+	"\x10CreateCollection\x12!.vecdb.v1.CreateCollectionRequest\x1a\x18.vecdb.v1.CollectionInfo\x12I\n" +
+	"\rGetCollection\x12\x1e.vecdb.v1.GetCollectionRequest\x1a\x18.vecdb.v1.CollectionInfo\x12V\n" +
+	"\x0fListCollections\x12 .vecdb.v1.ListCollectionsRequest\x1a!.vecdb.v1.ListCollectionsResponse\x12S\n" +
+	"\x0eDropCollection\x12\x1f.vecdb.v1.DropCollectionRequest\x1a .vecdb.v1.DropCollectionResponse\x12=\n" +
+	"\x06Upsert\x12\x17.vecdb.v1.UpsertRequest\x1a\x18.vecdb.v1.UpsertResponse(\x01\x12;\n" +
+	"\x06Delete\x12\x17.vecdb.v1.DeleteRequest\x1a\x18.vecdb.v1.DeleteResponse\x12;\n" +
+	"\x06Search\x12\x17.vecdb.v1.SearchRequest\x1a\x18.vecdb.v1.SearchResponse\x12E\n" +
+	"\fSearchStream\x12\x17.vecdb.v1.SearchRequest\x1a\x18.vecdb.v1.SearchResponse(\x010\x01B0Z.github.com/tmdgusya/database-class/pkg/vecdbpbb\x06proto3"
+
+var (
+	file_vecdb_proto_rawDescOnce sync.Once
+	file_vecdb_proto_rawDescData []byte
+)
+
+func file_vecdb_proto_rawDescGZIP() []byte {
+	file_vecdb_proto_rawDescOnce.Do(func() {
+		file_vecdb_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_vecdb_proto_rawDesc), len(file_vecdb_proto_rawDesc)))
+	})
+	return file_vecdb_proto_rawDescData
+}
+
+var file_vecdb_proto_msgTypes = make([]protoimpl.MessageInfo, 19)
+var file_vecdb_proto_goTypes = []any{
+	(*CollectionConfig)(nil),        // 0: vecdb.v1.CollectionConfig
+	(*FieldSchema)(nil),             // 1: vecdb.v1.FieldSchema
+	(*CollectionInfo)(nil),          // 2: vecdb.v1.CollectionInfo
+	(*Point)(nil),                   // 3: vecdb.v1.Point
+	(*CreateCollectionRequest)(nil), // 4: vecdb.v1.CreateCollectionRequest
+	(*GetCollectionRequest)(nil),    // 5: vecdb.v1.GetCollectionRequest
+	(*ListCollectionsRequest)(nil),  // 6: vecdb.v1.ListCollectionsRequest
+	(*ListCollectionsResponse)(nil), // 7: vecdb.v1.ListCollectionsResponse
+	(*DropCollectionRequest)(nil),   // 8: vecdb.v1.DropCollectionRequest
+	(*DropCollectionResponse)(nil),  // 9: vecdb.v1.DropCollectionResponse
+	(*UpsertRequest)(nil),           // 10: vecdb.v1.UpsertRequest
+	(*UpsertResponse)(nil),          // 11: vecdb.v1.UpsertResponse
+	(*DeleteRequest)(nil),           // 12: vecdb.v1.DeleteRequest
+	(*DeleteResponse)(nil),          // 13: vecdb.v1.DeleteResponse
+	(*SearchRequest)(nil),           // 14: vecdb.v1.SearchRequest
+	(*Hit)(nil),                     // 15: vecdb.v1.Hit
+	(*SearchResponse)(nil),          // 16: vecdb.v1.SearchResponse
+	nil,                             // 17: vecdb.v1.CollectionConfig.SchemaEntry
+	nil,                             // 18: vecdb.v1.SearchRequest.FilterEntry
+	(*structpb.Struct)(nil),         // 19: google.protobuf.Struct
+	(*structpb.Value)(nil),          // 20: google.protobuf.Value
+}
+var file_vecdb_proto_depIdxs = []int32{
+	17, // 0: vecdb.v1.CollectionConfig.schema:type_name -> vecdb.v1.CollectionConfig.SchemaEntry
+	0,  // 1: vecdb.v1.CollectionInfo.config:type_name -> vecdb.v1.CollectionConfig
+	19, // 2: vecdb.v1.Point.payload:type_name -> google.protobuf.Struct
+	0,  // 3: vecdb.v1.CreateCollectionRequest.config:type_name -> vecdb.v1.CollectionConfig
+	3,  // 4: vecdb.v1.UpsertRequest.points:type_name -> vecdb.v1.Point
+	18, // 5: vecdb.v1.SearchRequest.filter:type_name -> vecdb.v1.SearchRequest.FilterEntry
+	19, // 6: vecdb.v1.Hit.payload:type_name -> google.protobuf.Struct
+	15, // 7: vecdb.v1.SearchResponse.hits:type_name -> vecdb.v1.Hit
+	1,  // 8: vecdb.v1.CollectionConfig.SchemaEntry.value:type_name -> vecdb.v1.FieldSchema
+	20, // 9: vecdb.v1.SearchRequest.FilterEntry.value:type_name -> google.protobuf.Value
+	4,  // 10: vecdb.v1.VectorDB.CreateCollection:input_type -> vecdb.v1.CreateCollectionRequest
+	5,  // 11: vecdb.v1.VectorDB.GetCollection:input_type -> vecdb.v1.GetCollectionRequest
+	6,  // 12: vecdb.v1.VectorDB.ListCollections:input_type -> vecdb.v1.ListCollectionsRequest
+	8,  // 13: vecdb.v1.VectorDB.DropCollection:input_type -> vecdb.v1.DropCollectionRequest
+	10, // 14: vecdb.v1.VectorDB.Upsert:input_type -> vecdb.v1.UpsertRequest
+	12, // 15: vecdb.v1.VectorDB.Delete:input_type -> vecdb.v1.DeleteRequest
+	14, // 16: vecdb.v1.VectorDB.Search:input_type -> vecdb.v1.SearchRequest
+	14, // 17: vecdb.v1.VectorDB.SearchStream:input_type -> vecdb.v1.SearchRequest
+	2,  // 18: vecdb.v1.VectorDB.CreateCollection:output_type -> vecdb.v1.CollectionInfo
+	2,  // 19: vecdb.v1.VectorDB.GetCollection:output_type -> vecdb.v1.CollectionInfo
+	7,  // 20: vecdb.v1.VectorDB.ListCollections:output_type -> vecdb.v1.ListCollectionsResponse
+	9,  // 21: vecdb.v1.VectorDB.DropCollection:output_type -> vecdb.v1.DropCollectionResponse
+	11, // 22: vecdb.v1.VectorDB.Upsert:output_type -> vecdb.v1.UpsertResponse
+	13, // 23: vecdb.v1.VectorDB.Delete:output_type -> vecdb.v1.DeleteResponse
+	16, // 24: vecdb.v1.VectorDB.Search:output_type -> vecdb.v1.SearchResponse
+	16, // 25: vecdb.v1.VectorDB.SearchStream:output_type -> vecdb.v1.SearchResponse
+	18, // [18:26] is the sub-list for method output_type
+	10, // [10:18] is the sub-list for method input_type
+	10, // [10:10] is the sub-list for extension type_name
+	10, // [10:10] is the sub-list for extension extendee
+	0,  // [0:10] is the sub-list for field type_name
+}
+
+func init() { file_vecdb_proto_init() }
+func file_vecdb_proto_init() {
+	if File_vecdb_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_vecdb_proto_rawDesc), len(file_vecdb_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   19,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_vecdb_proto_goTypes,
+		DependencyIndexes: file_vecdb_proto_depIdxs,
+		MessageInfos:      file_vecdb_proto_msgTypes,
+	}.Build()
+	File_vecdb_proto = out.File
+	file_vecdb_proto_goTypes = nil
+	file_vecdb_proto_depIdxs = nil
+}
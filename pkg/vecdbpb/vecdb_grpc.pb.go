@@ -0,0 +1,393 @@
+// gRPC API of cmd/vecdbd
+//
+// It mirrors the HTTP/JSON API: named collections of points with string IDs
+// and JSON-like payloads. Vectors travel as packed float32, 4 bytes per
+// component instead of ~20 characters of JSON; the server stores float64.
+//
+// Regenerate vecdb.pb.go and vecdb_grpc.pb.go after editing, with
+// protoc-gen-go and protoc-gen-go-grpc:
+//
+//	protoc --go_out=. --go_opt=paths=source_relative \
+//	    --go-grpc_out=. --go-grpc_opt=paths=source_relative vecdb.proto
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: vecdb.proto
+
+package vecdbpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	VectorDB_CreateCollection_FullMethodName = "/vecdb.v1.VectorDB/CreateCollection"
+	VectorDB_GetCollection_FullMethodName    = "/vecdb.v1.VectorDB/GetCollection"
+	VectorDB_ListCollections_FullMethodName  = "/vecdb.v1.VectorDB/ListCollections"
+	VectorDB_DropCollection_FullMethodName   = "/vecdb.v1.VectorDB/DropCollection"
+	VectorDB_Upsert_FullMethodName           = "/vecdb.v1.VectorDB/Upsert"
+	VectorDB_Delete_FullMethodName           = "/vecdb.v1.VectorDB/Delete"
+	VectorDB_Search_FullMethodName           = "/vecdb.v1.VectorDB/Search"
+	VectorDB_SearchStream_FullMethodName     = "/vecdb.v1.VectorDB/SearchStream"
+)
+
+// VectorDBClient is the client API for VectorDB service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type VectorDBClient interface {
+	CreateCollection(ctx context.Context, in *CreateCollectionRequest, opts ...grpc.CallOption) (*CollectionInfo, error)
+	GetCollection(ctx context.Context, in *GetCollectionRequest, opts ...grpc.CallOption) (*CollectionInfo, error)
+	ListCollections(ctx context.Context, in *ListCollectionsRequest, opts ...grpc.CallOption) (*ListCollectionsResponse, error)
+	DropCollection(ctx context.Context, in *DropCollectionRequest, opts ...grpc.CallOption) (*DropCollectionResponse, error)
+	// Upsert stores every batch as it arrives, so a failed stream keeps the
+	// batches before the failing one
+	Upsert(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[UpsertRequest, UpsertResponse], error)
+	Delete(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*DeleteResponse, error)
+	Search(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*SearchResponse, error)
+	// SearchStream answers each request with one response, in order
+	SearchStream(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[SearchRequest, SearchResponse], error)
+}
+
+type vectorDBClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewVectorDBClient(cc grpc.ClientConnInterface) VectorDBClient {
+	return &vectorDBClient{cc}
+}
+
+func (c *vectorDBClient) CreateCollection(ctx context.Context, in *CreateCollectionRequest, opts ...grpc.CallOption) (*CollectionInfo, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CollectionInfo)
+	err := c.cc.Invoke(ctx, VectorDB_CreateCollection_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *vectorDBClient) GetCollection(ctx context.Context, in *GetCollectionRequest, opts ...grpc.CallOption) (*CollectionInfo, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CollectionInfo)
+	err := c.cc.Invoke(ctx, VectorDB_GetCollection_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *vectorDBClient) ListCollections(ctx context.Context, in *ListCollectionsRequest, opts ...grpc.CallOption) (*ListCollectionsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListCollectionsResponse)
+	err := c.cc.Invoke(ctx, VectorDB_ListCollections_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *vectorDBClient) DropCollection(ctx context.Context, in *DropCollectionRequest, opts ...grpc.CallOption) (*DropCollectionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DropCollectionResponse)
+	err := c.cc.Invoke(ctx, VectorDB_DropCollection_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *vectorDBClient) Upsert(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[UpsertRequest, UpsertResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &VectorDB_ServiceDesc.Streams[0], VectorDB_Upsert_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[UpsertRequest, UpsertResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type VectorDB_UpsertClient = grpc.ClientStreamingClient[UpsertRequest, UpsertResponse]
+
+func (c *vectorDBClient) Delete(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*DeleteResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteResponse)
+	err := c.cc.Invoke(ctx, VectorDB_Delete_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *vectorDBClient) Search(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*SearchResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SearchResponse)
+	err := c.cc.Invoke(ctx, VectorDB_Search_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *vectorDBClient) SearchStream(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[SearchRequest, SearchResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &VectorDB_ServiceDesc.Streams[1], VectorDB_SearchStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SearchRequest, SearchResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type VectorDB_SearchStreamClient = grpc.BidiStreamingClient[SearchRequest, SearchResponse]
+
+// VectorDBServer is the server API for VectorDB service.
+// All implementations must embed UnimplementedVectorDBServer
+// for forward compatibility.
+type VectorDBServer interface {
+	CreateCollection(context.Context, *CreateCollectionRequest) (*CollectionInfo, error)
+	GetCollection(context.Context, *GetCollectionRequest) (*CollectionInfo, error)
+	ListCollections(context.Context, *ListCollectionsRequest) (*ListCollectionsResponse, error)
+	DropCollection(context.Context, *DropCollectionRequest) (*DropCollectionResponse, error)
+	// Upsert stores every batch as it arrives, so a failed stream keeps the
+	// batches before the failing one
+	Upsert(grpc.ClientStreamingServer[UpsertRequest, UpsertResponse]) error
+	Delete(context.Context, *DeleteRequest) (*DeleteResponse, error)
+	Search(context.Context, *SearchRequest) (*SearchResponse, error)
+	// SearchStream answers each request with one response, in order
+	SearchStream(grpc.BidiStreamingServer[SearchRequest, SearchResponse]) error
+	mustEmbedUnimplementedVectorDBServer()
+}
+
+// UnimplementedVectorDBServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedVectorDBServer struct{}
+
+func (UnimplementedVectorDBServer) CreateCollection(context.Context, *CreateCollectionRequest) (*CollectionInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateCollection not implemented")
+}
+func (UnimplementedVectorDBServer) GetCollection(context.Context, *GetCollectionRequest) (*CollectionInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCollection not implemented")
+}
+func (UnimplementedVectorDBServer) ListCollections(context.Context, *ListCollectionsRequest) (*ListCollectionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListCollections not implemented")
+}
+func (UnimplementedVectorDBServer) DropCollection(context.Context, *DropCollectionRequest) (*DropCollectionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DropCollection not implemented")
+}
+func (UnimplementedVectorDBServer) Upsert(grpc.ClientStreamingServer[UpsertRequest, UpsertResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method Upsert not implemented")
+}
+func (UnimplementedVectorDBServer) Delete(context.Context, *DeleteRequest) (*DeleteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Delete not implemented")
+}
+func (UnimplementedVectorDBServer) Search(context.Context, *SearchRequest) (*SearchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Search not implemented")
+}
+func (UnimplementedVectorDBServer) SearchStream(grpc.BidiStreamingServer[SearchRequest, SearchResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method SearchStream not implemented")
+}
+func (UnimplementedVectorDBServer) mustEmbedUnimplementedVectorDBServer() {}
+func (UnimplementedVectorDBServer) testEmbeddedByValue()                  {}
+
+// UnsafeVectorDBServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to VectorDBServer will
+// result in compilation errors.
+type UnsafeVectorDBServer interface {
+	mustEmbedUnimplementedVectorDBServer()
+}
+
+func RegisterVectorDBServer(s grpc.ServiceRegistrar, srv VectorDBServer) {
+	// If the following call pancis, it indicates UnimplementedVectorDBServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&VectorDB_ServiceDesc, srv)
+}
+
+func _VectorDB_CreateCollection_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateCollectionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VectorDBServer).CreateCollection(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VectorDB_CreateCollection_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VectorDBServer).CreateCollection(ctx, req.(*CreateCollectionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VectorDB_GetCollection_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCollectionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VectorDBServer).GetCollection(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VectorDB_GetCollection_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VectorDBServer).GetCollection(ctx, req.(*GetCollectionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VectorDB_ListCollections_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListCollectionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VectorDBServer).ListCollections(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VectorDB_ListCollections_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VectorDBServer).ListCollections(ctx, req.(*ListCollectionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VectorDB_DropCollection_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DropCollectionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VectorDBServer).DropCollection(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VectorDB_DropCollection_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VectorDBServer).DropCollection(ctx, req.(*DropCollectionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VectorDB_Upsert_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(VectorDBServer).Upsert(&grpc.GenericServerStream[UpsertRequest, UpsertResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type VectorDB_UpsertServer = grpc.ClientStreamingServer[UpsertRequest, UpsertResponse]
+
+func _VectorDB_Delete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VectorDBServer).Delete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VectorDB_Delete_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VectorDBServer).Delete(ctx, req.(*DeleteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VectorDB_Search_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VectorDBServer).Search(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VectorDB_Search_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VectorDBServer).Search(ctx, req.(*SearchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VectorDB_SearchStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(VectorDBServer).SearchStream(&grpc.GenericServerStream[SearchRequest, SearchResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type VectorDB_SearchStreamServer = grpc.BidiStreamingServer[SearchRequest, SearchResponse]
+
+// VectorDB_ServiceDesc is the grpc.ServiceDesc for VectorDB service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var VectorDB_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "vecdb.v1.VectorDB",
+	HandlerType: (*VectorDBServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateCollection",
+			Handler:    _VectorDB_CreateCollection_Handler,
+		},
+		{
+			MethodName: "GetCollection",
+			Handler:    _VectorDB_GetCollection_Handler,
+		},
+		{
+			MethodName: "ListCollections",
+			Handler:    _VectorDB_ListCollections_Handler,
+		},
+		{
+			MethodName: "DropCollection",
+			Handler:    _VectorDB_DropCollection_Handler,
+		},
+		{
+			MethodName: "Delete",
+			Handler:    _VectorDB_Delete_Handler,
+		},
+		{
+			MethodName: "Search",
+			Handler:    _VectorDB_Search_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Upsert",
+			Handler:       _VectorDB_Upsert_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "SearchStream",
+			Handler:       _VectorDB_SearchStream_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "vecdb.proto",
+}
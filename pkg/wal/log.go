@@ -0,0 +1,441 @@
+// Package wal makes in-memory indexes crash-safe with a write-ahead log
+//
+// Each add, delete or update is appended to the log as a length-prefixed,
+// CRC-32C-protected record before it is applied in memory. A Store pairs the
+// log with a snapshot: Open restores the snapshot and replays the records
+// logged after it, and Checkpoint writes a new snapshot and truncates the log.
+//
+//	store, err := wal.Open(dir, wal.Options{Sync: wal.SyncBatch}, restore, apply)
+//	_, err = store.Append(func() error { return idx.Add(v) }, wal.Record{Op: wal.OpAdd, Vector: v})
+//	err = store.Checkpoint(idx.Save)
+package wal
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// SyncPolicy decides when appended records are fsynced
+// Every policy writes each record to the file before Append returns, so a
+// process crash loses nothing; the policies differ in what an operating
+// system crash or power loss can take with it.
+type SyncPolicy int
+
+const (
+	SyncAlways   SyncPolicy = iota // fsync before every Append returns
+	SyncBatch                      // fsync once BatchSize records are pending
+	SyncInterval                   // fsync pending records every Interval
+)
+
+func (p SyncPolicy) String() string {
+	switch p {
+	case SyncAlways:
+		return "always"
+	case SyncBatch:
+		return "batch"
+	case SyncInterval:
+		return "interval"
+	default:
+		return fmt.Sprintf("SyncPolicy(%d)", int(p))
+	}
+}
+
+// Options configures a Log; the zero value fsyncs every append
+type Options struct {
+	Sync      SyncPolicy
+	BatchSize int           // SyncBatch: records per fsync; default 64
+	Interval  time.Duration // SyncInterval: time between fsyncs; default 100ms
+}
+
+func (o Options) withDefaults() (Options, error) {
+	switch o.Sync {
+	case SyncAlways:
+	case SyncBatch:
+		if o.BatchSize == 0 {
+			o.BatchSize = 64
+		}
+		if o.BatchSize < 0 {
+			return o, fmt.Errorf("batch size must be positive, got %d", o.BatchSize)
+		}
+	case SyncInterval:
+		if o.Interval == 0 {
+			o.Interval = 100 * time.Millisecond
+		}
+		if o.Interval < 0 {
+			return o, fmt.Errorf("sync interval must be positive, got %v", o.Interval)
+		}
+	default:
+		return o, fmt.Errorf("unknown sync policy %v", o.Sync)
+	}
+	return o, nil
+}
+
+// logMagic starts every log file; the base LSN follows it
+const (
+	logMagic      = "VECWAL01"
+	logHeaderSize = len(logMagic) + 8
+)
+
+// ErrClosed is returned by operations on a closed Log or Store
+var ErrClosed = errors.New("wal: closed")
+
+// Log is an append-only file of records
+// Open truncates a torn tail left by a crash, so the file always ends on a
+// record boundary; a damaged record with more log after it is reported as
+// corruption instead, and the file is left as it is. After a write fails the Log refuses further appends,
+// since the file may hold a partial record. A Log is safe for concurrent use.
+type Log struct {
+	path string
+	opts Options
+
+	mu        sync.Mutex
+	f         *os.File
+	buf       []byte
+	size      int64  // Bytes of valid log, header included
+	last      uint64 // LSN of the newest record, or the base LSN
+	pending   int    // Records written but not yet fsynced
+	discarded int64  // Bytes of torn tail dropped by OpenLog
+	err       error  // Sticky write error, or ErrClosed
+
+	stop     chan struct{} // Closed to end the SyncInterval goroutine
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// OpenLog opens or creates the log at path
+func OpenLog(path string, opts Options) (*Log, error) {
+	opts, err := opts.withDefaults()
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	l := &Log{path: path, opts: opts, f: f}
+	if err := l.recover(); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("open %s: %w", path, err)
+	}
+
+	if opts.Sync == SyncInterval {
+		l.stop = make(chan struct{})
+		l.done = make(chan struct{})
+		go l.syncLoop()
+	}
+	return l, nil
+}
+
+// recover validates the file, finds its last record and drops a torn tail
+func (l *Log) recover() error {
+	info, err := l.f.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() < int64(logHeaderSize) {
+		// New, or a crash interrupted reset before the header was complete
+		return l.reset(0)
+	}
+
+	header := make([]byte, logHeaderSize)
+	if _, err := l.f.ReadAt(header, 0); err != nil {
+		return err
+	}
+	if string(header[:len(logMagic)]) != logMagic {
+		return errors.New("not a write-ahead log")
+	}
+	l.last = binary.LittleEndian.Uint64(header[len(logMagic):])
+	l.size = int64(logHeaderSize)
+
+	err = l.scan(func(rec Record, n int) error {
+		if rec.LSN <= l.last {
+			return fmt.Errorf("%w: LSN %d after %d", errCorrupt, rec.LSN, l.last)
+		}
+		l.last = rec.LSN
+		l.size += int64(n)
+		return nil
+	}, info.Size())
+	if err != nil {
+		if !isTorn(err) {
+			return err
+		}
+		torn, terr := l.tornTail(l.size, info.Size())
+		if terr != nil {
+			return terr
+		}
+		if !torn {
+			// Truncating here would throw away records that may be intact
+			return fmt.Errorf("record at offset %d: %w; log continues past it", l.size, err)
+		}
+	}
+
+	if l.discarded = info.Size() - l.size; l.discarded > 0 {
+		if err := l.f.Truncate(l.size); err != nil {
+			return err
+		}
+		if err := l.f.Sync(); err != nil {
+			return err
+		}
+	}
+	_, err = l.f.Seek(l.size, io.SeekStart)
+	return err
+}
+
+// tornTail reports whether the damaged record at off is what an interrupted
+// write leaves behind
+// That is a record running to or past end, or one followed only by zeros,
+// as file systems may extend a file before the data reaches it.
+func (l *Log) tornTail(off, end int64) (bool, error) {
+	var header [frameHeaderSize]byte
+	if end-off < frameHeaderSize {
+		return true, nil
+	}
+	if _, err := l.f.ReadAt(header[:], off); err != nil {
+		return false, err
+	}
+	frameEnd := off + frameHeaderSize + int64(binary.LittleEndian.Uint32(header[:]))
+	if frameEnd >= end {
+		return true, nil
+	}
+
+	r := bufio.NewReader(io.NewSectionReader(l.f, frameEnd, end-frameEnd))
+	for {
+		b, err := r.ReadByte()
+		if err == io.EOF {
+			return true, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		if b != 0 {
+			return false, nil
+		}
+	}
+}
+
+// isTorn reports whether err marks the end of the valid records rather than
+// a failure to read them
+func isTorn(err error) bool {
+	return errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, errCorrupt)
+}
+
+// scan calls fn for each record between the header and end
+func (l *Log) scan(fn func(rec Record, n int) error, end int64) error {
+	r := bufio.NewReaderSize(io.NewSectionReader(l.f, int64(logHeaderSize), end-int64(logHeaderSize)), 1<<16)
+	var buf []byte
+	for {
+		rec, n, b, err := readFrame(r, buf)
+		buf = b
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(rec, n); err != nil {
+			return err
+		}
+	}
+}
+
+// reset empties the log; the next record gets an LSN above base
+// Caller must hold the lock (or own l exclusively).
+func (l *Log) reset(base uint64) error {
+	if err := l.f.Truncate(0); err != nil {
+		return err
+	}
+	header := make([]byte, 0, logHeaderSize)
+	header = append(header, logMagic...)
+	header = binary.LittleEndian.AppendUint64(header, base)
+	if _, err := l.f.WriteAt(header, 0); err != nil {
+		return err
+	}
+	if err := l.f.Sync(); err != nil {
+		return err
+	}
+	if _, err := l.f.Seek(int64(logHeaderSize), io.SeekStart); err != nil {
+		return err
+	}
+	l.size = int64(logHeaderSize)
+	l.last = base
+	l.pending = 0
+	return nil
+}
+
+// Append writes records in one write and assigns their LSNs in order
+// It returns the LSN of the last record. The records are durable on return
+// under SyncAlways, and once the policy's next fsync has run otherwise.
+func (l *Log) Append(records ...Record) (uint64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.err != nil {
+		return 0, l.err
+	}
+	if len(records) == 0 {
+		return l.last, nil
+	}
+
+	l.buf = l.buf[:0]
+	lsn := l.last
+	for _, r := range records {
+		lsn++
+		r.LSN = lsn
+		l.buf = appendFrame(l.buf, r)
+	}
+	if _, err := l.f.Write(l.buf); err != nil {
+		// Cut off whatever part of the batch reached the file; if even that
+		// fails, reopening the log will drop it as a torn tail
+		if l.f.Truncate(l.size) == nil {
+			l.f.Seek(l.size, io.SeekStart)
+		}
+		l.err = fmt.Errorf("wal: append: %w", err)
+		return 0, l.err
+	}
+	l.size += int64(len(l.buf))
+	l.last = lsn
+	l.pending += len(records)
+
+	if l.opts.Sync == SyncAlways || (l.opts.Sync == SyncBatch && l.pending >= l.opts.BatchSize) {
+		if err := l.sync(); err != nil {
+			return 0, err
+		}
+	}
+	return lsn, nil
+}
+
+// Sync fsyncs any records not yet synced
+func (l *Log) Sync() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.err != nil {
+		return l.err
+	}
+	return l.sync()
+}
+
+// sync is Sync with the lock held
+// A failed fsync may have dropped dirty pages, so it also breaks the log.
+func (l *Log) sync() error {
+	if l.pending == 0 {
+		return nil
+	}
+	if err := l.f.Sync(); err != nil {
+		l.err = fmt.Errorf("wal: sync: %w", err)
+		return l.err
+	}
+	l.pending = 0
+	return nil
+}
+
+func (l *Log) syncLoop() {
+	defer close(l.done)
+	ticker := time.NewTicker(l.opts.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			l.Sync() // A failure sticks and surfaces on the next Append
+		}
+	}
+}
+
+// Replay calls fn for every record in LSN order, stopping at fn's first error
+func (l *Log) Replay(fn func(Record) error) error {
+	l.mu.Lock()
+	end := l.size
+	err := l.err
+	l.mu.Unlock()
+	if errors.Is(err, ErrClosed) {
+		return err
+	}
+
+	// Records up to end were validated by OpenLog or written by Append and
+	// are never rewritten, so reading them needs no lock
+	err = l.scan(func(rec Record, _ int) error { return fn(rec) }, end)
+	if isTorn(err) {
+		return fmt.Errorf("wal: replay %s: %w", l.path, err)
+	}
+	return err
+}
+
+// LastLSN returns the LSN of the newest record
+// After a Truncate it is the LSN the log was truncated at.
+func (l *Log) LastLSN() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.last
+}
+
+// Size returns the log's length in bytes, header included
+func (l *Log) Size() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.size
+}
+
+// Discarded returns the bytes of torn tail OpenLog removed
+func (l *Log) Discarded() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.discarded
+}
+
+// Truncate removes every record; LSNs keep counting from LastLSN
+// Call it only once the records are covered by a durable snapshot.
+func (l *Log) Truncate() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.err != nil {
+		return l.err
+	}
+	if err := l.reset(l.last); err != nil {
+		l.err = fmt.Errorf("wal: truncate: %w", err)
+		return l.err
+	}
+	return nil
+}
+
+// advance makes the next LSN exceed lsn
+// A snapshot can cover LSNs a crashed Truncate never recorded in the header.
+func (l *Log) advance(lsn uint64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.last = max(l.last, lsn)
+}
+
+// Close fsyncs pending records and closes the file
+func (l *Log) Close() error {
+	l.stopOnce.Do(func() {
+		if l.stop != nil {
+			close(l.stop)
+			<-l.done
+		}
+	})
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if errors.Is(l.err, ErrClosed) {
+		return l.err
+	}
+	var err error
+	if l.err == nil {
+		err = l.sync()
+	}
+	if cerr := l.f.Close(); err == nil {
+		err = cerr
+	}
+	l.err = ErrClosed
+	return err
+}
@@ -0,0 +1,222 @@
+package wal
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+
+	"github.com/tmdgusya/database-class/pkg/vector"
+)
+
+// Op is the kind of change a record describes
+type Op uint8
+
+const (
+	OpAdd    Op = iota + 1 // A new vector
+	OpDelete               // Removal of ID
+	OpUpdate               // Replacement of ID's vector and data
+)
+
+func (op Op) String() string {
+	switch op {
+	case OpAdd:
+		return "add"
+	case OpDelete:
+		return "delete"
+	case OpUpdate:
+		return "update"
+	default:
+		return fmt.Sprintf("Op(%d)", uint8(op))
+	}
+}
+
+// Record is one logged change
+// The log does not interpret ID, Vector or Data; the code that replays it
+// does. Delete records usually carry only an ID.
+type Record struct {
+	LSN    uint64 // Log sequence number, assigned by Append
+	Op     Op
+	ID     string
+	Vector vector.Vector
+	Data   []byte // Caller-defined, e.g. a JSON payload
+}
+
+// Record framing, all little-endian:
+//
+//	length uint32   size of body
+//	crc    uint32   CRC-32C of body
+//	body:
+//	  lsn    uint64
+//	  op     uint8
+//	  id     uvarint length + bytes
+//	  vector uvarint dimension + float64 bits each
+//	  data   uvarint length + bytes
+const frameHeaderSize = 8
+
+// maxRecordSize bounds the body length read from disk, so a corrupt length
+// field cannot make replay allocate gigabytes
+const maxRecordSize = 64 << 20
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// errCorrupt reports a record that fails its checksum or cannot be decoded
+var errCorrupt = errors.New("corrupt record")
+
+// appendFrame appends r's framed encoding to buf
+func appendFrame(buf []byte, r Record) []byte {
+	start := len(buf)
+	buf = append(buf, make([]byte, frameHeaderSize)...)
+	buf = binary.LittleEndian.AppendUint64(buf, r.LSN)
+	buf = append(buf, byte(r.Op))
+	buf = binary.AppendUvarint(buf, uint64(len(r.ID)))
+	buf = append(buf, r.ID...)
+	buf = binary.AppendUvarint(buf, uint64(len(r.Vector)))
+	for _, x := range r.Vector {
+		buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(x))
+	}
+	buf = binary.AppendUvarint(buf, uint64(len(r.Data)))
+	buf = append(buf, r.Data...)
+
+	body := buf[start+frameHeaderSize:]
+	binary.LittleEndian.PutUint32(buf[start:], uint32(len(body)))
+	binary.LittleEndian.PutUint32(buf[start+4:], crc32.Checksum(body, crcTable))
+	return buf
+}
+
+// WriteRecord writes r framed as in the log: length, CRC-32C and body
+// Transports other than the log file, such as replication streams, use it
+// to carry records with the same corruption checks.
+func WriteRecord(w io.Writer, r Record) error {
+	_, err := w.Write(appendFrame(nil, r))
+	return err
+}
+
+// ReadRecord reads one record written by WriteRecord
+// It returns io.EOF if r ends cleanly before the record.
+func ReadRecord(r io.Reader) (Record, error) {
+	rec, _, _, err := readFrame(r, nil)
+	return rec, err
+}
+
+// readFrame reads one record
+// It returns io.EOF at a clean end of the log, and io.ErrUnexpectedEOF or
+// errCorrupt for a torn or damaged record. The second result is the number
+// of bytes the record occupies.
+func readFrame(r io.Reader, buf []byte) (Record, int, []byte, error) {
+	var header [frameHeaderSize]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return Record{}, 0, buf, err
+	}
+	size := binary.LittleEndian.Uint32(header[:])
+	sum := binary.LittleEndian.Uint32(header[4:])
+	if size > maxRecordSize {
+		return Record{}, 0, buf, fmt.Errorf("%w: length %d exceeds %d", errCorrupt, size, maxRecordSize)
+	}
+
+	if cap(buf) < int(size) {
+		buf = make([]byte, size)
+	}
+	body := buf[:size]
+	if _, err := io.ReadFull(r, body); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return Record{}, 0, buf, err
+	}
+	if crc32.Checksum(body, crcTable) != sum {
+		return Record{}, 0, buf, fmt.Errorf("%w: checksum mismatch", errCorrupt)
+	}
+
+	rec, err := decodeBody(body)
+	if err != nil {
+		return Record{}, 0, buf, err
+	}
+	return rec, frameHeaderSize + int(size), buf, nil
+}
+
+// decodeBody decodes a checksummed body, copying everything out of it
+func decodeBody(body []byte) (Record, error) {
+	d := decoder{buf: body}
+	rec := Record{LSN: d.uint64(), Op: Op(d.byte())}
+	rec.ID = string(d.bytes())
+	if n := d.uvarint(); d.err == nil {
+		if n > uint64(len(d.buf))/8 {
+			d.err = fmt.Errorf("%w: vector of %d components in %d bytes", errCorrupt, n, len(d.buf))
+		} else if n > 0 {
+			rec.Vector = make(vector.Vector, n)
+			for i := range rec.Vector {
+				rec.Vector[i] = math.Float64frombits(d.uint64())
+			}
+		}
+	}
+	if data := d.bytes(); len(data) > 0 {
+		rec.Data = append([]byte(nil), data...)
+	}
+	if d.err == nil && len(d.buf) > 0 {
+		d.err = fmt.Errorf("%w: %d trailing bytes", errCorrupt, len(d.buf))
+	}
+	if d.err == nil && (rec.Op < OpAdd || rec.Op > OpUpdate) {
+		d.err = fmt.Errorf("%w: unknown op %d", errCorrupt, rec.Op)
+	}
+	return rec, d.err
+}
+
+// decoder consumes a body; the first error sticks and later reads are no-ops
+type decoder struct {
+	buf []byte
+	err error
+}
+
+func (d *decoder) need(n int) bool {
+	if d.err == nil && len(d.buf) < n {
+		d.err = fmt.Errorf("%w: truncated body", errCorrupt)
+	}
+	return d.err == nil
+}
+
+func (d *decoder) byte() byte {
+	if !d.need(1) {
+		return 0
+	}
+	b := d.buf[0]
+	d.buf = d.buf[1:]
+	return b
+}
+
+func (d *decoder) uint64() uint64 {
+	if !d.need(8) {
+		return 0
+	}
+	v := binary.LittleEndian.Uint64(d.buf)
+	d.buf = d.buf[8:]
+	return v
+}
+
+func (d *decoder) uvarint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(d.buf)
+	if n <= 0 {
+		d.err = fmt.Errorf("%w: bad varint", errCorrupt)
+		return 0
+	}
+	d.buf = d.buf[n:]
+	return v
+}
+
+func (d *decoder) bytes() []byte {
+	n := d.uvarint()
+	if d.err == nil && n > uint64(len(d.buf)) {
+		d.err = fmt.Errorf("%w: truncated body", errCorrupt)
+	}
+	if d.err != nil {
+		return nil
+	}
+	b := d.buf[:n]
+	d.buf = d.buf[n:]
+	return b
+}
@@ -0,0 +1,210 @@
+package wal
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// File names inside a Store's directory
+const (
+	logFile      = "wal.log"
+	snapshotFile = "snapshot"
+)
+
+// snapshotMagic starts a snapshot file; the LSN it covers follows it
+const snapshotMagic = "VECSNAP1"
+
+// Store keeps an in-memory structure durable as a snapshot plus a log of
+// the changes made since
+//
+// Open restores the snapshot and replays the newer records; Checkpoint
+// writes a new snapshot and truncates the log. Because each snapshot records
+// the LSN it covers, a crash at any point of a checkpoint is safe: records
+// the snapshot already contains are skipped on replay.
+type Store struct {
+	dir string
+	log *Log
+
+	mu      sync.Mutex // Orders Append against Checkpoint
+	covered uint64     // LSN covered by the snapshot on disk
+}
+
+// Open opens or creates the store in dir and rebuilds its state
+// restore, called only if a snapshot exists, reads it; apply is then called
+// for each record logged after it, in order. Both run before Open returns.
+func Open(dir string, opts Options, restore func(io.Reader) error, apply func(Record) error) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	s := &Store{dir: dir}
+
+	covered, err := s.restore(restore)
+	if err != nil {
+		return nil, err
+	}
+	s.covered = covered
+
+	s.log, err = OpenLog(filepath.Join(dir, logFile), opts)
+	if err != nil {
+		return nil, err
+	}
+	s.log.advance(covered)
+
+	err = s.log.Replay(func(rec Record) error {
+		if rec.LSN <= covered {
+			return nil
+		}
+		if err := apply(rec); err != nil {
+			return fmt.Errorf("replay LSN %d (%s %q): %w", rec.LSN, rec.Op, rec.ID, err)
+		}
+		return nil
+	})
+	if err != nil {
+		s.log.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// restore reads the snapshot, if any, and returns the LSN it covers
+func (s *Store) restore(restore func(io.Reader) error) (uint64, error) {
+	s.removeTemp()
+
+	path := filepath.Join(s.dir, snapshotFile)
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	r := bufio.NewReaderSize(f, 1<<20)
+	header := make([]byte, len(snapshotMagic)+8)
+	if _, err := io.ReadFull(r, header); err != nil || string(header[:len(snapshotMagic)]) != snapshotMagic {
+		return 0, fmt.Errorf("%s: not a snapshot", path)
+	}
+	if err := restore(r); err != nil {
+		return 0, fmt.Errorf("restore %s: %w", path, err)
+	}
+	return binary.LittleEndian.Uint64(header[len(snapshotMagic):]), nil
+}
+
+// removeTemp deletes snapshots a crashed Checkpoint left half-written
+func (s *Store) removeTemp() {
+	entries, _ := os.ReadDir(s.dir)
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), snapshotFile+".tmp") {
+			os.Remove(filepath.Join(s.dir, e.Name()))
+		}
+	}
+}
+
+// Append logs records and then runs apply, if not nil
+// Callers apply the change to their in-memory state inside apply, so that a
+// concurrent Checkpoint sees either the state before the records or the state
+// after them, never the records without their effect. Validate the change
+// first: a logged record is replayed on the next Open even if apply failed.
+func (s *Store) Append(apply func() error, records ...Record) (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	lsn, err := s.log.Append(records...)
+	if err != nil {
+		return 0, err
+	}
+	if apply != nil {
+		if err := apply(); err != nil {
+			return lsn, err
+		}
+	}
+	return lsn, nil
+}
+
+// Checkpoint writes a snapshot with save and truncates the log
+// Appends wait while save runs. If Checkpoint fails, the previous snapshot
+// and the full log are still there and Open recovers from them.
+func (s *Store) Checkpoint(save func(io.Writer) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Everything the snapshot will contain must be durable in the log too,
+	// or a crash after the rename could leave a gap behind the snapshot
+	if err := s.log.Sync(); err != nil {
+		return err
+	}
+	lsn := s.log.LastLSN()
+	if err := s.writeSnapshot(lsn, save); err != nil {
+		return fmt.Errorf("checkpoint: %w", err)
+	}
+	s.covered = lsn
+	return s.log.Truncate()
+}
+
+// writeSnapshot atomically replaces the snapshot with one covering lsn
+func (s *Store) writeSnapshot(lsn uint64, save func(io.Writer) error) error {
+	tmp, err := os.CreateTemp(s.dir, snapshotFile+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // No-op after the rename
+
+	w := bufio.NewWriterSize(tmp, 1<<20)
+	w.WriteString(snapshotMagic)
+	binary.Write(w, binary.LittleEndian, lsn)
+	if err := save(w); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(s.dir, snapshotFile)); err != nil {
+		return err
+	}
+	return syncDir(s.dir)
+}
+
+// syncDir makes a rename in dir durable
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+// Covered returns the LSN the current snapshot covers (0 if none)
+func (s *Store) Covered() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.covered
+}
+
+// Log returns the underlying log, for Sync, LastLSN and Size
+// Appending to it directly bypasses the ordering Append guarantees.
+func (s *Store) Log() *Log {
+	return s.log
+}
+
+// Close fsyncs the log and closes it
+func (s *Store) Close() error {
+	return s.log.Close()
+}
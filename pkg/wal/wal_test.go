@@ -0,0 +1,325 @@
+package wal
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	flat "github.com/tmdgusya/database-class/01-flat/solution"
+	"github.com/tmdgusya/database-class/pkg/distance"
+	"github.com/tmdgusya/database-class/pkg/testdata"
+	"github.com/tmdgusya/database-class/pkg/vector"
+)
+
+func sampleRecords() []Record {
+	return []Record{
+		{Op: OpAdd, ID: "a", Vector: vector.Vector{1, 2, 3}, Data: []byte(`{"lang":"ko"}`)},
+		{Op: OpUpdate, ID: "a", Vector: vector.Vector{-1, 0.5, 1e-300}},
+		{Op: OpDelete, ID: "a"},
+		{Op: OpAdd, Vector: vector.Vector{0}},
+	}
+}
+
+func readAll(t *testing.T, l *Log) []Record {
+	t.Helper()
+	var got []Record
+	if err := l.Replay(func(r Record) error {
+		got = append(got, r)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return got
+}
+
+func TestLogRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wal.log")
+	l, err := OpenLog(path, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := sampleRecords()
+	if lsn, err := l.Append(want[:2]...); err != nil || lsn != 2 {
+		t.Fatalf("Append = %d, %v; want LSN 2", lsn, err)
+	}
+	for _, r := range want[2:] {
+		if _, err := l.Append(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.Append(want[0]); !errors.Is(err, ErrClosed) {
+		t.Errorf("Append after Close: got %v, want ErrClosed", err)
+	}
+
+	l, err = OpenLog(path, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	for i := range want {
+		want[i].LSN = uint64(i + 1)
+	}
+	if got := readAll(t, l); !reflect.DeepEqual(got, want) {
+		t.Errorf("replayed\n%+v\nwant\n%+v", got, want)
+	}
+	if l.LastLSN() != 4 {
+		t.Errorf("LastLSN = %d, want 4", l.LastLSN())
+	}
+}
+
+func TestLogDropsTornTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wal.log")
+	l, err := OpenLog(path, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	recs := sampleRecords()
+	l.Append(recs...)
+	good := l.Size()
+	l.Close()
+
+	tests := []struct {
+		name   string
+		damage func(data []byte) []byte
+		keep   int // Records that survive
+	}{
+		{"partial header", func(d []byte) []byte { return append(d, 1, 2, 3) }, 4},
+		{"partial body", func(d []byte) []byte { return d[:len(d)-3] }, 3},
+		{"flipped bit", func(d []byte) []byte { d[len(d)-1] ^= 1; return d }, 3},
+		{"huge length", func(d []byte) []byte { return append(d, 0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0) }, 4},
+		{"zero-filled tail", func(d []byte) []byte { return append(d, make([]byte, 100)...) }, 4},
+		{"damaged record then zeros", func(d []byte) []byte { d[len(d)-1] ^= 1; return append(d, make([]byte, 100)...) }, 3},
+	}
+	orig, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := tt.damage(append([]byte(nil), orig...))
+			if err := os.WriteFile(path, data, 0o644); err != nil {
+				t.Fatal(err)
+			}
+			l, err := OpenLog(path, Options{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer l.Close()
+
+			if got := readAll(t, l); len(got) != tt.keep {
+				t.Fatalf("replayed %d records, want %d", len(got), tt.keep)
+			}
+			if l.Discarded() == 0 || l.Size() > good {
+				t.Errorf("Discarded = %d, Size = %d (valid log is %d bytes)", l.Discarded(), l.Size(), good)
+			}
+
+			// New records follow the last good one
+			if lsn, err := l.Append(recs[0]); err != nil || lsn != uint64(tt.keep+1) {
+				t.Errorf("Append after recovery = %d, %v; want LSN %d", lsn, err, tt.keep+1)
+			}
+		})
+	}
+}
+
+func TestLogRejectsCorruptionBeforeTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wal.log")
+	l, err := OpenLog(path, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Append(sampleRecords()...)
+	l.Close()
+
+	// Damage the first record; the three after it are still intact
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[logHeaderSize+frameHeaderSize] ^= 1
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := OpenLog(path, Options{}); !errors.Is(err, errCorrupt) {
+		t.Fatalf("OpenLog() error = %v, want a corruption error", err)
+	}
+	after, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(after, data) {
+		t.Error("OpenLog() modified a corrupt log")
+	}
+}
+
+func TestSyncPolicies(t *testing.T) {
+	for _, opts := range []Options{
+		{Sync: SyncAlways},
+		{Sync: SyncBatch, BatchSize: 3},
+		{Sync: SyncInterval, Interval: time.Millisecond},
+	} {
+		t.Run(opts.Sync.String(), func(t *testing.T) {
+			l, err := OpenLog(filepath.Join(t.TempDir(), "wal.log"), opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < 10; i++ {
+				if _, err := l.Append(Record{Op: OpAdd, Vector: vector.Vector{float64(i)}}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			l.mu.Lock()
+			pending := l.pending
+			l.mu.Unlock()
+			switch opts.Sync {
+			case SyncAlways:
+				if pending != 0 {
+					t.Errorf("%d records pending under SyncAlways", pending)
+				}
+			case SyncBatch:
+				if pending != 10%3 {
+					t.Errorf("%d records pending, want %d", pending, 10%3)
+				}
+			}
+			if err := l.Close(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+
+	if _, err := OpenLog(filepath.Join(t.TempDir(), "wal.log"), Options{Sync: SyncBatch, BatchSize: -1}); err == nil {
+		t.Error("negative batch size accepted")
+	}
+}
+
+// durableIndex is a flat index kept durable by a Store, as a caller would
+type durableIndex struct {
+	idx   *flat.FlatIndex
+	store *Store
+}
+
+func openDurable(t *testing.T, dir string) *durableIndex {
+	t.Helper()
+	d := &durableIndex{}
+	var err error
+	d.idx, err = flat.NewFlatIndex(flat.Config{Metric: distance.L2Distance})
+	if err != nil {
+		t.Fatal(err)
+	}
+	restore := func(r io.Reader) error {
+		d.idx, err = flat.LoadFlatIndex(r, distance.L2Distance)
+		return err
+	}
+	apply := func(rec Record) error { return d.idx.Add(rec.Vector) }
+	if d.store, err = Open(dir, Options{Sync: SyncBatch}, restore, apply); err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func (d *durableIndex) add(t *testing.T, v vector.Vector) {
+	t.Helper()
+	if _, err := d.store.Append(func() error { return d.idx.Add(v) }, Record{Op: OpAdd, Vector: v}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStoreRecovery(t *testing.T) {
+	dir := t.TempDir()
+	vectors := testdata.GenerateRandomVectors(30, 4, 1)
+
+	d := openDurable(t, dir)
+	for _, v := range vectors[:10] {
+		d.add(t, v)
+	}
+	// A crash: nothing is closed, but every record reached the file
+	recovered := openDurable(t, dir)
+	if got := recovered.idx.Size(); got != 10 {
+		t.Fatalf("recovered %d vectors from the log alone, want 10", got)
+	}
+	recovered.store.Close()
+
+	if err := d.store.Checkpoint(d.idx.Save); err != nil {
+		t.Fatal(err)
+	}
+	if size := d.store.Log().Size(); size != int64(logHeaderSize) {
+		t.Errorf("log is %d bytes after a checkpoint, want just the header", size)
+	}
+	for _, v := range vectors[10:] {
+		d.add(t, v)
+	}
+	if err := d.store.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	d = openDurable(t, dir)
+	defer d.store.Close()
+	if d.store.Covered() != 10 {
+		t.Errorf("snapshot covers LSN %d, want 10", d.store.Covered())
+	}
+	if d.idx.Size() != len(vectors) {
+		t.Fatalf("recovered %d vectors, want %d", d.idx.Size(), len(vectors))
+	}
+	for i, v := range vectors {
+		results, err := d.idx.Search(v, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if results[0].Index != i {
+			t.Errorf("vector %d recovered at position %d", i, results[0].Index)
+		}
+	}
+}
+
+// A crash between writing the snapshot and truncating the log leaves records
+// the snapshot already contains; they must not be applied twice
+func TestStoreSkipsCoveredRecords(t *testing.T) {
+	dir := t.TempDir()
+	d := openDurable(t, dir)
+	for _, v := range testdata.GenerateRandomVectors(5, 2, 2) {
+		d.add(t, v)
+	}
+	if err := d.store.writeSnapshot(d.store.Log().LastLSN(), d.idx.Save); err != nil {
+		t.Fatal(err)
+	}
+	d.store.Close()
+
+	d = openDurable(t, dir)
+	defer d.store.Close()
+	if d.idx.Size() != 5 {
+		t.Errorf("recovered %d vectors, want 5", d.idx.Size())
+	}
+	if lsn, err := d.store.Append(nil, Record{Op: OpDelete, ID: "x"}); err != nil || lsn != 6 {
+		t.Errorf("next LSN = %d, %v; want 6", lsn, err)
+	}
+}
+
+func TestStoreFailedCheckpoint(t *testing.T) {
+	dir := t.TempDir()
+	d := openDurable(t, dir)
+	d.add(t, vector.Vector{1})
+	if err := d.store.Checkpoint(func(io.Writer) error { return errors.New("disk full") }); err == nil {
+		t.Fatal("failed save reported success")
+	}
+	d.add(t, vector.Vector{2})
+	d.store.Close()
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("directory holds %d files, want only the log", len(entries))
+	}
+	d = openDurable(t, dir)
+	defer d.store.Close()
+	if d.idx.Size() != 2 {
+		t.Errorf("recovered %d vectors, want 2", d.idx.Size())
+	}
+}